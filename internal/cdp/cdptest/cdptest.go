@@ -0,0 +1,167 @@
+// Package cdptest runs a fake DevTools endpoint for tests: /json/list with
+// the given targets, and a websocket per target whose calls go to a Handler.
+package cdptest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"nhooyr.io/websocket"
+)
+
+// Target is one entry of /json/list.
+type Target struct {
+	ID    string
+	Type  string // "page" when empty
+	URL   string
+	Title string
+	// Attached targets are listed without a webSocketDebuggerUrl, the way
+	// Chrome lists a tab another DevTools client already holds.
+	Attached bool
+}
+
+// Request is one CDP call received on a target's websocket.
+type Request struct {
+	Target string          `json:"-"` // ID of the target dialed
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// Expression is params.expression, as sent with Runtime.evaluate.
+func (r Request) Expression() string {
+	var p struct {
+		Expression string `json:"expression"`
+	}
+	_ = json.Unmarshal(r.Params, &p)
+	return p.Expression
+}
+
+// Conn is one client's websocket to a target.
+type Conn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+	req  *http.Request
+}
+
+// Send writes any message, such as an event, to the client.
+func (c *Conn) Send(v interface{}) {
+	data, _ := json.Marshal(v)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_ = c.conn.Write(c.req.Context(), websocket.MessageText, data)
+}
+
+// Reply answers call id with result; nil is sent as an empty object.
+func (c *Conn) Reply(id int64, result interface{}) {
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	c.Send(map[string]interface{}{"id": id, "result": result})
+}
+
+// Fail answers call id with a protocol error.
+func (c *Conn) Fail(id int64, message string) {
+	c.Send(map[string]interface{}{"id": id, "error": map[string]interface{}{"code": -32000, "message": message}})
+}
+
+// EvalResult is the Runtime.evaluate result carrying value by value.
+func EvalResult(value interface{}) map[string]interface{} {
+	raw, _ := json.Marshal(value)
+	return map[string]interface{}{"result": map[string]interface{}{"type": "object", "value": json.RawMessage(raw)}}
+}
+
+// Handler answers one call, usually with Reply or Fail. A call it leaves
+// unanswered hangs, like one sent to a frozen tab.
+type Handler func(c *Conn, req Request)
+
+// Browser is a running fake DevTools endpoint.
+type Browser struct {
+	Host string
+	Port int
+
+	mu    sync.Mutex
+	dials map[string]int
+}
+
+// New starts a fake browser listing targets; it is closed when t ends.
+// Only listed, unattached targets accept websockets.
+func New(t testing.TB, targets []Target, handle Handler) *Browser {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	b := &Browser{Host: "127.0.0.1", Port: srv.Listener.Addr().(*net.TCPAddr).Port, dials: map[string]int{}}
+	dialable := map[string]bool{}
+	for _, target := range targets {
+		dialable[target.ID] = !target.Attached
+	}
+	mux.HandleFunc("/json/list", func(w http.ResponseWriter, r *http.Request) {
+		list := make([]map[string]string, 0, len(targets))
+		for _, target := range targets {
+			kind := target.Type
+			if kind == "" {
+				kind = "page"
+			}
+			entry := map[string]string{"id": target.ID, "type": kind, "url": target.URL, "title": target.Title}
+			if !target.Attached {
+				entry["webSocketDebuggerUrl"] = b.WebSocketURL(target.ID)
+			}
+			list = append(list, entry)
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	})
+	mux.HandleFunc("/devtools/page/", func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/devtools/page/")
+		if !dialable[id] {
+			http.NotFound(w, r)
+			return
+		}
+		b.mu.Lock()
+		b.dials[id]++
+		b.mu.Unlock()
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close(websocket.StatusNormalClosure, "")
+		conn := &Conn{conn: ws, req: r}
+		for {
+			_, data, err := ws.Read(r.Context())
+			if err != nil {
+				return
+			}
+			req := Request{Target: id}
+			if json.Unmarshal(data, &req) != nil {
+				continue
+			}
+			handle(conn, req)
+		}
+	})
+	return b
+}
+
+// NewTab starts a fake browser with a single page target "T1" and returns
+// its websocket URL.
+func NewTab(t testing.TB, handle Handler) string {
+	t.Helper()
+	return New(t, []Target{{ID: "T1", URL: "about:blank"}}, handle).WebSocketURL("T1")
+}
+
+// WebSocketURL is the debugger URL of target id.
+func (b *Browser) WebSocketURL(id string) string {
+	return "ws://" + b.Host + ":" + strconv.Itoa(b.Port) + "/devtools/page/" + id
+}
+
+// Dials counts the websockets accepted for target id.
+func (b *Browser) Dials(id string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dials[id]
+}
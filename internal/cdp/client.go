@@ -1,11 +1,14 @@
 package cdp
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -24,13 +27,34 @@ type Client struct {
 	eventHandlers map[int64]func(Event)
 	handlerID     int64
 
+	bindingMu sync.Mutex
+	bindings  map[string]*Binding
+
 	nextID    int64
+	lastRead  int64 // unix nanoseconds of the last message or pong
 	readCtx   context.Context
 	cancel    context.CancelFunc
 	closed    chan struct{}
 	closeOnce sync.Once
+
+	errMu sync.Mutex
+	err   error // why the read loop ended
 }
 
+// ErrConnectionLost reports that the peer stopped answering: nothing arrived
+// for the idle period and a ping went unanswered. Pending calls fail with it
+// and Done is closed, instead of the command hanging on a dead socket.
+var ErrConnectionLost = errors.New("devtools connection lost")
+
+// idleTimeout is how long a connection may stay silent before it is pinged,
+// and pingTimeout how long the pong may take before the peer counts as gone.
+// Silence alone is normal (a quiet page streams no events), so only the
+// unanswered ping marks the connection dead.
+var (
+	idleTimeout = 30 * time.Second
+	pingTimeout = 10 * time.Second
+)
+
 // Event represents an async CDP notification.
 type Event struct {
 	Method string
@@ -62,9 +86,40 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("cdp error %d: %s", e.Code, e.Message)
 }
 
+// Hooks observe protocol traffic, e.g. for timing. Unset hooks cost nothing.
+type Hooks struct {
+	// OnDial reports how long establishing a websocket took.
+	OnDial func(wsURL string, d time.Duration, err error)
+	// OnSend runs just before a command is written.
+	OnSend func(id int64, method string)
+	// OnReceive runs once a command's response (or failure) arrives.
+	OnReceive func(id int64, method string, d time.Duration, err error)
+}
+
+var hooks Hooks
+
+// SetHooks installs hooks for every later Dial and Call.
+func SetHooks(h Hooks) {
+	hooks = h
+}
+
 // Dial establishes a websocket connection to the DevTools target.
 func Dial(ctx context.Context, wsURL string) (*Client, error) {
-	conn, _, err := websocket.Dial(ctx, wsURL, nil)
+	return Dialer{}.Dial(ctx, wsURL)
+}
+
+// Dial establishes a websocket connection to the DevTools target, sending
+// d's Authorization header.
+func (d Dialer) Dial(ctx context.Context, wsURL string) (*Client, error) {
+	var opts *websocket.DialOptions
+	if d.Authorization != "" {
+		opts = &websocket.DialOptions{HTTPHeader: http.Header{"Authorization": []string{d.Authorization}}}
+	}
+	start := time.Now()
+	conn, _, err := websocket.Dial(ctx, wsURL, opts)
+	if hooks.OnDial != nil {
+		hooks.OnDial(wsURL, time.Since(start), err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -74,18 +129,30 @@ func Dial(ctx context.Context, wsURL string) (*Client, error) {
 		conn:          conn,
 		pending:       make(map[int64]chan response),
 		eventHandlers: make(map[int64]func(Event)),
+		bindings:      make(map[string]*Binding),
 		readCtx:       readCtx,
 		cancel:        cancel,
 		closed:        make(chan struct{}),
 	}
+	c.touch()
 	go c.readLoop()
+	go c.watchIdle()
 	return c, nil
 }
 
-// Close tears down the websocket connection.
+// Close tears down the websocket connection, removing any active bindings first.
 func (c *Client) Close() error {
 	var err error
 	c.closeOnce.Do(func() {
+		c.bindingMu.Lock()
+		active := make([]*Binding, 0, len(c.bindings))
+		for _, b := range c.bindings {
+			active = append(active, b)
+		}
+		c.bindingMu.Unlock()
+		for _, b := range active {
+			b.Close()
+		}
 		c.cancel()
 		err = c.conn.Close(websocket.StatusNormalClosure, "")
 		<-c.closed
@@ -93,9 +160,29 @@ func (c *Client) Close() error {
 	return err
 }
 
+// Done is closed once the connection's read loop exits (Close, or the target went away).
+func (c *Client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Err returns why the connection ended once Done is closed, wrapping
+// ErrConnectionLost when the peer stopped answering pings.
+func (c *Client) Err() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	return c.err
+}
+
 // Call sends a protocol command and decodes the response.
-func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) (err error) {
 	id := atomic.AddInt64(&c.nextID, 1)
+	if hooks.OnSend != nil {
+		hooks.OnSend(id, method)
+	}
+	if hooks.OnReceive != nil {
+		start := time.Now()
+		defer func() { hooks.OnReceive(id, method, time.Since(start), err) }()
+	}
 	payload := map[string]interface{}{
 		"id":     id,
 		"method": method,
@@ -124,6 +211,10 @@ func (c *Client) Call(ctx context.Context, method string, params interface{}, re
 	case <-ctx.Done():
 		c.removePending(id)
 		return ctx.Err()
+	case <-c.closed:
+		// failAll may have run before this call registered.
+		c.removePending(id)
+		return c.Err()
 	case resp := <-ch:
 		if resp.err != nil {
 			return resp.err
@@ -152,9 +243,16 @@ func (c *Client) readLoop() {
 	for {
 		_, data, err := c.conn.Read(c.readCtx)
 		if err != nil {
+			c.errMu.Lock()
+			if c.err == nil {
+				c.err = err
+			}
+			err = c.err
+			c.errMu.Unlock()
 			c.failAll(err)
 			return
 		}
+		c.touch()
 		var probe struct {
 			ID     *int64          `json:"id"`
 			Method string          `json:"method"`
@@ -185,6 +283,53 @@ func (c *Client) readLoop() {
 	}
 }
 
+func (c *Client) touch() {
+	atomic.StoreInt64(&c.lastRead, time.Now().UnixNano())
+}
+
+// watchIdle pings the peer whenever nothing has been read for idleTimeout.
+// A ping that goes unanswered within pingTimeout ends the read loop with
+// ErrConnectionLost, which fails the pending calls and closes Done.
+func (c *Client) watchIdle() {
+	timer := time.NewTimer(idleTimeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-timer.C:
+		}
+		idle := time.Since(time.Unix(0, atomic.LoadInt64(&c.lastRead)))
+		if idle < idleTimeout {
+			timer.Reset(idleTimeout - idle)
+			continue
+		}
+		// Ping closes the connection itself when its context expires, so
+		// time it here to record why before the read loop fails.
+		pong := make(chan error, 1)
+		go func() { pong <- c.conn.Ping(c.readCtx) }()
+		select {
+		case err := <-pong:
+			if err != nil {
+				return // the read loop fails with the same cause
+			}
+			c.touch()
+			timer.Reset(idleTimeout)
+			continue
+		case <-c.closed:
+			return
+		case <-time.After(pingTimeout):
+		}
+		c.errMu.Lock()
+		if c.err == nil {
+			c.err = fmt.Errorf("%w: no reply for %s", ErrConnectionLost, (idle + pingTimeout).Round(time.Second))
+		}
+		c.errMu.Unlock()
+		c.cancel()
+		return
+	}
+}
+
 func (c *Client) failAll(err error) {
 	c.pendingMu.Lock()
 	defer c.pendingMu.Unlock()
@@ -219,6 +364,115 @@ func (c *Client) SubscribeEvents(fn func(Event)) func() {
 	}
 }
 
+// EventRing holds the most recent events a filter accepted, oldest first.
+type EventRing struct {
+	mu     sync.Mutex
+	events []Event
+	size   int
+	stop   func()
+}
+
+// RecordEvents keeps the last size events that keep accepts until Stop. It
+// only subscribes; enabling the domains that emit the events is up to the
+// caller.
+func (c *Client) RecordEvents(size int, keep func(Event) bool) *EventRing {
+	r := &EventRing{size: size}
+	r.stop = c.SubscribeEvents(func(evt Event) {
+		if !keep(evt) {
+			return
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if len(r.events) == r.size {
+			r.events = append(r.events[:0], r.events[1:]...)
+		}
+		r.events = append(r.events, evt)
+	})
+	return r
+}
+
+// Events returns a copy of the recorded events, oldest first.
+func (r *EventRing) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// Stop ends the recording; the recorded events stay available.
+func (r *EventRing) Stop() {
+	r.stop()
+}
+
+// Binding is a page-to-CLI channel created via Runtime.addBinding. Calling
+// window.<name>(string) in the page delivers the string on Messages.
+type Binding struct {
+	Name     string
+	Messages <-chan string
+
+	client      *Client
+	done        chan struct{}
+	unsubscribe func()
+	closeOnce   sync.Once
+}
+
+// Bind subscribes to Runtime.bindingCalled and then registers the binding, so
+// payloads sent right after registration aren't missed. The binding is removed
+// on Close (or when the client closes).
+func (c *Client) Bind(ctx context.Context, name string) (*Binding, error) {
+	ch := make(chan string, 64)
+	b := &Binding{Name: name, Messages: ch, client: c, done: make(chan struct{})}
+	b.unsubscribe = c.SubscribeEvents(func(evt Event) {
+		if evt.Method != "Runtime.bindingCalled" {
+			return
+		}
+		var payload struct {
+			Name    string `json:"name"`
+			Payload string `json:"payload"`
+		}
+		if err := json.Unmarshal(evt.Params, &payload); err != nil || payload.Name != name {
+			return
+		}
+		select {
+		case ch <- payload.Payload:
+		case <-b.done:
+		}
+	})
+	if err := b.Register(ctx); err != nil {
+		b.unsubscribe()
+		return nil, err
+	}
+	c.bindingMu.Lock()
+	c.bindings[name] = b
+	c.bindingMu.Unlock()
+	return b, nil
+}
+
+// Register (re-)adds the binding to the target. Chrome keeps bindings across
+// navigations, but some embedders drop them, so callers may re-register after a
+// new execution context appears.
+func (b *Binding) Register(ctx context.Context) error {
+	return b.client.Call(ctx, "Runtime.addBinding", map[string]interface{}{"name": b.Name}, nil)
+}
+
+// Done is closed once the binding has been removed.
+func (b *Binding) Done() <-chan struct{} {
+	return b.done
+}
+
+// Close unsubscribes and removes the binding from the page.
+func (b *Binding) Close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+		b.unsubscribe()
+		b.client.bindingMu.Lock()
+		delete(b.client.bindings, b.Name)
+		b.client.bindingMu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = b.client.Call(ctx, "Runtime.removeBinding", map[string]interface{}{"name": b.Name}, nil)
+	})
+}
+
 // RuntimeEvaluateResult contains the response from Runtime.evaluate.
 type RuntimeEvaluateResult struct {
 	Result           RemoteObject      `json:"result"`
@@ -237,8 +491,11 @@ type RemoteObject struct {
 
 // ExceptionDetails are returned on script errors.
 type ExceptionDetails struct {
-	Text      string        `json:"text"`
-	Exception *RemoteObject `json:"exception"`
+	Text         string        `json:"text"`
+	LineNumber   int           `json:"lineNumber"`
+	ColumnNumber int           `json:"columnNumber"`
+	URL          string        `json:"url"`
+	Exception    *RemoteObject `json:"exception"`
 }
 
 // EvaluateRaw evaluates JS inside the target and returns the raw CDP response.
@@ -331,12 +588,27 @@ func exceptionError(ctx context.Context, c *Client, details *ExceptionDetails) e
 
 // RemoteObjectValue resolves a RemoteObject into a native Go value.
 func (c *Client) RemoteObjectValue(ctx context.Context, obj RemoteObject) (interface{}, error) {
+	return c.remoteObjectValue(ctx, obj, false)
+}
+
+// RemoteObjectOrdered is RemoteObjectValue for values that are only printed:
+// objects and arrays stay the json.RawMessage the page serialized, so their
+// key order survives (see format.Style.KeepOrder). Other values resolve as
+// in RemoteObjectValue.
+func (c *Client) RemoteObjectOrdered(ctx context.Context, obj RemoteObject) (interface{}, error) {
+	return c.remoteObjectValue(ctx, obj, true)
+}
+
+func (c *Client) remoteObjectValue(ctx context.Context, obj RemoteObject, ordered bool) (interface{}, error) {
 	// CDP represents JS `null` as {type:"object", subtype:"null"} and may omit both
 	// `value` and `description`. Treat it as a Go nil so JSON output is `null`.
 	if obj.Type == "object" && obj.Subtype == "null" {
 		return nil, nil
 	}
 	if obj.Value != nil {
+		if raw := bytes.TrimSpace(*obj.Value); ordered && len(raw) > 0 && (raw[0] == '{' || raw[0] == '[') {
+			return json.RawMessage(raw), nil
+		}
 		var out interface{}
 		if err := json.Unmarshal(*obj.Value, &out); err != nil {
 			return nil, err
@@ -373,8 +645,29 @@ func (c *Client) RemoteObjectValue(ctx context.Context, obj RemoteObject) (inter
                         return this.outerHTML;
                     }
                 } catch (e) {}
+                // JSON.stringify turns Map/Set into {} and typed arrays into
+                // index-keyed objects; convert them to plain JSON shapes first.
+                const toBase64 = (buffer) => {
+                    const bytes = new Uint8Array(buffer);
+                    let binary = '';
+                    for (let i = 0; i < bytes.length; i += 0x8000) {
+                        binary += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+                    }
+                    return btoa(binary);
+                };
+                const replacer = (key, value) => {
+                    if (value instanceof Map) return { entries: Array.from(value.entries()) };
+                    if (value instanceof Set) return Array.from(value);
+                    if (value instanceof ArrayBuffer) return toBase64(value);
+                    if (value instanceof DataView) return toBase64(value.buffer.slice(value.byteOffset, value.byteOffset + value.byteLength));
+                    if (ArrayBuffer.isView(value)) {
+                        return Array.from(value, (v) => typeof v === 'bigint' ? v.toString() : v);
+                    }
+                    if (typeof value === 'bigint') return value.toString();
+                    return value;
+                };
                 try {
-                    const json = JSON.stringify(this);
+                    const json = JSON.stringify(this, replacer);
                     if (json !== undefined) {
                         return JSON.parse(json);
                     }
@@ -396,10 +689,153 @@ func (c *Client) RemoteObjectValue(ctx context.Context, obj RemoteObject) (inter
 			}
 			return nil, errors.New(call.ExceptionDetails.Text)
 		}
-		return c.RemoteObjectValue(ctx, call.Result)
+		return c.remoteObjectValue(ctx, call.Result, ordered)
 	}
 	if obj.Description != "" {
 		return obj.Description, nil
 	}
 	return obj.Type, nil
 }
+
+// propertyDescriptor is one entry of a Runtime.getProperties result.
+type propertyDescriptor struct {
+	Name  string        `json:"name"`
+	Value *RemoteObject `json:"value"`
+	Get   *RemoteObject `json:"get"`
+}
+
+// snapshotObjectGroup holds the objects PropertySnapshot creates, so they
+// are released together.
+const snapshotObjectGroup = "cdp-cli-snapshot"
+
+// PropertySnapshot resolves obj from Runtime.getProperties instead of JSON,
+// so non-enumerable properties and getter values (e.g. a DOM node's live
+// properties, or class accessors) are included. Getters anywhere on the
+// prototype chain are invoked. Nested objects are expanded depth more levels;
+// past that, objects and functions appear as their description. Primitives
+// resolve as in RemoteObjectValue.
+func (c *Client) PropertySnapshot(ctx context.Context, obj RemoteObject, depth int) (interface{}, error) {
+	defer func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		c.Call(releaseCtx, "Runtime.releaseObjectGroup", map[string]interface{}{"objectGroup": snapshotObjectGroup}, nil)
+	}()
+	return c.propertySnapshot(ctx, obj, depth)
+}
+
+func (c *Client) propertySnapshot(ctx context.Context, obj RemoteObject, depth int) (interface{}, error) {
+	if obj.ObjectID == "" || obj.Type != "object" || obj.Subtype == "null" {
+		if obj.Type == "function" {
+			return obj.Description, nil
+		}
+		return c.RemoteObjectValue(ctx, obj)
+	}
+	var own struct {
+		Result []propertyDescriptor `json:"result"`
+	}
+	if err := c.Call(ctx, "Runtime.getProperties", map[string]interface{}{
+		"objectId":      obj.ObjectID,
+		"ownProperties": true,
+	}, &own); err != nil {
+		return nil, err
+	}
+	var accessors struct {
+		Result []propertyDescriptor `json:"result"`
+	}
+	if err := c.Call(ctx, "Runtime.getProperties", map[string]interface{}{
+		"objectId":               obj.ObjectID,
+		"ownProperties":          false,
+		"accessorPropertiesOnly": true,
+	}, &accessors); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]RemoteObject)
+	var getters []string
+	seen := make(map[string]bool)
+	for _, p := range append(own.Result, accessors.Result...) {
+		if p.Name == "__proto__" || seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+		switch {
+		case p.Value != nil:
+			values[p.Name] = *p.Value
+		case p.Get != nil && p.Get.Type == "function":
+			getters = append(getters, p.Name)
+		}
+	}
+	if len(getters) > 0 {
+		got, err := c.invokeGetters(ctx, obj.ObjectID, getters)
+		if err != nil {
+			return nil, err
+		}
+		for name, v := range got {
+			values[name] = v
+		}
+	}
+
+	out := make(map[string]interface{}, len(values))
+	for name, v := range values {
+		if v.ObjectID != "" && (v.Type == "function" || (v.Type == "object" && depth <= 0)) {
+			out[name] = v.Description
+			continue
+		}
+		resolved, err := c.propertySnapshot(ctx, v, depth-1)
+		if err != nil {
+			resolved = v.Description
+		}
+		out[name] = resolved
+	}
+	if n, ok := out["length"].(float64); ok && obj.Subtype == "array" {
+		items := make([]interface{}, int(n))
+		for i := range items {
+			items[i] = out[strconv.Itoa(i)]
+		}
+		return items, nil
+	}
+	return out, nil
+}
+
+// invokeGetters reads the named properties of the object in one call and
+// returns each value as a RemoteObject. A throwing getter yields its error
+// message as the value.
+func (c *Client) invokeGetters(ctx context.Context, objectID string, names []string) (map[string]RemoteObject, error) {
+	var call struct {
+		Result           RemoteObject      `json:"result"`
+		ExceptionDetails *ExceptionDetails `json:"exceptionDetails"`
+	}
+	if err := c.Call(ctx, "Runtime.callFunctionOn", map[string]interface{}{
+		"objectId": objectID,
+		"functionDeclaration": `function(names) {
+                const out = Object.create(null);
+                for (const name of names) {
+                    try { out[name] = this[name]; } catch (e) { out[name] = 'getter threw: ' + e; }
+                }
+                return out;
+            }`,
+		"arguments":   []map[string]interface{}{{"value": names}},
+		"objectGroup": snapshotObjectGroup,
+	}, &call); err != nil {
+		return nil, err
+	}
+	if call.ExceptionDetails != nil {
+		return nil, errors.New(call.ExceptionDetails.Text)
+	}
+	var props struct {
+		Result []propertyDescriptor `json:"result"`
+	}
+	if err := c.Call(ctx, "Runtime.getProperties", map[string]interface{}{
+		"objectId":      call.Result.ObjectID,
+		"ownProperties": true,
+	}, &props); err != nil {
+		return nil, err
+	}
+	out := make(map[string]RemoteObject, len(props.Result))
+	for _, p := range props.Result {
+		if p.Value != nil {
+			out[p.Name] = *p.Value
+		}
+	}
+	return out, nil
+}
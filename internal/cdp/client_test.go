@@ -2,7 +2,12 @@ package cdp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
 )
 
 func TestRemoteObjectValue_NullSubtype(t *testing.T) {
@@ -19,3 +24,230 @@ func TestRemoteObjectValue_NullSubtype(t *testing.T) {
 		t.Fatalf("expected nil value, got %#v", v)
 	}
 }
+
+func TestBindDeliversPayloadsAndRemovesOnClose(t *testing.T) {
+	removed := make(chan string, 1)
+	wsURL := cdptest.NewTab(t, func(conn *cdptest.Conn, req cdptest.Request) {
+		conn.Reply(req.ID, nil)
+		var params struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		switch req.Method {
+		case "Runtime.addBinding":
+			conn.Send(map[string]interface{}{
+				"method": "Runtime.bindingCalled",
+				"params": map[string]interface{}{"name": params.Name, "payload": "hello"},
+			})
+		case "Runtime.removeBinding":
+			removed <- params.Name
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	b, err := c.Bind(ctx, "chan")
+	if err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	select {
+	case msg := <-b.Messages:
+		if msg != "hello" {
+			t.Fatalf("unexpected payload %q", msg)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for binding payload")
+	}
+	c.Close()
+	select {
+	case name := <-removed:
+		if name != "chan" {
+			t.Fatalf("unexpected removed binding %q", name)
+		}
+	case <-ctx.Done():
+		t.Fatal("binding was not removed on close")
+	}
+}
+
+func TestHooksObserveDialAndCalls(t *testing.T) {
+	wsURL := cdptest.NewTab(t, func(conn *cdptest.Conn, req cdptest.Request) {
+		if req.Method == "Bad.method" {
+			conn.Fail(req.ID, "not found")
+			return
+		}
+		conn.Reply(req.ID, nil)
+	})
+
+	var dials, sends int
+	var received []string
+	var failures int
+	SetHooks(Hooks{
+		OnDial: func(string, time.Duration, error) { dials++ },
+		OnSend: func(int64, string) { sends++ },
+		OnReceive: func(_ int64, method string, _ time.Duration, err error) {
+			received = append(received, method)
+			if err != nil {
+				failures++
+			}
+		},
+	})
+	defer SetHooks(Hooks{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer c.Close()
+	if err := c.Call(ctx, "Page.enable", nil, nil); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if err := c.Call(ctx, "Bad.method", nil, nil); err == nil {
+		t.Fatal("expected protocol error")
+	}
+	if dials != 1 || sends != 2 || len(received) != 2 || failures != 1 {
+		t.Fatalf("dials=%d sends=%d received=%v failures=%d", dials, sends, received, failures)
+	}
+}
+
+func TestPropertySnapshotIncludesGetters(t *testing.T) {
+	// obj-1 has an own non-enumerable "id", a nested object and a getter
+	// "value" on its prototype; obj-2 is the nested object.
+	props := map[string][]interface{}{
+		"obj-1/own": {
+			map[string]interface{}{"name": "id", "value": map[string]interface{}{"type": "string", "value": "name"}},
+			map[string]interface{}{"name": "style", "value": map[string]interface{}{"type": "object", "objectId": "obj-2", "description": "CSSStyleDeclaration"}},
+			map[string]interface{}{"name": "__proto__", "value": map[string]interface{}{"type": "object", "objectId": "proto"}},
+		},
+		"obj-1/accessors": {
+			map[string]interface{}{"name": "value", "get": map[string]interface{}{"type": "function", "objectId": "fn"}},
+		},
+		"getters/own": {
+			map[string]interface{}{"name": "value", "value": map[string]interface{}{"type": "string", "value": "typed text"}},
+		},
+		"obj-2/own": {
+			map[string]interface{}{"name": "color", "value": map[string]interface{}{"type": "string", "value": "red"}},
+		},
+	}
+	var released bool
+	wsURL := cdptest.NewTab(t, func(conn *cdptest.Conn, req cdptest.Request) {
+		var params struct {
+			ObjectID               string `json:"objectId"`
+			AccessorPropertiesOnly bool   `json:"accessorPropertiesOnly"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		var result interface{}
+		switch req.Method {
+		case "Runtime.getProperties":
+			key := params.ObjectID + "/own"
+			if params.AccessorPropertiesOnly {
+				key = params.ObjectID + "/accessors"
+			}
+			list := props[key]
+			if list == nil {
+				list = []interface{}{}
+			}
+			result = map[string]interface{}{"result": list}
+		case "Runtime.callFunctionOn":
+			result = map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "getters"}}
+		case "Runtime.releaseObjectGroup":
+			released = true
+		}
+		conn.Reply(req.ID, result)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	obj := RemoteObject{Type: "object", Subtype: "node", ObjectID: "obj-1"}
+	shallow, err := c.PropertySnapshot(ctx, obj, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := shallow.(map[string]interface{})
+	if m["id"] != "name" || m["value"] != "typed text" || m["style"] != "CSSStyleDeclaration" {
+		t.Fatalf("unexpected shallow snapshot %#v", m)
+	}
+	if _, ok := m["__proto__"]; ok {
+		t.Fatal("__proto__ should be skipped")
+	}
+	deep, err := c.PropertySnapshot(ctx, obj, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	style, _ := deep.(map[string]interface{})["style"].(map[string]interface{})
+	if style["color"] != "red" {
+		t.Fatalf("nested object not expanded: %#v", deep)
+	}
+	if !released {
+		t.Fatal("snapshot objects were not released")
+	}
+}
+
+func TestSilentPeerFailsPendingCalls(t *testing.T) {
+	defer func(idle, ping time.Duration) { idleTimeout, pingTimeout = idle, ping }(idleTimeout, pingTimeout)
+	idleTimeout, pingTimeout = 100*time.Millisecond, 100*time.Millisecond
+
+	release := make(chan struct{})
+	wsURL := cdptest.NewTab(t, func(conn *cdptest.Conn, req cdptest.Request) {
+		if req.Method == "Test.hang" {
+			// Stop reading without closing, so pings go unanswered.
+			<-release
+			return
+		}
+		conn.Reply(req.ID, nil)
+	})
+	t.Cleanup(func() { close(release) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// A quiet but healthy connection answers its pings and stays up.
+	time.Sleep(3 * idleTimeout)
+	if err := c.Call(ctx, "Test.ping", nil, nil); err != nil {
+		t.Fatalf("idle connection was dropped: %v", err)
+	}
+
+	if err := c.Call(ctx, "Test.hang", nil, nil); !errors.Is(err, ErrConnectionLost) {
+		t.Fatalf("hung peer: got %v, want ErrConnectionLost", err)
+	}
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed after the connection was lost")
+	}
+	if !errors.Is(c.Err(), ErrConnectionLost) {
+		t.Fatalf("Err() = %v", c.Err())
+	}
+	if err := c.Call(ctx, "Test.ping", nil, nil); err == nil {
+		t.Fatal("call on a lost connection succeeded")
+	}
+}
+
+func TestRecordEventsKeepsTheLatest(t *testing.T) {
+	c := &Client{eventHandlers: make(map[int64]func(Event))}
+	ring := c.RecordEvents(2, func(evt Event) bool { return evt.Method != "Skip" })
+	for _, m := range []string{"A", "Skip", "B", "C"} {
+		c.dispatchEvent(Event{Method: m})
+	}
+	ring.Stop()
+	c.dispatchEvent(Event{Method: "D"})
+	got := ring.Events()
+	if len(got) != 2 || got[0].Method != "B" || got[1].Method != "C" {
+		t.Fatalf("recorded %+v, want B then C", got)
+	}
+}
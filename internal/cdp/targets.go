@@ -6,9 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -22,30 +24,186 @@ type TargetInfo struct {
 	Description string `json:"description"`
 }
 
-// ListTargets fetches targets exposed on the DevTools port.
-func ListTargets(ctx context.Context, host string, port int) ([]TargetInfo, error) {
-	endpoint := fmt.Sprintf("http://%s:%d/json/list", host, port)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+// Dialer reaches a DevTools endpoint: its HTTP discovery calls and the
+// websockets of its targets. The zero value uses plain http/ws without
+// credentials, as the package-level Dial does.
+type Dialer struct {
+	// Secure switches discovery to https and websockets to wss.
+	Secure bool
+	// Authorization is sent as the Authorization header on every request.
+	Authorization string
+	// Retry bounds the retries of HTTP endpoint calls; the zero value means
+	// DefaultRetry.
+	Retry Retry
+}
+
+func (d Dialer) endpointURL(host string, port int, path string) string {
+	scheme := "http"
+	if d.Secure {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, host, port, path)
+}
+
+func (d Dialer) newRequest(ctx context.Context, method, endpoint string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
+	if d.Authorization != "" {
+		req.Header.Set("Authorization", d.Authorization)
+	}
+	return req, nil
+}
+
+// Retry bounds how often the HTTP endpoints are retried when the DevTools port
+// can't be reached (Chrome still starting, SSH tunnel flapping). Attempts of 1
+// disables retries. The wait doubles after each failed attempt.
+type Retry struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// DefaultRetry makes 5 attempts over roughly two seconds.
+var DefaultRetry = Retry{Attempts: 5, Backoff: 125 * time.Millisecond}
+
+func (d Dialer) retry() Retry {
+	if d.Retry == (Retry{}) {
+		return DefaultRetry
+	}
+	return d.Retry
+}
+
+// EndpointError reports that the DevTools HTTP endpoint could not be reached
+// after retrying.
+type EndpointError struct {
+	Endpoint string
+	Attempts int
+	Elapsed  time.Duration
+	Refused  bool
+	Err      error
+}
+
+func (e *EndpointError) Error() string {
+	attempts := fmt.Sprintf("%d attempt", e.Attempts)
+	if e.Attempts != 1 {
+		attempts += "s"
+	}
+	if e.Refused {
+		return fmt.Sprintf("connection refused at %s after %s over %s (is Chrome running with --remote-debugging-port?)", e.Endpoint, attempts, e.Elapsed.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%s unreachable after %s over %s: %v", e.Endpoint, attempts, e.Elapsed.Round(time.Millisecond), e.Err)
+}
+
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+// retryable reports whether err is a transport failure worth retrying, as
+// opposed to an HTTP-level answer or a cancelled context. A call that isn't
+// idempotent is only retried when it never reached the browser: a connection
+// dropped mid-request may already have done its work.
+func retryable(err error, idempotent bool) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var opErr *net.OpError
+	if !idempotent {
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// do sends a request to the DevTools HTTP endpoint, retrying transport
+// failures with backoff while ctx allows. Non-200 responses are returned as
+// httpStatusError without retrying.
+func (d Dialer) do(ctx context.Context, method, endpoint string, idempotent bool) ([]byte, error) {
 	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	policy := d.retry()
+	attempts := policy.Attempts
+	if attempts < 1 {
+		attempts = 1
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return nil, fmt.Errorf("list targets: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	wait := policy.Backoff
+	start := time.Now()
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		req, err := d.newRequest(ctx, method, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			body, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if readErr != nil {
+				return nil, readErr
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, httpStatusError{status: resp.StatusCode, body: strings.TrimSpace(string(truncate(body, 1024)))}
+			}
+			return body, nil
+		}
+		lastErr = err
+		if !retryable(err, idempotent) {
+			return nil, err
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, &EndpointError{Endpoint: endpoint, Attempts: attempt, Elapsed: time.Since(start), Refused: errors.Is(lastErr, syscall.ECONNREFUSED), Err: lastErr}
+		}
+		wait *= 2
+	}
+	return nil, &EndpointError{Endpoint: endpoint, Attempts: attempts, Elapsed: time.Since(start), Refused: errors.Is(lastErr, syscall.ECONNREFUSED), Err: lastErr}
+}
+
+func truncate(b []byte, n int) []byte {
+	if len(b) > n {
+		return b[:n]
+	}
+	return b
+}
+
+// ListTargets fetches targets exposed on the DevTools port.
+func (d Dialer) ListTargets(ctx context.Context, host string, port int) ([]TargetInfo, error) {
+	body, err := d.do(ctx, http.MethodGet, d.endpointURL(host, port, "/json/list"), true)
+	if err != nil {
+		return nil, fmt.Errorf("list targets: %w", err)
 	}
 	var targets []TargetInfo
-	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+	if err := json.Unmarshal(body, &targets); err != nil {
 		return nil, err
 	}
 	return targets, nil
 }
 
+// VersionInfo mirrors /json/version.
+type VersionInfo struct {
+	Browser         string `json:"Browser"`
+	ProtocolVersion string `json:"Protocol-Version"`
+	UserAgent       string `json:"User-Agent"`
+	V8Version       string `json:"V8-Version"`
+	WebKitVersion   string `json:"WebKit-Version"`
+	WebSocket       string `json:"webSocketDebuggerUrl"`
+}
+
+// BrowserVersion fetches the browser's /json/version metadata.
+func (d Dialer) BrowserVersion(ctx context.Context, host string, port int) (VersionInfo, error) {
+	body, err := d.do(ctx, http.MethodGet, d.endpointURL(host, port, "/json/version"), true)
+	if err != nil {
+		return VersionInfo{}, fmt.Errorf("browser version: %w", err)
+	}
+	var info VersionInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return VersionInfo{}, err
+	}
+	return info, nil
+}
+
 type httpStatusError struct {
 	status int
 	body   string
@@ -56,26 +214,17 @@ func (e httpStatusError) Error() string {
 }
 
 // CreateTarget requests a fresh tab pointing at the provided URL.
-func CreateTarget(ctx context.Context, host string, port int, targetURL string) (TargetInfo, error) {
-	endpoint := fmt.Sprintf("http://%s:%d/json/new?%s", host, port, url.QueryEscape(targetURL))
-	client := &http.Client{Timeout: 5 * time.Second}
-
+func (d Dialer) CreateTarget(ctx context.Context, host string, port int, targetURL string) (TargetInfo, error) {
+	endpoint := d.endpointURL(host, port, "/json/new?"+url.QueryEscape(targetURL))
 	try := func(method string) (TargetInfo, error) {
-		req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
-		if err != nil {
-			return TargetInfo{}, err
-		}
-		resp, err := client.Do(req)
+		// Each call opens a tab, so a request that may have arrived is not
+		// sent again.
+		body, err := d.do(ctx, method, endpoint, false)
 		if err != nil {
 			return TargetInfo{}, err
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-			return TargetInfo{}, httpStatusError{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
-		}
 		var target TargetInfo
-		if err := json.NewDecoder(resp.Body).Decode(&target); err != nil {
+		if err := json.Unmarshal(body, &target); err != nil {
 			return TargetInfo{}, err
 		}
 		return target, nil
@@ -87,7 +236,10 @@ func CreateTarget(ctx context.Context, host string, port int, targetURL string)
 	}
 	var statusErr httpStatusError
 	if errors.As(err, &statusErr) && statusErr.status == http.StatusMethodNotAllowed {
-		return try(http.MethodGet)
+		target, err = try(http.MethodGet)
+		if err == nil {
+			return target, nil
+		}
 	}
 	return TargetInfo{}, fmt.Errorf("create target: %w", err)
 }
@@ -114,41 +266,17 @@ func FindTarget(targets []TargetInfo, rawURL string) (TargetInfo, bool) {
 }
 
 // ActivateTarget asks the browser to focus a tab.
-func ActivateTarget(ctx context.Context, host string, port int, targetID string) error {
-	endpoint := fmt.Sprintf("http://%s:%d/json/activate/%s", host, port, targetID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return err
-	}
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("activate target: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+func (d Dialer) ActivateTarget(ctx context.Context, host string, port int, targetID string) error {
+	if _, err := d.do(ctx, http.MethodGet, d.endpointURL(host, port, "/json/activate/"+targetID), true); err != nil {
+		return fmt.Errorf("activate target: %w", err)
 	}
 	return nil
 }
 
 // CloseTarget asks the browser to close a tab.
-func CloseTarget(ctx context.Context, host string, port int, targetID string) error {
-	endpoint := fmt.Sprintf("http://%s:%d/json/close/%s", host, port, targetID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
-	if err != nil {
-		return err
-	}
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return fmt.Errorf("close target: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+func (d Dialer) CloseTarget(ctx context.Context, host string, port int, targetID string) error {
+	if _, err := d.do(ctx, http.MethodGet, d.endpointURL(host, port, "/json/close/"+targetID), true); err != nil {
+		return fmt.Errorf("close target: %w", err)
 	}
 	return nil
 }
@@ -0,0 +1,128 @@
+package cdp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func hostPort(t *testing.T, rawURL string) (string, int) {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Hostname(), port
+}
+
+func TestListTargetsRetriesRefusedConnections(t *testing.T) {
+	d := Dialer{Retry: Retry{Attempts: 3, Backoff: time.Millisecond}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	_, err = d.ListTargets(context.Background(), "127.0.0.1", port)
+	var endpointErr *EndpointError
+	if !errors.As(err, &endpointErr) {
+		t.Fatalf("expected EndpointError, got %v", err)
+	}
+	if endpointErr.Attempts != 3 || !endpointErr.Refused {
+		t.Fatalf("unexpected endpoint error: %+v", endpointErr)
+	}
+	if !strings.Contains(err.Error(), "--remote-debugging-port") {
+		t.Fatalf("expected hint in error, got %v", err)
+	}
+}
+
+func TestListTargetsDoesNotRetryHTTPErrors(t *testing.T) {
+	d := Dialer{Retry: Retry{Attempts: 5, Backoff: time.Millisecond}}
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "nope", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	host, port := hostPort(t, srv.URL)
+
+	if _, err := d.ListTargets(context.Background(), host, port); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Fatalf("expected 1 request, got %d", got)
+	}
+}
+
+func TestDialerSendsItsAuthorization(t *testing.T) {
+	var got atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	host, port := hostPort(t, srv.URL)
+
+	// Dialers don't share state: a plain one sends nothing after an
+	// authorized one was used.
+	for _, tc := range []struct {
+		dialer Dialer
+		want   string
+	}{
+		{Dialer{Authorization: "Bearer T"}, "Bearer T"},
+		{Dialer{}, ""},
+	} {
+		if _, err := tc.dialer.ListTargets(context.Background(), host, port); err != nil {
+			t.Fatal(err)
+		}
+		if got.Load() != tc.want {
+			t.Fatalf("Authorization = %q, want %q", got.Load(), tc.want)
+		}
+	}
+}
+
+func TestCreateTargetIsNotResentAfterAReset(t *testing.T) {
+	d := Dialer{Retry: Retry{Attempts: 5, Backoff: time.Millisecond}}
+	var created int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Chrome opened the tab, then the connection dropped before the reply.
+		atomic.AddInt32(&created, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+	}))
+	defer srv.Close()
+	host, port := hostPort(t, srv.URL)
+
+	if _, err := d.CreateTarget(context.Background(), host, port, "https://example.com/"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := atomic.LoadInt32(&created); got != 1 {
+		t.Fatalf("/json/new was sent %d times, want 1", got)
+	}
+	// Reading the list again after a reset is harmless, so that still retries.
+	atomic.StoreInt32(&created, 0)
+	if _, err := d.ListTargets(context.Background(), host, port); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := atomic.LoadInt32(&created); got != 5 {
+		t.Fatalf("/json/list was sent %d times, want 5", got)
+	}
+}
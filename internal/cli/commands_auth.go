@@ -0,0 +1,286 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// maxAuthChallenges is how many challenges in a row an origin may send
+// before the credentials are treated as rejected.
+const maxAuthChallenges = 3
+
+var authHelp = commandHelp{
+	Description: `Answers HTTP auth prompts, which would otherwise block automation with a
+native dialog. The password can come from an environment variable so it
+stays out of shell history.`,
+	Examples: []commandExample{
+		{"cdp auth --session app --origin https://intranet.example --user ada --pass-env INTRANET_PASS", "Log in once, reading the password from the environment."},
+		{"cdp auth --session app --origin https://staging.example --user qa --pass secret --watch", "Keep answering challenges until Ctrl+C."},
+		{"cdp auth --session app --origin https://staging.example --user qa --pass-env PW --for 1m", "Answer challenges for one minute."},
+	},
+}
+
+func cmdAuth(args []string) error {
+	fs := newFlagSet("auth", "usage: cdp auth --session <name> --origin https://internal.example --user USER (--pass PASS | --pass-env VAR) [--watch | --for 30s]\n\nAnswers HTTP auth challenges (Fetch.authRequired) from one origin with the given\ncredentials; requests to other origins pass through untouched. Without --watch it\nstops once the credentials are accepted or --for runs out; with --watch it keeps\nanswering until Ctrl+C. Interception ends when the command exits, so navigate\nwhile it runs. Credentials are only held in memory, never saved with the session.")
+	sessionFlag := addSessionFlag(fs)
+	originFlag := fs.String("origin", "", "Origin to answer challenges for (scheme://host[:port])")
+	user := fs.String("user", "", "User name")
+	pass := fs.String("pass", "", "Password (visible in the process list; prefer --pass-env)")
+	passEnv := fs.String("pass-env", "", "Read the password from this environment variable")
+	watch := fs.Bool("watch", false, "Keep answering challenges until Ctrl+C")
+	holdFor := fs.Duration("for", 30*time.Second, "Without --watch, how long to wait for a challenge to be answered")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Timeout for connecting and enabling interception")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	if *originFlag == "" || *user == "" {
+		fs.Usage()
+		return errors.New("--origin and --user are required")
+	}
+	origin, err := normalizeOrigin(*originFlag)
+	if err != nil {
+		return err
+	}
+	password := *pass
+	if *passEnv != "" {
+		if password != "" {
+			return errors.New("use either --pass or --pass-env")
+		}
+		var ok bool
+		if password, ok = os.LookupEnv(*passEnv); !ok {
+			return fmt.Errorf("environment variable %s is not set", *passEnv)
+		}
+	}
+	if *holdFor <= 0 && !*watch {
+		return errors.New("--for must be positive")
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	ctx, cancel := withCommandTimeout(runCtx, *timeout)
+	defer cancel()
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	watcher := newAuthWatcher(origin, *user, password)
+	unsubscribe, err := interceptAuth(ctx, handle.client, watcher)
+	if err != nil {
+		return err
+	}
+	defer unsubscribe()
+	if *watch {
+		fmt.Printf("Answering auth challenges from %s as %s (Ctrl+C to stop)\n", origin, *user)
+	} else {
+		fmt.Printf("Answering auth challenges from %s as %s for up to %s\n", origin, *user, *holdFor)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	var deadline <-chan time.Time
+	if !*watch {
+		timer := time.NewTimer(*holdFor)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	for {
+		select {
+		case err := <-watcher.failed:
+			return err
+		case <-watcher.accepted:
+			fmt.Printf("Credentials accepted by %s\n", origin)
+			if !*watch {
+				return nil
+			}
+		case <-deadline:
+			if watcher.challenged() {
+				return fmt.Errorf("no response from %s after answering its auth challenge within %s", origin, *holdFor)
+			}
+			fmt.Fprintf(os.Stderr, "No auth challenge from %s within %s\n", origin, *holdFor)
+			return nil
+		case <-handle.client.Done():
+			return errors.New("connection to the session closed")
+		case <-sigCh:
+			return nil
+		}
+	}
+}
+
+// normalizeOrigin reduces a URL to scheme://host[:port], dropping default ports.
+func normalizeOrigin(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid origin %q (expected e.g. https://internal.example)", raw)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (scheme == "https" && port == "443") || (scheme == "http" && port == "80") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	return scheme + "://" + host, nil
+}
+
+// authWatcher decides how to answer challenges for one origin and notices
+// when the credentials are accepted or keep being rejected.
+type authWatcher struct {
+	origin, user, pass string
+
+	mu       sync.Mutex
+	attempts int // challenges answered since the last non-401 response
+	seen     bool
+	accepted chan struct{}
+	failed   chan error
+}
+
+func newAuthWatcher(origin, user, pass string) *authWatcher {
+	return &authWatcher{origin: origin, user: user, pass: pass, accepted: make(chan struct{}, 1), failed: make(chan error, 1)}
+}
+
+// onChallenge returns the Fetch.continueWithAuth response for a challenge
+// from origin: Default for other origins, ProvideCredentials for ours, and
+// CancelAuth once the origin keeps asking (wrong credentials).
+func (w *authWatcher) onChallenge(origin string) map[string]interface{} {
+	if normalized, err := normalizeOrigin(origin); err != nil || normalized != w.origin {
+		return map[string]interface{}{"response": "Default"}
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.seen = true
+	w.attempts++
+	if w.attempts > maxAuthChallenges {
+		select {
+		case w.failed <- fmt.Errorf("%s rejected the credentials for %s %d times in a row; cancelled the auth prompt", w.origin, w.user, maxAuthChallenges):
+		default:
+		}
+		return map[string]interface{}{"response": "CancelAuth"}
+	}
+	return map[string]interface{}{"response": "ProvideCredentials", "username": w.user, "password": w.pass}
+}
+
+// onResponse watches responses from the origin: anything but a 401 after
+// answering a challenge means the credentials worked.
+func (w *authWatcher) onResponse(responseURL string, status int) {
+	if normalized, err := normalizeOrigin(responseURL); err != nil || normalized != w.origin || status == 401 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.attempts == 0 {
+		return
+	}
+	w.attempts = 0
+	select {
+	case w.accepted <- struct{}{}:
+	default:
+	}
+}
+
+func (w *authWatcher) challenged() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.seen
+}
+
+// interceptAuth enables Fetch with handleAuthRequests for the watcher's
+// origin only, so other requests are never paused. Interception lasts until
+// the returned func runs (or the connection closes).
+func interceptAuth(ctx context.Context, client *cdp.Client, w *authWatcher) (func(), error) {
+	if err := client.Call(ctx, "Network.enable", nil, nil); err != nil {
+		return nil, err
+	}
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		switch evt.Method {
+		case "Fetch.authRequired":
+			var payload struct {
+				RequestID     string `json:"requestId"`
+				AuthChallenge struct {
+					Origin string `json:"origin"`
+				} `json:"authChallenge"`
+			}
+			if err := json.Unmarshal(evt.Params, &payload); err != nil {
+				return
+			}
+			response := w.onChallenge(payload.AuthChallenge.Origin)
+			// Event handlers run on the read loop, so CDP calls must happen elsewhere.
+			go func() {
+				callCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				client.Call(callCtx, "Fetch.continueWithAuth", map[string]interface{}{
+					"requestId":             payload.RequestID,
+					"authChallengeResponse": response,
+				}, nil)
+			}()
+		case "Fetch.requestPaused":
+			var payload struct {
+				RequestID string `json:"requestId"`
+			}
+			if err := json.Unmarshal(evt.Params, &payload); err != nil {
+				return
+			}
+			go continueFetchRequest(client, payload.RequestID)
+		case "Network.responseReceived":
+			var payload struct {
+				Response struct {
+					URL    string `json:"url"`
+					Status int    `json:"status"`
+				} `json:"response"`
+			}
+			if err := json.Unmarshal(evt.Params, &payload); err != nil {
+				return
+			}
+			w.onResponse(payload.Response.URL, payload.Response.Status)
+		}
+	})
+	if err := client.Call(ctx, "Fetch.enable", map[string]interface{}{
+		"patterns":           []map[string]interface{}{{"urlPattern": w.origin + "/*"}},
+		"handleAuthRequests": true,
+	}, nil); err != nil {
+		unsubscribe()
+		return nil, fmt.Errorf("Fetch.enable: %w", err)
+	}
+	return func() {
+		unsubscribe()
+		disableCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		client.Call(disableCtx, "Fetch.disable", nil, nil)
+	}, nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import "testing"
+
+func TestNormalizeOrigin(t *testing.T) {
+	cases := map[string]string{
+		"https://Internal.Example":           "https://internal.example",
+		"https://internal.example:443/login": "https://internal.example",
+		"http://localhost:8080/":             "http://localhost:8080",
+		"http://[::1]:80":                    "http://[::1]",
+	}
+	for in, want := range cases {
+		got, err := normalizeOrigin(in)
+		if err != nil || got != want {
+			t.Fatalf("normalizeOrigin(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := normalizeOrigin("internal.example"); err == nil {
+		t.Fatal("expected an error for an origin without a scheme")
+	}
+}
+
+func TestAuthWatcher(t *testing.T) {
+	w := newAuthWatcher("https://internal.example", "u", "p")
+	if got := w.onChallenge("https://other.example"); got["response"] != "Default" {
+		t.Fatalf("other origin answered with %v", got)
+	}
+	if got := w.onChallenge("https://internal.example:443"); got["response"] != "ProvideCredentials" || got["username"] != "u" {
+		t.Fatalf("origin answered with %v", got)
+	}
+	w.onResponse("https://internal.example/app", 401)
+	w.onResponse("https://internal.example/app", 200)
+	select {
+	case <-w.accepted:
+	default:
+		t.Fatal("expected a 200 after answering to count as accepted")
+	}
+
+	// Wrong credentials: the origin keeps challenging until we cancel.
+	for i := 0; i < maxAuthChallenges; i++ {
+		if got := w.onChallenge("https://internal.example"); got["response"] != "ProvideCredentials" {
+			t.Fatalf("challenge %d answered with %v", i+1, got)
+		}
+	}
+	if got := w.onChallenge("https://internal.example"); got["response"] != "CancelAuth" {
+		t.Fatalf("expected CancelAuth after %d rejections, got %v", maxAuthChallenges, got)
+	}
+	select {
+	case err := <-w.failed:
+		if err == nil {
+			t.Fatal("expected a failure error")
+		}
+	default:
+		t.Fatal("expected the rejection loop to be reported")
+	}
+}
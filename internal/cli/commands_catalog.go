@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type catalogFlag struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Default     string `json:"default"`
+	Description string `json:"description"`
+}
+
+type catalogCommand struct {
+	Name        string           `json:"name"`
+	Aliases     []string         `json:"aliases,omitempty"`
+	Group       string           `json:"group,omitempty"`
+	Summary     string           `json:"summary"`
+	Usage       string           `json:"usage,omitempty"`
+	Args        string           `json:"args,omitempty"`
+	Stability   string           `json:"stability,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Examples    []commandExample `json:"examples,omitempty"`
+	Flags       []catalogFlag    `json:"flags"`
+	Subcommands []catalogCommand `json:"subcommands,omitempty"`
+}
+
+var commandsHelp = commandHelp{
+	Description: `Lists the commands by group. The JSON catalog describes each command's
+arguments, flags, description and examples. Wrappers and shell completion
+scripts read it instead of parsing --help.`,
+	Examples: []commandExample{
+		{"cdp commands", "List every command by group."},
+		{"cdp commands --json", "Print the full catalog as JSON."},
+		{"cdp commands --json --pretty=false", "Print the catalog compactly for tools."},
+	},
+}
+
+func cmdCommands(args []string) error {
+	fs := newFlagSet("commands", "usage: cdp commands [--json]\n\nLists every command with its arguments and flags, read from the same\ndefinitions the commands parse with. --json is meant for wrappers and completion.")
+	jsonOut := fs.Bool("json", false, "Output the catalog as JSON")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+
+	catalog := commandCatalog()
+	if *jsonOut {
+		out, err := jsonStyle.JSON(map[string]interface{}{
+			"version":  cliVersion(),
+			"commands": catalog,
+		}, *pretty, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+	for i, group := range commandGroups {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(group)
+		for _, c := range catalog {
+			if c.Group != group {
+				continue
+			}
+			printCatalogRow(c.Name, c)
+			for _, sub := range c.Subcommands {
+				printCatalogRow(c.Name+" "+sub.Name, sub)
+			}
+		}
+	}
+	fmt.Println("\nRun 'cdp help <command>' for flags and examples, or 'cdp commands --json' for everything.")
+	return nil
+}
+
+func printCatalogRow(name string, c catalogCommand) {
+	if c.Args != "" {
+		name += " " + c.Args
+	}
+	summary := c.Summary
+	if c.Stability == experimental {
+		summary += " (experimental)"
+	}
+	fmt.Printf("  %-38s %s\n", name, summary)
+}
+
+// commandCatalog describes every registered command. Flags come from the
+// command's own flag set, captured by running it with --help.
+func commandCatalog() []catalogCommand {
+	out := make([]catalogCommand, 0, len(commandRegistry))
+	for _, c := range commandRegistry {
+		entry := describeCommand(c, c.run, nil)
+		entry.Aliases = c.Aliases
+		entry.Group = c.Group
+		entry.Stability = c.Stability
+		entry.Description = c.Help.Description
+		entry.Examples = c.Help.Examples
+		for _, sub := range c.Subcommands {
+			subEntry := describeCommand(sub, c.run, []string{sub.Name})
+			subEntry.Examples = commandExamples(c.Help, c.Name, sub.Name)
+			entry.Subcommands = append(entry.Subcommands, subEntry)
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+func describeCommand(c cliCommand, run func([]string) error, prefix []string) catalogCommand {
+	entry := catalogCommand{Name: c.Name, Summary: c.Summary, Args: c.Args, Flags: []catalogFlag{}}
+	if len(c.Subcommands) > 0 {
+		// Group commands only print their subcommand list for --help.
+		return entry
+	}
+	fs := captureFlagSet(run, append(prefix, "--help"))
+	if fs == nil {
+		return entry
+	}
+	entry.Usage, _, _ = strings.Cut(flagSetUsage(fs), "\n")
+	entry.Usage = strings.TrimPrefix(entry.Usage, "usage: ")
+	fs.VisitAll(func(f *flag.Flag) {
+		_, description := flag.UnquoteUsage(f)
+		entry.Flags = append(entry.Flags, catalogFlag{
+			Name:        f.Name,
+			Type:        flagType(f),
+			Default:     f.DefValue,
+			Description: description,
+		})
+	})
+	return entry
+}
+
+// captureFlagSet runs a command's help path with output discarded and returns
+// the first flag set it created. Commands define their flags before looking at
+// args, so the set is complete even if the command rejects --help.
+func captureFlagSet(run func([]string) error, args []string) *flag.FlagSet {
+	var captured *flag.FlagSet
+	flagSetObserver = func(fs *flag.FlagSet) {
+		fs.SetOutput(io.Discard)
+		if captured == nil {
+			captured = fs
+		}
+	}
+	defer func() { flagSetObserver = nil }()
+	_ = run(args)
+	return captured
+}
+
+func flagType(f *flag.Flag) string {
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+		return "bool"
+	}
+	if _, ok := f.Value.(*stringListFlag); ok {
+		return "list"
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return "string"
+	}
+	switch getter.Get().(type) {
+	case int, int64, uint, uint64:
+		return "int"
+	case float64:
+		return "float"
+	case time.Duration:
+		return "duration"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import "testing"
+
+func TestCommandCatalog(t *testing.T) {
+	catalog := commandCatalog()
+	if len(catalog) != len(commandRegistry) {
+		t.Fatalf("catalog has %d commands, registry %d", len(catalog), len(commandRegistry))
+	}
+	seen := map[string]bool{}
+	byName := map[string]catalogCommand{}
+	for _, c := range catalog {
+		if seen[c.Name] {
+			t.Fatalf("duplicate command %q", c.Name)
+		}
+		seen[c.Name] = true
+		byName[c.Name] = c
+		if c.Stability != stable && c.Stability != experimental {
+			t.Fatalf("%s: bad stability %q", c.Name, c.Stability)
+		}
+	}
+
+	if byName["eval"].Description == "" || len(byName["eval"].Examples) == 0 {
+		t.Fatalf("eval has no description or examples: %+v", byName["eval"])
+	}
+
+	var depth *catalogFlag
+	for i, f := range byName["eval"].Flags {
+		if f.Name == "depth" {
+			depth = &byName["eval"].Flags[i]
+		}
+	}
+	if depth == nil || depth.Type != "int" || depth.Default != "-1" {
+		t.Fatalf("eval --depth = %+v", depth)
+	}
+
+	var open *catalogCommand
+	for i, sub := range byName["tabs"].Subcommands {
+		if sub.Name == "open" {
+			open = &byName["tabs"].Subcommands[i]
+		}
+	}
+	if open == nil || open.Usage == "" || len(open.Flags) == 0 || len(open.Examples) == 0 {
+		t.Fatalf("tabs open = %+v", open)
+	}
+	if flagSetObserver != nil {
+		t.Fatal("flagSetObserver left installed")
+	}
+}
+
+func TestLookupCommandAlias(t *testing.T) {
+	c, ok := lookupCommand("--version")
+	if !ok || c.Name != "version" {
+		t.Fatalf("lookupCommand(--version) = %+v, %v", c, ok)
+	}
+	if _, ok := lookupCommand("nope"); ok {
+		t.Fatal("unknown command resolved")
+	}
+}
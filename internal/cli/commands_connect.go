@@ -4,23 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var connectHelp = commandHelp{
+	Description: `Binds a session name to one DevTools target and saves it, so later commands
+only need --session. Pick the tab by --url, by --tab (index, id or a pattern
+from 'cdp tabs list'), open a fresh one with --new, or bind any target,
+including extension workers, with --target-id. Reconnecting under an existing
+name replaces the binding. With --expect-origin, every later command first
+checks the tab's location.origin against the regex and fails on a mismatch,
+so a session that was rebound to another tab can't act on the wrong page.`,
+	Examples: []commandExample{
+		{"cdp connect --session app --port 9222 --url https://app.example/", "Bind 'app' to the tab showing that URL."},
+		{"cdp connect --session app --port 9222 --tab 2", "Bind to the third tab in 'cdp tabs list' order."},
+		{"cdp connect --session scratch --port 9222 --new --new-url https://example.com", "Open a new tab and bind it."},
+		{"cdp connect --session app --port 9222 --url https://app.example/ --user-script helpers.js --wait-ready", "Inject your helpers with every command; save once the page has loaded."},
+		{`cdp connect --session app --port 9222 --tab app.example --expect-origin "https://app\.example\.com"`, "Refuse to run commands if the session ever lands on another site."},
+		{"cdp connect --session ext --port 9222 --target-id 6A1F0C2D", "Bind an extension service worker listed by 'cdp extensions list'."},
+	},
+}
+
 func cmdConnect(args []string) error {
-	fs := newFlagSet("connect", "usage: cdp connect --session <name> --port --url\nor:    cdp connect --session <name> --port --tab <index|id|pattern>\nor:    cdp connect --session <name> --port --new [--new-url <url>]")
+	fs := newFlagSet("connect", "usage: cdp connect --session <name> --port --url\nor:    cdp connect --session <name> --port --tab <index|id|pattern>\nor:    cdp connect --session <name> --port --new [--new-url <url>]\nor:    cdp connect --session <name> --port --target-id <id>   (any target, e.g. an extension service worker from 'cdp extensions list')\n(add --user-script path.js, repeatable, to inject your own helpers alongside WebNav;\n--wait-ready/--wait-title/--wait-url delay saving until the tab has settled, bounded by --timeout;\n--expect-origin REGEX makes every later command check the tab's origin first)")
 	sessionFlag := addSessionFlag(fs)
-	host := fs.String("host", "127.0.0.1", "DevTools host")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
 	port := fs.Int("port", portDefault(0), "DevTools port")
 	targetURL := fs.String("url", "", "Tab URL to bind to")
 	targetRef := fs.String("tab", "", "Tab index, id, or pattern from tabs list")
+	targetID := fs.String("target-id", "", "Exact target id from /json/list; also binds non-page targets (extension service workers, background pages)")
 	newTab := fs.Bool("new", false, "Open a new tab and connect to it")
 	newURL := fs.String("new-url", "about:blank", "URL to open when using --new")
 	activate := fs.Bool("activate", true, "Activate the tab after opening (with --new)")
-	timeout := fs.Duration("timeout", 5*time.Second, "Connection timeout")
+	bypassCSP := fs.Bool("bypass-csp", false, "Bypass the page's Content Security Policy whenever this session is used")
+	waitReady := fs.Bool("wait-ready", false, "Wait for document.readyState == complete before saving the session")
+	waitTitle := fs.String("wait-title", "", "Wait until the tab title matches this regex before saving the session")
+	waitURL := fs.String("wait-url", "", "Wait until the tab URL matches this regex before saving the session")
+	var userScripts stringListFlag
+	fs.Var(&userScripts, "user-script", "JS file injected alongside WebNav on every command (repeatable)")
+	expectOrigin := fs.String("expect-origin", "", "Regex the tab's origin must match (whole origin, e.g. 'https://app\\.example\\.com'); later commands fail if the session ends up on another origin")
+	persistWebNav := fs.Bool("persist-webnav", false, "Register WebNav for new documents on each connection, so navigations mid-command keep it (see cdp inject --auto)")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Connection timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -40,27 +68,54 @@ func cmdConnect(args []string) error {
 	if *port == 0 {
 		return errors.New("--port is required")
 	}
-	if *newTab && (*targetURL != "" || *targetRef != "") {
-		return errors.New("use --new without --url or --tab")
+	selectors := 0
+	for _, set := range []bool{*newTab, *targetURL != "", *targetRef != "", *targetID != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors > 1 {
+		return errors.New("use only one of --url, --tab, --target-id, or --new")
+	}
+	if selectors == 0 {
+		return errors.New("one of --url, --tab, --target-id, or --new is required")
+	}
+	var titleRe, urlRe *regexp.Regexp
+	if *waitTitle != "" {
+		if titleRe, err = compileRouteRegex(*waitTitle, "--wait-title"); err != nil {
+			return err
+		}
+	}
+	if *waitURL != "" {
+		if urlRe, err = compileRouteRegex(*waitURL, "--wait-url"); err != nil {
+			return err
+		}
 	}
-	if *targetURL != "" && *targetRef != "" {
-		return errors.New("use either --url or --tab, not both")
+	if *expectOrigin != "" {
+		if _, err := compileExpectedOrigin(*expectOrigin); err != nil {
+			return err
+		}
 	}
-	if !*newTab && *targetURL == "" && *targetRef == "" {
-		return errors.New("one of --url, --tab, or --new is required")
+	scripts := make([]store.UserScript, 0, len(userScripts))
+	for _, path := range userScripts {
+		script, _, err := loadUserScript(path)
+		if err != nil {
+			return err
+		}
+		scripts = append(scripts, script)
 	}
 	st, err := store.Load()
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	var target cdp.TargetInfo
 	switch {
 	case *newTab:
-		tab, err := cdp.CreateTarget(ctx, *host, *port, *newURL)
+		tab, err := dialer().CreateTarget(ctx, *host, *port, *newURL)
 		if err != nil {
 			return err
 		}
@@ -68,13 +123,13 @@ func cmdConnect(args []string) error {
 			tab.URL = *newURL
 		}
 		if *activate {
-			if err := cdp.ActivateTarget(ctx, *host, *port, tab.ID); err != nil {
+			if err := dialer().ActivateTarget(ctx, *host, *port, tab.ID); err != nil {
 				return err
 			}
 		}
 		target = tab
 	case *targetRef != "":
-		tabs, err := fetchTabs(ctx, *host, *port)
+		tabs, err := fetchTabs(ctx, dialer(), *host, *port)
 		if err != nil {
 			return fmt.Errorf("list tabs failed (check with 'cdp tabs list --host %s --port %d'): %w", *host, *port, err)
 		}
@@ -86,8 +141,23 @@ func cmdConnect(args []string) error {
 			return err
 		}
 		target = tab
+	case *targetID != "":
+		targets, err := dialer().ListTargets(ctx, *host, *port)
+		if err != nil {
+			return fmt.Errorf("list targets failed (check with 'cdp extensions list --host %s --port %d'): %w", *host, *port, err)
+		}
+		found := false
+		for _, t := range targets {
+			if t.ID == *targetID {
+				target, found = t, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no target with id %s (run 'cdp extensions list --host %s --port %d' to confirm)", *targetID, *host, *port)
+		}
 	default:
-		targets, err := cdp.ListTargets(ctx, *host, *port)
+		targets, err := dialer().ListTargets(ctx, *host, *port)
 		if err != nil {
 			return fmt.Errorf("list targets failed (check with 'cdp tabs list --host %s --port %d'): %w", *host, *port, err)
 		}
@@ -100,17 +170,47 @@ func cmdConnect(args []string) error {
 	if target.WebSocket == "" {
 		return errors.New("target does not expose webSocketDebuggerUrl")
 	}
-	wsURL := rewriteWebSocketURL(target.WebSocket, *host, *port)
+	wsURL := rewriteWebSocketURL(target.WebSocket, *host, *port, dialer().Secure)
 
-	client, err := cdp.Dial(ctx, wsURL)
+	client, err := dialer().Dial(ctx, wsURL)
 	if err != nil {
 		return err
 	}
 	defer client.Close()
 
-	if _, err := client.Evaluate(ctx, "document.readyState"); err != nil {
+	// Workers have no document; a round trip through the runtime is enough.
+	handshake := "document.readyState"
+	if !targetHasDocument(target.Type) {
+		handshake = "typeof self"
+		if *waitReady || titleRe != nil || urlRe != nil {
+			return fmt.Errorf("--wait-ready/--wait-title/--wait-url need a page, not a %s target", target.Type)
+		}
+	}
+	if _, err := client.Evaluate(ctx, handshake); err != nil {
 		return fmt.Errorf("tab handshake failed: %w", err)
 	}
+	if *bypassCSP {
+		if err := setBypassCSP(ctx, client, true); err != nil {
+			return err
+		}
+	}
+	if *waitReady || titleRe != nil || urlRe != nil {
+		if err := waitForPageSettled(ctx, client, *waitReady, titleRe, urlRe, 100*time.Millisecond); err != nil {
+			return err
+		}
+		// The tab may have committed a different document since it was listed.
+		var info struct {
+			TargetInfo struct {
+				URL   string `json:"url"`
+				Title string `json:"title"`
+			} `json:"targetInfo"`
+		}
+		if err := client.Call(ctx, "Target.getTargetInfo", nil, &info); err != nil {
+			return err
+		}
+		target.URL = info.TargetInfo.URL
+		target.Title = info.TargetInfo.Title
+	}
 
 	session := store.Session{
 		Name:           name,
@@ -123,18 +223,53 @@ func cmdConnect(args []string) error {
 		Type:           target.Type,
 		LastConnected:  time.Now(),
 		LastTargetInfo: target.Description,
+		BypassCSP:      *bypassCSP,
+		PersistWebNav:  *persistWebNav,
+		Profile:        connectedProfile(),
+		UserScripts:    scripts,
+		ExpectedOrigin: *expectOrigin,
+	}
+	if err := checkExpectedOrigin(ctx, client, session); err != nil {
+		return err
 	}
 	if err := st.Set(session); err != nil {
 		return err
 	}
 	fmt.Printf("Connected %s -> %s (%s)\n", name, target.Title, target.URL)
+	for _, script := range scripts {
+		fmt.Printf("  user script: %s\n", script.Path)
+	}
 	return nil
 }
 
+// keepAliveCalls are the CDP calls `cdp keep-alive` makes. The persistent ones
+// are recorded as session overrides, so --restore-overrides can re-apply them
+// after a detach and `cdp state` can report them.
+var keepAliveCalls = []struct {
+	method     string
+	params     map[string]interface{}
+	persistent bool
+}{
+	{"Emulation.setFocusEmulationEnabled", map[string]interface{}{"enabled": true}, true},
+	{"Page.setWebLifecycleState", map[string]interface{}{"state": "active"}, true},
+	{"Page.bringToFront", nil, false},
+}
+
+var keepAliveHelp = commandHelp{
+	Description: `Stops Chrome from treating a background tab as hidden: enables focus emulation,
+sets the page lifecycle to active and brings the tab to the front. Run it before
+long automation in a window you are not looking at.`,
+	Examples: []commandExample{
+		{"cdp keep-alive --session app", "Keep 'app' active and focused."},
+		{"cdp keep-alive --session app --timeout 10s", "Allow more time on a slow browser."},
+		{"cdp keep-alive", "Keep the session from CDP_SESSION_NAME active."},
+	},
+}
+
 func cmdKeepAlive(args []string) error {
 	fs := newFlagSet("keep-alive", "usage: cdp keep-alive --session <name>")
 	sessionFlag := addSessionFlag(fs)
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -157,7 +292,7 @@ func cmdKeepAlive(args []string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -166,16 +301,14 @@ func cmdKeepAlive(args []string) error {
 	}
 	defer handle.Close()
 
-	commands := []struct {
-		method string
-		params map[string]interface{}
-	}{
-		{"Emulation.setFocusEmulationEnabled", map[string]interface{}{"enabled": true}},
-		{"Page.setWebLifecycleState", map[string]interface{}{"state": "active"}},
-		{"Page.bringToFront", nil},
-	}
-	for _, cmd := range commands {
-		if err := handle.client.Call(ctx, cmd.method, cmd.params, nil); err != nil {
+	for _, cmd := range keepAliveCalls {
+		var err error
+		if cmd.persistent {
+			err = applyOverride(ctx, handle.client, cmd.method, cmd.params)
+		} else {
+			err = handle.client.Call(ctx, cmd.method, cmd.params, nil)
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -183,6 +316,196 @@ func cmdKeepAlive(args []string) error {
 	return nil
 }
 
+// stateTimerProbe is the setTimeout delay `cdp state` measures; a tab whose
+// timer fires much later than this is being throttled.
+const stateTimerProbe = 100 * time.Millisecond
+
+type pageState struct {
+	VisibilityState string  `json:"visibilityState"`
+	HasFocus        bool    `json:"hasFocus"`
+	FocusEmulation  bool    `json:"focusEmulation"`
+	KeepAlive       bool    `json:"keepAliveRecorded"`
+	ScriptsDisabled bool    `json:"scriptsDisabled"`
+	Lifecycle       string  `json:"lifecycle"`
+	WasDiscarded    bool    `json:"wasDiscarded"`
+	TimerMs         float64 `json:"timerMs"`
+	TimerExpectedMs float64 `json:"timerExpectedMs"`
+	ServiceWorker   bool    `json:"serviceWorkerController"`
+	Verdict         string  `json:"verdict"`
+	Throttled       bool    `json:"throttled"`
+}
+
+var stateHelp = commandHelp{
+	Description: `Explains why a tab might be slow or stuck without changing it: background tabs
+have throttled timers, frozen lifecycle states stop script, and an unfocused
+window changes focus events. The timer probe measures the real delay of a 100ms
+setTimeout.`,
+	Examples: []commandExample{
+		{"cdp state --session app", "Print a readable summary of the tab's state."},
+		{"cdp state --session app --json", "Print the same report as JSON for scripts."},
+		{"cdp state --session app --timeout 20s", "Allow extra time for a heavily throttled tab."},
+	},
+}
+
+func cmdState(args []string) error {
+	fs := newFlagSet("state", "usage: cdp state --session <name> [--json]\n\nRead-only check for backgrounded or throttled tabs: visibility, focus, focus emulation,\nlifecycle, the real delay of a 100ms timer, and service worker control.")
+	sessionFlag := addSessionFlag(fs)
+	jsonOut := fs.Bool("json", false, "Output JSON")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	state := pageState{
+		TimerExpectedMs: durationMs(stateTimerProbe),
+		FocusEmulation:  focusEmulationRecorded(handle.session.Overrides),
+		KeepAlive:       keepAliveRecorded(handle.session.Overrides),
+		ScriptsDisabled: scriptsDisabledActive(handle.session),
+	}
+	value, err := handle.client.Evaluate(ctx, fmt.Sprintf(`(async () => {
+        const start = performance.now();
+        await new Promise(resolve => setTimeout(resolve, %d));
+        return {
+            visibilityState: document.visibilityState,
+            hasFocus: document.hasFocus(),
+            wasDiscarded: !!document.wasDiscarded,
+            prerendering: !!document.prerendering,
+            timerMs: performance.now() - start,
+            serviceWorker: !!(navigator.serviceWorker && navigator.serviceWorker.controller),
+        };
+    })()`, stateTimerProbe.Milliseconds()))
+	if errors.Is(err, context.DeadlineExceeded) && state.ScriptsDisabled {
+		return fmt.Errorf("page JS did not finish a %s timer within %s: %s; run 'cdp js --session %s --enable'", stateTimerProbe, *timeout, scriptsDisabledHint, name)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("tab appears frozen: page JS did not finish a %s timer within %s; consider cdp keep-alive", stateTimerProbe, *timeout)
+	}
+	if err != nil {
+		return err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected state result type %T", value)
+	}
+	state.VisibilityState, _ = m["visibilityState"].(string)
+	state.HasFocus, _ = m["hasFocus"].(bool)
+	state.WasDiscarded, _ = m["wasDiscarded"].(bool)
+	state.TimerMs, _ = m["timerMs"].(float64)
+	state.ServiceWorker, _ = m["serviceWorker"].(bool)
+	// A page that runs JS is not frozen; Chrome only exposes the rest via visibility.
+	switch {
+	case m["prerendering"] == true:
+		state.Lifecycle = "prerendering"
+	case state.VisibilityState == "hidden":
+		state.Lifecycle = "hidden"
+	case state.HasFocus:
+		state.Lifecycle = "active"
+	default:
+		state.Lifecycle = "passive"
+	}
+	state.Verdict, state.Throttled = stateVerdict(state)
+
+	if *jsonOut {
+		out, err := jsonStyle.JSON(state, *pretty, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+	onOff := map[bool]string{true: "yes", false: "no"}
+	fmt.Printf("visibility:      %s\n", state.VisibilityState)
+	fmt.Printf("focused:         %s (focus emulation recorded: %s)\n", onOff[state.HasFocus], onOff[state.FocusEmulation])
+	fmt.Printf("lifecycle:       %s%s\n", state.Lifecycle, map[bool]string{true: " (was discarded)", false: ""}[state.WasDiscarded])
+	fmt.Printf("keep-alive:      %s\n", map[bool]string{true: "recorded as session overrides", false: "not recorded"}[state.KeepAlive])
+	fmt.Printf("timer:           %.0fms for a %.0fms setTimeout\n", state.TimerMs, state.TimerExpectedMs)
+	fmt.Printf("service worker:  %s\n", onOff[state.ServiceWorker])
+	fmt.Printf("scripts:         %s\n", map[bool]string{true: "disabled (recorded override)", false: "enabled"}[state.ScriptsDisabled])
+	fmt.Println(state.Verdict)
+	return nil
+}
+
+// focusEmulationRecorded reports whether the session has focus emulation on
+// among its recorded overrides.
+func focusEmulationRecorded(overrides []store.Override) bool {
+	enabled := false
+	for _, o := range overrides {
+		if o.Method == "Emulation.setFocusEmulationEnabled" {
+			enabled, _ = o.Params["enabled"].(bool)
+		}
+	}
+	return enabled
+}
+
+// keepAliveRecorded reports whether every persistent keep-alive call is among
+// the session's recorded overrides.
+func keepAliveRecorded(overrides []store.Override) bool {
+	recorded := map[string]bool{}
+	for _, o := range overrides {
+		recorded[o.Method] = true
+	}
+	for _, cmd := range keepAliveCalls {
+		if cmd.persistent && !recorded[cmd.method] {
+			return false
+		}
+	}
+	return true
+}
+
+// stateVerdict summarizes a pageState in one line and reports whether the tab
+// looks throttled or backgrounded.
+func stateVerdict(s pageState) (string, bool) {
+	switch {
+	case s.ScriptsDisabled:
+		return "script execution is disabled for this session: WebNav commands will fail; run cdp js --enable", false
+	case s.TimerMs > 3*s.TimerExpectedMs:
+		return fmt.Sprintf("tab appears throttled: %.0fms timer took %.0fms; consider cdp keep-alive", s.TimerExpectedMs, s.TimerMs), true
+	case s.VisibilityState == "hidden":
+		return "tab is hidden: timers and rendering may be throttled; consider cdp keep-alive", true
+	case !s.HasFocus && !s.FocusEmulation:
+		return "tab is visible but not focused: focus-dependent UI may misbehave; consider cdp keep-alive", false
+	default:
+		return "tab looks active", false
+	}
+}
+
+var disconnectHelp = commandHelp{
+	Description: `Removes a saved session. The tab itself is left open; use 'cdp tabs close' to
+close it as well.`,
+	Examples: []commandExample{
+		{"cdp disconnect --session app", "Forget the 'app' session."},
+		{"cdp disconnect", "Forget the session named by CDP_SESSION_NAME."},
+		{"cdp --json-errors disconnect --session scratch", "Report an unknown session as JSON on stderr."},
+	},
+}
+
 func cmdDisconnect(args []string) error {
 	fs := newFlagSet("disconnect", "usage: cdp disconnect --session <name>")
 	sessionFlag := addSessionFlag(fs)
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+func TestStateVerdict(t *testing.T) {
+	base := pageState{VisibilityState: "visible", HasFocus: true, TimerMs: 101, TimerExpectedMs: 100}
+	if verdict, throttled := stateVerdict(base); verdict != "tab looks active" || throttled {
+		t.Fatalf("active tab: %q %v", verdict, throttled)
+	}
+	slow := base
+	slow.TimerMs = 987
+	if verdict, throttled := stateVerdict(slow); !throttled || !strings.Contains(verdict, "100ms timer took 987ms") {
+		t.Fatalf("slow timer: %q %v", verdict, throttled)
+	}
+	hidden := base
+	hidden.VisibilityState = "hidden"
+	if _, throttled := stateVerdict(hidden); !throttled {
+		t.Fatal("hidden tab should count as throttled")
+	}
+	unfocused := base
+	unfocused.HasFocus = false
+	if verdict, _ := stateVerdict(unfocused); !strings.Contains(verdict, "not focused") {
+		t.Fatalf("unfocused tab: %q", verdict)
+	}
+	unfocused.FocusEmulation = true
+	if verdict, _ := stateVerdict(unfocused); verdict != "tab looks active" {
+		t.Fatalf("focus emulation should cover missing focus: %q", verdict)
+	}
+}
+
+func TestFocusEmulationRecorded(t *testing.T) {
+	overrides := []store.Override{
+		{Method: "Emulation.setFocusEmulationEnabled", Params: map[string]interface{}{"enabled": true}},
+		{Method: "Network.setExtraHTTPHeaders"},
+	}
+	if !focusEmulationRecorded(overrides) {
+		t.Fatal("expected focus emulation")
+	}
+	overrides = append(overrides, store.Override{Method: "Emulation.setFocusEmulationEnabled", Params: map[string]interface{}{"enabled": false}})
+	if focusEmulationRecorded(overrides) {
+		t.Fatal("later disable should win")
+	}
+}
+
+func TestKeepAliveRecorded(t *testing.T) {
+	overrides := []store.Override{{Method: "Emulation.setFocusEmulationEnabled", Params: map[string]interface{}{"enabled": true}}}
+	if keepAliveRecorded(overrides) {
+		t.Fatal("lifecycle override is missing")
+	}
+	overrides = append(overrides, store.Override{Method: "Page.setWebLifecycleState", Params: map[string]interface{}{"state": "active"}})
+	if !keepAliveRecorded(overrides) {
+		t.Fatal("expected keep-alive to be recorded")
+	}
+}
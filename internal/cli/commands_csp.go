@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var cspHelp = commandHelp{
+	Description: `Pages with a strict Content-Security-Policy can block injected helpers and eval.
+The bypass setting is stored on the session and applied on every connection until
+it is disabled.`,
+	Examples: []commandExample{
+		{"cdp csp bypass --session app --enable", "Bypass CSP for 'app'."},
+		{"cdp csp bypass --session app --disable", "Turn the bypass off again."},
+		{"cdp csp bypass --session app --enable --timeout 10s", "Allow more time on a slow tab."},
+	},
+}
+
+func cmdCSP(args []string) error {
+	if len(args) == 0 {
+		printCSPUsage()
+		return usagef("usage: cdp csp <command> (bypass)")
+	}
+	if isHelpArg(args[0]) {
+		printCSPUsage()
+		return nil
+	}
+	switch args[0] {
+	case "bypass":
+		return cmdCSPBypass(args[1:])
+	default:
+		return usagef("unknown csp command %q (expected bypass)", args[0])
+	}
+}
+
+func printCSPUsage() {
+	fmt.Println("usage: cdp csp <command> (bypass)")
+	fmt.Println("Commands:")
+	fmt.Println("  bypass  Toggle Page.setBypassCSP for a session (remembered across commands)")
+	fmt.Println("Run 'cdp csp <command> --help' for details.")
+}
+
+func cmdCSPBypass(args []string) error {
+	fs := newFlagSet("csp bypass", "usage: cdp csp bypass --session <name> --enable|--disable")
+	sessionFlag := addSessionFlag(fs)
+	enable := fs.Bool("enable", false, "Bypass the page's Content Security Policy")
+	disable := fs.Bool("disable", false, "Restore Content Security Policy enforcement")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	if *enable == *disable {
+		return errors.New("pass exactly one of --enable or --disable")
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	// Always send the call (even for --disable) so enforcement is really
+	// restored, not just forgotten for future commands.
+	if err := setBypassCSP(ctx, handle.client, *enable); err != nil {
+		return err
+	}
+	handle.session.BypassCSP = *enable
+	if *enable {
+		fmt.Printf("CSP bypass enabled for %s (reload the page to apply it to the current document)\n", name)
+	} else {
+		fmt.Printf("CSP bypass disabled for %s\n", name)
+	}
+	return nil
+}
+
+func setBypassCSP(ctx context.Context, client *cdp.Client, enabled bool) error {
+	return client.Call(ctx, "Page.setBypassCSP", map[string]interface{}{"enabled": enabled}, nil)
+}
+
+// withCSPHint points at `cdp csp bypass` when err looks like a CSP violation
+// (e.g. style-src blocking an injected <style>, or eval being refused).
+func withCSPHint(client *cdp.Client, err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "content security policy") && !strings.Contains(msg, "unsafe-eval") {
+		return err
+	}
+	session := "<name>"
+	if s := lookupSession(client); s != nil {
+		if s.BypassCSP {
+			return err
+		}
+		session = s.Name
+	}
+	return fmt.Errorf("%w (the page's CSP blocked this; try 'cdp csp bypass --session %s --enable' and reload)", err, session)
+}
@@ -1,31 +1,50 @@
 package cli
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"os/signal"
+	"sort"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/veilm/cdp-cli/internal/format"
+	"github.com/veilm/cdp-cli/internal/cdp"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var domHelp = commandHelp{
+	Description: `Prints the first match's outer HTML and its text as JSON. Reach for it when
+'cdp read' hides the markup you need, such as attributes, hidden children or
+exact whitespace.`,
+	Examples: []commandExample{
+		{`cdp dom --session app "#summary"`, "Dump the summary element."},
+		{`cdp dom --session app "table.orders" --raw-text`, "Get the table's textContent, hidden cells included."},
+		{`cdp dom --session app article --block-sep " | "`, "Join text blocks with a visible separator."},
+	},
+}
+
 func cmdDOM(args []string) error {
-	fs := newFlagSet("dom", "usage: cdp dom --session <name> \".selector\"")
+	fs := newFlagSet("dom", "usage: cdp dom --session <name> \".selector\" [--raw-text] [--block-sep SEP]\n\nThe text field is innerText by default. --raw-text uses textContent (no layout\nnormalization); --block-sep walks the text nodes and joins block-level elements\nwith SEP (escapes like \\n and \\t are decoded).")
 	sessionFlag := addSessionFlag(fs)
 	pretty := fs.Bool("pretty", true, "Pretty print output")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	rawText := fs.Bool("raw-text", false, "Return textContent instead of innerText")
+	blockSep := fs.String("block-sep", "", "Separate block-level elements with this string (implies a text-node walk)")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	switch len(args) {
 	case 0:
 		fs.Usage()
-		return errors.New("usage: cdp dom --session <name> \".selector\"")
+		return usagef("usage: cdp dom --session <name> \".selector\"")
 	case 1:
 		if isHelpArg(args[0]) {
 			fs.Usage()
 			return nil
 		}
-		return errors.New("usage: cdp dom --session <name> \".selector\"")
+		return usagef("usage: cdp dom --session <name> \".selector\"")
 	}
 	pos, err := parseInterspersed(fs, args)
 	if err != nil {
@@ -36,7 +55,7 @@ func cmdDOM(args []string) error {
 	}
 	selector := pos[0]
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	if err := rejectUnsupportedSelector(selector, "dom", false); err != nil {
 		return err
@@ -51,7 +70,7 @@ func cmdDOM(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -60,16 +79,54 @@ func cmdDOM(args []string) error {
 	}
 	defer handle.Close()
 
+	sep := ""
+	useSep := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "block-sep" {
+			useSep = true
+			sep = decodeEscapes(*blockSep)
+		}
+	})
+	sepJSON, _ := json.Marshal(sep)
 	expression := fmt.Sprintf(`(() => {
         const el = document.querySelector(%s);
         if (!el) { return null; }
+        const raw = %t, useSep = %t, sep = %s;
+        const skip = new Set(["SCRIPT", "STYLE", "NOSCRIPT", "TEMPLATE"]);
+        const isBlock = (node) => {
+            const display = getComputedStyle(node).display;
+            return display !== "none" && !display.startsWith("inline") && display !== "contents";
+        };
+        const walk = () => {
+            const parts = [];
+            const boundary = () => {
+                if (parts.length && parts[parts.length - 1] !== sep) parts.push(sep);
+            };
+            const visit = (node) => {
+                if (node.nodeType === Node.TEXT_NODE) {
+                    const text = raw ? node.data : node.data.replace(/\s+/g, " ");
+                    if (raw || text.trim()) parts.push(text);
+                    return;
+                }
+                if (node.nodeType !== Node.ELEMENT_NODE || skip.has(node.tagName)) return;
+                const block = node !== el && isBlock(node);
+                if (block) boundary();
+                for (const child of node.childNodes) visit(child);
+                if (block) boundary();
+            };
+            visit(el);
+            while (parts.length && parts[parts.length - 1] === sep) parts.pop();
+            let text = parts.join("");
+            if (!raw && sep) text = text.split(sep).map(s => s.trim()).join(sep);
+            return text;
+        };
         return {
             outerHTML: el.outerHTML,
-            text: el.innerText,
+            text: useSep ? walk() : (raw ? el.textContent : el.innerText),
         };
-    })()`, strconv.Quote(selector))
+    })()`, strconv.Quote(selector), *rawText, useSep, string(sepJSON))
 
-	value, err := handle.client.Evaluate(ctx, expression)
+	value, err := evaluatePrinted(ctx, handle.client, expression)
 	if err != nil {
 		return err
 	}
@@ -77,7 +134,7 @@ func cmdDOM(args []string) error {
 		fmt.Println("null")
 		return nil
 	}
-	output, err := format.JSON(value, *pretty, -1)
+	output, err := jsonStyle.JSON(value, *pretty, -1)
 	if err != nil {
 		return err
 	}
@@ -85,20 +142,152 @@ func cmdDOM(args []string) error {
 	return nil
 }
 
+// decodeEscapes turns backslash escapes such as \n and \t typed on the command
+// line into the characters they name; invalid sequences are kept verbatim.
+func decodeEscapes(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	if decoded, err := strconv.Unquote(`"` + strings.ReplaceAll(s, `"`, `\"`) + `"`); err == nil {
+		return decoded
+	}
+	return s
+}
+
+// styleProperties are the computed styles reported by `cdp styles`.
+var styleProperties = []string{
+	"display", "position", "top", "left", "right", "bottom", "width", "height",
+	"marginTop", "marginRight", "marginBottom", "marginLeft",
+	"paddingTop", "paddingRight", "paddingBottom", "paddingLeft",
+	"borderTopWidth", "borderRightWidth", "borderBottomWidth", "borderLeftWidth",
+	"fontSize", "fontWeight", "lineHeight", "color", "backgroundColor",
+	"content", "transform", "inset", "gap",
+	"flex", "flexDirection", "flexWrap", "justifyContent", "alignItems",
+	"gridTemplateColumns", "gridTemplateRows", "gridArea",
+}
+
+// stylePseudoElements are the pseudo-elements `cdp styles --pseudo` accepts.
+var stylePseudoElements = []string{"before", "after", "placeholder", "marker"}
+
+// parseStylePseudo turns "before" or "::before" into "::before".
+func parseStylePseudo(value string) (string, error) {
+	name := strings.TrimPrefix(strings.TrimSpace(value), "::")
+	for _, known := range stylePseudoElements {
+		if name == known {
+			return "::" + name, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --pseudo %q (expected %s)", value, strings.Join(stylePseudoElements, ", "))
+}
+
+// styleQuery selects the computed styles to read. With all, every property
+// of the computed style is enumerated, narrowed to props when given.
+type styleQuery struct {
+	props  []string
+	all    bool
+	pseudo string
+}
+
+// readComputedStylesJS is a JS function (el, pseudo, props, all) returning
+// {name: value}. Names may be camelCase or kebab-case (custom properties
+// too); they are reported as given.
+const readComputedStylesJS = `function(el, pseudo, props, all) {
+        const computed = window.getComputedStyle(el, pseudo || null);
+        const kebab = (p) => p.startsWith("--") ? p : p.replace(/[A-Z]/g, (c) => "-" + c.toLowerCase());
+        let names = props || [];
+        if (all) {
+            const wanted = props ? new Set(props.map(kebab)) : null;
+            names = [];
+            for (let i = 0; i < computed.length; i++) {
+                if (!wanted || wanted.has(computed[i])) { names.push(computed[i]); }
+            }
+        }
+        const out = {};
+        for (const p of names) {
+            let v = computed.getPropertyValue(kebab(p));
+            if (v === "" && p in computed) { v = String(computed[p]); }
+            out[p] = v;
+        }
+        return out;
+    }`
+
+// stylesExpression builds the one-shot `cdp styles` evaluation. The element's
+// styles come with its box; --pseudo reports only the pseudo-element's
+// styles, and includePseudo adds ::before and ::after blocks.
+func stylesExpression(selector string, query styleQuery, includePseudo bool) string {
+	var propsJSON []byte
+	if query.props != nil {
+		propsJSON, _ = json.Marshal(query.props)
+	} else if !query.all {
+		propsJSON, _ = json.Marshal(styleProperties)
+	} else {
+		propsJSON = []byte("null")
+	}
+	return fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (!el) { return null; }
+        const read = %s;
+        const props = %s;
+        const all = %t;
+        const pseudo = %s;
+        if (pseudo) {
+            return { pseudo, styles: read(el, pseudo, props, all) };
+        }
+        const rect = el.getBoundingClientRect();
+        const result = {
+            styles: read(el, null, props, all),
+            box: {
+                top: rect.top,
+                left: rect.left,
+                right: rect.right,
+                bottom: rect.bottom,
+                width: rect.width,
+                height: rect.height,
+            }
+        };
+        if (%t) {
+            result.pseudo = {
+                "::before": read(el, "::before", props, all),
+                "::after": read(el, "::after", props, all),
+            };
+        }
+        return result;
+    })()`, strconv.Quote(selector), readComputedStylesJS, propsJSON, query.all, strconv.Quote(query.pseudo), includePseudo)
+}
+
+var stylesHelp = commandHelp{
+	Description: `Prints computed styles for the first match: a default set of layout properties,
+--props for your own list, or --all-props for everything. --pseudo reads one
+pseudo-element and --include-pseudo adds all of them. --watch samples the styles
+and prints only what changed, which helps with transitions.`,
+	Examples: []commandExample{
+		{`cdp styles --session app ".btn-primary"`, "Show the button's key layout styles."},
+		{`cdp styles --session app ".badge" --props color,background-color,font-weight`, "Read three properties."},
+		{`cdp styles --session app ".required-label" --pseudo after --props content,color`, "Inspect a ::after marker."},
+		{`cdp styles --session app ".drawer" --watch 100ms --duration 2s --props transform,opacity`, "Follow an animation for two seconds."},
+	},
+}
+
 func cmdStyles(args []string) error {
-	fs := newFlagSet("styles", "usage: cdp styles --session <name> \".selector\"")
+	fs := newFlagSet("styles", "usage: cdp styles --session <name> \".selector\" [--props a,b] [--all-props] [--pseudo before|after|placeholder|marker | --include-pseudo] [--watch 500ms [--duration 10s]]\n\nPrints computed styles as JSON: {styles, box} for the element, {pseudo, styles} with\n--pseudo, and {styles, box, pseudo: {\"::before\", \"::after\"}} with --include-pseudo.\n--props narrows every mode, including --all-props and --watch.")
 	sessionFlag := addSessionFlag(fs)
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	watch := fs.Duration("watch", 0, "Sample styles on this interval and print only changes (0 disables)")
+	duration := fs.Duration("duration", 10*time.Second, "How long --watch runs")
+	props := fs.String("props", "", "Comma-separated properties to report (default: the standard set, plus box.top/left/width/height with --watch)")
+	allProps := fs.Bool("all-props", false, "Report every computed property instead of the standard set")
+	pseudoFlag := fs.String("pseudo", "", "Read the styles of a pseudo-element: before, after, placeholder or marker")
+	includePseudo := fs.Bool("include-pseudo", false, "Also report the ::before and ::after styles alongside the element's")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	switch len(args) {
 	case 0:
 		fs.Usage()
-		return errors.New("usage: cdp styles --session <name> \".selector\"")
+		return usagef("usage: cdp styles --session <name> \".selector\"")
 	case 1:
 		if isHelpArg(args[0]) {
 			fs.Usage()
 			return nil
 		}
-		return errors.New("usage: cdp styles --session <name> \".selector\"")
+		return usagef("usage: cdp styles --session <name> \".selector\"")
 	}
 	pos, err := parseInterspersed(fs, args)
 	if err != nil {
@@ -109,11 +298,29 @@ func cmdStyles(args []string) error {
 	}
 	selector := pos[0]
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	if err := rejectUnsupportedSelector(selector, "styles", false); err != nil {
 		return err
 	}
+	if *watch < 0 || (*watch > 0 && *duration <= 0) {
+		return errors.New("--watch and --duration must be positive")
+	}
+	query := styleQuery{all: *allProps}
+	if strings.TrimSpace(*props) != "" {
+		query.props = splitCommaList(*props)
+	}
+	if *pseudoFlag != "" {
+		if *includePseudo {
+			return errors.New("use either --pseudo or --include-pseudo, not both")
+		}
+		if query.pseudo, err = parseStylePseudo(*pseudoFlag); err != nil {
+			return err
+		}
+	}
+	if *includePseudo && *watch > 0 {
+		return errors.New("--include-pseudo can't be combined with --watch; watch one pseudo-element with --pseudo")
+	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
 		fs.Usage()
@@ -124,7 +331,7 @@ func cmdStyles(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 	handle, err := openSession(ctx, st, name)
 	if err != nil {
@@ -132,61 +339,215 @@ func cmdStyles(args []string) error {
 	}
 	defer handle.Close()
 
+	if *watch > 0 {
+		if query.props == nil && !query.all {
+			query.props = append(append([]string{}, styleProperties...), "box.top", "box.left", "box.width", "box.height")
+		}
+		return watchStyles(handle.client, selector, query, *watch, *duration, *timeout)
+	}
+
+	value, err := evaluatePrinted(ctx, handle.client, stylesExpression(selector, query, *includePseudo))
+	if err != nil {
+		return err
+	}
+	output, err := jsonStyle.JSON(value, true, -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+type styleChange struct {
+	Prop string
+	Old  string
+	New  string
+}
+
+// diffStyleSamples lists properties whose value differs between two samples, sorted by name.
+func diffStyleSamples(prev, cur map[string]string) []styleChange {
+	var changes []styleChange
+	for prop, val := range cur {
+		if old, ok := prev[prop]; !ok || old != val {
+			changes = append(changes, styleChange{Prop: prop, Old: prev[prop], New: val})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Prop < changes[j].Prop })
+	return changes
+}
+
+// sampleLoop runs tick now and then every interval until duration passes or
+// the user interrupts, stopping early on the first error. It backs the watch
+// modes that poll an element over one connection (styles --watch,
+// rect --follow).
+func sampleLoop(interval, duration time.Duration, tick func() error) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	if err := tick(); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(duration)
+	defer deadline.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := tick(); err != nil {
+				return err
+			}
+		case <-deadline.C:
+			return nil
+		case <-sigCh:
+			return nil
+		}
+	}
+}
+
+// watchStyles samples the query's properties on every tick with a single
+// evaluation and prints the deltas, then a per-property change summary.
+// "box.*" properties read the element's rect, even when watching a
+// pseudo-element.
+func watchStyles(client *cdp.Client, selector string, query styleQuery, interval, duration, evalTimeout time.Duration) error {
+	var styleProps, boxProps []string
+	for _, p := range query.props {
+		if strings.HasPrefix(p, "box.") {
+			boxProps = append(boxProps, p)
+		} else {
+			styleProps = append(styleProps, p)
+		}
+	}
+	styleJSON, boxJSON := []byte("null"), []byte("[]")
+	if query.props != nil || !query.all {
+		styleJSON, _ = json.Marshal(append([]string{}, styleProps...))
+	}
+	if boxProps != nil {
+		boxJSON, _ = json.Marshal(boxProps)
+	}
 	expression := fmt.Sprintf(`(() => {
         const el = document.querySelector(%s);
         if (!el) { return null; }
-        const computed = window.getComputedStyle(el);
+        const out = (%s)(el, %s, %s, %t);
         const rect = el.getBoundingClientRect();
-        const interesting = [
-            'display','position','top','left','right','bottom','width','height',
-            'marginTop','marginRight','marginBottom','marginLeft',
-            'paddingTop','paddingRight','paddingBottom','paddingLeft',
-            'borderTopWidth','borderRightWidth','borderBottomWidth','borderLeftWidth',
-            'fontSize','fontWeight','lineHeight','color','backgroundColor'
-        ];
-        const styles = {};
-        for (const key of interesting) {
-            styles[key] = computed.getPropertyValue(key);
+        for (const p of %s) {
+            out[p] = String(Math.round(rect[p.slice(4)] * 100) / 100);
         }
-        return {
-            styles,
-            box: {
-                top: rect.top,
-                left: rect.left,
-                right: rect.right,
-                bottom: rect.bottom,
-                width: rect.width,
-                height: rect.height,
-            }
-        };
-    })()`, strconv.Quote(selector))
+        return out;
+    })()`, strconv.Quote(selector), readComputedStylesJS, strconv.Quote(query.pseudo), styleJSON, query.all, boxJSON)
 
-	value, err := handle.client.Evaluate(ctx, expression)
-	if err != nil {
-		return err
+	sample := func() (map[string]string, error) {
+		ctx, cancel := commandContext(evalTimeout)
+		defer cancel()
+		value, err := client.Evaluate(ctx, expression)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		raw, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected style sample type %T", value)
+		}
+		out := make(map[string]string, len(raw))
+		for k, v := range raw {
+			out[k] = fmt.Sprint(v)
+		}
+		return out, nil
 	}
-	output, err := format.JSON(value, true, -1)
-	if err != nil {
+
+	start := time.Now()
+	stamp := func() string { return fmt.Sprintf("[+%.3fs]", time.Since(start).Seconds()) }
+
+	var last map[string]string
+	present := false
+	samples := 0
+	counts := map[string]int{}
+	tick := func() error {
+		cur, err := sample()
+		if err != nil {
+			return err
+		}
+		samples++
+		if cur == nil {
+			if present || samples == 1 {
+				fmt.Printf("%s element %s not found (still watching)\n", stamp(), selector)
+			}
+			present = false
+			return nil
+		}
+		if !present && samples > 1 {
+			fmt.Printf("%s element %s appeared\n", stamp(), selector)
+		}
+		present = true
+		if last == nil {
+			fmt.Printf("%s watching %d properties on %s\n", stamp(), len(cur), selector)
+			last = cur
+			return nil
+		}
+		for _, ch := range diffStyleSamples(last, cur) {
+			fmt.Printf("%s %s: %s -> %s\n", stamp(), ch.Prop, ch.Old, ch.New)
+			counts[ch.Prop]++
+		}
+		last = cur
+		return nil
+	}
+
+	if err := sampleLoop(interval, duration, tick); err != nil {
 		return err
 	}
-	fmt.Println(output)
+
+	fmt.Printf("Summary: %d samples over %s\n", samples, time.Since(start).Round(time.Millisecond))
+	if len(counts) == 0 {
+		fmt.Println("  no properties changed")
+		return nil
+	}
+	names := make([]string, 0, len(counts))
+	for prop := range counts {
+		names = append(names, prop)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, prop := range names {
+		fmt.Printf("  %s: %d change(s)\n", prop, counts[prop])
+	}
 	return nil
 }
 
+var rectHelp = commandHelp{
+	Description: `Prints an element's bounding box and whether it is in the viewport and visible.
+--occlusion reports what covers its center. --follow watches for layout shifts
+over time and summarises how far the element moved.`,
+	Examples: []commandExample{
+		{`cdp rect --session app "#checkout"`, "Print the button's box and visibility."},
+		{`cdp rect --session app ".tooltip" --occlusion`, "Check whether something covers the tooltip."},
+		{`cdp rect --session app ".hero img" --follow --duration 3s --json`, "Record layout shifts as JSONL for three seconds."},
+	},
+}
+
 func cmdRect(args []string) error {
-	fs := newFlagSet("rect", "usage: cdp rect --session <name> \".selector\"")
+	fs := newFlagSet("rect", "usage: cdp rect --session <name> \".selector\" [--occlusion] [--follow [--interval 100ms] [--duration 5s] [--epsilon 0.5] [--json]]\n\nPrints the element's DOMRect plus inViewport (any part on screen), fullyInViewport,\nand visible (rendered with non-zero size, not display:none, visibility:hidden or\nopacity:0, with hiddenReason otherwise). --occlusion also reports whether another\nelement covers its center point.\n\n--follow samples x/y/width/height over one connection and prints a line (or a JSONL\nrecord with --json) whenever one moves by more than --epsilon px, then min/max per\ndimension and whether the element was removed, re-added or replaced meanwhile.")
 	sessionFlag := addSessionFlag(fs)
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	occlusion := fs.Bool("occlusion", false, "Also check whether another element covers the element's center (elementFromPoint)")
+	follow := fs.Bool("follow", false, "Sample the rect repeatedly and print changes")
+	interval := fs.Duration("interval", 100*time.Millisecond, "With --follow, time between samples")
+	duration := fs.Duration("duration", 5*time.Second, "How long --follow runs")
+	epsilon := fs.Float64("epsilon", 0.5, "With --follow, ignore changes up to this many px")
+	jsonl := fs.Bool("json", false, "With --follow, print JSONL records instead of text")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	switch len(args) {
 	case 0:
 		fs.Usage()
-		return errors.New("usage: cdp rect --session <name> \".selector\"")
+		return usagef("usage: cdp rect --session <name> \".selector\"")
 	case 1:
 		if isHelpArg(args[0]) {
 			fs.Usage()
 			return nil
 		}
-		return errors.New("usage: cdp rect --session <name> \".selector\"")
+		return usagef("usage: cdp rect --session <name> \".selector\"")
 	}
 	pos, err := parseInterspersed(fs, args)
 	if err != nil {
@@ -197,11 +558,14 @@ func cmdRect(args []string) error {
 	}
 	selector := pos[0]
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	if err := rejectUnsupportedSelector(selector, "rect", false); err != nil {
 		return err
 	}
+	if *follow && (*interval <= 0 || *duration <= 0 || *epsilon < 0) {
+		return errors.New("--interval and --duration must be positive and --epsilon non-negative")
+	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
 		fs.Usage()
@@ -212,7 +576,7 @@ func cmdRect(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -221,11 +585,29 @@ func cmdRect(args []string) error {
 	}
 	defer handle.Close()
 
+	if *follow {
+		return followRect(handle.client, selector, rectFollowOptions{
+			interval:    *interval,
+			duration:    *duration,
+			epsilon:     *epsilon,
+			jsonl:       *jsonl,
+			evalTimeout: *timeout,
+		})
+	}
+
 	expression := fmt.Sprintf(`(() => {
         const el = document.querySelector(%s);
         if (!el) { return null; }
         const rect = el.getBoundingClientRect();
-        return {
+        const vw = window.innerWidth, vh = window.innerHeight;
+        const style = window.getComputedStyle(el);
+        let hiddenReason = null;
+        if (!el.isConnected || !el.getClientRects().length) hiddenReason = "not rendered";
+        else if (style.display === "none") hiddenReason = "display:none";
+        else if (style.visibility === "hidden" || style.visibility === "collapse") hiddenReason = "visibility:" + style.visibility;
+        else if (parseFloat(style.opacity) === 0) hiddenReason = "opacity:0";
+        else if (rect.width === 0 || rect.height === 0) hiddenReason = "zero size";
+        const out = {
             x: rect.x,
             y: rect.y,
             top: rect.top,
@@ -234,14 +616,135 @@ func cmdRect(args []string) error {
             bottom: rect.bottom,
             width: rect.width,
             height: rect.height,
+            inViewport: rect.right > 0 && rect.bottom > 0 && rect.left < vw && rect.top < vh,
+            fullyInViewport: rect.left >= 0 && rect.top >= 0 && rect.right <= vw && rect.bottom <= vh,
+            visible: hiddenReason === null,
         };
-    })()`, strconv.Quote(selector))
+        if (hiddenReason) out.hiddenReason = hiddenReason;
+        if (%t) {
+            // Only meaningful when the center is on screen; null otherwise.
+            const cx = rect.left + rect.width / 2, cy = rect.top + rect.height / 2;
+            out.occluded = null;
+            if (cx >= 0 && cy >= 0 && cx < vw && cy < vh) {
+                const top = document.elementFromPoint(cx, cy);
+                out.occluded = !!top && top !== el && !el.contains(top);
+                if (out.occluded) {
+                    let label = top.tagName.toLowerCase();
+                    if (top.id) label += "#" + top.id;
+                    else if (typeof top.className === "string" && top.className.trim()) label += "." + top.className.trim().split(/\s+/).slice(0, 2).join(".");
+                    out.occludedBy = label;
+                }
+            }
+        }
+        return out;
+    })()`, strconv.Quote(selector), *occlusion)
+
+	value, err := evaluatePrinted(ctx, handle.client, expression)
+	if err != nil {
+		return err
+	}
+	output, err := jsonStyle.JSON(value, true, -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+var hitTestHelp = commandHelp{
+	Description: `Answers why a click lands on the wrong element: lists every element under a
+viewport point, topmost first, with its selector and pointer-events state.
+Coordinates are CSS pixels, as printed by 'cdp rect'.`,
+	Examples: []commandExample{
+		{"cdp hit-test --session app 200 340", "List what is stacked at (200, 340)."},
+		{"cdp hit-test --session app 10 10 --pretty=false", "Print compact JSON."},
+		{"cdp hit-test --session app 640 360 --timeout 2s", "Probe the centre of a 1280x720 viewport."},
+	},
+}
+
+func cmdHitTest(args []string) error {
+	fs := newFlagSet("hit-test", "usage: cdp hit-test --session <name> <x> <y>\n\nLists the elements stacked at a viewport coordinate (topmost first) via document.elementsFromPoint.")
+	sessionFlag := addSessionFlag(fs)
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) < 2 {
+		return usagef("usage: cdp hit-test --session <name> <x> <y>")
+	}
+	if len(pos) > 2 {
+		return usagef("unexpected argument: %s", pos[2])
+	}
+	x, err := strconv.ParseFloat(pos[0], 64)
+	if err != nil {
+		return fmt.Errorf("invalid x %q: %w", pos[0], err)
+	}
+	y, err := strconv.ParseFloat(pos[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid y %q: %w", pos[1], err)
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	expression := fmt.Sprintf(`(() => {
+        function cssPath(el) {
+            const parts = [];
+            while (el && el.nodeType === 1 && el !== document.documentElement) {
+                if (el.id) {
+                    parts.unshift("#" + CSS.escape(el.id));
+                    break;
+                }
+                let part = el.tagName.toLowerCase();
+                const parent = el.parentElement;
+                if (parent) {
+                    const same = Array.from(parent.children).filter((c) => c.tagName === el.tagName);
+                    if (same.length > 1) part += ":nth-of-type(" + (same.indexOf(el) + 1) + ")";
+                }
+                parts.unshift(part);
+                el = parent;
+            }
+            return parts.join(" > ");
+        }
+        return document.elementsFromPoint(%s, %s).map((el) => {
+            const style = window.getComputedStyle(el);
+            const r = el.getBoundingClientRect();
+            return {
+                tag: el.tagName.toLowerCase(),
+                selector: cssPath(el),
+                pointerEvents: style.pointerEvents,
+                zIndex: style.zIndex,
+                rect: {x: r.x, y: r.y, width: r.width, height: r.height},
+            };
+        });
+    })()`, strconv.FormatFloat(x, 'f', -1, 64), strconv.FormatFloat(y, 'f', -1, 64))
 
-	value, err := handle.client.Evaluate(ctx, expression)
+	value, err := evaluatePrinted(ctx, handle.client, expression)
 	if err != nil {
 		return err
 	}
-	output, err := format.JSON(value, true, -1)
+	output, err := jsonStyle.JSON(value, *pretty, -1)
 	if err != nil {
 		return err
 	}
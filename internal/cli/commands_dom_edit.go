@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// maxOuterHTMLBytes caps --outer-html; anything larger is more likely the wrong
+// file than a replacement for one element.
+const maxOuterHTMLBytes = 1 << 20
+
+// domEdit is one DOM agent mutation applied to each selected node.
+type domEdit struct {
+	verb   string // past tense, for the report
+	method string
+	params func(nodeID int) map[string]interface{}
+}
+
+var domEditHelp = commandHelp{
+	Description: `Edits the page through the DOM agent rather than script, so it works with CSP
+and frozen frameworks alike: remove nodes, set or remove an attribute, or swap in
+outer HTML from a file. Only the first match is changed unless --all; --force
+allows edits to html, head and body.`,
+	Examples: []commandExample{
+		{`cdp dom-edit --session app ".cookie-banner" --remove`, "Remove the cookie banner."},
+		{`cdp dom-edit --session app "img" --set-attr loading=eager --all`, "Make every image load eagerly."},
+		{`cdp dom-edit --session app "#price" --outer-html fixture.html`, "Replace an element with HTML from a file."},
+		{`cdp dom-edit --session app "button.submit" --remove-attr disabled`, "Enable a disabled button."},
+	},
+}
+
+func cmdDOMEdit(args []string) error {
+	fs := newFlagSet("dom-edit", "usage: cdp dom-edit --session <name> \".selector\" (--remove | --set-attr name=value | --remove-attr name | --outer-html file.html) [--all] [--force]\n\nEdits through the DevTools DOM agent (DOM.removeNode, DOM.setAttributeValue,\nDOM.removeAttribute, DOM.setOuterHTML) rather than page JS. Only the first match is\nedited unless --all. The documentElement is refused without --force.")
+	sessionFlag := addSessionFlag(fs)
+	remove := fs.Bool("remove", false, "Remove the matched node(s)")
+	setAttr := fs.String("set-attr", "", "Set an attribute, as name=value")
+	removeAttr := fs.String("remove-attr", "", "Remove an attribute")
+	outerHTML := fs.String("outer-html", "", "Replace the node's outer HTML with this file's contents")
+	all := fs.Bool("all", false, "Edit every match instead of the first")
+	force := fs.Bool("force", false, "Allow editing the documentElement and --outer-html files over 1 MiB")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) < 1 {
+		fs.Usage()
+		return errors.New("missing selector")
+	}
+	selector := pos[0]
+	if len(pos) > 1 {
+		return usagef("unexpected argument: %s", pos[1])
+	}
+	if err := rejectUnsupportedSelector(selector, "dom-edit", false); err != nil {
+		return err
+	}
+
+	var edits []domEdit
+	if *remove {
+		edits = append(edits, domEdit{verb: "Removed", method: "DOM.removeNode", params: func(id int) map[string]interface{} {
+			return map[string]interface{}{"nodeId": id}
+		}})
+	}
+	if *setAttr != "" {
+		attr, value, ok := strings.Cut(*setAttr, "=")
+		attr = strings.TrimSpace(attr)
+		if !ok || attr == "" {
+			return fmt.Errorf("invalid --set-attr %q (expected name=value)", *setAttr)
+		}
+		edits = append(edits, domEdit{verb: fmt.Sprintf("Set %s=%q on", attr, value), method: "DOM.setAttributeValue", params: func(id int) map[string]interface{} {
+			return map[string]interface{}{"nodeId": id, "name": attr, "value": value}
+		}})
+	}
+	if *removeAttr != "" {
+		attr := strings.TrimSpace(*removeAttr)
+		edits = append(edits, domEdit{verb: fmt.Sprintf("Removed %s from", attr), method: "DOM.removeAttribute", params: func(id int) map[string]interface{} {
+			return map[string]interface{}{"nodeId": id, "name": attr}
+		}})
+	}
+	if *outerHTML != "" {
+		html, err := readOuterHTMLFile(*outerHTML, *force)
+		if err != nil {
+			return err
+		}
+		edits = append(edits, domEdit{verb: "Replaced outer HTML of", method: "DOM.setOuterHTML", params: func(id int) map[string]interface{} {
+			return map[string]interface{}{"nodeId": id, "outerHTML": html}
+		}})
+	}
+	if len(edits) != 1 {
+		fs.Usage()
+		return errors.New("pass exactly one of --remove, --set-attr, --remove-attr, or --outer-html")
+	}
+	edit := edits[0]
+
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	nodeIDs, docElement, err := queryAllNodeIDs(ctx, handle.client, selector)
+	if err != nil {
+		return err
+	}
+	if len(nodeIDs) == 0 {
+		return fmt.Errorf("selector %s not found", selector)
+	}
+	matched := len(nodeIDs)
+	if !*all {
+		nodeIDs = nodeIDs[:1]
+	}
+	if !*force {
+		for _, id := range nodeIDs {
+			if id == docElement {
+				return fmt.Errorf("selector %s matches the documentElement; pass --force to edit it anyway", selector)
+			}
+		}
+	}
+
+	edited, failed := 0, 0
+	for _, id := range nodeIDs {
+		if err := handle.client.Call(ctx, edit.method, edit.params(id), nil); err != nil {
+			// With --all, removing an ancestor first detaches its matched descendants.
+			fmt.Fprintf(os.Stderr, "warning: %s failed for node %d: %v\n", edit.method, id, err)
+			failed++
+			continue
+		}
+		edited++
+	}
+	fmt.Printf("%s %d node(s) matching %s", edit.verb, edited, selector)
+	if matched > len(nodeIDs) {
+		fmt.Printf(" (first of %d matches; pass --all for every match)", matched)
+	}
+	fmt.Println()
+	if edited == 0 {
+		return fmt.Errorf("%s failed for every node", edit.method)
+	}
+	return nil
+}
+
+func readOuterHTMLFile(path string, force bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return "", fmt.Errorf("%s is empty (use --remove to delete the node)", path)
+	}
+	if len(data) > maxOuterHTMLBytes && !force {
+		return "", fmt.Errorf("%s is %d bytes, over the %d byte --outer-html limit; pass --force to send it anyway", path, len(data), maxOuterHTMLBytes)
+	}
+	return string(data), nil
+}
+
+// queryAllNodeIDs returns DOM agent node ids for every match of selector, plus
+// the documentElement's id so callers can guard destructive edits.
+func queryAllNodeIDs(ctx context.Context, client *cdp.Client, selector string) ([]int, int, error) {
+	var doc struct {
+		Root struct {
+			NodeID   int `json:"nodeId"`
+			Children []struct {
+				NodeID   int    `json:"nodeId"`
+				NodeType int    `json:"nodeType"`
+				NodeName string `json:"nodeName"`
+			} `json:"children"`
+		} `json:"root"`
+	}
+	if err := client.Call(ctx, "DOM.getDocument", map[string]interface{}{"depth": 1}, &doc); err != nil {
+		return nil, 0, err
+	}
+	if doc.Root.NodeID == 0 {
+		return nil, 0, errors.New("DOM.getDocument returned empty root")
+	}
+	docElement := 0
+	for _, child := range doc.Root.Children {
+		if child.NodeType == 1 {
+			docElement = child.NodeID
+			break
+		}
+	}
+	var result struct {
+		NodeIDs []int `json:"nodeIds"`
+	}
+	if err := client.Call(ctx, "DOM.querySelectorAll", map[string]interface{}{
+		"nodeId":   doc.Root.NodeID,
+		"selector": selector,
+	}, &result); err != nil {
+		return nil, 0, err
+	}
+	return result.NodeIDs, docElement, nil
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffStyleSamples(t *testing.T) {
+	prev := map[string]string{"width": "100px", "color": "red", "display": "block"}
+	cur := map[string]string{"width": "120px", "color": "red", "display": "none"}
+	changes := diffStyleSamples(prev, cur)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0] != (styleChange{Prop: "display", Old: "block", New: "none"}) {
+		t.Fatalf("unexpected first change: %+v", changes[0])
+	}
+	if changes[1] != (styleChange{Prop: "width", Old: "100px", New: "120px"}) {
+		t.Fatalf("unexpected second change: %+v", changes[1])
+	}
+}
+
+func TestDecodeEscapes(t *testing.T) {
+	cases := map[string]string{
+		`\n`:       "\n",
+		` | `:      " | ",
+		`\t-\t`:    "\t-\t",
+		`say "hi"`: `say "hi"`,
+		`bad\q`:    `bad\q`,
+	}
+	for in, want := range cases {
+		if got := decodeEscapes(in); got != want {
+			t.Fatalf("decodeEscapes(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReadOuterHTMLFile(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.html")
+	big := filepath.Join(dir, "big.html")
+	empty := filepath.Join(dir, "empty.html")
+	os.WriteFile(small, []byte("<div>ok</div>"), 0o644)
+	os.WriteFile(big, []byte(strings.Repeat("x", maxOuterHTMLBytes+1)), 0o644)
+	os.WriteFile(empty, []byte(" \n"), 0o644)
+
+	if html, err := readOuterHTMLFile(small, false); err != nil || html != "<div>ok</div>" {
+		t.Fatalf("small file: %q, %v", html, err)
+	}
+	if _, err := readOuterHTMLFile(big, false); err == nil {
+		t.Error("oversized file should need --force")
+	}
+	if _, err := readOuterHTMLFile(big, true); err != nil {
+		t.Errorf("oversized file with --force: %v", err)
+	}
+	if _, err := readOuterHTMLFile(empty, true); err == nil {
+		t.Error("empty file should be refused")
+	}
+}
+
+func TestStylesPseudoOptions(t *testing.T) {
+	for _, in := range []string{"before", "::before"} {
+		if got, err := parseStylePseudo(in); err != nil || got != "::before" {
+			t.Fatalf("parseStylePseudo(%q) = %q, %v", in, got, err)
+		}
+	}
+	if _, err := parseStylePseudo("first-line"); err == nil {
+		t.Fatal("expected an unsupported pseudo-element to be rejected")
+	}
+
+	expr := stylesExpression(".card", styleQuery{props: []string{"content"}}, true)
+	for _, want := range []string{`const props = ["content"];`, `const all = false;`, `"::after": read(el, "::after", props, all)`} {
+		if !strings.Contains(expr, want) {
+			t.Fatalf("include-pseudo expression missing %q:\n%s", want, expr)
+		}
+	}
+	expr = stylesExpression(".card", styleQuery{all: true, pseudo: "::marker"}, false)
+	for _, want := range []string{`const props = null;`, `const all = true;`, `const pseudo = "::marker";`, "computed.length"} {
+		if !strings.Contains(expr, want) {
+			t.Fatalf("--all-props --pseudo expression missing %q:\n%s", want, expr)
+		}
+	}
+	if expr := stylesExpression(".card", styleQuery{}, false); !strings.Contains(expr, `"gridTemplateColumns"`) {
+		t.Fatal("default property set should include grid properties")
+	}
+}
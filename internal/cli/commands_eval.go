@@ -2,28 +2,54 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/veilm/cdp-cli/internal/cdp"
 	"github.com/veilm/cdp-cli/internal/format"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var evalHelp = commandHelp{
+	Description: `Evaluates an expression in the page and prints the result as JSON. Promises are
+awaited. Scripts can come from --file or --stdin, and --body wraps the code in a
+function so 'return' works. --arg values reach the script as the ARGS array, so
+quoting never has to be spliced into the code.`,
+	Examples: []commandExample{
+		{`cdp eval --session app "document.title"`, "Print the page title."},
+		{"cdp eval --session app --file scrape.js --arg 2024-01 --arg draft", `Run a script with ARGS set to ["2024-01", "draft"].`},
+		{`cdp eval --session app --body "const rows = document.querySelectorAll('tr'); return rows.length"`, "Use statements and return a value."},
+		{`cdp eval --session app "window.store.getState()" --depth 2 --max-array 5`, "Print a large object, truncated."},
+		{`cdp eval --session app "performance.getEntriesByType('navigation')[0]" --set nav`, "Keep the result at window.__cdp__.nav for later commands."},
+	},
+}
+
 func cmdEval(args []string) error {
 	fs := newFlagSet("eval", "usage: cdp eval --session <name> \"expr\"")
 	sessionFlag := addSessionFlag(fs)
 	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	colorMode := fs.String("color", "auto", "Colorize JSON output: auto (TTY and no NO_COLOR), always, never")
 	depth := fs.Int("depth", -1, "Max depth before truncating (-1 = unlimited)")
+	maxArray := fs.Int("max-array", -1, "Keep at most N items per array, then a \"[+M more]\" marker (-1 = unlimited)")
+	maxString := fs.Int("max-string", -1, "Keep at most N characters per string (-1 = unlimited)")
 	jsonOutput := fs.Bool("json", true, "Serialize objects via JSON.stringify when possible")
 	waitReady := fs.Bool("wait", false, "Wait for document.readyState == 'complete' before evaluating")
-	timeout := fs.Duration("timeout", 10*time.Second, "Eval timeout")
+	noReadyCheck := addReadyCheckFlag(fs)
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Eval timeout")
 	file := fs.String("file", "", "Read JS from file path ('-' for stdin)")
 	readStdin := fs.Bool("stdin", false, "Read JS from stdin")
 	body := fs.Bool("body", false, "Treat input as a function body (wrap in an IIFE and return its value)")
+	var scriptArgs stringListFlag
+	fs.Var(&scriptArgs, "arg", "Value for the script's global ARGS array (repeatable, in order)")
+	setVar := fs.String("set", "", "Also store the result on window.__cdp__.<name> for later commands")
+	ownProps := fs.Bool("own-props", false, "Snapshot object results via Runtime.getProperties: non-enumerable properties and getter values included")
+	deep := fs.Int("deep", 0, "With --own-props, expand nested objects N levels (implies --own-props)")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -32,6 +58,10 @@ func cmdEval(args []string) error {
 	if err != nil {
 		return err
 	}
+	color, err := useColor(*colorMode)
+	if err != nil {
+		return err
+	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
 		fs.Usage()
@@ -55,7 +85,7 @@ func cmdEval(args []string) error {
 	switch {
 	case filePath != "":
 		if len(pos) > 0 {
-			return fmt.Errorf("unexpected argument: %s", pos[0])
+			return usagef("unexpected argument: %s", pos[0])
 		}
 		src, err := readScriptFile(filePath)
 		if err != nil {
@@ -64,7 +94,7 @@ func cmdEval(args []string) error {
 		expression = src
 	case useStdin:
 		if len(pos) > 0 {
-			return fmt.Errorf("unexpected argument: %s", pos[0])
+			return usagef("unexpected argument: %s", pos[0])
 		}
 		src, err := io.ReadAll(os.Stdin)
 		if err != nil {
@@ -77,13 +107,22 @@ func cmdEval(args []string) error {
 		}
 		expression = pos[0]
 		if len(pos) > 1 {
-			return fmt.Errorf("unexpected argument: %s", pos[1])
+			return usagef("unexpected argument: %s", pos[1])
 		}
 	}
 	if strings.TrimSpace(expression) == "" {
 		return errors.New("JS expression is empty")
 	}
+	if *deep < 0 {
+		return errors.New("--deep must be >= 0")
+	}
+	if *setVar != "" && !jsIdentifierPattern.MatchString(*setVar) {
+		return fmt.Errorf("invalid --set name %q (use a JS identifier)", *setVar)
+	}
 	bodyInput := expression
+	if len(scriptArgs) > 0 {
+		expression = withScriptArgs(expression, scriptArgs)
+	}
 	if *body {
 		expression = "(function(){\n" + expression + "\n})()"
 	}
@@ -93,7 +132,7 @@ func cmdEval(args []string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -106,6 +145,8 @@ func cmdEval(args []string) error {
 		if err := waitForReadyState(ctx, handle.client, 200*time.Millisecond); err != nil {
 			return err
 		}
+	} else if !*noReadyCheck {
+		warnIfLoading(ctx, handle.client, "Pass --wait to evaluate once it has loaded")
 	}
 
 	returnByValue := false
@@ -119,17 +160,24 @@ func cmdEval(args []string) error {
 			return err
 		}
 	}
-	value, err := handle.client.RemoteObjectValue(ctx, res.Result)
-	if err != nil {
-		return err
+	if *setVar != "" {
+		if err := storeScratchValue(ctx, handle.client, *setVar, res.Result); err != nil {
+			return fmt.Errorf("store --set %s: %w", *setVar, err)
+		}
 	}
-	if !*jsonOutput && res.Result.Type == "object" && res.Result.Subtype == "node" {
-		fmt.Fprintln(os.Stderr, "warning: eval returned a DOM node; use --json if you want serialized output")
+	var value interface{}
+	snapshot := (*ownProps || *deep > 0) && res.Result.Type == "object" && res.Result.ObjectID != ""
+	if snapshot {
+		value, err = handle.client.PropertySnapshot(ctx, res.Result, *deep)
+	} else {
+		value, err = printedValue(ctx, handle.client, res.Result)
 	}
-	output, err := format.JSON(value, *pretty, *depth)
 	if err != nil {
 		return err
 	}
+	if !snapshot && !*jsonOutput && res.Result.Type == "object" && res.Result.Subtype == "node" {
+		fmt.Fprintln(os.Stderr, "warning: eval returned a DOM node; use --json if you want serialized output")
+	}
 	if *body && !containsReturnKeyword(bodyInput) {
 		if value == nil {
 			fmt.Fprintln(os.Stderr, "warning: the input function body returned undefined; did you forget to include a return statement?")
@@ -137,6 +185,47 @@ func cmdEval(args []string) error {
 			fmt.Fprintln(os.Stderr, "warning: the input function body returned undefined; did you forget to include a return statement?")
 		}
 	}
-	fmt.Println(output)
+	limits := format.Limits{Depth: *depth, Array: *maxArray, String: *maxString}
+	if !color {
+		return jsonStyle.WriteJSON(os.Stdout, value, *pretty, limits)
+	}
+	output, err := jsonStyle.JSONLimited(value, *pretty, limits)
+	if err != nil {
+		return err
+	}
+	fmt.Println(format.Colorize(output))
 	return nil
 }
+
+// withScriptArgs prepends `const ARGS = [...];` to src. Eval runs in REPL
+// mode, so the declaration can be repeated by later evals in the same page.
+func withScriptArgs(src string, args []string) string {
+	encoded, _ := json.Marshal(args)
+	return "const ARGS = " + string(encoded) + ";\n" + src
+}
+
+// scratchNamespace is the page global that `eval --set` writes into. It lives as
+// long as the document does, so values survive across separate cdp invocations.
+const scratchNamespace = "__cdp__"
+
+func storeScratchValue(ctx context.Context, client *cdp.Client, name string, obj cdp.RemoteObject) error {
+	ns := fmt.Sprintf("(window[%s] = window[%s] || {})", strconv.Quote(scratchNamespace), strconv.Quote(scratchNamespace))
+	if obj.ObjectID != "" {
+		// Keep objects by reference (DOM nodes, functions, etc.) rather than a serialized copy.
+		return client.Call(ctx, "Runtime.callFunctionOn", map[string]interface{}{
+			"objectId":            obj.ObjectID,
+			"functionDeclaration": fmt.Sprintf("function() { %s[%s] = this; }", ns, strconv.Quote(name)),
+		}, nil)
+	}
+	literal := "undefined"
+	switch {
+	case obj.Type == "object" && obj.Subtype == "null":
+		literal = "null"
+	case obj.UnserializableValue != "":
+		literal = obj.UnserializableValue
+	case obj.Value != nil:
+		literal = string(*obj.Value)
+	}
+	_, err := client.EvaluateRaw(ctx, fmt.Sprintf("void (%s[%s] = %s)", ns, strconv.Quote(name), literal), true)
+	return err
+}
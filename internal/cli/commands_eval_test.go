@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// fakeEvalTab answers Runtime.evaluate with respond(expression) as a
+// by-value result, and every other method with an empty result.
+func fakeEvalTab(t *testing.T, respond func(expression string) interface{}) string {
+	t.Helper()
+	return cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		if req.Method == "Runtime.evaluate" {
+			c.Reply(req.ID, cdptest.EvalResult(respond(req.Expression())))
+			return
+		}
+		c.Reply(req.ID, nil)
+	})
+}
+
+// holdFakeSession saves a session called name and makes openSession hand
+// out a client dialed to wsURL for it, the way `cdp repl` holds one.
+func holdFakeSession(t *testing.T, name, wsURL string) {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CDP_STORE", filepath.Join(t.TempDir(), "sessions.json"))
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Set(store.Session{Name: name}); err != nil {
+		t.Fatal(err)
+	}
+	client, err := cdp.Dial(context.Background(), wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heldConnection.name, heldConnection.client = name, client
+	t.Cleanup(func() {
+		heldConnection.name, heldConnection.client = "", nil
+		client.Close()
+	})
+}
+
+func TestEvalTimeoutZeroWaitsForSlowExpression(t *testing.T) {
+	holdFakeSession(t, "slow", fakeEvalTab(t, func(expression string) interface{} {
+		if strings.Contains(expression, "slow") {
+			time.Sleep(300 * time.Millisecond)
+			return 42
+		}
+		return "complete"
+	}))
+
+	expr := "new Promise(r => setTimeout(() => r(42), 300)) /* slow */"
+	if err := cmdEval([]string{"--session", "slow", "--timeout", "50ms", expr}); errorCode(err) != exitTimeout {
+		t.Fatalf("--timeout 50ms should time out, got %v", err)
+	}
+	if err := cmdEval([]string{"--session", "slow", "--timeout", "0", expr}); err != nil {
+		t.Fatalf("--timeout 0 should wait for the expression, got %v", err)
+	}
+}
+
+func TestEvalArgsBecomeARGS(t *testing.T) {
+	var got []string
+	holdFakeSession(t, "args", fakeEvalTab(t, func(expression string) interface{} {
+		if strings.Contains(expression, "ARGS") {
+			got = append(got, expression)
+		}
+		return "complete"
+	}))
+	if err := cmdEval([]string{"--session", "args", "--arg", "Invoice #3", "--arg", `say "hi"`, "ARGS.length"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "const ARGS = [\"Invoice #3\",\"say \\\"hi\\\"\"];\nARGS.length"
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("evaluated %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+var extensionsHelp = commandHelp{
+	Description: `Finds the targets that belong to installed extensions: service workers,
+background pages, popups and options pages. Bind one with 'cdp connect
+--target-id' to run eval or log inside the extension.`,
+	Examples: []commandExample{
+		{"cdp extensions list --port 9222", "List extension targets as JSON."},
+		{"cdp extensions list --plain", "Print one extension per block."},
+		{"cdp extensions list --host 10.0.0.5 --port 9333 --pretty=false", "Query a remote browser."},
+	},
+}
+
+func cmdExtensions(args []string) error {
+	if len(args) == 0 {
+		printExtensionsUsage()
+		return usagef("usage: cdp extensions <command> (list)")
+	}
+	if isHelpArg(args[0]) {
+		printExtensionsUsage()
+		return nil
+	}
+	switch args[0] {
+	case "list":
+		return cmdExtensionsList(args[1:])
+	default:
+		return usagef("unknown extensions command %q (expected list)", args[0])
+	}
+}
+
+func printExtensionsUsage() {
+	fmt.Println("usage: cdp extensions <command> (list)")
+	fmt.Println("Commands:")
+	fmt.Println("  list    List extension targets (service workers, background, popup and options pages) by extension id")
+	fmt.Println("Bind a session to one with 'cdp connect --session <name> --port <port> --target-id <id>'.")
+}
+
+func cmdExtensionsList(args []string) error {
+	fs := newFlagSet("extensions list", "usage: cdp extensions list [--host --port] [--plain] [--pretty=false]\n\nGroups the chrome-extension:// targets from /json/list by extension id. Pass a\ntarget id to 'cdp connect --target-id' to run eval or log in that context.")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
+	port := fs.Int("port", portDefault(9222), "DevTools port")
+	plain := fs.Bool("plain", false, "Output plain text instead of JSON")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	targets, err := dialer().ListTargets(ctx, *host, *port)
+	if err != nil {
+		return err
+	}
+	extensions := groupExtensionTargets(targets)
+
+	if *plain {
+		if len(extensions) == 0 {
+			fmt.Println("No extension targets found")
+			return nil
+		}
+		for _, ext := range extensions {
+			fmt.Println(ext.ID)
+			for _, t := range ext.Targets {
+				fmt.Printf("  %-11s %-16s %s  %s\n", t.Role, t.Type, t.TargetID, t.URL)
+			}
+		}
+		return nil
+	}
+	output, err := jsonStyle.JSON(extensions, *pretty, -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// extensionInfo is one extension and its debuggable targets.
+type extensionInfo struct {
+	ID      string            `json:"id"`
+	Targets []extensionTarget `json:"targets"`
+}
+
+// extensionTarget is a target owned by an extension. Role is "background"
+// for the service worker or background page, "popup" and "options" for the
+// pages named that way, and the target type otherwise.
+type extensionTarget struct {
+	Role     string `json:"role"`
+	Type     string `json:"type"`
+	TargetID string `json:"targetId"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+}
+
+// extensionRoleOrder sorts an extension's targets background first.
+var extensionRoleOrder = map[string]int{"background": 0, "popup": 1, "options": 2}
+
+// groupExtensionTargets groups the chrome-extension:// targets by extension
+// id, ordered by id.
+func groupExtensionTargets(targets []cdp.TargetInfo) []extensionInfo {
+	byID := make(map[string]*extensionInfo)
+	var ids []string
+	for _, t := range targets {
+		id, ok := extensionID(t.URL)
+		if !ok {
+			continue
+		}
+		ext, ok := byID[id]
+		if !ok {
+			ext = &extensionInfo{ID: id, Targets: []extensionTarget{}}
+			byID[id] = ext
+			ids = append(ids, id)
+		}
+		ext.Targets = append(ext.Targets, extensionTarget{
+			Role:     extensionRole(t),
+			Type:     t.Type,
+			TargetID: t.ID,
+			Title:    t.Title,
+			URL:      t.URL,
+		})
+	}
+	sort.Strings(ids)
+	out := make([]extensionInfo, 0, len(ids))
+	for _, id := range ids {
+		ext := byID[id]
+		sort.SliceStable(ext.Targets, func(i, j int) bool {
+			return roleRank(ext.Targets[i].Role) < roleRank(ext.Targets[j].Role)
+		})
+		out = append(out, *ext)
+	}
+	return out
+}
+
+func roleRank(role string) int {
+	if rank, ok := extensionRoleOrder[role]; ok {
+		return rank
+	}
+	return len(extensionRoleOrder)
+}
+
+// extensionID returns the extension id of a chrome-extension:// URL.
+func extensionID(rawURL string) (string, bool) {
+	rest, ok := strings.CutPrefix(rawURL, "chrome-extension://")
+	if !ok {
+		return "", false
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id, id != ""
+}
+
+func extensionRole(t cdp.TargetInfo) string {
+	switch t.Type {
+	case "service_worker", "background_page":
+		return "background"
+	}
+	page := strings.ToLower(path.Base(strings.SplitN(t.URL, "?", 2)[0]))
+	switch {
+	case strings.Contains(page, "popup"):
+		return "popup"
+	case strings.Contains(page, "options"):
+		return "options"
+	}
+	return t.Type
+}
+
+// targetHasDocument reports whether a target type runs with a DOM; workers
+// have neither document nor window.
+func targetHasDocument(targetType string) bool {
+	switch targetType {
+	case "service_worker", "shared_worker", "worker":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+func TestGroupExtensionTargets(t *testing.T) {
+	targets := []cdp.TargetInfo{
+		{ID: "P1", Type: "page", URL: "https://example.com/"},
+		{ID: "E2", Type: "page", URL: "chrome-extension://bbb/options.html"},
+		{ID: "E1", Type: "page", URL: "chrome-extension://aaa/popup.html?tab=1"},
+		{ID: "W1", Type: "service_worker", URL: "chrome-extension://aaa/background.js"},
+		{ID: "E3", Type: "iframe", URL: "chrome-extension://aaa/frame.html"},
+	}
+	got := groupExtensionTargets(targets)
+	if len(got) != 2 || got[0].ID != "aaa" || got[1].ID != "bbb" {
+		t.Fatalf("unexpected extensions %+v", got)
+	}
+	var roles []string
+	for _, target := range got[0].Targets {
+		roles = append(roles, target.Role+":"+target.TargetID)
+	}
+	if want := "background:W1 popup:E1 iframe:E3"; strings.Join(roles, " ") != want {
+		t.Fatalf("roles = %q, want %q", strings.Join(roles, " "), want)
+	}
+	if got[1].Targets[0].Role != "options" {
+		t.Fatalf("expected an options page, got %+v", got[1].Targets)
+	}
+	if _, ok := extensionID("chrome-extension:///x"); ok {
+		t.Fatal("an empty extension id should not match")
+	}
+	if targetHasDocument("service_worker") || !targetHasDocument("background_page") {
+		t.Fatal("only worker targets should lack a document")
+	}
+}
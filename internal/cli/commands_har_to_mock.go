@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mockCapture is one request/response pair read from a network-log directory
+// or a HAR file.
+type mockCapture struct {
+	Timestamp time.Time
+	Method    string
+	URL       string
+	Status    int
+	Headers   map[string]string
+	Body      []byte
+	Skip      string // reason the capture cannot become a rule
+}
+
+var harToMockHelp = commandHelp{
+	Description: `Turns recorded traffic into a rules file that a mock server or test double can
+replay. Query strings can be dropped to make rules match more loosely, and
+--url-filter keeps only the endpoints you care about.`,
+	Examples: []commandExample{
+		{"cdp har-to-mock captures/ --output mocks/rules.json", "Convert a network-log capture."},
+		{"cdp har-to-mock session.har --output rules.json --url-filter /api/ --strip-query", "Convert the API calls from a HAR file."},
+		{"cdp har-to-mock captures/ --output rules.json --url-filter graphql", "Keep only the GraphQL calls."},
+	},
+}
+
+func cmdHarToMock(args []string) error {
+	fs := newFlagSet("har-to-mock", "usage: cdp har-to-mock <capture-dir|file.har> --output rules.json [--url-filter REGEX] [--strip-query]\n\nConverts network-log captures (or a HAR file) into mock rules. Bodies are written to a\nbodies/ directory next to the rules file. For repeated method+URL pairs the latest\ncapture wins, and volatile headers (date, etag, content-length, ...) are dropped.")
+	output := fs.String("output", "", "Rules file to write (required)")
+	urlFilter := fs.String("url-filter", "", "Only convert requests whose URL matches this regex")
+	stripQuery := fs.Bool("strip-query", false, "Match URLs without their query string")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usagef("usage: cdp har-to-mock <capture-dir|file.har> --output rules.json")
+	}
+	if *output == "" {
+		return errors.New("--output is required")
+	}
+	var filter *regexp.Regexp
+	if *urlFilter != "" {
+		filter, err = regexp.Compile(escapeLeadingPlusRegexSpec(*urlFilter))
+		if err != nil {
+			return fmt.Errorf("invalid --url-filter regex: %w", err)
+		}
+	}
+
+	var captures []mockCapture
+	if info, err := os.Stat(pos[0]); err != nil {
+		return err
+	} else if info.IsDir() {
+		captures, err = readCaptureDir(pos[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		captures, err = readHARFile(pos[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	rules, bodies, skipped := buildMockRules(captures, filter, *stripQuery)
+	bodiesDir := filepath.Join(filepath.Dir(*output), "bodies")
+	if len(bodies) > 0 {
+		if err := os.MkdirAll(bodiesDir, 0o755); err != nil {
+			return fmt.Errorf("create bodies directory: %w", err)
+		}
+	}
+	for i := range rules {
+		body, ok := bodies[i]
+		if !ok {
+			continue
+		}
+		name := fmt.Sprintf("%03d-%s-%s.bin", i+1, strings.ToUpper(rules[i].Method), shortenURLFragment(rules[i].URL, 80))
+		if err := os.WriteFile(filepath.Join(bodiesDir, name), body, 0o644); err != nil {
+			return err
+		}
+		rules[i].BodyFile = "bodies/" + name
+	}
+	if err := writeJSONFile(*output, mockRuleFile{Rules: rules}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d rule(s) to %s", len(rules), *output)
+	if len(skipped) > 0 {
+		reasons := make([]string, 0, len(skipped))
+		total := 0
+		for reason, n := range skipped {
+			reasons = append(reasons, fmt.Sprintf("%d %s", n, reason))
+			total += n
+		}
+		sort.Strings(reasons)
+		fmt.Printf("; skipped %d (%s)", total, strings.Join(reasons, ", "))
+	}
+	fmt.Println()
+	return nil
+}
+
+// buildMockRules turns captures into rules ordered by first appearance, keeping
+// the latest capture for each method+URL. bodies maps rule index to body bytes;
+// skipped counts captures by reason.
+func buildMockRules(captures []mockCapture, filter *regexp.Regexp, stripQuery bool) ([]mockRule, map[int][]byte, map[string]int) {
+	sorted := append([]mockCapture(nil), captures...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	skipped := map[string]int{}
+	index := map[string]int{}
+	var rules []mockRule
+	bodies := map[int][]byte{}
+	for _, c := range sorted {
+		switch {
+		case c.Skip != "":
+			skipped[c.Skip]++
+			continue
+		case filter != nil && !filter.MatchString(c.URL):
+			skipped["filtered"]++
+			continue
+		}
+		ruleURL := c.URL
+		if stripQuery {
+			ruleURL = stripURLQuery(ruleURL)
+		}
+		method := strings.ToUpper(c.Method)
+		rule := mockRule{
+			Method:      method,
+			URL:         ruleURL,
+			IgnoreQuery: stripQuery,
+			Status:      c.Status,
+			Headers:     stableMockHeaders(c.Headers),
+		}
+		key := method + " " + ruleURL
+		if i, ok := index[key]; ok {
+			skipped["superseded"]++
+			rules[i] = rule
+			delete(bodies, i)
+			if len(c.Body) > 0 {
+				bodies[i] = c.Body
+			}
+			continue
+		}
+		index[key] = len(rules)
+		if len(c.Body) > 0 {
+			bodies[len(rules)] = c.Body
+		}
+		rules = append(rules, rule)
+	}
+	return rules, bodies, skipped
+}
+
+// readCaptureDir reads the per-request folders written by network-log.
+func readCaptureDir(dir string) ([]mockCapture, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var captures []mockCapture
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		captureDir := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(captureDir, "metadata.json"))
+		if err != nil {
+			continue
+		}
+		var meta struct {
+			Timestamp         string `json:"timestamp"`
+			URL               string `json:"url"`
+			Method            string `json:"method"`
+			Status            string `json:"status"`
+			ResponseBodyError string `json:"responseBodyError"`
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(captureDir, "metadata.json"), err)
+		}
+		c := mockCapture{URL: meta.URL, Method: meta.Method, Headers: map[string]string{}}
+		c.Timestamp, _ = time.Parse(time.RFC3339Nano, meta.Timestamp)
+		if status, err := strconv.Atoi(meta.Status); err == nil {
+			c.Status = status
+		} else {
+			c.Skip = "no response"
+		}
+		if meta.ResponseBodyError != "" && c.Skip == "" {
+			c.Skip = "body unavailable"
+		}
+		if data, err := os.ReadFile(filepath.Join(captureDir, "response-headers.json")); err == nil {
+			_ = json.Unmarshal(data, &c.Headers)
+		}
+		if body, err := os.ReadFile(filepath.Join(captureDir, "response-body.bin")); err == nil {
+			c.Body = body
+		}
+		captures = append(captures, c)
+	}
+	return captures, nil
+}
+
+// readHARFile reads entries from a HAR 1.2 file.
+func readHARFile(path string) ([]mockCapture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har struct {
+		Log struct {
+			Entries []struct {
+				StartedDateTime string `json:"startedDateTime"`
+				Request         struct {
+					Method string `json:"method"`
+					URL    string `json:"url"`
+				} `json:"request"`
+				Response struct {
+					Status  int                `json:"status"`
+					Headers []fetchHeaderEntry `json:"headers"`
+					Content struct {
+						Text     string `json:"text"`
+						Encoding string `json:"encoding"`
+					} `json:"content"`
+				} `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("parse HAR %s: %w", path, err)
+	}
+	captures := make([]mockCapture, 0, len(har.Log.Entries))
+	for _, e := range har.Log.Entries {
+		c := mockCapture{
+			URL:     e.Request.URL,
+			Method:  e.Request.Method,
+			Status:  e.Response.Status,
+			Headers: normalizeHeaderList(e.Response.Headers),
+		}
+		c.Timestamp, _ = time.Parse(time.RFC3339Nano, e.StartedDateTime)
+		if c.Status <= 0 {
+			c.Skip = "no response"
+		}
+		if e.Response.Content.Encoding == "base64" {
+			body, err := base64.StdEncoding.DecodeString(e.Response.Content.Text)
+			if err != nil && c.Skip == "" {
+				c.Skip = "body unavailable"
+			}
+			c.Body = body
+		} else {
+			c.Body = []byte(e.Response.Content.Text)
+		}
+		captures = append(captures, c)
+	}
+	return captures, nil
+}
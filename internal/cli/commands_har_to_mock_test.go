@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestBuildMockRules(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	captures := []mockCapture{
+		{Timestamp: base.Add(2 * time.Second), Method: "get", URL: "https://api.test/items?page=2", Status: 200, Body: []byte("new"),
+			Headers: map[string]string{"content-type": "application/json", "date": "x", "etag": "y"}},
+		{Timestamp: base, Method: "GET", URL: "https://api.test/items?page=1", Status: 200, Body: []byte("old")},
+		{Timestamp: base.Add(time.Second), Method: "POST", URL: "https://api.test/login", Status: 204},
+		{Timestamp: base, Method: "GET", URL: "https://cdn.test/app.js", Status: 200},
+		{Timestamp: base, Method: "GET", URL: "https://api.test/pending", Skip: "no response"},
+	}
+	rules, bodies, skipped := buildMockRules(captures, regexp.MustCompile(`api\.test`), true)
+	if len(rules) != 2 {
+		t.Fatalf("rules = %+v", rules)
+	}
+	items := rules[0]
+	if items.URL != "https://api.test/items" || !items.IgnoreQuery || items.Method != "GET" {
+		t.Fatalf("items rule = %+v", items)
+	}
+	if string(bodies[0]) != "new" {
+		t.Fatalf("expected latest body, got %q", bodies[0])
+	}
+	if len(items.Headers) != 1 || items.Headers["content-type"] != "application/json" {
+		t.Fatalf("volatile headers kept: %+v", items.Headers)
+	}
+	if _, ok := bodies[1]; ok {
+		t.Fatal("empty body should not get a body file")
+	}
+	if skipped["superseded"] != 1 || skipped["filtered"] != 1 || skipped["no response"] != 1 {
+		t.Fatalf("skipped = %+v", skipped)
+	}
+}
@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// infoCollector gathers one part of a `cdp info` report. Collectors run with
+// their own deadline so a hung domain (e.g. evaluation blocked by an open
+// alert) only fails that collector.
+type infoCollector struct {
+	name string
+	run  func(ctx context.Context) (interface{}, error)
+}
+
+type infoResult struct {
+	Name  string      `json:"name"`
+	OK    bool        `json:"ok"`
+	Error string      `json:"error,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+var infoHelp = commandHelp{
+	Description: `Gathers what a bug report usually needs in one go: open dialogs, URL and
+title, viewport, cookie count, WebNav state, recent console errors and a
+screenshot. Each collector has its own deadline, so one stuck probe does not
+lose the rest.`,
+	Examples: []commandExample{
+		{"cdp info --session app", "Print a summary."},
+		{"cdp info --session app --output bug-1234/", "Write every collector's output to a directory."},
+		{"cdp info --session app --console-window 2m --collector-timeout 10s", "Look further back in the console."},
+	},
+}
+
+func cmdInfo(args []string) error {
+	fs := newFlagSet("info", "usage: cdp info --session <name> [--output dir/] [--console-window 30s]\n\nCollects a state snapshot for bug reports. Without --output a summary is printed;\nwith it, each collector writes its own file (plus screenshot.png and summary.json).")
+	sessionFlag := addSessionFlag(fs)
+	output := fs.String("output", "", "Directory to write the report into")
+	consoleWindow := fs.Duration("console-window", 30*time.Second, "Count console errors logged within this window")
+	collectorTimeout := addTimeoutVar(fs, "collector-timeout", 3*time.Second, "Deadline for each collector")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Connection timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	record, _ := st.Get(name)
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+	client := handle.client
+
+	var screenshot []byte
+	collectors := []infoCollector{
+		// Dialogs block Runtime.evaluate, so look for one before the JS collectors.
+		{"dialog", func(ctx context.Context) (interface{}, error) { return collectDialogState(ctx, client) }},
+		{"page", func(ctx context.Context) (interface{}, error) {
+			return client.Evaluate(ctx, `({url: location.href, title: document.title, readyState: document.readyState})`)
+		}},
+		{"viewport", func(ctx context.Context) (interface{}, error) {
+			return client.Evaluate(ctx, `({width: window.innerWidth, height: window.innerHeight, devicePixelRatio: window.devicePixelRatio, scrollX: window.scrollX, scrollY: window.scrollY, documentHeight: document.documentElement.scrollHeight})`)
+		}},
+		{"userAgent", func(ctx context.Context) (interface{}, error) {
+			return client.Evaluate(ctx, `navigator.userAgent`)
+		}},
+		{"cookies", func(ctx context.Context) (interface{}, error) {
+			var res struct {
+				Cookies []json.RawMessage `json:"cookies"`
+			}
+			if err := client.Call(ctx, "Network.getCookies", nil, &res); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"count": len(res.Cookies)}, nil
+		}},
+		{"webnav", func(ctx context.Context) (interface{}, error) {
+			value, err := client.Evaluate(ctx, `({injected: !!window.WebNavInjected, version: window.WebNavInjectedVersion || 0})`)
+			if m, ok := value.(map[string]interface{}); ok {
+				m["cliVersion"] = webNavVersion
+			}
+			return value, err
+		}},
+		{"console", func(ctx context.Context) (interface{}, error) {
+			return collectConsoleErrors(ctx, client, *consoleWindow)
+		}},
+		{"screenshot", func(ctx context.Context) (interface{}, error) {
+			if *output == "" {
+				return map[string]interface{}{"skipped": "pass --output to save a screenshot"}, nil
+			}
+			var shot struct {
+				Data string `json:"data"`
+			}
+			if err := client.Call(ctx, "Page.captureScreenshot", map[string]interface{}{"format": "png"}, &shot); err != nil {
+				return nil, err
+			}
+			data, err := base64.StdEncoding.DecodeString(shot.Data)
+			if err != nil {
+				return nil, err
+			}
+			screenshot = data
+			return map[string]interface{}{"file": "screenshot.png", "bytes": len(data)}, nil
+		}},
+		{"session", func(ctx context.Context) (interface{}, error) {
+			if record.Name == "" {
+				return nil, fmt.Errorf("session %q not found in store", name)
+			}
+			return record, nil
+		}},
+	}
+
+	results := make([]infoResult, 0, len(collectors))
+	for _, c := range collectors {
+		results = append(results, runInfoCollector(c, *collectorTimeout))
+	}
+
+	if *output == "" {
+		printInfoSummary(results)
+		return nil
+	}
+	if err := os.MkdirAll(*output, 0o755); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if !r.OK || r.Name == "screenshot" {
+			continue
+		}
+		data, err := jsonStyle.JSON(r.Data, true, -1)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(*output, r.Name+".json"), []byte(data+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+	if screenshot != nil {
+		if err := os.WriteFile(filepath.Join(*output, "screenshot.png"), screenshot, 0o644); err != nil {
+			return err
+		}
+	}
+	summary, err := jsonStyle.JSON(results, true, -1)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(*output, "summary.json"), []byte(summary+"\n"), 0o644); err != nil {
+		return err
+	}
+	printInfoSummary(results)
+	fmt.Printf("Report written to %s\n", *output)
+	return nil
+}
+
+func runInfoCollector(c infoCollector, timeout time.Duration) (result infoResult) {
+	result.Name = c.name
+	defer func() {
+		if r := recover(); r != nil {
+			result.OK = false
+			result.Error = fmt.Sprint("panic: ", r)
+		}
+	}()
+	ctx, cancel := commandContext(timeout)
+	defer cancel()
+	data, err := c.run(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		result.Error = err.Error()
+		return result
+	}
+	result.OK = true
+	result.Data = data
+	return result
+}
+
+func printInfoSummary(results []infoResult) {
+	failed := 0
+	for _, r := range results {
+		if !r.OK {
+			failed++
+			fmt.Printf("%-11s FAILED: %s\n", r.Name+":", r.Error)
+			continue
+		}
+		line, err := jsonStyle.JSON(r.Data, false, -1)
+		if err != nil {
+			line = fmt.Sprint(r.Data)
+		}
+		fmt.Printf("%-11s %s\n", r.Name+":", abbreviate(line, 200))
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d collectors failed\n", failed, len(results))
+	}
+}
+
+// collectDialogState enables the Page domain, which re-announces a pending
+// JavaScript dialog, and waits briefly for that event.
+func collectDialogState(ctx context.Context, client *cdp.Client) (interface{}, error) {
+	var (
+		mu     sync.Mutex
+		dialog map[string]interface{}
+	)
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		if evt.Method != "Page.javascriptDialogOpening" {
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.Unmarshal(evt.Params, &payload); err == nil {
+			mu.Lock()
+			dialog = payload
+			mu.Unlock()
+		}
+	})
+	defer unsubscribe()
+	if err := client.Call(ctx, "Page.enable", nil, nil); err != nil {
+		return nil, err
+	}
+	select {
+	case <-time.After(300 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if dialog == nil {
+		return map[string]interface{}{"open": false}, nil
+	}
+	return map[string]interface{}{"open": true, "type": dialog["type"], "message": dialog["message"]}, nil
+}
+
+// collectConsoleErrors enables Runtime and Log briefly; Chrome replays buffered
+// messages on enable, so errors newer than window can be counted.
+func collectConsoleErrors(ctx context.Context, client *cdp.Client, window time.Duration) (interface{}, error) {
+	cutoff := float64(time.Now().Add(-window).UnixMilli())
+	var (
+		mu     sync.Mutex
+		counts = map[string]int{}
+	)
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		var payload struct {
+			Type      string  `json:"type"`
+			Timestamp float64 `json:"timestamp"`
+			Entry     struct {
+				Level     string  `json:"level"`
+				Timestamp float64 `json:"timestamp"`
+			} `json:"entry"`
+		}
+		if err := json.Unmarshal(evt.Params, &payload); err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch evt.Method {
+		case "Runtime.consoleAPICalled":
+			if payload.Type == "error" && payload.Timestamp >= cutoff {
+				counts["console"]++
+			}
+		case "Runtime.exceptionThrown":
+			if payload.Timestamp >= cutoff {
+				counts["exceptions"]++
+			}
+		case "Log.entryAdded":
+			if payload.Entry.Level == "error" && payload.Entry.Timestamp >= cutoff {
+				counts["log"]++
+			}
+		}
+	})
+	defer unsubscribe()
+	if err := client.Call(ctx, "Runtime.enable", nil, nil); err != nil {
+		return nil, err
+	}
+	if err := client.Call(ctx, "Log.enable", nil, nil); err != nil {
+		return nil, err
+	}
+	select {
+	case <-time.After(500 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return map[string]interface{}{
+		"window":     window.String(),
+		"console":    counts["console"],
+		"exceptions": counts["exceptions"],
+		"log":        counts["log"],
+	}, nil
+}
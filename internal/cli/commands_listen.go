@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var listenHelp = commandHelp{
+	Description: `Adds a binding function to the page and prints every string passed to it. This
+is a cheap channel from page code to your terminal. --persist keeps the binding
+across navigations.`,
+	Examples: []commandExample{
+		{"cdp listen --session app --binding cdpEmit", "Print window.cdpEmit(...) calls until Ctrl+C."},
+		{"cdp listen --session app --binding report --jsonl --limit 10", "Collect ten messages as JSONL."},
+		{"cdp listen --session app --binding trace --persist --timeout 1m", "Keep listening across reloads for a minute."},
+	},
+}
+
+func cmdListen(args []string) error {
+	fs := newFlagSet("listen", "usage: cdp listen --session <name> --binding <name> [--jsonl] [--persist]\n\nPrints everything the page sends via window.<binding>(string) until Ctrl+C.")
+	sessionFlag := addSessionFlag(fs)
+	bindingFlag := fs.String("binding", "", "Binding name exposed on window (e.g. myChannel)")
+	jsonl := fs.Bool("jsonl", false, "Emit one JSON object per message (payload parsed as JSON when possible)")
+	persist := fs.Bool("persist", false, "Re-register the binding whenever the page navigates")
+	limit := fs.Int("limit", 0, "Stop after N messages (<=0 for unlimited)")
+	timeout := addTimeoutFlag(fs, 0, "Stop after this long")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	if *bindingFlag == "" {
+		return errors.New("--binding is required")
+	}
+	if !jsIdentifierPattern.MatchString(*bindingFlag) {
+		return fmt.Errorf("invalid --binding name %q (use a JS identifier)", *bindingFlag)
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	if err := handle.client.Call(ctx, "Runtime.enable", nil, nil); err != nil {
+		return err
+	}
+	binding, err := handle.client.Bind(ctx, *bindingFlag)
+	if err != nil {
+		return err
+	}
+	defer binding.Close()
+
+	if *persist {
+		unsubscribe := handle.client.SubscribeEvents(func(evt cdp.Event) {
+			if evt.Method != "Runtime.executionContextCreated" {
+				return
+			}
+			go func() {
+				regCtx, regCancel := context.WithTimeout(ctx, 2*time.Second)
+				defer regCancel()
+				if err := binding.Register(regCtx); err != nil && ctx.Err() == nil {
+					fmt.Fprintln(os.Stderr, "warning: re-register binding:", err)
+				}
+			}()
+		})
+		defer unsubscribe()
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	var timeoutCh <-chan time.Time
+	if *timeout > 0 {
+		timer := time.NewTimer(*timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	fmt.Fprintf(os.Stderr, "Listening on window.%s(...). Ctrl+C to stop.\n", *bindingFlag)
+	count := 0
+	exitReason := ""
+loop:
+	for {
+		select {
+		case msg := <-binding.Messages:
+			if *jsonl {
+				var payload interface{} = msg
+				var decoded interface{}
+				if err := json.Unmarshal([]byte(msg), &decoded); err == nil {
+					payload = decoded
+				}
+				out, err := jsonStyle.JSON(map[string]interface{}{
+					"binding":   *bindingFlag,
+					"timestamp": time.Now().Format(time.RFC3339Nano),
+					"payload":   payload,
+				}, false, -1)
+				if err != nil {
+					return err
+				}
+				fmt.Println(out)
+			} else {
+				fmt.Println(msg)
+			}
+			count++
+			if *limit > 0 && count >= *limit {
+				exitReason = fmt.Sprintf("limit reached (%d messages)", *limit)
+				break loop
+			}
+		case <-timeoutCh:
+			exitReason = fmt.Sprintf("timeout reached (%s)", *timeout)
+			break loop
+		case <-sigCh:
+			exitReason = "interrupted"
+			break loop
+		case <-handle.client.Done():
+			fmt.Fprintf(os.Stderr, "Listen ended (connection lost). Messages: %d\n", count)
+			return handle.client.Err()
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Listen ended (%s). Messages: %d\n", exitReason, count)
+	return nil
+}
@@ -6,28 +6,43 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
-	"github.com/veilm/cdp-cli/internal/format"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var logHelp = commandHelp{
+	Description: `Streams console messages, exceptions and browser log entries until --timeout,
+--limit or Ctrl+C. An optional setup script runs after the listeners attach, so
+output it causes is not missed.`,
+	Examples: []commandExample{
+		{"cdp log --session app", "Stream the console until Ctrl+C."},
+		{`cdp log --session app --level "error|exception"`, "Show only errors."},
+		{`cdp log --session app "location.reload()" --timeout 10s --jsonl`, "Reload and capture ten seconds of output as JSONL."},
+		{"cdp log --session app --limit 1 --level warning", "Wait for the first warning."},
+	},
+}
+
 func cmdLog(args []string) error {
 	fs := newFlagSet("log", "usage: cdp log --session <name> [\"setup script\"] [options]")
 	sessionFlag := addSessionFlag(fs)
 	limitFlag := fs.Int("limit", 0, "Maximum log entries to collect (<=0 for unlimited)")
-	timeoutFlag := fs.Duration("timeout", 0, "Maximum time to wait for log events (0 disables)")
+	timeoutFlag := addTimeoutFlag(fs, 0, "Maximum time to wait for log events")
 	levelFlag := fs.String("level", "", "Regex to filter by level/type (e.g. 'error|warning|exception')")
+	jsonlFlag := fs.Bool("jsonl", false, "Emit one JSON object per entry (with a console.group depth field)")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -46,7 +61,7 @@ func cmdLog(args []string) error {
 		script = pos[0]
 	}
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	limit := *limitFlag
 	timeout := *timeoutFlag
@@ -68,27 +83,17 @@ func cmdLog(args []string) error {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	handle, err := openSession(ctx, st, name)
+	events := make(chan cdp.Event, 64)
+	handle, unsubscribe, err := attachLogStream(ctx, st, name, events)
 	if err != nil {
 		return err
 	}
-	defer handle.Close()
-
-	if err := handle.client.Call(ctx, "Runtime.enable", nil, nil); err != nil {
-		return err
-	}
-	if err := handle.client.Call(ctx, "Log.enable", nil, nil); err != nil {
-		return err
-	}
-
-	events := make(chan cdp.Event, 64)
-	unsubscribe := handle.client.SubscribeEvents(func(evt cdp.Event) {
-		select {
-		case events <- evt:
-		default:
+	defer func() {
+		if handle != nil {
+			unsubscribe()
+			handle.Close()
 		}
-	})
-	defer unsubscribe()
+	}()
 
 	if script != "" {
 		if _, err := handle.client.Evaluate(ctx, script); err != nil {
@@ -118,6 +123,7 @@ func cmdLog(args []string) error {
 	}
 	fmt.Fprintf(os.Stderr, "Streaming console output (limit=%s, timeout=%s). Ctrl+C to stop.\n", limitInfo, timeoutInfo)
 
+	printer := &logPrinter{jsonl: *jsonlFlag}
 	logCount := 0
 	exitReason := ""
 
@@ -144,7 +150,7 @@ loop:
 			}
 			break loop
 		case evt := <-events:
-			printed, err := handleLogEvent(ctx, handle.client, evt, levelFilter)
+			printed, err := printer.handle(ctx, handle.client, evt, levelFilter)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, "log handler:", err)
 			}
@@ -162,6 +168,19 @@ loop:
 			exitReason = "interrupted"
 			cancel()
 			break loop
+		case <-handle.client.Done():
+			// The tab closed or the connection went silent; attach again so
+			// a browser hiccup doesn't end (or hang) the stream.
+			lost := handle.client.Err()
+			unsubscribe()
+			handle.Close()
+			fmt.Fprintf(os.Stderr, "Connection lost (%v); reconnecting\n", lost)
+			attachCtx, attachCancel := withCommandTimeout(ctx, logReconnectTimeout)
+			handle, unsubscribe, err = attachLogStream(attachCtx, st, name, events)
+			attachCancel()
+			if err != nil {
+				return fmt.Errorf("reconnecting after %v: %w", lost, err)
+			}
 		}
 	}
 
@@ -172,7 +191,63 @@ loop:
 	return nil
 }
 
-func handleLogEvent(ctx context.Context, client *cdp.Client, evt cdp.Event, levelFilter *regexp.Regexp) (bool, error) {
+// logReconnectTimeout bounds each attempt to re-attach a log stream.
+const logReconnectTimeout = 10 * time.Second
+
+// attachLogStream opens the session, enables the console domains and forwards
+// every event to events, dropping them when the consumer falls behind.
+func attachLogStream(ctx context.Context, st *store.Store, name string, events chan<- cdp.Event) (*sessionHandle, func(), error) {
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, method := range []string{"Runtime.enable", "Log.enable"} {
+		if err := handle.client.Call(ctx, method, nil, nil); err != nil {
+			handle.Close()
+			return nil, nil, err
+		}
+	}
+	unsubscribe := handle.client.SubscribeEvents(func(evt cdp.Event) {
+		select {
+		case events <- evt:
+		default:
+		}
+	})
+	return handle, unsubscribe, nil
+}
+
+// logPrinter renders console events, tracking console.group nesting so
+// subsequent lines are indented (or tagged with a depth in JSONL mode).
+type logPrinter struct {
+	jsonl bool
+	depth int
+	out   io.Writer // os.Stdout when nil
+}
+
+const logTableCellLimit = 40
+
+func (p *logPrinter) writer() io.Writer {
+	if p.out == nil {
+		return os.Stdout
+	}
+	return p.out
+}
+
+func (p *logPrinter) emit(line string) {
+	fmt.Fprintf(p.writer(), "%s%s\n", strings.Repeat("  ", p.depth), line)
+}
+
+func (p *logPrinter) emitJSON(entry map[string]interface{}) {
+	entry["group"] = p.depth
+	out, err := jsonStyle.JSON(entry, false, -1)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "log handler:", err)
+		return
+	}
+	fmt.Fprintln(p.writer(), out)
+}
+
+func (p *logPrinter) handle(ctx context.Context, client *cdp.Client, evt cdp.Event, levelFilter *regexp.Regexp) (bool, error) {
 	switch evt.Method {
 	case "Runtime.consoleAPICalled":
 		var payload struct {
@@ -182,21 +257,34 @@ func handleLogEvent(ctx context.Context, client *cdp.Client, evt cdp.Event, leve
 		if err := json.Unmarshal(evt.Params, &payload); err != nil {
 			return false, err
 		}
+		if payload.Type == "endGroup" {
+			// Always track group ends (even when filtered) so nesting stays balanced.
+			if p.depth > 0 {
+				p.depth--
+			}
+			return false, nil
+		}
 		if levelFilter != nil && !levelFilter.MatchString(payload.Type) {
+			if payload.Type == "startGroup" || payload.Type == "startGroupCollapsed" {
+				p.depth++
+			}
 			return false, nil
 		}
+		raw := make([]interface{}, 0, len(payload.Args))
 		values := make([]string, 0, len(payload.Args))
 		for _, arg := range payload.Args {
 			val, err := client.RemoteObjectValue(ctx, arg)
 			if err != nil {
+				raw = append(raw, nil)
 				values = append(values, fmt.Sprintf("<error: %v>", err))
 				continue
 			}
+			raw = append(raw, val)
 			switch t := val.(type) {
 			case string:
 				values = append(values, t)
 			default:
-				out, err := format.JSON(t, false, 2)
+				out, err := jsonStyle.JSON(t, false, 2)
 				if err != nil {
 					values = append(values, fmt.Sprintf("%v", t))
 				} else {
@@ -204,7 +292,37 @@ func handleLogEvent(ctx context.Context, client *cdp.Client, evt cdp.Event, leve
 				}
 			}
 		}
-		fmt.Printf("[%s] %s\n", payload.Type, strings.Join(values, " "))
+		if p.jsonl {
+			p.emitJSON(map[string]interface{}{"type": payload.Type, "args": raw})
+		} else {
+			switch payload.Type {
+			case "table":
+				var data interface{}
+				if len(raw) > 0 {
+					data = raw[0]
+				}
+				p.emit("[table]")
+				for _, line := range renderConsoleTable(data, logTableCellLimit) {
+					p.emit(line)
+				}
+			case "startGroup", "startGroupCollapsed":
+				label := strings.Join(values, " ")
+				if label == "" {
+					label = "console.group"
+				}
+				p.emit("[group] " + label)
+			case "timeEnd":
+				p.emit("[timer] " + strings.Join(values, " "))
+			case "count", "countReset":
+				// The page sends the counter already formatted as "label: N".
+				p.emit("[count] " + strings.Join(values, " "))
+			default:
+				p.emit(fmt.Sprintf("[%s] %s", payload.Type, strings.Join(values, " ")))
+			}
+		}
+		if payload.Type == "startGroup" || payload.Type == "startGroupCollapsed" {
+			p.depth++
+		}
 		return true, nil
 
 	case "Runtime.exceptionThrown":
@@ -239,17 +357,25 @@ func handleLogEvent(ctx context.Context, client *cdp.Client, evt cdp.Event, leve
 				desc = string(*details.Exception.Value)
 			}
 		}
+		if p.jsonl {
+			entry := map[string]interface{}{"type": "exception", "text": details.Text}
+			if desc != "" {
+				entry["description"] = desc
+			}
+			p.emitJSON(entry)
+			return true, nil
+		}
 		if desc != "" {
-			fmt.Printf("[exception] %s\n", desc)
+			p.emit(fmt.Sprintf("[exception] %s", desc))
 		} else {
-			fmt.Printf("[exception] %s\n", details.Text)
+			p.emit(fmt.Sprintf("[exception] %s", details.Text))
 			if details.StackTrace != nil {
 				for _, f := range details.StackTrace.CallFrames {
 					fn := f.FunctionName
 					if fn == "" {
 						fn = "(anonymous)"
 					}
-					fmt.Printf("  at %s (%s:%d:%d)\n", fn, f.URL, f.LineNumber+1, f.ColumnNumber+1)
+					p.emit(fmt.Sprintf("  at %s (%s:%d:%d)", fn, f.URL, f.LineNumber+1, f.ColumnNumber+1))
 				}
 			}
 		}
@@ -273,24 +399,157 @@ func handleLogEvent(ctx context.Context, client *cdp.Client, evt cdp.Event, leve
 		if levelFilter != nil && !levelFilter.MatchString(entry.Level) {
 			return false, nil
 		}
+		if p.jsonl {
+			p.emitJSON(map[string]interface{}{
+				"type":   "entry",
+				"source": entry.Source,
+				"level":  entry.Level,
+				"text":   entry.Text,
+				"url":    entry.URL,
+				"line":   entry.Line,
+				"column": entry.Column,
+			})
+			return true, nil
+		}
 		location := ""
 		if entry.URL != "" {
 			location = fmt.Sprintf(" (%s:%d:%d)", entry.URL, entry.Line, entry.Column)
 		}
-		fmt.Printf("[%s/%s] %s%s\n", entry.Source, entry.Level, entry.Text, location)
+		p.emit(fmt.Sprintf("[%s/%s] %s%s", entry.Source, entry.Level, entry.Text, location))
 		return true, nil
 	}
 	return false, nil
 }
 
+// renderConsoleTable lays out console.table data (an array or object of rows)
+// as aligned text lines, mirroring the DevTools "(index)" column.
+func renderConsoleTable(data interface{}, cellLimit int) []string {
+	type row struct {
+		index string
+		cells map[string]string
+	}
+	var rows []row
+	var columns []string
+	seen := make(map[string]bool)
+	hasValue := false
+	addRow := func(index string, value interface{}) {
+		r := row{index: index, cells: make(map[string]string)}
+		if m, ok := value.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if !seen[k] {
+					seen[k] = true
+					columns = append(columns, k)
+				}
+				r.cells[k] = formatTableCell(m[k])
+			}
+		} else {
+			hasValue = true
+			r.cells["Value"] = formatTableCell(value)
+		}
+		rows = append(rows, r)
+	}
+	switch v := data.(type) {
+	case []interface{}:
+		for i, item := range v {
+			addRow(strconv.Itoa(i), item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			addRow(k, v[k])
+		}
+	default:
+		return []string{formatTableCell(data)}
+	}
+	if hasValue {
+		columns = append(columns, "Value")
+	}
+	header := append([]string{"(index)"}, columns...)
+	widths := make([]int, len(header))
+	table := make([][]string, 0, len(rows)+1)
+	table = append(table, header)
+	for _, r := range rows {
+		line := []string{r.index}
+		for _, c := range columns {
+			line = append(line, r.cells[c])
+		}
+		table = append(table, line)
+	}
+	for i := range table {
+		for j := range table[i] {
+			table[i][j] = cropForTTY(table[i][j], cellLimit)
+			if n := len([]rune(table[i][j])); n > widths[j] {
+				widths[j] = n
+			}
+		}
+	}
+	lines := make([]string, 0, len(table))
+	for _, cells := range table {
+		var b strings.Builder
+		for j, cell := range cells {
+			if j > 0 {
+				b.WriteString(" | ")
+			}
+			b.WriteString(cell)
+			if j < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[j]-len([]rune(cell))))
+			}
+		}
+		lines = append(lines, strings.TrimRight(b.String(), " "))
+	}
+	return lines
+}
+
+func formatTableCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		out, err := jsonStyle.JSON(v, false, 1)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return out
+	}
+}
+
+var networkLogHelp = commandHelp{
+	Description: `Records matching requests, writing each request and response to a directory
+(metadata, headers and bodies) until Ctrl+C. The filters are regexes.
+'cdp network-log grep' searches a capture afterwards and 'cdp har-to-mock' turns
+it into mock rules.`,
+	Examples: []commandExample{
+		{"cdp network-log --session app --url /api/ --dir captures/", "Record API traffic into captures/."},
+		{`cdp network-log --session app --method POST --status "^5"`, "Capture only failing POSTs."},
+		{"cdp network-log --session app --mime json --per-request-timeout 30s", "Record JSON responses, allowing slow bodies 30s."},
+		{`cdp network-log grep captures/ "order_id" --json-path $.data.items`, "Find captures whose items mention order_id."},
+		{`cdp network-log grep captures/ "x-request-id: abc" --headers --ignore-case`, "Search the recorded headers too."},
+	},
+}
+
 func cmdNetworkLog(args []string) error {
-	fs := newFlagSet("network-log", "usage: cdp network-log --session <name> [options]")
+	if len(args) > 0 && args[0] == "grep" {
+		return cmdNetworkLogGrep(args[1:])
+	}
+	fs := newFlagSet("network-log", "usage: cdp network-log --session <name> [options]\n   or: cdp network-log grep <dir> <pattern> [--regex] [--headers] [--json-path PATH]")
 	sessionFlag := addSessionFlag(fs)
 	dirFlag := fs.String("dir", "", "Directory for captured requests (default ./cdp-<name>-network-log)")
 	urlPattern := fs.String("url", "", "Regex to match request URLs")
 	methodPattern := fs.String("method", "", "Regex to match HTTP methods")
 	statusPattern := fs.String("status", "", "Regex to match HTTP status codes")
 	mimePattern := fs.String("mime", "", "Regex to match response Content-Type values")
+	perRequestTimeout := fs.Duration("per-request-timeout", 10*time.Second, "Max time a request stays paused for its body fetch before it is continued")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -308,6 +567,9 @@ func cmdNetworkLog(args []string) error {
 		return err
 	}
 
+	if *perRequestTimeout <= 0 {
+		return errors.New("--per-request-timeout must be > 0")
+	}
 	filters, err := buildNetworkFilters(*urlPattern, *methodPattern, *statusPattern, *mimePattern)
 	if err != nil {
 		return err
@@ -340,8 +602,9 @@ func cmdNetworkLog(args []string) error {
 	defer handle.Close()
 
 	opts := networkCaptureOptions{
-		Dir:     outputDir,
-		Filters: filters,
+		Dir:               outputDir,
+		Filters:           filters,
+		PerRequestTimeout: *perRequestTimeout,
 	}
 
 	errCh := make(chan error, 1)
@@ -372,8 +635,57 @@ func cmdNetworkLog(args []string) error {
 // network-log helpers
 
 type networkCaptureOptions struct {
-	Dir     string
-	Filters networkFilters
+	Dir               string
+	Filters           networkFilters
+	PerRequestTimeout time.Duration
+}
+
+// pausedFetches tracks Fetch.requestPaused events that have not been continued
+// yet, so they can all be released at once when the page navigates or
+// network-log stops. Chrome otherwise holds the navigation until they time out.
+type pausedFetches struct {
+	mu      sync.Mutex
+	pending map[string]context.CancelFunc
+}
+
+func newPausedFetches() *pausedFetches {
+	return &pausedFetches{pending: map[string]context.CancelFunc{}}
+}
+
+func (p *pausedFetches) add(requestID string, cancel context.CancelFunc) {
+	p.mu.Lock()
+	p.pending[requestID] = cancel
+	p.mu.Unlock()
+}
+
+// take removes requestID and reports whether the caller still has to continue
+// it (false once releaseAll got there first).
+func (p *pausedFetches) take(requestID string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.pending[requestID]
+	delete(p.pending, requestID)
+	return ok
+}
+
+// releaseAll abandons body fetching for every outstanding request and
+// continues them immediately.
+func (p *pausedFetches) releaseAll(client *cdp.Client, reason string) int {
+	p.mu.Lock()
+	ids := make([]string, 0, len(p.pending))
+	for id, cancel := range p.pending {
+		cancel()
+		ids = append(ids, id)
+	}
+	p.pending = map[string]context.CancelFunc{}
+	p.mu.Unlock()
+	for _, id := range ids {
+		continueFetchRequest(client, id)
+	}
+	if len(ids) > 0 {
+		fmt.Fprintf(os.Stderr, "cdp network-log: released %d paused request(s) (%s)\n", len(ids), reason)
+	}
+	return len(ids)
 }
 
 type networkFilters struct {
@@ -435,6 +747,7 @@ func (f networkFilters) match(url, method, status, mime string) bool {
 
 type fetchRequestPausedEvent struct {
 	RequestID          string             `json:"requestId"`
+	NetworkID          string             `json:"networkId"`
 	Request            fetchRequestInfo   `json:"request"`
 	ResponseStatusCode *int               `json:"responseStatusCode"`
 	ResponseHeaders    []fetchHeaderEntry `json:"responseHeaders"`
@@ -466,7 +779,7 @@ func runNetworkCapture(ctx context.Context, client *cdp.Client, opts networkCapt
 		},
 		"handleAuthRequests": false,
 	}, nil); err != nil {
-		return err
+		return fmt.Errorf("Fetch.enable: %w", err)
 	}
 	defer func() {
 		disableCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -474,8 +787,45 @@ func runNetworkCapture(ctx context.Context, client *cdp.Client, opts networkCapt
 		client.Call(disableCtx, "Fetch.disable", nil, nil)
 	}()
 
+	// Page events tell us when the main frame navigates; without them paused
+	// requests are still released on shutdown and by their own deadline.
+	mainFrameID := ""
+	if err := client.Call(ctx, "Page.enable", nil, nil); err == nil {
+		var tree struct {
+			FrameTree struct {
+				Frame struct {
+					ID string `json:"id"`
+				} `json:"frame"`
+			} `json:"frameTree"`
+		}
+		if err := client.Call(ctx, "Page.getFrameTree", nil, &tree); err == nil {
+			mainFrameID = tree.FrameTree.Frame.ID
+		}
+	}
+
+	paused := newPausedFetches()
+	redirects := newRedirectTracker()
+	initiators := newInitiatorTracker()
 	var wg sync.WaitGroup
 	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		if initiators.observe(evt) {
+			return
+		}
+		if evt.Method == "Page.frameStartedLoading" {
+			var frame struct {
+				FrameID string `json:"frameId"`
+			}
+			if err := json.Unmarshal(evt.Params, &frame); err != nil || (mainFrameID != "" && frame.FrameID != mainFrameID) {
+				return
+			}
+			// Event handlers run on the read loop, so CDP calls must happen elsewhere.
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				paused.releaseAll(client, "navigation")
+			}()
+			return
+		}
 		if evt.Method != "Fetch.requestPaused" {
 			return
 		}
@@ -485,17 +835,24 @@ func runNetworkCapture(ctx context.Context, client *cdp.Client, opts networkCapt
 		}
 		select {
 		case <-ctx.Done():
+			// Shutting down: don't leave the request paused.
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				continueFetchRequest(client, payload.RequestID)
+			}()
 			return
 		default:
 		}
 		wg.Add(1)
 		go func(event fetchRequestPausedEvent) {
 			defer wg.Done()
-			processFetchPaused(ctx, client, opts, event)
+			processFetchPaused(ctx, client, opts, paused, redirects, initiators, event)
 		}(payload)
 	})
 	defer func() {
 		unsubscribe()
+		paused.releaseAll(client, "stopping")
 		wg.Wait()
 	}()
 
@@ -504,6 +861,8 @@ func runNetworkCapture(ctx context.Context, client *cdp.Client, opts networkCapt
 }
 
 type networkCapture struct {
+	// Sequence orders captures within one process; see nextCaptureSequence.
+	Sequence          int64
 	Timestamp         time.Time
 	RequestID         string
 	URL               string
@@ -516,10 +875,187 @@ type networkCapture struct {
 	RequestBody       []byte
 	ResponseBody      []byte
 	ResponseBodyError string
+	// Location is the target of a 3xx response.
+	Location string
+	// RedirectChain lists the earlier captured hops of this request's
+	// redirect sequence, oldest first.
+	RedirectChain []redirectHop
+	// Initiator is Network.requestWillBeSent's initiator (parser, script
+	// with its call stack, preload, ...), verbatim.
+	Initiator json.RawMessage
+}
+
+// redirectHop identifies one captured response in a redirect sequence; its
+// capture directory starts with the zero-padded Sequence.
+type redirectHop struct {
+	Sequence int64  `json:"sequence"`
+	URL      string `json:"url"`
+	Status   string `json:"status"`
+}
+
+// redirectTracker links the hops of a redirect. Chrome keeps the networkId
+// across redirects, so hops are chained by it while responses keep
+// redirecting.
+type redirectTracker struct {
+	mu     sync.Mutex
+	chains map[string][]redirectHop
 }
 
-func processFetchPaused(ctx context.Context, client *cdp.Client, opts networkCaptureOptions, event fetchRequestPausedEvent) {
-	defer continueFetchRequest(client, event.RequestID)
+func newRedirectTracker() *redirectTracker {
+	return &redirectTracker{chains: map[string][]redirectHop{}}
+}
+
+// hop records a captured response and returns the hops before it. The chain
+// stays open only while redirect is true, so finished requests aren't kept.
+func (t *redirectTracker) hop(networkID string, h redirectHop, redirect bool) []redirectHop {
+	if networkID == "" {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.chains[networkID]
+	if redirect {
+		t.chains[networkID] = append(append([]redirectHop{}, prev...), h)
+	} else {
+		delete(t.chains, networkID)
+	}
+	return prev
+}
+
+// initiatorTracker remembers the initiator Network.requestWillBeSent reported
+// for each request until its response is captured. Network's requestId is
+// Fetch's networkId; the URL is the fallback when Fetch doesn't carry one.
+type initiatorTracker struct {
+	mu    sync.Mutex
+	byID  map[string]json.RawMessage
+	byURL map[string]json.RawMessage
+	urls  map[string]string
+}
+
+func newInitiatorTracker() *initiatorTracker {
+	return &initiatorTracker{byID: map[string]json.RawMessage{}, byURL: map[string]json.RawMessage{}, urls: map[string]string{}}
+}
+
+// observe records or forgets initiators from Network events, reporting
+// whether evt was one of them. It runs on the read loop and makes no calls;
+// requestWillBeSent always arrives before the response is paused.
+func (t *initiatorTracker) observe(evt cdp.Event) bool {
+	switch evt.Method {
+	case "Network.requestWillBeSent":
+		var payload struct {
+			RequestID string `json:"requestId"`
+			Request   struct {
+				URL string `json:"url"`
+			} `json:"request"`
+			Initiator json.RawMessage `json:"initiator"`
+		}
+		if err := json.Unmarshal(evt.Params, &payload); err != nil || len(payload.Initiator) == 0 {
+			return true
+		}
+		t.mu.Lock()
+		t.byID[payload.RequestID] = payload.Initiator
+		t.byURL[payload.Request.URL] = payload.Initiator
+		t.urls[payload.RequestID] = payload.Request.URL
+		t.mu.Unlock()
+		return true
+	case "Network.loadingFinished", "Network.loadingFailed":
+		var payload struct {
+			RequestID string `json:"requestId"`
+		}
+		if err := json.Unmarshal(evt.Params, &payload); err == nil {
+			t.mu.Lock()
+			t.forget(payload.RequestID)
+			t.mu.Unlock()
+		}
+		return true
+	}
+	return false
+}
+
+// take returns the initiator of a paused response and forgets it; a
+// redirect's next hop is announced by a fresh requestWillBeSent.
+func (t *initiatorTracker) take(networkID, url string) json.RawMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if initiator, ok := t.byID[networkID]; ok && networkID != "" {
+		t.forget(networkID)
+		return initiator
+	}
+	initiator := t.byURL[url]
+	for id, u := range t.urls {
+		if u == url {
+			t.forget(id)
+		}
+	}
+	return initiator
+}
+
+func (t *initiatorTracker) forget(requestID string) {
+	if url, ok := t.urls[requestID]; ok {
+		delete(t.byURL, url)
+	}
+	delete(t.byID, requestID)
+	delete(t.urls, requestID)
+}
+
+// initiatorFrames flattens an initiator's call stack, async parents
+// included, into "fn (url:line:col)" lines, innermost first. Line and column
+// are 1-based like an editor's.
+func initiatorFrames(initiator json.RawMessage) []string {
+	type callFrame struct {
+		FunctionName string `json:"functionName"`
+		URL          string `json:"url"`
+		LineNumber   int    `json:"lineNumber"`
+		ColumnNumber int    `json:"columnNumber"`
+	}
+	type stackTrace struct {
+		Description string      `json:"description"`
+		CallFrames  []callFrame `json:"callFrames"`
+		Parent      *stackTrace `json:"parent"`
+	}
+	var parsed struct {
+		Stack *stackTrace `json:"stack"`
+	}
+	if len(initiator) == 0 || json.Unmarshal(initiator, &parsed) != nil {
+		return nil
+	}
+	var frames []string
+	for stack := parsed.Stack; stack != nil; stack = stack.Parent {
+		if stack != parsed.Stack && stack.Description != "" {
+			frames = append(frames, "-- "+stack.Description+" --")
+		}
+		for _, f := range stack.CallFrames {
+			name := f.FunctionName
+			if name == "" {
+				name = "(anonymous)"
+			}
+			frames = append(frames, fmt.Sprintf("%s (%s:%d:%d)", name, f.URL, f.LineNumber+1, f.ColumnNumber+1))
+		}
+	}
+	return frames
+}
+
+func isRedirectStatus(status string) bool {
+	switch status {
+	case "301", "302", "303", "307", "308":
+		return true
+	}
+	return false
+}
+
+// processFetchPaused captures one paused response. The request is continued as
+// soon as its body is in hand (or opts.PerRequestTimeout passes, or paused
+// releases it), before anything is written to disk.
+func processFetchPaused(ctx context.Context, client *cdp.Client, opts networkCaptureOptions, paused *pausedFetches, redirects *redirectTracker, initiators *initiatorTracker, event fetchRequestPausedEvent) {
+	workCtx, cancel := context.WithTimeout(ctx, opts.PerRequestTimeout)
+	defer cancel()
+	paused.add(event.RequestID, cancel)
+	release := func() {
+		if paused.take(event.RequestID) {
+			continueFetchRequest(client, event.RequestID)
+		}
+	}
+	defer release()
 
 	url := event.Request.URL
 	method := event.Request.Method
@@ -529,11 +1065,21 @@ func processFetchPaused(ctx context.Context, client *cdp.Client, opts networkCap
 	}
 	responseHeaders := normalizeHeaderList(event.ResponseHeaders)
 	contentType := strings.ToLower(responseHeaders["content-type"])
+	initiator := initiators.take(event.NetworkID, url)
 	if !opts.Filters.match(url, method, status, contentType) {
 		return
 	}
+	seq := nextCaptureSequence()
+	// Record the hop before continuing the request, so the next hop of a
+	// redirect (same networkId) always finds it.
+	location := ""
+	if isRedirectStatus(status) {
+		location = responseHeaders["location"]
+	}
+	chain := redirects.hop(event.NetworkID, redirectHop{Sequence: seq, URL: url, Status: status}, location != "")
 
-	body, bodyErr := fetchResponseBody(ctx, client, event.RequestID)
+	body, bodyErr := fetchResponseBody(workCtx, client, event.RequestID)
+	release()
 	requestHeaders := sanitizeHeaderMap(event.Request.Headers)
 	var requestBody []byte
 	if event.Request.PostData != "" {
@@ -541,6 +1087,7 @@ func processFetchPaused(ctx context.Context, client *cdp.Client, opts networkCap
 	}
 
 	capture := networkCapture{
+		Sequence:          seq,
 		Timestamp:         time.Now(),
 		RequestID:         event.RequestID,
 		URL:               url,
@@ -553,6 +1100,9 @@ func processFetchPaused(ctx context.Context, client *cdp.Client, opts networkCap
 		RequestBody:       requestBody,
 		ResponseBody:      body,
 		ResponseBodyError: bodyErr,
+		Location:          location,
+		RedirectChain:     chain,
+		Initiator:         initiator,
 	}
 	if err := writeNetworkCapture(opts.Dir, capture); err != nil {
 		fmt.Fprintf(os.Stderr, "cdp network-log: failed to write capture for %s: %v\n", event.RequestID, err)
@@ -564,9 +1114,7 @@ func fetchResponseBody(ctx context.Context, client *cdp.Client, requestID string
 		Body          string `json:"body"`
 		Base64Encoded bool   `json:"base64Encoded"`
 	}
-	callCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
-	if err := client.Call(callCtx, "Fetch.getResponseBody", map[string]interface{}{
+	if err := client.Call(ctx, "Fetch.getResponseBody", map[string]interface{}{
 		"requestId": requestID,
 	}, &result); err != nil {
 		return nil, err.Error()
@@ -618,14 +1166,22 @@ func sanitizeHeaderMap(headers map[string]interface{}) map[string]string {
 	return result
 }
 
+// captureSequence numbers matched requests in the order they were paused, so
+// capture directories sort in request order and never share a name.
+var captureSequence int64
+
+func nextCaptureSequence() int64 {
+	return atomic.AddInt64(&captureSequence, 1)
+}
+
 func writeNetworkCapture(baseDir string, capture networkCapture) error {
-	dirName := formatCaptureDirName(capture)
-	captureDir := filepath.Join(baseDir, dirName)
-	if err := os.MkdirAll(captureDir, 0o755); err != nil {
+	captureDir, err := makeCaptureDir(baseDir, formatCaptureDirName(capture))
+	if err != nil {
 		return err
 	}
 
 	metadata := map[string]interface{}{
+		"sequence":  capture.Sequence,
 		"timestamp": capture.Timestamp.Format(time.RFC3339Nano),
 		"requestId": capture.RequestID,
 		"url":       capture.URL,
@@ -639,6 +1195,19 @@ func writeNetworkCapture(baseDir string, capture networkCapture) error {
 	if capture.ResponseBodyError != "" {
 		metadata["responseBodyError"] = capture.ResponseBodyError
 	}
+	if capture.Location != "" {
+		metadata["location"] = capture.Location
+	}
+	if len(capture.RedirectChain) > 0 {
+		metadata["redirectedFrom"] = capture.RedirectChain[len(capture.RedirectChain)-1]
+		metadata["redirectChain"] = capture.RedirectChain
+	}
+	if len(capture.Initiator) > 0 {
+		metadata["initiator"] = capture.Initiator
+		if frames := initiatorFrames(capture.Initiator); len(frames) > 0 {
+			metadata["initiatorStack"] = frames
+		}
+	}
 	if err := writeJSONFile(filepath.Join(captureDir, "metadata.json"), metadata); err != nil {
 		return err
 	}
@@ -688,13 +1257,40 @@ func sanitizePathFragment(value string) string {
 }
 
 func formatCaptureDirName(capture networkCapture) string {
-	ms := capture.Timestamp.UnixNano() / int64(time.Millisecond)
 	method := strings.ToUpper(strings.TrimSpace(capture.Method))
 	if method == "" {
 		method = "REQ"
 	}
 	urlFragment := shortenURLFragment(capture.URL, 96)
-	return fmt.Sprintf("%d-%s-%s", ms, method, urlFragment)
+	// Six digits keep a plain `ls` in arrival order for long sessions, where
+	// "10000-..." would otherwise sort before "9999-...".
+	return fmt.Sprintf("%06d-%s-%s", capture.Sequence, method, urlFragment)
+}
+
+// makeCaptureDir creates baseDir/name, or name-b, name-c, ... when an earlier
+// run already left a capture there, so captures are never mixed together.
+func makeCaptureDir(baseDir, name string) (string, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return "", err
+	}
+	for i := 0; ; i++ {
+		candidate := name
+		switch {
+		case i == 0:
+		case i < 26:
+			candidate = fmt.Sprintf("%s-%c", name, 'a'+i)
+		default:
+			candidate = fmt.Sprintf("%s-%d", name, i+1)
+		}
+		dir := filepath.Join(baseDir, candidate)
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			return dir, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
 }
 
 func shortenURLFragment(raw string, limit int) string {
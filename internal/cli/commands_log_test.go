@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+func TestRenderConsoleTable(t *testing.T) {
+	data := []interface{}{
+		map[string]interface{}{"name": "alice", "age": float64(30)},
+		map[string]interface{}{"name": "bob"},
+		"loose",
+	}
+	lines := renderConsoleTable(data, 40)
+	want := []string{
+		"(index) | age | name  | Value",
+		"0       | 30  | alice |",
+		"1       |     | bob   |",
+		"2       |     |       | loose",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected table:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestRenderConsoleTableTruncatesCells(t *testing.T) {
+	data := []interface{}{strings.Repeat("x", 50)}
+	lines := renderConsoleTable(data, 10)
+	if !strings.HasSuffix(lines[1], "xxxxxxxxxx[...]") {
+		t.Fatalf("expected truncated cell, got %q", lines[1])
+	}
+}
+
+func TestPausedFetchesTake(t *testing.T) {
+	paused := newPausedFetches()
+	canceled := false
+	paused.add("r1", func() { canceled = true })
+	if !paused.take("r1") {
+		t.Fatal("first take should own the request")
+	}
+	if paused.take("r1") {
+		t.Fatal("second take should not continue the request again")
+	}
+	if n := paused.releaseAll(nil, "test"); n != 0 || canceled {
+		t.Fatalf("releaseAll after take released %d (canceled=%v)", n, canceled)
+	}
+}
+
+func TestWriteNetworkCaptureTightLoop(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	const n = 200
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			capture := networkCapture{Sequence: nextCaptureSequence(), Timestamp: now, RequestID: "r", URL: "https://example.com/api", Method: "GET", Status: "200"}
+			if err := writeNetworkCapture(dir, capture); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Fatalf("got %d capture dirs, want %d", len(entries), n)
+	}
+	var last int64
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name(), "metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var meta struct {
+			Sequence int64 `json:"sequence"`
+		}
+		if err := json.Unmarshal(data, &meta); err != nil {
+			t.Fatal(err)
+		}
+		if meta.Sequence <= last {
+			t.Fatalf("%s: sequence %d not after %d; name order should match request order", e.Name(), meta.Sequence, last)
+		}
+		last = meta.Sequence
+	}
+
+	late := formatCaptureDirName(networkCapture{Sequence: 10000, Method: "GET", URL: "https://example.com/api"})
+	early := formatCaptureDirName(networkCapture{Sequence: 9999, Method: "GET", URL: "https://example.com/api"})
+	if late <= early {
+		t.Fatalf("%s sorts before %s", late, early)
+	}
+
+	// A second run restarts at sequence 1 and must not reuse a directory.
+	rerun := t.TempDir()
+	capture := networkCapture{Sequence: 1, Timestamp: now, URL: "https://example.com/api", Method: "GET"}
+	name := formatCaptureDirName(capture)
+	for _, want := range []string{name, name + "-b", name + "-c"} {
+		if err := writeNetworkCapture(rerun, capture); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(rerun, want, "metadata.json")); err != nil {
+			t.Errorf("expected capture in %s: %v", want, err)
+		}
+	}
+}
+
+func TestRedirectTrackerLinksHops(t *testing.T) {
+	tracker := newRedirectTracker()
+	login := redirectHop{Sequence: 1, URL: "https://app.example/", Status: "302"}
+	sso := redirectHop{Sequence: 2, URL: "https://sso.example/authorize", Status: "302"}
+	if chain := tracker.hop("n1", login, true); len(chain) != 0 {
+		t.Fatalf("first hop has a chain: %v", chain)
+	}
+	if chain := tracker.hop("n1", sso, true); len(chain) != 1 || chain[0] != login {
+		t.Fatalf("second hop chain = %v", chain)
+	}
+	final := tracker.hop("n1", redirectHop{Sequence: 3, URL: "https://app.example/home", Status: "200"}, false)
+	if len(final) != 2 || final[0] != login || final[1] != sso {
+		t.Fatalf("final hop chain = %v", final)
+	}
+	if chain := tracker.hop("n1", redirectHop{Sequence: 4}, false); len(chain) != 0 {
+		t.Fatalf("chain kept after the redirect finished: %v", chain)
+	}
+
+	dir := t.TempDir()
+	capture := networkCapture{Sequence: 3, URL: "https://app.example/home", Method: "GET", Status: "200", RedirectChain: final}
+	if err := writeNetworkCapture(dir, capture); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, formatCaptureDirName(capture), "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta struct {
+		RedirectedFrom redirectHop   `json:"redirectedFrom"`
+		RedirectChain  []redirectHop `json:"redirectChain"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.RedirectedFrom != sso || len(meta.RedirectChain) != 2 {
+		t.Fatalf("metadata = %s", data)
+	}
+}
+
+func TestInitiatorTrackerRecordsStack(t *testing.T) {
+	tracker := newInitiatorTracker()
+	event := func(method string, params string) cdp.Event {
+		return cdp.Event{Method: method, Params: json.RawMessage(params)}
+	}
+	script := `{"type":"script","stack":{"callFrames":[{"functionName":"loadFlags","url":"https://app.example/main.js","lineNumber":41,"columnNumber":9}],"parent":{"description":"Promise.then","callFrames":[{"functionName":"","url":"https://app.example/boot.js","lineNumber":0,"columnNumber":0}]}}}`
+	if !tracker.observe(event("Network.requestWillBeSent", `{"requestId":"n1","request":{"url":"https://api.example/flags"},"initiator":`+script+`}`)) {
+		t.Fatal("requestWillBeSent not handled")
+	}
+	tracker.observe(event("Network.requestWillBeSent", `{"requestId":"n2","request":{"url":"https://app.example/style.css"},"initiator":{"type":"parser"}}`))
+	if tracker.observe(event("Fetch.requestPaused", `{}`)) {
+		t.Fatal("Fetch events belong to the capture loop")
+	}
+
+	initiator := tracker.take("n1", "https://api.example/flags")
+	if len(initiator) == 0 {
+		t.Fatal("no initiator for n1")
+	}
+	if again := tracker.take("n1", "https://api.example/flags"); len(again) != 0 {
+		t.Fatalf("initiator kept after take: %s", again)
+	}
+	if byURL := tracker.take("", "https://app.example/style.css"); !strings.Contains(string(byURL), "parser") {
+		t.Fatalf("URL fallback = %s", byURL)
+	}
+	tracker.observe(event("Network.requestWillBeSent", `{"requestId":"n3","request":{"url":"https://x.example/"},"initiator":{"type":"other"}}`))
+	tracker.observe(event("Network.loadingFinished", `{"requestId":"n3"}`))
+	if len(tracker.byID) != 0 || len(tracker.byURL) != 0 {
+		t.Fatalf("finished requests not forgotten: %v %v", tracker.byID, tracker.byURL)
+	}
+
+	dir := t.TempDir()
+	capture := networkCapture{Sequence: 1, URL: "https://api.example/flags", Method: "GET", Status: "200", Initiator: initiator}
+	if err := writeNetworkCapture(dir, capture); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, formatCaptureDirName(capture), "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta struct {
+		Initiator      map[string]interface{} `json:"initiator"`
+		InitiatorStack []string               `json:"initiatorStack"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"loadFlags (https://app.example/main.js:42:10)", "-- Promise.then --", "(anonymous) (https://app.example/boot.js:1:1)"}
+	if meta.Initiator["type"] != "script" || strings.Join(meta.InitiatorStack, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected initiator metadata %+v", meta)
+	}
+}
+
+func TestLogPrinterLabelsCountersAndTimers(t *testing.T) {
+	var out strings.Builder
+	p := &logPrinter{out: &out}
+	for _, call := range []struct{ typ, text string }{
+		{"count", "clicks: 2"},
+		{"countReset", "clicks: 0"},
+		{"timeEnd", "load: 12.5 ms"},
+	} {
+		params, _ := json.Marshal(map[string]interface{}{
+			"type": call.typ,
+			"args": []map[string]interface{}{{"type": "string", "value": call.text}},
+		})
+		if _, err := p.handle(context.Background(), nil, cdp.Event{Method: "Runtime.consoleAPICalled", Params: params}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "[count] clicks: 2\n[count] clicks: 0\n[timer] load: 12.5 ms\n"
+	if out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
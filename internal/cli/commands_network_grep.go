@@ -0,0 +1,358 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// grepSnippetWidth is how much of a long matching line is shown around the match.
+const grepSnippetWidth = 160
+
+type captureGrepOptions struct {
+	find        func(line string) []int // byte range of the first match, or nil
+	headers     bool
+	requestBody bool
+	binary      bool
+	jsonPath    []interface{}
+	jsonPathRaw string
+	maxPerFile  int
+}
+
+type captureGrepMatch struct {
+	File string
+	Line int
+	Text string
+}
+
+func cmdNetworkLogGrep(args []string) error {
+	fs := newFlagSet("network-log grep", "usage: cdp network-log grep <dir> <pattern> [--regex] [--ignore-case] [--headers] [--request-body] [--json-path $.data.items] [--binary]\n\nSearches the response bodies of a network-log capture directory (pretty JSON when\navailable) and prints each matching capture with its URL and matching lines. Exits\nnon-zero when nothing matched.")
+	useRegex := fs.Bool("regex", false, "Treat pattern as a regular expression instead of a literal string")
+	ignoreCase := fs.Bool("ignore-case", false, "Match case-insensitively")
+	headers := fs.Bool("headers", false, "Also search request and response headers")
+	requestBody := fs.Bool("request-body", false, "Also search request bodies")
+	jsonPath := fs.String("json-path", "", "Only search this part of JSON response bodies (e.g. $.data.items[0])")
+	binary := fs.Bool("binary", false, "Search bodies that look binary instead of skipping them")
+	maxPerFile := fs.Int("max-per-file", 5, "Show at most N matching lines per file (0 = all)")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 2 {
+		fs.Usage()
+		return usagef("usage: cdp network-log grep <dir> <pattern>")
+	}
+	dir, pattern := pos[0], pos[1]
+	if !*useRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if *ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	opts := captureGrepOptions{
+		find:        re.FindStringIndex,
+		headers:     *headers,
+		requestBody: *requestBody,
+		binary:      *binary,
+		maxPerFile:  *maxPerFile,
+	}
+	if *jsonPath != "" {
+		if opts.jsonPath, err = parseJSONPath(*jsonPath); err != nil {
+			return err
+		}
+		opts.jsonPathRaw = *jsonPath
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	matched := 0
+	for _, name := range names {
+		captureDir := filepath.Join(dir, name)
+		meta, err := readCaptureMetadata(captureDir)
+		if err != nil {
+			continue
+		}
+		matches, err := grepCapture(captureDir, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", name, err)
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		matched++
+		fmt.Printf("%s  %s %s (%s)\n", name, meta.Method, meta.URL, meta.Status)
+		for _, m := range matches {
+			if m.Line == 0 {
+				fmt.Printf("  %s: %s\n", m.File, m.Text)
+				continue
+			}
+			fmt.Printf("  %s:%d: %s\n", m.File, m.Line, m.Text)
+		}
+	}
+	if matched == 0 {
+		return errors.New("no captures matched")
+	}
+	fmt.Fprintf(os.Stderr, "%d capture(s) matched\n", matched)
+	return nil
+}
+
+type captureMetadata struct {
+	URL    string `json:"url"`
+	Method string `json:"method"`
+	Status string `json:"status"`
+}
+
+func readCaptureMetadata(captureDir string) (captureMetadata, error) {
+	var meta captureMetadata
+	data, err := os.ReadFile(filepath.Join(captureDir, "metadata.json"))
+	if err != nil {
+		return meta, err
+	}
+	err = json.Unmarshal(data, &meta)
+	return meta, err
+}
+
+// grepCapture searches one capture directory. The response body is read from
+// the pretty-printed response-body.json when network-log wrote one.
+func grepCapture(captureDir string, opts captureGrepOptions) ([]captureGrepMatch, error) {
+	var matches []captureGrepMatch
+	var errs []string
+	search := func(file string) {
+		found, err := grepCaptureFile(filepath.Join(captureDir, file), file, opts, nil)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Sprintf("%s: %v", file, err))
+		}
+		matches = append(matches, found...)
+	}
+	switch {
+	case len(opts.jsonPath) > 0:
+		found, err := grepCaptureJSONPath(filepath.Join(captureDir, "response-body.bin"), opts)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Sprintf("response-body.bin: %v", err))
+		}
+		matches = append(matches, found...)
+	default:
+		if _, err := os.Stat(filepath.Join(captureDir, "response-body.json")); err == nil {
+			search("response-body.json")
+		} else {
+			search("response-body.bin")
+		}
+	}
+	if opts.requestBody {
+		search("request-body.bin")
+	}
+	if opts.headers {
+		search("request-headers.json")
+		search("response-headers.json")
+	}
+	if len(errs) > 0 {
+		return matches, errors.New(strings.Join(errs, "; "))
+	}
+	return matches, nil
+}
+
+// grepCaptureFile streams path (or r, when set) line by line.
+func grepCaptureFile(path, label string, opts captureGrepOptions, r io.Reader) ([]captureGrepMatch, error) {
+	if r == nil {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	br := bufio.NewReaderSize(r, 64*1024)
+	if !opts.binary {
+		sample, _ := br.Peek(8 * 1024)
+		if looksBinary(sample) {
+			return nil, nil
+		}
+	}
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	var matches []captureGrepMatch
+	hidden := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		loc := opts.find(text)
+		if loc == nil {
+			continue
+		}
+		if opts.maxPerFile > 0 && len(matches) >= opts.maxPerFile {
+			hidden++
+			continue
+		}
+		matches = append(matches, captureGrepMatch{File: label, Line: line, Text: grepSnippet(text, loc)})
+	}
+	if hidden > 0 {
+		matches = append(matches, captureGrepMatch{File: label, Text: fmt.Sprintf("(+%d more matching lines)", hidden)})
+	}
+	return matches, scanner.Err()
+}
+
+func grepCaptureJSONPath(path string, opts captureGrepOptions) ([]captureGrepMatch, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var payload interface{}
+	if err := json.NewDecoder(f).Decode(&payload); err != nil {
+		// Not a JSON body; --json-path has nothing to look at.
+		return nil, nil
+	}
+	value, ok := applyJSONPath(payload, opts.jsonPath)
+	if !ok {
+		return nil, nil
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	// Line numbers are within the pretty-printed value, so label them with the path.
+	return grepCaptureFile("", "response-body "+opts.jsonPathRaw, opts, bytes.NewReader(data))
+}
+
+// looksBinary reports whether a body sample has NUL bytes or is not UTF-8
+// (ignoring a rune cut off at the end of the sample).
+func looksBinary(sample []byte) bool {
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	for len(sample) > 0 {
+		r, size := utf8.DecodeRune(sample)
+		if r == utf8.RuneError && size == 1 {
+			return len(sample) >= utf8.UTFMax || utf8.FullRune(sample)
+		}
+		sample = sample[size:]
+	}
+	return false
+}
+
+// grepSnippet trims a matching line, cutting long lines down to the area
+// around the match.
+func grepSnippet(line string, loc []int) string {
+	if len(line) <= grepSnippetWidth {
+		return strings.TrimSpace(line)
+	}
+	pad := (grepSnippetWidth - (loc[1] - loc[0])) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	start, end := loc[0]-pad, loc[1]+pad
+	if start < 0 {
+		start = 0
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end++
+	}
+	out := line[start:end]
+	if start > 0 {
+		out = "..." + out
+	}
+	if end < len(line) {
+		out += "..."
+	}
+	return out
+}
+
+// parseJSONPath parses a simple path such as $.data.items[0].name into object
+// keys (strings) and array indexes (ints).
+func parseJSONPath(path string) ([]interface{}, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var steps []interface{}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid --json-path %q: empty key", path)
+			}
+			steps = append(steps, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid --json-path %q: missing ]", path)
+			}
+			inner := rest[1:end]
+			if idx, err := strconv.Atoi(inner); err == nil {
+				steps = append(steps, idx)
+			} else if unquoted, err := strconv.Unquote(strings.ReplaceAll(inner, "'", "\"")); err == nil {
+				steps = append(steps, unquoted)
+			} else {
+				return nil, fmt.Errorf("invalid --json-path %q: bad index [%s]", path, inner)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid --json-path %q (expected $.key or [index])", path)
+		}
+	}
+	return steps, nil
+}
+
+func applyJSONPath(value interface{}, steps []interface{}) (interface{}, bool) {
+	for _, step := range steps {
+		switch s := step.(type) {
+		case string:
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if value, ok = obj[s]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := value.([]interface{})
+			if !ok {
+				return nil, false
+			}
+			if s < 0 {
+				s += len(arr)
+			}
+			if s < 0 || s >= len(arr) {
+				return nil, false
+			}
+			value = arr[s]
+		}
+	}
+	return value, true
+}
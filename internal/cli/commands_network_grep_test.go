@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	steps, err := parseJSONPath(`$.data.items[2]["odd key"].name`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"data", "items", 2, "odd key", "name"}
+	if !reflect.DeepEqual(steps, want) {
+		t.Fatalf("steps = %#v, want %#v", steps, want)
+	}
+	for _, bad := range []string{"$..x", "$.a[", "data"} {
+		if _, err := parseJSONPath(bad); err == nil {
+			t.Errorf("parseJSONPath(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLooksBinary(t *testing.T) {
+	if looksBinary([]byte(`{"ok": "café"}`)) {
+		t.Error("UTF-8 JSON is not binary")
+	}
+	if !looksBinary([]byte{0x89, 'P', 'N', 'G', 0, 0}) {
+		t.Error("PNG header should be binary")
+	}
+	// A multi-byte rune cut off at the end of the sample is still text.
+	if looksBinary([]byte("caf\xc3")) {
+		t.Error("truncated rune at the end should not count as binary")
+	}
+}
+
+func TestGrepCapture(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "response-body.bin"), []byte(`{"data":{"items":[{"flag":"feature_flag_x"}]},"other":"feature_flag_x"}`), 0o644)
+	os.WriteFile(filepath.Join(dir, "response-body.json"), []byte("{\n  \"flag\": \"feature_flag_x\",\n  \"x\": 1\n}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "response-headers.json"), []byte(`{"x-flag": "feature_flag_x"}`), 0o644)
+	re := regexp.MustCompile("feature_flag_x")
+
+	matches, err := grepCapture(dir, captureGrepOptions{find: re.FindStringIndex})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].File != "response-body.json" || matches[0].Line != 2 {
+		t.Fatalf("body matches = %+v", matches)
+	}
+
+	matches, _ = grepCapture(dir, captureGrepOptions{find: re.FindStringIndex, headers: true})
+	if len(matches) != 2 || matches[1].File != "response-headers.json" {
+		t.Fatalf("with --headers = %+v", matches)
+	}
+
+	path, _ := parseJSONPath("$.data.items")
+	matches, _ = grepCapture(dir, captureGrepOptions{find: re.FindStringIndex, jsonPath: path, jsonPathRaw: "$.data.items"})
+	if len(matches) != 1 || !strings.Contains(matches[0].Text, `"flag": "feature_flag_x"`) {
+		t.Fatalf("with --json-path = %+v", matches)
+	}
+}
+
+func TestGrepSnippet(t *testing.T) {
+	line := strings.Repeat("a", 500) + "NEEDLE" + strings.Repeat("b", 500)
+	got := grepSnippet(line, []int{500, 506})
+	if !strings.HasPrefix(got, "...") || !strings.HasSuffix(got, "...") || !strings.Contains(got, "NEEDLE") || len(got) > grepSnippetWidth+6 {
+		t.Fatalf("snippet = %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var overridesHelp = commandHelp{
+	Description: `Records CDP calls that configure a tab (device metrics, extra headers, and so
+on) on the session. Overrides end when a DevTools session detaches, so with
+auto on they are re-applied each time a command connects.`,
+	Examples: []commandExample{
+		{`cdp overrides set --session app Emulation.setDeviceMetricsOverride '{"width":390,"height":844,"deviceScaleFactor":3,"mobile":true}'`, "Emulate a phone viewport and record it."},
+		{"cdp overrides auto --session app on", "Re-apply recorded overrides on every connection."},
+		{"cdp overrides list --session app --json", "Show what is recorded."},
+		{"cdp overrides clear --session app Network.setExtraHTTPHeaders", "Forget one override; omit the method to forget all."},
+	},
+}
+
+func cmdOverrides(args []string) error {
+	if len(args) == 0 {
+		printOverridesUsage()
+		return usagef("usage: cdp overrides <command> (list|set|clear|auto)")
+	}
+	if isHelpArg(args[0]) {
+		printOverridesUsage()
+		return nil
+	}
+	switch args[0] {
+	case "list":
+		return cmdOverridesList(args[1:])
+	case "set":
+		return cmdOverridesSet(args[1:])
+	case "clear":
+		return cmdOverridesClear(args[1:])
+	case "auto":
+		return cmdOverridesAuto(args[1:])
+	default:
+		return usagef("unknown overrides command %q (expected list, set, clear, or auto)", args[0])
+	}
+}
+
+func printOverridesUsage() {
+	fmt.Println("usage: cdp overrides <command> (list|set|clear|auto)")
+	fmt.Println("Chrome drops emulation/header overrides when a DevTools session detaches, i.e. after")
+	fmt.Println("every cdp command. Recorded overrides are re-applied when a session is opened with")
+	fmt.Println("auto-restore on, or when the command is run as 'cdp --restore-overrides <command>'.")
+	fmt.Println("Commands:")
+	fmt.Println("  list   Show a session's recorded overrides")
+	fmt.Println("  set    Apply a CDP override command and record it")
+	fmt.Println("  clear  Forget recorded overrides")
+	fmt.Println("  auto   Turn auto-restore on or off for a session")
+	fmt.Println("Run 'cdp overrides <command> --help' for details.")
+}
+
+func cmdOverridesList(args []string) error {
+	fs := newFlagSet("overrides list", "usage: cdp overrides list --session <name> [--json]")
+	sessionFlag := addSessionFlag(fs)
+	jsonOut := fs.Bool("json", false, "Output JSON")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	session, ok := st.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown session %q", name)
+	}
+
+	if *jsonOut {
+		out, err := jsonStyle.JSON(map[string]interface{}{
+			"autoRestore": session.AutoRestore,
+			"overrides":   session.Overrides,
+		}, *pretty, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+	auto := "off"
+	if session.AutoRestore {
+		auto = "on"
+	}
+	fmt.Printf("auto-restore: %s\n", auto)
+	if len(session.Overrides) == 0 {
+		fmt.Println("No recorded overrides")
+		return nil
+	}
+	for _, o := range session.Overrides {
+		params, err := jsonStyle.JSON(o.Params, false, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%-40s %s  %s\n", o.Method, o.AppliedAt.Format(time.RFC3339), abbreviate(params, 80))
+	}
+	return nil
+}
+
+func cmdOverridesSet(args []string) error {
+	fs := newFlagSet("overrides set", "usage: cdp overrides set --session <name> <Domain.method> ['{\"json\": \"params\"}']\n\nExamples:\n  cdp overrides set Emulation.setDeviceMetricsOverride '{\"width\":390,\"height\":844,\"deviceScaleFactor\":3,\"mobile\":true}'\n  cdp overrides set Network.setExtraHTTPHeaders '{\"headers\":{\"X-Debug\":\"1\"}}'")
+	sessionFlag := addSessionFlag(fs)
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return usagef("usage: cdp overrides set --session <name> <Domain.method> [json-params]")
+	}
+	if len(pos) > 2 {
+		return usagef("unexpected argument: %s", pos[2])
+	}
+	method := pos[0]
+	if !strings.Contains(method, ".") {
+		return fmt.Errorf("invalid CDP method %q (expected Domain.method)", method)
+	}
+	var params map[string]interface{}
+	if len(pos) == 2 {
+		if err := json.Unmarshal([]byte(pos[1]), &params); err != nil {
+			return fmt.Errorf("invalid params JSON: %w", err)
+		}
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	if err := applyOverride(ctx, handle.client, method, params); err != nil {
+		return err
+	}
+	fmt.Printf("Applied and recorded %s\n", method)
+	if !handle.session.AutoRestore {
+		fmt.Println("It lasts until this command exits; run 'cdp overrides auto on' (or use 'cdp --restore-overrides ...') to re-apply it.")
+	}
+	return nil
+}
+
+func cmdOverridesClear(args []string) error {
+	fs := newFlagSet("overrides clear", "usage: cdp overrides clear --session <name> [Domain.method]\n\nForgets recorded overrides (all of them without a method). Overrides already\napplied end when their DevTools session detaches.")
+	sessionFlag := addSessionFlag(fs)
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) > 1 {
+		return usagef("unexpected argument: %s", pos[1])
+	}
+	method := ""
+	if len(pos) == 1 {
+		method = pos[0]
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	session, ok := st.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown session %q", name)
+	}
+	removed := forgetOverrides(&session, method)
+	if method != "" && removed == 0 {
+		return fmt.Errorf("no recorded override %s", method)
+	}
+	if err := st.Set(session); err != nil {
+		return err
+	}
+	fmt.Printf("Forgot %d override(s)\n", removed)
+	return nil
+}
+
+func cmdOverridesAuto(args []string) error {
+	fs := newFlagSet("overrides auto", "usage: cdp overrides auto --session <name> on|off")
+	sessionFlag := addSessionFlag(fs)
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 || (pos[0] != "on" && pos[0] != "off") {
+		return usagef("usage: cdp overrides auto --session <name> on|off")
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	session, ok := st.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown session %q", name)
+	}
+	session.AutoRestore = pos[0] == "on"
+	if err := st.Set(session); err != nil {
+		return err
+	}
+	fmt.Printf("Auto-restore %s for session %s (%d recorded override(s))\n", pos[0], name, len(session.Overrides))
+	return nil
+}
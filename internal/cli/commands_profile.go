@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+)
+
+var profileHelp = commandHelp{
+	Description: `Profiles name a DevTools endpoint (host, port, TLS and an auth header) so that
+remote browsers can be selected with the global --profile flag instead of
+repeating the connection details. They are stored as [profiles.NAME] sections
+of config.toml, which can also be edited by hand.`,
+	Examples: []commandExample{
+		{`cdp profile add staging --host browsers.internal --port 443 --secure --auth "Bearer TOKEN"`, "Save a remote endpoint."},
+		{"cdp profile list", "Show the saved profiles."},
+		{"cdp profile remove staging", "Delete a profile."},
+	},
+}
+
+func cmdProfile(args []string) error {
+	if len(args) == 0 {
+		printProfileUsage()
+		return usagef("usage: cdp profile <command> (list|add|remove)")
+	}
+	if isHelpArg(args[0]) {
+		printProfileUsage()
+		return nil
+	}
+	switch args[0] {
+	case "list":
+		return cmdProfileList(args[1:])
+	case "add":
+		return cmdProfileAdd(args[1:])
+	case "remove":
+		return cmdProfileRemove(args[1:])
+	default:
+		return usagef("unknown profile command %q (expected list, add, or remove)", args[0])
+	}
+}
+
+func printProfileUsage() {
+	fmt.Println("usage: cdp profile <command> (list|add|remove)")
+	fmt.Println("Commands:")
+	fmt.Println("  list    Show saved connection profiles")
+	fmt.Println("  add     Save (or replace) a profile")
+	fmt.Println("  remove  Delete a profile")
+	fmt.Println("Use a profile with 'cdp --profile <name> <command> ...' (or CDP_PROFILE=<name>).")
+}
+
+func cmdProfileList(args []string) error {
+	fs := newFlagSet("profile list", "usage: cdp profile list")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	profiles, err := configProfiles(activeConfig)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		fmt.Println("No saved profiles")
+		return nil
+	}
+	fmt.Printf("%-12s %-24s %-6s %-6s %s\n", "NAME", "HOST", "PORT", "SCHEME", "AUTH")
+	for _, name := range sortedProfileNames(profiles) {
+		p := profiles[name]
+		auth := "-"
+		if p.Authorization != "" {
+			auth = "yes"
+		}
+		fmt.Printf("%-12s %-24s %-6d %-6s %s\n", name, p.Host, p.Port, p.scheme(), auth)
+	}
+	return nil
+}
+
+func cmdProfileAdd(args []string) error {
+	fs := newFlagSet("profile add", "usage: cdp profile add <name> --host <host> --port <port> [--secure] [--auth \"Bearer TOKEN\"]")
+	host := fs.String("host", "127.0.0.1", "DevTools host")
+	port := fs.Int("port", 9222, "DevTools port")
+	secure := fs.Bool("secure", false, "Use https/wss instead of http/ws")
+	auth := fs.String("auth", "", "Authorization header value sent with every request")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usagef("usage: cdp profile add <name> --host <host> --port <port>")
+	}
+	if *port <= 0 {
+		return errors.New("--port must be positive")
+	}
+	profile := connectionProfile{Name: pos[0], Host: *host, Port: *port, Secure: *secure, Authorization: *auth}
+	if err := writeProfile(profile.Name, profileSection(profile)); err != nil {
+		return err
+	}
+	fmt.Printf("Saved profile %s -> %s://%s:%d\n", profile.Name, profile.scheme(), *host, *port)
+	return nil
+}
+
+func cmdProfileRemove(args []string) error {
+	fs := newFlagSet("profile remove", "usage: cdp profile remove <name>")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usagef("usage: cdp profile remove <name>")
+	}
+	profiles, err := configProfiles(activeConfig)
+	if err != nil {
+		return err
+	}
+	if _, ok := profiles[pos[0]]; !ok {
+		return fmt.Errorf("unknown profile %q", pos[0])
+	}
+	if err := writeProfile(pos[0], ""); err != nil {
+		return err
+	}
+	fmt.Printf("Removed profile %s\n", pos[0])
+	return nil
+}
+
+// connectedProfile is the profile name recorded on sessions created by connect.
+func connectedProfile() string {
+	if activeProfile == nil {
+		return ""
+	}
+	return activeProfile.Name
+}
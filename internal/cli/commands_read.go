@@ -1,26 +1,48 @@
 package cli
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/veilm/cdp-cli/internal/format"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var readHelp = commandHelp{
+	Description: `Renders the page, or the elements matching each selector, as indented text with
+element labels, which is usually far smaller than the HTML. --has-text and
+--att-value narrow the matches. When nothing matches, a suggestion goes to stderr
+and the command exits 6 unless --allow-empty.`,
+	Examples: []commandExample{
+		{"cdp read --session app", "Print the whole page as readable text."},
+		{`cdp read --session app "main article" --has-text Invoice`, "Read only articles that mention Invoice."},
+		{`cdp read --session app --after-route "/orders/\\d+" --show-route`, "Wait for the SPA route, then print it and the content."},
+		{"cdp read --session app .results --allow-empty --json", "Print JSON and exit 0 even if .results is missing."},
+		{"cdp read --session app --stats --with-network", "Add page weight and in-flight request details."},
+	},
+}
+
 func cmdRead(args []string) error {
 	fs := newFlagSet("read", "usage: cdp read --session <name> [options] [selector...]")
 	sessionFlag := addSessionFlag(fs)
 	jsonOut := fs.Bool("json", false, "Output JSON instead of text")
 	waitMs := fs.Int("wait-ms", 0, "Extra wait before parsing (ms)")
 	waitReady := fs.Bool("wait", false, "Wait for document.readyState == 'complete' before reading")
+	noReadyCheck := addReadyCheckFlag(fs)
 	hasText := fs.String("has-text", "", "Only include elements whose subtree text matches this text/regex")
 	attValue := fs.String("att-value", "", "Only include elements whose attribute values match this text/regex")
 	classLimit := fs.Int("class-limit", 3, "Max number of classes to include in element labels")
-	timeout := fs.Duration("timeout", 10*time.Second, "Command timeout")
+	afterRoute := fs.String("after-route", "", "Wait until location.href matches this regex before reading (SPA navigation)")
+	showRoute := fs.Bool("show-route", false, "Print the current route (path, query, hash) before the content")
+	statsFlag := fs.Bool("stats", false, "Append a one-line page weight summary (requests, transfer size, DOM nodes, load time)")
+	withNetwork := fs.Bool("with-network", false, "Prepend in-flight request count and last response age (samples Network for ~250ms)")
+	allowEmpty := fs.Bool("allow-empty", false, "Exit 0 when the selector matches nothing (the suggestion still goes to stderr)")
+	idleUI := addIdleUIFlags(fs)
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
 
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
@@ -38,22 +60,26 @@ func cmdRead(args []string) error {
 	}
 
 	selector := strings.TrimSpace(strings.Join(pos, " "))
-	if selector != "" {
-		selector = normalizeSelector(selector)
-	}
 	if *classLimit < 0 {
 		return errors.New("--class-limit must be >= 0")
 	}
 	if *waitMs < 0 {
 		return errors.New("--wait-ms must be >= 0")
 	}
+	var routeRe *regexp.Regexp
+	if *afterRoute != "" {
+		routeRe, err = compileRouteRegex(*afterRoute, "--after-route")
+		if err != nil {
+			return err
+		}
+	}
 
 	st, err := store.Load()
 	if err != nil {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, sessionName)
@@ -66,76 +92,126 @@ func cmdRead(args []string) error {
 		if err := waitForReadyState(ctx, handle.client, 200*time.Millisecond); err != nil {
 			return err
 		}
+	} else if !*noReadyCheck {
+		warnIfLoading(ctx, handle.client, "Pass --wait to read once it has loaded")
 	}
 
-	if err := ensureWebNavInjected(ctx, handle.client); err != nil {
+	if routeRe != nil {
+		if _, err := waitForRoute(ctx, handle.client, routeRe); err != nil {
+			return err
+		}
+	}
+	if err := idleUI.waitBefore(ctx, handle.client, fs, sessionName); err != nil {
 		return err
 	}
 
-	opts := map[string]interface{}{
-		"waitMs": *waitMs,
-		"rootSelector": func() interface{} {
-			if selector == "" {
-				return nil
-			}
-			return selector
-		}(),
-		"hasText":    *hasText,
-		"attValue":   *attValue,
-		"classLimit": *classLimit,
-	}
-	optsJSON, _ := json.Marshal(opts)
-
-	expression := fmt.Sprintf("window.WebNavRead(%s)", string(optsJSON))
-	// Use the "by reference" eval path (returnByValue=false) since read results can be
-	// large and some Chromium builds are flaky about returning them by value.
-	raw, err := handle.client.EvaluateRaw(ctx, expression, false)
-	if err != nil {
-		return err
+	var network *networkSample
+	if *withNetwork {
+		sample := sampleNetwork(ctx, handle.client)
+		network = &sample
+	}
+
+	classes := *classLimit
+	if classes == 0 {
+		classes = -1 // ReadOptions treats 0 as the default
 	}
-	value, err := handle.client.RemoteObjectValue(ctx, raw.Result)
+	page, err := ReadPage(ctx, handle.client, ReadOptions{
+		Selector:   selector,
+		HasText:    *hasText,
+		AttValue:   *attValue,
+		ClassLimit: classes,
+		WaitMS:     *waitMs,
+	})
 	if err != nil {
 		return err
 	}
-	m, ok := value.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("unexpected WebNavRead result type %T", value)
+	url, title, lines := page.URL, page.Title, page.Lines
+	var missErr error
+	if !page.Matched {
+		missErr = &noMatchError{selector: selector, diagnostics: page.Diagnostics}
+		if *allowEmpty {
+			fmt.Fprintln(os.Stderr, missErr)
+			missErr = nil
+		}
 	}
-	url, _ := m["url"].(string)
-	title, _ := m["title"].(string)
 
-	linesAny, _ := m["lines"].([]interface{})
-	lines := make([]string, 0, len(linesAny))
-	for _, v := range linesAny {
-		if s, ok := v.(string); ok {
-			lines = append(lines, s)
-		} else if v != nil {
-			lines = append(lines, fmt.Sprint(v))
+	route := ""
+	if *showRoute {
+		value, err := handle.client.Evaluate(ctx, "location.pathname + location.search + location.hash")
+		if err != nil {
+			return err
 		}
+		route, _ = value.(string)
+	}
+
+	var stats *pageStats
+	if *statsFlag {
+		collected, err := collectPageStats(ctx, handle.client)
+		if err != nil {
+			return fmt.Errorf("collect stats: %w", err)
+		}
+		stats = &collected
 	}
 
 	payload := struct {
-		URL   string   `json:"url"`
-		Title string   `json:"title"`
-		Lines []string `json:"lines"`
-	}{URL: url, Title: title, Lines: lines}
+		URL        string         `json:"url"`
+		Title      string         `json:"title"`
+		Route      string         `json:"route,omitempty"`
+		Matched    bool           `json:"matched"`
+		MatchCount int            `json:"matchCount"`
+		Lines      []string       `json:"lines"`
+		Stats      *pageStats     `json:"stats,omitempty"`
+		Network    *networkSample `json:"network,omitempty"`
+	}{URL: url, Title: title, Route: route, Matched: page.Matched, MatchCount: page.MatchCount, Lines: lines, Stats: stats, Network: network}
 
 	if *jsonOut {
-		pretty, _ := json.MarshalIndent(payload, "", "  ")
-		fmt.Println(string(pretty))
-		return nil
+		if err := jsonStyle.WriteJSON(os.Stdout, payload, true, format.DepthLimit(-1)); err != nil {
+			return err
+		}
+		return missErr
 	}
 
-	if len(lines) == 0 && title != "" {
+	if network != nil {
+		fmt.Println(network.String())
+	}
+	if *showRoute {
+		fmt.Printf("route: %s\n", route)
+	}
+	switch {
+	case !page.Matched:
+		// Keep stdout empty; the miss is reported on stderr.
+	case len(lines) == 0 && title != "":
 		fmt.Println(strings.TrimSpace(title))
-		return nil
+	default:
+		out := strings.Join(lines, "\n")
+		fmt.Print(out)
+		if !strings.HasSuffix(out, "\n") {
+			fmt.Print("\n")
+		}
+	}
+	if stats != nil {
+		fmt.Println(stats.String())
+	}
+	return missErr
+}
+
+// noMatchError is a read whose selector or filters matched nothing. Its
+// message carries WebNav's "did you mean" suggestion, which ReportError
+// prints to stderr.
+type noMatchError struct {
+	selector    string
+	diagnostics []string
+}
+
+func (e *noMatchError) Error() string {
+	msg := "no matches"
+	if e.selector != "" {
+		msg = "no matches in the DOM for " + e.selector
 	}
-	out := strings.Join(lines, "\n")
-	fmt.Print(out)
-	if !strings.HasSuffix(out, "\n") {
-		fmt.Print("\n")
+	if len(e.diagnostics) > 1 {
+		msg += "\n" + strings.Join(e.diagnostics[1:], "\n")
 	}
-	return nil
+	return msg
 }
 
 func normalizeSelector(selector string) string {
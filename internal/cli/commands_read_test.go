@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadNoMatchExitsNonZero(t *testing.T) {
+	holdFakeSession(t, "reader", fakeEvalTab(t, func(expression string) interface{} {
+		switch {
+		case strings.Contains(expression, "WebNavInjectedVersion"):
+			return true
+		case strings.HasPrefix(expression, "window.WebNavRead("):
+			return map[string]interface{}{
+				"url": "https://example.com/", "title": "Example", "lines": []string{},
+				"matched": false, "matchCount": 0,
+				"diagnostics": []string{"no matches in the DOM for .missing", `did you mean ".missing-item", which has 1 match:`, "\tItem"},
+			}
+		default:
+			return "complete"
+		}
+	}))
+
+	err := cmdRead([]string{"--session", "reader", ".missing"})
+	if errorCode(err) != exitNoMatch {
+		t.Fatalf("expected exit code %d, got %v", exitNoMatch, err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "no matches in the DOM for .missing\n") || !strings.Contains(msg, "did you mean") {
+		t.Fatalf("unexpected no-match message %q", msg)
+	}
+	if err := cmdRead([]string{"--session", "reader", "--allow-empty", ".missing"}); err != nil {
+		t.Fatalf("--allow-empty should exit 0, got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+type rectFollowOptions struct {
+	interval    time.Duration
+	duration    time.Duration
+	epsilon     float64
+	jsonl       bool
+	evalTimeout time.Duration
+}
+
+// rectDims are the dimensions rect --follow tracks, in output order.
+var rectDims = []string{"x", "y", "width", "height"}
+
+// rectSample is one reading of the followed element. Identity is "marked"
+// when it is the element tagged on an earlier sample, "new" when a fresh
+// element matched the selector (and has just been tagged).
+type rectSample struct {
+	Identity string
+	Dims     map[string]float64
+}
+
+// rectDeltas returns the per-dimension change from prev to cur, and whether
+// any of them exceeds epsilon.
+func rectDeltas(prev, cur map[string]float64, epsilon float64) (map[string]float64, bool) {
+	deltas := make(map[string]float64, len(rectDims))
+	changed := false
+	for _, dim := range rectDims {
+		d := cur[dim] - prev[dim]
+		deltas[dim] = d
+		if math.Abs(d) > epsilon {
+			changed = true
+		}
+	}
+	return deltas, changed
+}
+
+// rectRange tracks the min and max of each dimension over the samples.
+type rectRange struct {
+	min, max map[string]float64
+}
+
+func (r *rectRange) add(dims map[string]float64) {
+	if r.min == nil {
+		r.min, r.max = map[string]float64{}, map[string]float64{}
+		for _, dim := range rectDims {
+			r.min[dim], r.max[dim] = dims[dim], dims[dim]
+		}
+		return
+	}
+	for _, dim := range rectDims {
+		r.min[dim] = math.Min(r.min[dim], dims[dim])
+		r.max[dim] = math.Max(r.max[dim], dims[dim])
+	}
+}
+
+func roundPx(v float64) float64 { return math.Round(v*100) / 100 }
+
+// followRect samples selector's bounding rect on every tick and reports
+// changes larger than opts.epsilon, then min/max per dimension and whether
+// the element was removed, re-added or replaced. The element is tagged with
+// a data attribute so a re-render that swaps the node is told apart from
+// one that moves it.
+func followRect(client *cdp.Client, selector string, opts rectFollowOptions) error {
+	tokenBytes := make([]byte, 4)
+	_, _ = rand.Read(tokenBytes)
+	token := hex.EncodeToString(tokenBytes)
+	expression := fmt.Sprintf(`(() => {
+        const marker = "data-cdp-follow";
+        const token = %s;
+        let el = document.querySelector("[" + marker + "=\"" + token + "\"]");
+        let identity = "marked";
+        if (!el) {
+            el = document.querySelector(%s);
+            if (!el) { return null; }
+            el.setAttribute(marker, token);
+            identity = "new";
+        }
+        const rect = el.getBoundingClientRect();
+        return {identity, x: rect.x, y: rect.y, width: rect.width, height: rect.height};
+    })()`, strconv.Quote(token), strconv.Quote(selector))
+	defer func() {
+		ctx, cancel := commandContext(opts.evalTimeout)
+		defer cancel()
+		client.Evaluate(ctx, fmt.Sprintf(`document.querySelectorAll("[data-cdp-follow=\"%s\"]").forEach(el => el.removeAttribute("data-cdp-follow"))`, token))
+	}()
+
+	sample := func() (*rectSample, error) {
+		ctx, cancel := commandContext(opts.evalTimeout)
+		defer cancel()
+		value, err := client.Evaluate(ctx, expression)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		raw, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected rect sample type %T", value)
+		}
+		s := &rectSample{Dims: map[string]float64{}}
+		s.Identity, _ = raw["identity"].(string)
+		for _, dim := range rectDims {
+			v, _ := raw[dim].(float64)
+			s.Dims[dim] = roundPx(v)
+		}
+		return s, nil
+	}
+
+	start := time.Now()
+	elapsed := func() float64 { return math.Round(time.Since(start).Seconds()*1000) / 1000 }
+	emit := func(record map[string]interface{}, text string) error {
+		if !opts.jsonl {
+			fmt.Printf("[+%.3fs] %s\n", elapsed(), text)
+			return nil
+		}
+		record["t"] = elapsed()
+		out, err := jsonStyle.JSON(record, false, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+		return nil
+	}
+
+	var last map[string]float64
+	var ranges rectRange
+	present, seen := false, false
+	samples, changes := 0, 0
+	events := map[string]int{}
+	event := func(name, text string) error {
+		events[name]++
+		return emit(map[string]interface{}{"event": name}, text)
+	}
+	tick := func() error {
+		cur, err := sample()
+		if err != nil {
+			return err
+		}
+		samples++
+		if cur == nil {
+			if present {
+				present = false
+				return event("removed", "element "+selector+" removed")
+			}
+			if samples == 1 {
+				return emit(map[string]interface{}{"event": "missing"}, "element "+selector+" not found (still watching)")
+			}
+			return nil
+		}
+		if seen {
+			switch {
+			case cur.Identity == "new":
+				if err := event("replaced", "element "+selector+" replaced by a new node"); err != nil {
+					return err
+				}
+			case !present:
+				if err := event("re-added", "element "+selector+" re-added"); err != nil {
+					return err
+				}
+			}
+		}
+		present = true
+		ranges.add(cur.Dims)
+		if last == nil {
+			seen = true
+			last = cur.Dims
+			record := map[string]interface{}{}
+			for _, dim := range rectDims {
+				record[dim] = cur.Dims[dim]
+			}
+			return emit(record, fmt.Sprintf("following %s: %s", selector, formatRectDims(cur.Dims, nil)))
+		}
+		deltas, changed := rectDeltas(last, cur.Dims, opts.epsilon)
+		if !changed {
+			return nil
+		}
+		changes++
+		last = cur.Dims
+		record := map[string]interface{}{}
+		for _, dim := range rectDims {
+			record[dim] = cur.Dims[dim]
+			record["d"+dim] = roundPx(deltas[dim])
+		}
+		return emit(record, formatRectDims(cur.Dims, deltas))
+	}
+	if err := sampleLoop(opts.interval, opts.duration, tick); err != nil {
+		return err
+	}
+
+	if opts.jsonl {
+		summary := map[string]interface{}{"samples": samples, "changes": changes, "events": events, "min": ranges.min, "max": ranges.max}
+		return emit(map[string]interface{}{"summary": summary}, "")
+	}
+	fmt.Printf("Summary: %d samples over %s, %d change(s)\n", samples, time.Since(start).Round(time.Millisecond), changes)
+	if ranges.min == nil {
+		fmt.Println("  element never found")
+		return nil
+	}
+	for _, dim := range rectDims {
+		fmt.Printf("  %-6s min %g, max %g\n", dim+":", ranges.min[dim], ranges.max[dim])
+	}
+	if len(events) == 0 {
+		fmt.Println("  element stayed attached")
+	} else {
+		fmt.Printf("  element removed %d time(s), re-added %d, replaced %d\n", events["removed"], events["re-added"], events["replaced"])
+	}
+	return nil
+}
+
+// formatRectDims renders dims as "x=10 y=20 width=100 height=40", with the
+// delta after each changed dimension when deltas is given.
+func formatRectDims(dims, deltas map[string]float64) string {
+	parts := make([]string, 0, len(rectDims))
+	for _, dim := range rectDims {
+		part := fmt.Sprintf("%s=%g", dim, dims[dim])
+		if d := roundPx(deltas[dim]); deltas != nil && d != 0 {
+			part += fmt.Sprintf(" (%+g)", d)
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,27 @@
+package cli
+
+import "testing"
+
+func TestRectDeltasAndRange(t *testing.T) {
+	prev := map[string]float64{"x": 10, "y": 100, "width": 200, "height": 40}
+	jitter := map[string]float64{"x": 10.3, "y": 100, "width": 200, "height": 40}
+	if _, changed := rectDeltas(prev, jitter, 0.5); changed {
+		t.Fatal("sub-epsilon jitter reported as a change")
+	}
+	moved := map[string]float64{"x": 10, "y": 60, "width": 200, "height": 42}
+	deltas, changed := rectDeltas(prev, moved, 0.5)
+	if !changed || deltas["y"] != -40 || deltas["height"] != 2 {
+		t.Fatalf("deltas = %v, changed = %v", deltas, changed)
+	}
+	if got := formatRectDims(moved, deltas); got != "x=10 y=60 (-40) width=200 height=42 (+2)" {
+		t.Fatalf("formatRectDims = %q", got)
+	}
+
+	var r rectRange
+	for _, dims := range []map[string]float64{prev, moved, jitter} {
+		r.add(dims)
+	}
+	if r.min["y"] != 60 || r.max["y"] != 100 || r.min["x"] != 10 || r.max["x"] != 10.3 || r.max["height"] != 42 {
+		t.Fatalf("range min %v max %v", r.min, r.max)
+	}
+}
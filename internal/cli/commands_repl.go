@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+const replLineHelp = `Each line is either a cdp command (session flag optional) or a JS expression:
+  read --json
+  click "button.submit"
+  document.title
+Use eval '...' for JS that starts with a command name. exit, quit or EOF ends the session.`
+
+var replHelp = commandHelp{
+	Description: `Reads lines from stdin and runs them over a single connection. Lines starting
+with a command name run that command, with shell-style quoting; any other line is
+evaluated as JavaScript. Holding one connection keeps state such as overrides and
+WebNav registrations alive between steps.`,
+	Examples: []commandExample{
+		{"cdp repl --session app", "Start an interactive prompt against 'app'."},
+		{"cdp repl --session app --timeout 30s", "Allow a slow initial connection."},
+		{"cdp repl", "Use the session from CDP_SESSION_NAME, e.g. with a script piped on stdin."},
+	},
+}
+
+func cmdRepl(args []string) error {
+	fs := newFlagSet("repl", "usage: cdp repl --session <name>")
+	sessionFlag := addSessionFlag(fs)
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Timeout for the initial connection")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(*timeout)
+	heldConnection.name = name
+	handle, err := openSession(ctx, st, name)
+	cancel()
+	if err != nil {
+		heldConnection.name = ""
+		return err
+	}
+	defer func() {
+		heldConnection.name = ""
+		if heldConnection.client != nil {
+			heldConnection.client.Close()
+			heldConnection.client = nil
+		}
+	}()
+	handle.Close()
+
+	return runRepl(os.Stdin, stdinIsTerminal(), name)
+}
+
+// runRepl runs each line of in against session. At a prompt an error is
+// printed and the next line read; from a script the first error ends the
+// repl and is returned, so `cdp repl < steps.txt` fails like the step did.
+func runRepl(in io.Reader, interactive bool, session string) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for {
+		if interactive {
+			fmt.Fprint(os.Stderr, "cdp> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		switch line {
+		case "":
+			continue
+		case "exit", "quit":
+			return nil
+		case "help", "?":
+			fmt.Println(replLineHelp)
+			continue
+		}
+		err := runReplLine(session, line)
+		if err == nil || errors.Is(err, flag.ErrHelp) {
+			continue
+		}
+		if !interactive {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	if interactive {
+		fmt.Fprintln(os.Stderr)
+	}
+	return scanner.Err()
+}
+
+// runReplLine dispatches one line: a registered command runs with the rest of
+// the line split shell-style, anything else is evaluated as JS. Both default
+// to session.
+func runReplLine(session, line string) error {
+	first := strings.Fields(line)[0]
+	if c, ok := lookupCommand(first); ok {
+		if c.Name == "repl" {
+			return errors.New("already in a repl")
+		}
+		words, err := splitReplLine(line)
+		if err != nil {
+			return err
+		}
+		return runRegistered(c, withReplSession(c, session, words[1:]))
+	}
+	return cmdEval([]string{"--session", session, "--", line})
+}
+
+// withReplSession adds --session to args when they don't name a session and
+// the command (or its subcommand) has a --session flag.
+func withReplSession(c cliCommand, session string, args []string) []string {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if name, _ := splitFlagName(arg); strings.HasPrefix(arg, "-") && name == "session" {
+			return args
+		}
+	}
+	n := 0
+	if len(args) > 0 && hasSubcommand(c, args[0]) {
+		n = 1
+	}
+	fs := captureFlagSet(c.run, append(append([]string{}, args[:n]...), "--help"))
+	if fs == nil || fs.Lookup("session") == nil {
+		return args
+	}
+	out := append([]string{}, args[:n]...)
+	out = append(out, "--session", session)
+	return append(out, args[n:]...)
+}
+
+// splitReplLine splits a line into words the way a POSIX shell would for
+// plain quoting: single quotes are literal, double quotes allow \" and \\,
+// and a backslash outside quotes escapes the next character.
+func splitReplLine(line string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped, dquoteEscaped := false, false
+	for _, r := range line {
+		switch {
+		case dquoteEscaped:
+			if r != '"' && r != '\\' {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			dquoteEscaped = false
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				dquoteEscaped = true
+			default:
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitReplLine(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{`click "button.submit"`, []string{"click", "button.submit"}},
+		{`eval 'document.querySelector("a").href'`, []string{"eval", `document.querySelector("a").href`}},
+		{`type  --selector  #q   "hello \"you\""`, []string{"type", "--selector", "#q", `hello "you"`}},
+		{`eval "a\nb"`, []string{"eval", `a\nb`}},
+		{`wait a\ b ''`, []string{"wait", "a b", ""}},
+	}
+	for _, c := range cases {
+		got, err := splitReplLine(c.in)
+		if err != nil {
+			t.Fatalf("splitReplLine(%q): %v", c.in, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("splitReplLine(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+	for _, bad := range []string{`eval "x`, `eval 'x`, `eval x\`} {
+		if _, err := splitReplLine(bad); err == nil {
+			t.Errorf("splitReplLine(%q) should fail", bad)
+		}
+	}
+}
+
+func TestReplLinesDefaultToTheReplSession(t *testing.T) {
+	var evaluated []string
+	holdFakeSession(t, "app", fakeEvalTab(t, func(expression string) interface{} {
+		if expression != "document.readyState" {
+			evaluated = append(evaluated, expression)
+		}
+		return 42
+	}))
+	t.Setenv("CDP_SESSION_NAME", "")
+
+	if err := runRepl(strings.NewReader("document.title\neval '6 * 7'\n"), false, "app"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(evaluated, "|") != "document.title|6 * 7" {
+		t.Fatalf("evaluated %q, want both lines", evaluated)
+	}
+}
+
+func TestScriptedReplFailsOnTheFirstError(t *testing.T) {
+	var evaluated []string
+	holdFakeSession(t, "app", fakeEvalTab(t, func(expression string) interface{} {
+		evaluated = append(evaluated, expression)
+		return 1
+	}))
+
+	err := runRepl(strings.NewReader("eval --bogus\ndocument.title\n"), false, "app")
+	if err == nil || errorCode(err) != exitUsage {
+		t.Fatalf("err = %v, want a usage error", err)
+	}
+	if len(evaluated) != 0 {
+		t.Fatalf("kept going after the error: evaluated %q", evaluated)
+	}
+}
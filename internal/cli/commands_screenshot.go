@@ -5,27 +5,70 @@ import (
 	"context"
 	"encoding/base64"
 	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/png"
 	"math"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var screenshotHelp = commandHelp{
+	Description: `Captures the viewport, the full page or one element as PNG. --hover holds the
+real cursor over a trigger during the capture. --baseline compares against a
+reference image for visual checks, and --every records numbered frames until
+stopped.`,
+	Examples: []commandExample{
+		{"cdp screenshot --session app --output page.png", "Capture the viewport."},
+		{`cdp screenshot --session app --selector ".chart" --scale 2 --output chart@2x.png`, "Capture one element at retina scale."},
+		{`cdp screenshot --session app --hover ".info" --hover-until ".tooltip" --selector ".tooltip"`, "Capture a tooltip."},
+		{`cdp screenshot --session app --selector ".card" --output-dir shots/ --name "{session}-{selector}-{ts}"`, "Save shots/app-card-20260102-150405.png; later runs add new files instead of overwriting."},
+		{`cdp screenshot --all-tabs --output-dir shots/ --filter "grafana|kibana"`, "Capture every matching tab on the default port, four at a time, named from each tab's title."},
+		{`cdp screenshot --session app --selector ".sticky-footer" --scroll-into-view=false`, "Capture the footer where it sits now, cropped to the part on screen."},
+		{"cdp screenshot --session app --baseline golden/home.png --fail-threshold 0.5%", "Fail when more than 0.5% of the pixels changed."},
+		{"cdp screenshot --session app --every 1s --frames 10 --output-dir shots/ --on-change", "Record up to ten frames, skipping unchanged ones."},
+	},
+}
+
 func cmdScreenshot(args []string) error {
-	fs := newFlagSet("screenshot", "usage: cdp screenshot --session <name> [--selector ...]")
+	fs := newFlagSet("screenshot", "usage: cdp screenshot --session <name> [--selector ...] [--output file.png | --output-dir shots/ [--name \"{session}-{seq}\"]] [--hover \".trigger\" [--hover-wait 400ms | --hover-until \".tooltip\"]]\n       cdp screenshot --session <name> --every 2s [--frames 30 | --duration 1m] [--output-dir shots/] [--prefix step- | --name \"{ts}-{seq}\"] [--on-change]\n       cdp screenshot --all-tabs [--host --port] [--output-dir shots/] [--filter REGEX] [--activate] [--parallel 4]\n\nWith --hover the real mouse cursor (CDP Input) is held over the trigger while the\ncapture runs, then moved away; combine with --selector to crop to the tooltip.\n\n--every keeps the connection open and saves numbered frames on the interval until\n--frames captures, --duration, or Ctrl+C. --timeout then applies to each capture.\nWith --on-change a frame is only written when it differs from the last written one.\n\n--all-tabs captures each tab over its own short-lived connection, without a saved\nsession. A tab that fails doesn't stop the rest; the summary lists every failed or\nskipped tab with the reason, and the exit status is non-zero if any failed.")
 	sessionFlag := addSessionFlag(fs)
 	selector := fs.String("selector", "", "CSS selector to crop")
 	output := fs.String("output", "screenshot.png", "Output file path")
+	outputDir := fs.String("output-dir", "", "Save into this directory (created if missing) under --name instead of --output; with --every, where frames go (default .)")
+	nameTemplate := fs.String("name", "", "File name template for --output-dir: {session}, {ts}, {selector}, {seq} (default {session}-{seq}.png; with --every, <prefix>{seq}.png)")
 	fullPage := fs.Bool("full-page", false, "Capture beyond the current viewport (may cause resize/reflow in headful Chrome)")
 	cdpClip := fs.Bool("cdp-clip", false, "When using --selector, crop via CDP clip (may resize/reflow); default is capture viewport then crop locally")
-	scrollIntoView := fs.Bool("scroll-into-view", true, "When using --selector (without --cdp-clip), scroll the element into view before capture")
-	timeout := fs.Duration("timeout", 15*time.Second, "Command timeout")
+	scrollIntoView := fs.Bool("scroll-into-view", true, "When using --selector (without --cdp-clip), scroll the element into view before capture; with =false the capture is cropped to the part already in the viewport")
+	hover := fs.String("hover", "", "Hover this element with the CDP mouse and hold it during the capture")
+	hoverWait := fs.Duration("hover-wait", 400*time.Millisecond, "With --hover, time to wait for the hover state to render")
+	hoverUntil := fs.String("hover-until", "", "With --hover, wait until this selector is visible instead of --hover-wait")
+	baseline := fs.String("baseline", "", "Compare the capture against this PNG (created from the capture if missing)")
+	failThreshold := fs.String("fail-threshold", "0%", "With --baseline, fail when more than this share of pixels changed (e.g. 0.5%)")
+	diffOutput := fs.String("diff-output", "", "With --baseline, where to write the diff image (default <baseline>.diff.png)")
+	diffTolerance := fs.Int("diff-tolerance", 0, "With --baseline, ignore per-channel differences up to this value (0-255)")
+	scale := fs.Float64("scale", 0, "Capture at this device pixel ratio (e.g. 2 for retina) via Emulation.setDeviceMetricsOverride, restored afterwards; 0 keeps the display's")
+	every := fs.Duration("every", 0, "Capture a numbered frame on this interval until --frames, --duration, or Ctrl+C")
+	frames := fs.Int("frames", 0, "With --every, stop after N captures (0 = no limit)")
+	duration := fs.Duration("duration", 0, "With --every, stop after this long (0 = no limit)")
+	prefix := fs.String("prefix", "frame-", "With --every, frame file name prefix (frames are <prefix>0001.png, ...)")
+	onChange := fs.Bool("on-change", false, "With --every, only write frames that differ from the last written frame")
+	changeThresholdFlag := fs.String("change-threshold", "0.1%", "With --on-change, the share of the frame that must change for it to be written")
+	allTabs := fs.Bool("all-tabs", false, "Capture the viewport of every tab on --host/--port into --output-dir instead of a session's tab")
+	host := fs.String("host", hostDefault("127.0.0.1"), "With --all-tabs, DevTools host")
+	port := fs.Int("port", portDefault(9222), "With --all-tabs, DevTools port")
+	filter := fs.String("filter", "", "With --all-tabs, only capture tabs whose URL or title matches this regex")
+	activate := fs.Bool("activate", false, "With --all-tabs, bring each tab to the front before capturing it (background tabs may not paint); implies --parallel 1")
+	parallel := fs.Int("parallel", 4, "With --all-tabs, how many tabs to capture at once")
+	timeout := addTimeoutFlag(fs, 15*time.Second, "Command timeout (per tab with --all-tabs)")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -37,6 +80,24 @@ func cmdScreenshot(args []string) error {
 	if err := unexpectedArgs(pos); err != nil {
 		return err
 	}
+	if *allTabs {
+		if *selector != "" || *hover != "" || *every != 0 || *baseline != "" || *scale != 0 || *nameTemplate != "" || *sessionFlag != "" {
+			return errors.New("--all-tabs can't be combined with --session, --selector, --hover, --every, --baseline, --scale, or --name")
+		}
+		if *parallel < 1 {
+			return errors.New("--parallel must be at least 1")
+		}
+		opts := allTabsOptions{host: *host, port: *port, dir: *outputDir, activate: *activate, parallel: *parallel, fullPage: *fullPage, timeout: *timeout}
+		if opts.dir == "" {
+			opts.dir = "."
+		}
+		if *filter != "" {
+			if opts.filter, err = regexp.Compile(*filter); err != nil {
+				return fmt.Errorf("invalid --filter: %w", err)
+			}
+		}
+		return screenshotAllTabs(opts)
+	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
 		fs.Usage()
@@ -47,12 +108,68 @@ func cmdScreenshot(args []string) error {
 			return err
 		}
 	}
+	if *hover != "" {
+		if err := rejectUnsupportedSelector(*hover, "screenshot --hover", false); err != nil {
+			return err
+		}
+	} else if *hoverUntil != "" {
+		return errors.New("--hover-until requires --hover")
+	}
+	threshold, err := parsePercent(*failThreshold)
+	if err != nil {
+		return fmt.Errorf("--fail-threshold: %w", err)
+	}
+	if *diffTolerance < 0 || *diffTolerance > 255 {
+		return errors.New("--diff-tolerance must be between 0 and 255")
+	}
+	if *scale < 0 || *scale > 8 {
+		return errors.New("--scale must be between 0 and 8")
+	}
+	changeThreshold, err := parsePercent(*changeThresholdFlag)
+	if err != nil {
+		return fmt.Errorf("--change-threshold: %w", err)
+	}
+	if *every > 0 {
+		if *baseline != "" {
+			return errors.New("--baseline can't be combined with --every")
+		}
+		if *frames < 0 || *duration < 0 {
+			return errors.New("--frames and --duration must not be negative")
+		}
+	} else if *every < 0 {
+		return errors.New("--every must be positive")
+	} else if *frames != 0 || *duration != 0 || *onChange {
+		return errors.New("--frames, --duration, and --on-change require --every")
+	}
+	var outName *outputName
+	if *nameTemplate != "" {
+		if outName, err = parseOutputName(*nameTemplate, name, *selector); err != nil {
+			return err
+		}
+		if *every > 0 && !outName.hasSeq() {
+			return errors.New("--name needs {seq} with --every, or every frame would overwrite the last")
+		}
+	}
+	outputSet, prefixSet := false, false
+	fs.Visit(func(f *flag.Flag) {
+		outputSet = outputSet || f.Name == "output"
+		prefixSet = prefixSet || f.Name == "prefix"
+	})
+	if outputSet && (*outputDir != "" || *nameTemplate != "") {
+		return errors.New("--output can't be combined with --output-dir or --name")
+	}
+	if prefixSet && *nameTemplate != "" {
+		return errors.New("--prefix can't be combined with --name")
+	}
 
 	st, err := store.Load()
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	// With --every, --timeout bounds setup and each capture, not the whole run.
+	baseCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	ctx, cancel := withCommandTimeout(baseCtx, *timeout)
 	defer cancel()
 	handle, err := openSession(ctx, st, name)
 	if err != nil {
@@ -60,6 +177,135 @@ func cmdScreenshot(args []string) error {
 	}
 	defer handle.Close()
 
+	if *hover != "" {
+		if err := mouseHover(ctx, handle.client, *hover); err != nil {
+			return err
+		}
+		// Move the cursor off the page content so the hover state is dismissed,
+		// even if the capture below fails.
+		defer func() {
+			dismissCtx, dismissCancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer dismissCancel()
+			_ = moveMouse(dismissCtx, handle.client, 0, 0)
+		}()
+		if *hoverUntil != "" {
+			if err := waitForSelectorVisible(ctx, handle.client, *hoverUntil, 50*time.Millisecond); err != nil {
+				return err
+			}
+		} else {
+			select {
+			case <-time.After(*hoverWait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	if *scale > 0 {
+		restore, err := overrideDeviceScale(ctx, handle.client, *scale)
+		if err != nil {
+			return fmt.Errorf("--scale: %w", err)
+		}
+		defer restore()
+	}
+
+	shotOpts := ScreenshotOptions{
+		Selector: *selector,
+		FullPage: *fullPage,
+		CDPClip:  *cdpClip,
+		NoScroll: !*scrollIntoView,
+		Scale:    *scale,
+		hovering: *hover != "",
+		note: func(msg string) {
+			fmt.Fprintln(os.Stderr, "note:", msg)
+		},
+	}
+	// capture resolves any --selector crop afresh, so --every follows the
+	// element as the page changes.
+	capture := func(ctx context.Context) ([]byte, error) {
+		return captureScreenshot(ctx, handle.client, shotOpts)
+	}
+
+	if *every > 0 {
+		frameDir := *outputDir
+		if frameDir == "" {
+			frameDir = "."
+		}
+		return runScreenshotWatch(baseCtx, capture, screenshotWatchOptions{
+			every:           *every,
+			frames:          *frames,
+			duration:        *duration,
+			dir:             frameDir,
+			prefix:          *prefix,
+			name:            outName,
+			onChange:        *onChange,
+			changeThreshold: changeThreshold,
+			timeout:         *timeout,
+		})
+	}
+	data, err := capture(ctx)
+	if err != nil {
+		return err
+	}
+	path := *output
+	if *outputDir != "" || outName != nil {
+		if outName == nil {
+			outName, _ = parseOutputName("{session}-{seq}.png", name, *selector)
+		}
+		dir := *outputDir
+		if dir == "" {
+			dir = "."
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if path, err = outName.nextPath(dir, time.Now()); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s (%d bytes)\n", path, len(data))
+	if *baseline == "" {
+		return nil
+	}
+	return compareScreenshotBaseline(data, *baseline, *diffOutput, threshold, uint8(*diffTolerance))
+}
+
+// ScreenshotOptions control CaptureScreenshot.
+type ScreenshotOptions struct {
+	Selector string  // crop to this element
+	FullPage bool    // capture beyond the viewport (may resize/reflow headful Chrome)
+	CDPClip  bool    // with Selector, crop via a CDP clip instead of locally
+	NoScroll bool    // with Selector, don't scroll the element into view first
+	Scale    float64 // device pixel ratio to capture at; 0 keeps the display's
+	// hovering is set while cmdScreenshot holds the mouse over a --hover
+	// trigger, which scrolling would slide out from under the cursor.
+	hovering bool
+	// note, when set, is told about captures that were cropped to the
+	// visible part of the element.
+	note func(msg string)
+}
+
+// CaptureScreenshot returns a PNG of the viewport, the full page, or
+// opts.Selector. A Scale override is undone before it returns.
+func CaptureScreenshot(ctx context.Context, client *cdp.Client, opts ScreenshotOptions) ([]byte, error) {
+	if opts.Scale < 0 || opts.Scale > 8 {
+		return nil, errors.New("scale must be between 0 and 8")
+	}
+	if opts.Scale > 0 {
+		restore, err := overrideDeviceScale(ctx, client, opts.Scale)
+		if err != nil {
+			return nil, fmt.Errorf("scale: %w", err)
+		}
+		defer restore()
+	}
+	return captureScreenshot(ctx, client, opts)
+}
+
+// captureScreenshot takes one capture; any Scale override is the caller's.
+func captureScreenshot(ctx context.Context, client *cdp.Client, opts ScreenshotOptions) ([]byte, error) {
 	params := map[string]interface{}{
 		"format":      "png",
 		"fromSurface": true,
@@ -67,42 +313,54 @@ func cmdScreenshot(args []string) error {
 
 	// Default to "viewport only" to avoid the headful flicker/resize path in Chromium.
 	// `captureBeyondViewport=true` is still available via --full-page (or --cdp-clip).
-	params["captureBeyondViewport"] = *fullPage
+	params["captureBeyondViewport"] = opts.FullPage
 
 	var crop *screenshotCrop
-	if *selector != "" {
-		if *cdpClip {
-			clip, err := resolveClip(ctx, handle.client, *selector)
+	if opts.Selector != "" {
+		if opts.CDPClip {
+			clip, err := resolveClip(ctx, client, opts.Selector)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if clip == nil {
-				return fmt.Errorf("selector %s not found", *selector)
+				return nil, fmt.Errorf("selector %s not found", opts.Selector)
 			}
 			params["clip"] = clip
 			params["captureBeyondViewport"] = true
 		} else {
 			// Compute a viewport-relative crop rect, then crop locally to avoid Chromium resizing the view.
-			if *scrollIntoView {
-				if err := handle.client.Call(ctx, "DOM.enable", nil, nil); err != nil {
-					return err
-				}
-				nodeID, err := resolveNodeID(ctx, handle.client, *selector)
-				if err != nil {
-					return err
-				}
-				if nodeID == 0 {
-					return fmt.Errorf("selector %s not found", *selector)
-				}
-				_ = handle.client.Call(ctx, "DOM.scrollIntoViewIfNeeded", map[string]interface{}{"nodeId": nodeID}, nil)
-			}
+			// Scrolling while hovering would slide the trigger out from under the cursor.
+			scroll := !opts.NoScroll && !opts.hovering
 			var err error
-			crop, err = resolveViewportCrop(ctx, handle.client, *selector)
+			crop, err = resolveViewportCrop(ctx, client, opts.Selector, scroll)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if crop == nil {
-				return fmt.Errorf("selector %s not found", *selector)
+				return nil, fmt.Errorf("selector %s not found", opts.Selector)
+			}
+			if opts.Scale > 0 {
+				crop.DPR = opts.Scale
+			}
+			if reason := crop.emptyReason(); reason != "" {
+				return nil, emptyElementError(opts.Selector, reason)
+			}
+			if crop.offscreen() {
+				switch {
+				case opts.hovering:
+					return nil, offscreenElementError(opts.Selector, crop, "--hover keeps the page still, so scroll it into view before capturing")
+				case opts.NoScroll:
+					return nil, offscreenElementError(opts.Selector, crop, "drop --scroll-into-view=false or use --cdp-clip")
+				}
+				return nil, offscreenElementError(opts.Selector, crop, "it may be clipped by an overflow container or positioned off-screen; try --cdp-clip")
+			}
+			// Without scrolling the element may hang off the edge: capture
+			// what is on screen rather than failing.
+			if !scroll {
+				full := *crop
+				if crop.clipToViewport() && opts.note != nil {
+					opts.note(fmt.Sprintf("selector %s is partly outside the viewport; captured the visible %.0fx%.0f of %.0fx%.0f px", opts.Selector, crop.Width, crop.Height, full.Width, full.Height))
+				}
 			}
 		}
 	}
@@ -110,27 +368,115 @@ func cmdScreenshot(args []string) error {
 	var shot struct {
 		Data string `json:"data"`
 	}
-	if err := handle.client.Call(ctx, "Page.captureScreenshot", params, &shot); err != nil {
-		return err
+	if err := client.Call(ctx, "Page.captureScreenshot", params, &shot); err != nil {
+		return nil, err
 	}
 	data, err := base64.StdEncoding.DecodeString(shot.Data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if crop != nil {
 		cropped, err := cropPNG(data, *crop)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		data = cropped
 	}
+	return data, nil
+}
 
-	if err := os.WriteFile(*output, data, 0o644); err != nil {
+// compareScreenshotBaseline checks a capture against a baseline PNG, seeding the
+// baseline from the capture on first run.
+func compareScreenshotBaseline(data []byte, baseline, diffOutput string, threshold float64, tolerance uint8) error {
+	if _, err := os.Stat(baseline); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(baseline, data, 0o644); err != nil {
+			return err
+		}
+		fmt.Printf("Baseline %s created\n", baseline)
+		return nil
+	}
+	want, err := readPNG(baseline)
+	if err != nil {
 		return err
 	}
-	fmt.Printf("Saved %s (%d bytes)\n", *output, len(data))
-	return nil
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode capture: %w", err)
+	}
+	if diffOutput == "" {
+		diffOutput = strings.TrimSuffix(baseline, filepath.Ext(baseline)) + ".diff.png"
+	}
+	return reportImageDiff(want, got, diffOutput, threshold, tolerance)
+}
+
+// overrideDeviceScale emulates a device pixel ratio for the capture. The
+// returned func puts back the session's recorded metrics override, or clears
+// the emulation when there is none.
+func overrideDeviceScale(ctx context.Context, client *cdp.Client, scale float64) (func(), error) {
+	const method = "Emulation.setDeviceMetricsOverride"
+	var recorded *store.Override
+	if session := lookupSession(client); session != nil {
+		for i := range session.Overrides {
+			if session.Overrides[i].Method == method {
+				recorded = &session.Overrides[i]
+			}
+		}
+	}
+	// Width/height 0 keep the current viewport size.
+	params := map[string]interface{}{"width": 0, "height": 0, "mobile": false}
+	if recorded != nil {
+		for k, v := range recorded.Params {
+			params[k] = v
+		}
+	}
+	params["deviceScaleFactor"] = scale
+	if err := client.Call(ctx, method, params, nil); err != nil {
+		return nil, err
+	}
+	return func() {
+		restoreCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		var err error
+		if recorded != nil {
+			err = callOverride(restoreCtx, client, *recorded)
+		} else {
+			err = client.Call(restoreCtx, "Emulation.clearDeviceMetricsOverride", nil, nil)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "warning: unable to restore device metrics after --scale:", err)
+		}
+	}, nil
+}
+
+// mouseHover scrolls selector into view and moves the CDP mouse to its center,
+// so :hover styles and mouseenter/mouseover handlers fire as for a real cursor.
+func mouseHover(ctx context.Context, client *cdp.Client, selector string) error {
+	value, err := client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (!el) { return null; }
+        el.scrollIntoView({block: "center", inline: "center"});
+        const r = el.getBoundingClientRect();
+        return {x: r.left + r.width / 2, y: r.top + r.height / 2};
+    })()`, strconv.Quote(selector)))
+	if err != nil {
+		return err
+	}
+	point, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("selector %s not found", selector)
+	}
+	x, _ := point["x"].(float64)
+	y, _ := point["y"].(float64)
+	return moveMouse(ctx, client, x, y)
+}
+
+func moveMouse(ctx context.Context, client *cdp.Client, x, y float64) error {
+	return client.Call(ctx, "Input.dispatchMouseEvent", map[string]interface{}{
+		"type": "mouseMoved",
+		"x":    x,
+		"y":    y,
+	}, nil)
 }
 
 type screenshotCrop struct {
@@ -139,22 +485,46 @@ type screenshotCrop struct {
 	Width  float64
 	Height float64
 	DPR    float64
+	// Hidden is set when the element is not rendered at all (display:none).
+	Hidden         string
+	ViewportWidth  float64
+	ViewportHeight float64
 }
 
-func resolveViewportCrop(ctx context.Context, client *cdp.Client, selector string) (*screenshotCrop, error) {
+// resolveViewportCrop finds the element and measures its viewport-relative
+// box in one evaluation. With scroll, an element that isn't fully on screen
+// is first scrolled to the center, through every scrolling ancestor, so the
+// selector path costs a single round trip before the capture.
+func resolveViewportCrop(ctx context.Context, client *cdp.Client, selector string, scroll bool) (*screenshotCrop, error) {
 	expression := fmt.Sprintf(`(() => {
         const el = document.querySelector(%s);
         if (!el) { return null; }
+        if (%t) {
+            const b = el.getBoundingClientRect();
+            if (b.top < 0 || b.left < 0 || b.bottom > window.innerHeight || b.right > window.innerWidth) {
+                el.scrollIntoView({block: "center", inline: "center", behavior: "instant"});
+            }
+        }
         const r = el.getBoundingClientRect();
         const dpr = window.devicePixelRatio || 1;
+        const style = getComputedStyle(el);
+        let hidden = "";
+        if (style.display === "none") {
+            hidden = "has display:none";
+        } else if (el.getClientRects().length === 0) {
+            hidden = "is not rendered (an ancestor has display:none)";
+        }
         return {
             x: r.left,
             y: r.top,
             width: r.width,
             height: r.height,
-            dpr
+            dpr,
+            hidden,
+            viewportWidth: window.innerWidth,
+            viewportHeight: window.innerHeight
         };
-    })()`, strconv.Quote(selector))
+    })()`, strconv.Quote(selector), scroll)
 	value, err := client.Evaluate(ctx, expression)
 	if err != nil {
 		return nil, err
@@ -182,9 +552,61 @@ func resolveViewportCrop(ctx context.Context, client *cdp.Client, selector strin
 	if v, ok := m["dpr"].(float64); ok {
 		crop.DPR = v
 	}
+	crop.Hidden, _ = m["hidden"].(string)
+	if v, ok := m["viewportWidth"].(float64); ok {
+		crop.ViewportWidth = v
+	}
+	if v, ok := m["viewportHeight"].(float64); ok {
+		crop.ViewportHeight = v
+	}
 	return crop, nil
 }
 
+// emptyReason says why the element has nothing to capture (hidden or
+// zero-size), or "" when it has a visible box.
+func (c *screenshotCrop) emptyReason() string {
+	if c.Hidden != "" {
+		return c.Hidden
+	}
+	if c.Width <= 0 || c.Height <= 0 {
+		return fmt.Sprintf("is collapsed to %gx%g px", c.Width, c.Height)
+	}
+	return ""
+}
+
+// offscreen reports whether the element's box lies entirely outside the viewport.
+func (c *screenshotCrop) offscreen() bool {
+	if c.ViewportWidth <= 0 || c.ViewportHeight <= 0 {
+		return false
+	}
+	return c.X+c.Width <= 0 || c.Y+c.Height <= 0 || c.X >= c.ViewportWidth || c.Y >= c.ViewportHeight
+}
+
+// clipToViewport shrinks the box to its intersection with the viewport and
+// reports whether anything was cut off.
+func (c *screenshotCrop) clipToViewport() bool {
+	if c.ViewportWidth <= 0 || c.ViewportHeight <= 0 {
+		return false
+	}
+	left := math.Max(c.X, 0)
+	top := math.Max(c.Y, 0)
+	right := math.Min(c.X+c.Width, c.ViewportWidth)
+	bottom := math.Min(c.Y+c.Height, c.ViewportHeight)
+	if left == c.X && top == c.Y && right == c.X+c.Width && bottom == c.Y+c.Height {
+		return false
+	}
+	c.X, c.Y, c.Width, c.Height = left, top, right-left, bottom-top
+	return true
+}
+
+func emptyElementError(selector, reason string) error {
+	return fmt.Errorf("selector %s matched an element that %s, so there is nothing to capture; use cdp wait-visible (or wait --selector ... --visible) first", selector, reason)
+}
+
+func offscreenElementError(selector string, crop *screenshotCrop, hint string) error {
+	return fmt.Errorf("selector %s is outside the viewport (at x=%.0f y=%.0f, viewport %.0fx%.0f); %s", selector, crop.X, crop.Y, crop.ViewportWidth, crop.ViewportHeight, hint)
+}
+
 func cropPNG(pngBytes []byte, crop screenshotCrop) ([]byte, error) {
 	img, err := png.Decode(bytes.NewReader(pngBytes))
 	if err != nil {
@@ -201,7 +623,7 @@ func cropPNG(pngBytes []byte, crop screenshotCrop) ([]byte, error) {
 	right = clampInt(right, bounds.Min.X, bounds.Max.X)
 	bottom = clampInt(bottom, bounds.Min.Y, bounds.Max.Y)
 	if right <= left || bottom <= top {
-		return nil, fmt.Errorf("crop rectangle is empty after clamping (x=%d y=%d w=%d h=%d)", left, top, right-left, bottom-top)
+		return nil, fmt.Errorf("element is outside the captured image (crop x=%d y=%d w=%d h=%d after clamping); scroll it into view or use --cdp-clip", left, top, right-left, bottom-top)
 	}
 	if left == bounds.Min.X && top == bounds.Min.Y && right == bounds.Max.X && bottom == bounds.Max.Y {
 		return pngBytes, nil
@@ -252,6 +674,12 @@ func resolveClip(ctx context.Context, client *cdp.Client, selector string) (map[
 		} `json:"model"`
 	}
 	if err := client.Call(ctx, "DOM.getBoxModel", map[string]interface{}{"nodeId": node.NodeID}, &box); err != nil {
+		// Usually "Could not compute box model": say why if the page can tell us.
+		if crop, cerr := resolveViewportCrop(ctx, client, selector, false); cerr == nil && crop != nil {
+			if reason := crop.emptyReason(); reason != "" {
+				return nil, emptyElementError(selector, reason)
+			}
+		}
 		return nil, err
 	}
 	if len(box.Model.Content) < 8 {
@@ -271,7 +699,7 @@ func resolveClip(ctx context.Context, client *cdp.Client, selector string) (map[
 	width := right - left
 	height := bottom - top
 	if width <= 0 || height <= 0 {
-		return nil, errors.New("clip is empty")
+		return nil, emptyElementError(selector, fmt.Sprintf("is collapsed to %gx%g px", width, height))
 	}
 
 	return map[string]interface{}{
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var screenshotDiffHelp = commandHelp{
+	Description: `Compares two PNG files offline; no session is needed. Per-channel differences up
+to --tolerance are ignored, which absorbs antialiasing noise.`,
+	Examples: []commandExample{
+		{"cdp screenshot-diff before.png after.png", "Print the share of changed pixels."},
+		{"cdp screenshot-diff before.png after.png --out diff.png --tolerance 8", "Write a diff image, ignoring small colour shifts."},
+		{"cdp screenshot-diff golden.png current.png --threshold 1", "Exit non-zero when more than 1% changed."},
+	},
+}
+
+func cmdScreenshotDiff(args []string) error {
+	fs := newFlagSet("screenshot-diff", "usage: cdp screenshot-diff a.png b.png [--out diff.png] [--threshold PCT] [--tolerance N]\n\nCompares two PNGs pixel by pixel and prints the share of changed pixels. With --out\na diff image is written: changed pixels in red over a faded copy of the first image.\nExits non-zero when more than --threshold percent of the pixels changed.")
+	out := fs.String("out", "", "Write a highlighted diff PNG to this path")
+	threshold := fs.Float64("threshold", 0, "Allowed percentage of changed pixels before failing")
+	tolerance := fs.Int("tolerance", 0, "Ignore per-channel differences up to this value (0-255), e.g. for anti-aliasing")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 2 {
+		return usagef("usage: cdp screenshot-diff a.png b.png [--out diff.png]")
+	}
+	if *threshold < 0 || *threshold > 100 {
+		return errors.New("--threshold must be between 0 and 100")
+	}
+	if *tolerance < 0 || *tolerance > 255 {
+		return errors.New("--tolerance must be between 0 and 255")
+	}
+
+	a, err := readPNG(pos[0])
+	if err != nil {
+		return err
+	}
+	b, err := readPNG(pos[1])
+	if err != nil {
+		return err
+	}
+	return reportImageDiff(a, b, *out, *threshold, uint8(*tolerance))
+}
+
+// reportImageDiff compares a against b, optionally writes the diff image to
+// out, prints the changed share, and fails when it exceeds threshold percent.
+func reportImageDiff(a, b image.Image, out string, threshold float64, tolerance uint8) error {
+	diff, changed, total := diffImages(a, b, tolerance)
+	if out != "" {
+		if err := writePNG(out, diff); err != nil {
+			return fmt.Errorf("write diff image: %w", err)
+		}
+	}
+	pct := 0.0
+	if total > 0 {
+		pct = float64(changed) * 100 / float64(total)
+	}
+	if a.Bounds().Size() != b.Bounds().Size() {
+		fmt.Printf("size differs: %v vs %v\n", a.Bounds().Size(), b.Bounds().Size())
+	}
+	fmt.Printf("%.3f%% changed (%d of %d pixels)\n", pct, changed, total)
+	if pct > threshold {
+		if out != "" {
+			return fmt.Errorf("%.3f%% of pixels changed (threshold %g%%); see %s", pct, threshold, out)
+		}
+		return fmt.Errorf("%.3f%% of pixels changed (threshold %g%%)", pct, threshold)
+	}
+	return nil
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// parsePercent accepts "0.5%" or "0.5" and returns 0.5.
+func parsePercent(value string) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(value), "%"), 64)
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("invalid percentage %q (expected 0-100, e.g. 0.5%%)", value)
+	}
+	return n, nil
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+var (
+	diffHighlight = color.RGBA{R: 255, A: 255}
+	diffMissing   = color.RGBA{R: 255, B: 255, A: 255}
+)
+
+// diffImages compares a and b anchored at their top-left corners. Pixels that
+// exist in only one image count as changed (magenta in the diff); differing
+// pixels are red over a faded grayscale copy of a.
+func diffImages(a, b image.Image, tolerance uint8) (*image.RGBA, int, int) {
+	ab, bb := a.Bounds(), b.Bounds()
+	w, h := ab.Dx(), ab.Dy()
+	if bb.Dx() > w {
+		w = bb.Dx()
+	}
+	if bb.Dy() > h {
+		h = bb.Dy()
+	}
+	diff := image.NewRGBA(image.Rect(0, 0, w, h))
+	changed := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pa := image.Pt(ab.Min.X+x, ab.Min.Y+y)
+			pb := image.Pt(bb.Min.X+x, bb.Min.Y+y)
+			inA, inB := pa.In(ab), pb.In(bb)
+			if !inA || !inB {
+				changed++
+				diff.SetRGBA(x, y, diffMissing)
+				continue
+			}
+			ca := color.RGBAModel.Convert(a.At(pa.X, pa.Y)).(color.RGBA)
+			cb := color.RGBAModel.Convert(b.At(pb.X, pb.Y)).(color.RGBA)
+			if pixelDiffers(ca, cb, tolerance) {
+				changed++
+				diff.SetRGBA(x, y, diffHighlight)
+				continue
+			}
+			gray := uint8((uint16(ca.R)*30 + uint16(ca.G)*59 + uint16(ca.B)*11) / 100)
+			faded := 255 - (255-gray)/4
+			diff.SetRGBA(x, y, color.RGBA{R: faded, G: faded, B: faded, A: 255})
+		}
+	}
+	return diff, changed, w * h
+}
+
+func pixelDiffers(a, b color.RGBA, tolerance uint8) bool {
+	return channelDiff(a.R, b.R) > tolerance || channelDiff(a.G, b.G) > tolerance ||
+		channelDiff(a.B, b.B) > tolerance || channelDiff(a.A, b.A) > tolerance
+}
+
+func channelDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
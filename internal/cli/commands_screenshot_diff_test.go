@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffImages(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	b := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 4; x++ {
+			a.SetRGBA(x, y, color.RGBA{R: 10, G: 10, B: 10, A: 255})
+			b.SetRGBA(x, y, color.RGBA{R: 10, G: 10, B: 10, A: 255})
+		}
+	}
+	b.SetRGBA(1, 0, color.RGBA{R: 12, G: 10, B: 10, A: 255}) // within tolerance
+	b.SetRGBA(2, 1, color.RGBA{R: 200, G: 10, B: 10, A: 255})
+
+	diff, changed, total := diffImages(a, b, 5)
+	if total != 12 {
+		t.Fatalf("total = %d, want 12", total)
+	}
+	// One real change plus the 4-pixel row only b has.
+	if changed != 5 {
+		t.Fatalf("changed = %d, want 5", changed)
+	}
+	if diff.RGBAAt(2, 1) != diffHighlight || diff.RGBAAt(0, 2) != diffMissing {
+		t.Fatalf("unexpected diff colors %v %v", diff.RGBAAt(2, 1), diff.RGBAAt(0, 2))
+	}
+	if _, changed, _ := diffImages(a, b, 0); changed != 6 {
+		t.Fatalf("changed without tolerance = %d, want 6", changed)
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	for in, want := range map[string]float64{"0.5%": 0.5, "2": 2, " 10% ": 10} {
+		if got, err := parsePercent(in); err != nil || got != want {
+			t.Fatalf("parsePercent(%q) = %v, %v", in, got, err)
+		}
+	}
+	for _, bad := range []string{"", "abc", "-1%", "101"} {
+		if _, err := parsePercent(bad); err == nil {
+			t.Fatalf("parsePercent(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCompareScreenshotBaseline(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	baseline := filepath.Join(dir, "base.png")
+	if err := compareScreenshotBaseline(buf.Bytes(), baseline, "", 0, 0); err != nil {
+		t.Fatalf("seeding baseline: %v", err)
+	}
+	if err := compareScreenshotBaseline(buf.Bytes(), baseline, "", 0, 0); err != nil {
+		t.Fatalf("identical capture: %v", err)
+	}
+
+	img.SetRGBA(0, 0, color.RGBA{R: 255, A: 255})
+	buf.Reset()
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := compareScreenshotBaseline(buf.Bytes(), baseline, "", 0.5, 0); err == nil {
+		t.Fatal("1% change should exceed a 0.5% threshold")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "base.diff.png")); err != nil {
+		t.Fatalf("diff artifact missing: %v", err)
+	}
+	if err := compareScreenshotBaseline(buf.Bytes(), baseline, "", 1, 0); err != nil {
+		t.Fatalf("1%% change within 1%% threshold: %v", err)
+	}
+}
+
+func TestScreenshotCropDiagnostics(t *testing.T) {
+	visible := screenshotCrop{X: 10, Y: 20, Width: 100, Height: 50, ViewportWidth: 800, ViewportHeight: 600}
+	if r := visible.emptyReason(); r != "" || visible.offscreen() {
+		t.Fatalf("visible crop: reason %q offscreen %v", r, visible.offscreen())
+	}
+	hidden := screenshotCrop{Hidden: "has display:none", ViewportWidth: 800, ViewportHeight: 600}
+	if r := hidden.emptyReason(); r != "has display:none" {
+		t.Errorf("hidden reason = %q", r)
+	}
+	collapsed := screenshotCrop{X: 10, Y: 20, Width: 100, ViewportWidth: 800, ViewportHeight: 600}
+	if r := collapsed.emptyReason(); r != "is collapsed to 100x0 px" {
+		t.Errorf("collapsed reason = %q", r)
+	}
+	below := screenshotCrop{X: 10, Y: 1400, Width: 100, Height: 50, ViewportWidth: 800, ViewportHeight: 600}
+	if !below.offscreen() {
+		t.Error("element below the fold should be offscreen")
+	}
+	partial := screenshotCrop{X: -50, Y: 580, Width: 100, Height: 50, ViewportWidth: 800, ViewportHeight: 600}
+	if partial.offscreen() {
+		t.Error("partially visible element should not be offscreen")
+	}
+	if !partial.clipToViewport() || partial.X != 0 || partial.Y != 580 || partial.Width != 50 || partial.Height != 20 {
+		t.Errorf("clipped partial crop = %+v", partial)
+	}
+	if visible.clipToViewport() {
+		t.Error("a crop inside the viewport should not be clipped")
+	}
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+// activateSettle is how long --activate leaves a tab in front before the
+// capture, so a background tab that stopped painting can catch up.
+const activateSettle = 300 * time.Millisecond
+
+type allTabsOptions struct {
+	host     string
+	port     int
+	dir      string
+	filter   *regexp.Regexp
+	activate bool
+	parallel int
+	fullPage bool
+	timeout  time.Duration // per tab
+}
+
+// tabShot is the outcome for one tab: saved to path, or skipped/failed with
+// reason.
+type tabShot struct {
+	index  int
+	tab    cdp.TargetInfo
+	path   string
+	size   int
+	reason string
+	failed bool
+}
+
+// screenshotAllTabs captures the viewport of every page target on the port
+// over short-lived connections; it never touches the session store. One
+// tab's failure is reported in the summary and doesn't stop the others.
+func screenshotAllTabs(opts allTabsOptions) error {
+	ctx, cancel := commandContext(opts.timeout)
+	tabs, err := fetchTabs(ctx, dialer(), opts.host, opts.port)
+	cancel()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(opts.dir, 0o755); err != nil {
+		return err
+	}
+
+	shots := make([]tabShot, len(tabs))
+	parallel := opts.parallel
+	if opts.activate {
+		// Only one tab can be in front at a time.
+		parallel = 1
+	}
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, tab := range tabs {
+		shots[i] = tabShot{index: i + 1, tab: tab}
+		switch {
+		case opts.filter != nil && !opts.filter.MatchString(tab.URL) && !opts.filter.MatchString(tab.Title):
+			shots[i].reason = "doesn't match --filter"
+			continue
+		case tab.WebSocket == "":
+			shots[i].reason = "attached to another DevTools client"
+			continue
+		}
+		wg.Add(1)
+		go func(shot *tabShot) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			shot.path = filepath.Join(opts.dir, tabShotName(shot.index, shot.tab))
+			if err := captureTab(shot, opts); err != nil {
+				shot.failed, shot.reason = true, err.Error()
+			}
+		}(&shots[i])
+	}
+	wg.Wait()
+
+	var captured, failed, skipped []tabShot
+	for _, shot := range shots {
+		switch {
+		case shot.failed:
+			failed = append(failed, shot)
+		case shot.reason != "":
+			skipped = append(skipped, shot)
+		default:
+			captured = append(captured, shot)
+			fmt.Printf("Saved %s (%d bytes)\n", shot.path, shot.size)
+		}
+	}
+	fmt.Printf("Captured %d, failed %d, skipped %d of %d tab(s) in %s\n", len(captured), len(failed), len(skipped), len(tabs), opts.dir)
+	printTabShots("failed", failed)
+	printTabShots("skipped", skipped)
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d tab(s) failed", len(failed), len(tabs))
+	}
+	return nil
+}
+
+func captureTab(shot *tabShot, opts allTabsOptions) error {
+	ctx, cancel := commandContext(opts.timeout)
+	defer cancel()
+	client, err := dialer().Dial(ctx, rewriteWebSocketURL(shot.tab.WebSocket, opts.host, opts.port, dialer().Secure))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if opts.activate {
+		if err := client.Call(ctx, "Page.bringToFront", nil, nil); err != nil {
+			return fmt.Errorf("activate: %w", err)
+		}
+		select {
+		case <-time.After(activateSettle):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	data, err := captureScreenshot(ctx, client, ScreenshotOptions{FullPage: opts.fullPage})
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && !opts.activate {
+			return fmt.Errorf("%w (background tabs may need --activate)", err)
+		}
+		return err
+	}
+	if err := os.WriteFile(shot.path, data, 0o644); err != nil {
+		return err
+	}
+	shot.size = len(data)
+	return nil
+}
+
+// tabShotName names a tab's file from its position in the tab list and its
+// title, or its URL when the title is empty: "Grafana: Overview" at position
+// 3 becomes "03-grafana__overview.png".
+func tabShotName(index int, tab cdp.TargetInfo) string {
+	fragment := strings.ToLower(sanitizePathFragment(tab.Title))
+	if len(fragment) > 60 {
+		fragment = strings.TrimRight(fragment[:60], "_-")
+	}
+	if fragment == "" {
+		fragment = shortenURLFragment(tab.URL, 60)
+	}
+	return fmt.Sprintf("%02d-%s.png", index, fragment)
+}
+
+func printTabShots(label string, shots []tabShot) {
+	if len(shots) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", label)
+	for _, shot := range shots {
+		fmt.Printf("  %02d %s: %s\n", shot.index, shot.tab.URL, shot.reason)
+	}
+}
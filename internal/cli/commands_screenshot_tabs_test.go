@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/base64"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+)
+
+// fakeTabBrowser lists four page targets: two that answer captures, one that
+// never does, and one already attached elsewhere (no debugger URL).
+func fakeTabBrowser(t *testing.T) (host string, port int) {
+	t.Helper()
+	shot := base64.StdEncoding.EncodeToString(solidPNG(t, 4, 4, color.RGBA{A: 255}, image.Rectangle{}))
+	browser := cdptest.New(t, []cdptest.Target{
+		{ID: "A", URL: "https://grafana.example/d/1", Title: "Grafana: Overview"},
+		{ID: "B", URL: "https://kibana.example/app"},
+		{ID: "C", URL: "https://stuck.example/", Title: "Stuck"},
+		{ID: "D", URL: "https://taken.example/", Title: "Taken", Attached: true},
+	}, func(c *cdptest.Conn, req cdptest.Request) {
+		if req.Target != "C" {
+			c.Reply(req.ID, map[string]interface{}{"data": shot})
+		}
+	})
+	return browser.Host, browser.Port
+}
+
+func TestScreenshotAllTabsReportsEachTab(t *testing.T) {
+	host, port := fakeTabBrowser(t)
+	dir := t.TempDir()
+	err := cmdScreenshot([]string{"--all-tabs", "--host", host, "--port", strconv.Itoa(port), "--output-dir", dir, "--timeout", "300ms", "--parallel", "2"})
+	if err == nil || err.Error() != "1 of 4 tab(s) failed" {
+		t.Fatalf("err = %v", err)
+	}
+	for _, name := range []string{"01-grafana__overview.png", "02-kibana.example-app.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("wrote %d files, want 2", len(entries))
+	}
+
+	dir = t.TempDir()
+	if err := cmdScreenshot([]string{"--all-tabs", "--host", host, "--port", strconv.Itoa(port), "--output-dir", dir, "--filter", "grafana"}); err != nil {
+		t.Fatalf("filtered run: %v", err)
+	}
+}
+
+func TestTabShotName(t *testing.T) {
+	long := cdp.TargetInfo{Title: strings.Repeat("a", 80)}
+	if got := tabShotName(12, long); got != "12-"+strings.Repeat("a", 60)+".png" {
+		t.Errorf("long title: %q", got)
+	}
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const (
+	// frameSignatureSize is the grid --on-change compares frames on; each
+	// cell holds the mean luminance of its block of pixels.
+	frameSignatureSize = 64
+	// frameCellTolerance absorbs small luminance drift in a cell, such as a
+	// blinking caret or anti-aliasing.
+	frameCellTolerance = 6
+	// maxWatchFailures consecutive failed captures end --every.
+	maxWatchFailures = 3
+)
+
+type screenshotWatchOptions struct {
+	every           time.Duration
+	frames          int
+	duration        time.Duration
+	dir             string
+	prefix          string
+	name            *outputName // replaces prefix when set
+	onChange        bool
+	changeThreshold float64 // percent of signature cells
+	timeout         time.Duration
+}
+
+// runScreenshotWatch captures on opts.every until the frame or duration limit
+// or an interrupt, writing numbered frames and a final summary.
+func runScreenshotWatch(ctx context.Context, capture func(context.Context) ([]byte, error), opts screenshotWatchOptions) error {
+	if err := os.MkdirAll(opts.dir, 0o755); err != nil {
+		return err
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	var deadline <-chan time.Time
+	if opts.duration > 0 {
+		timer := time.NewTimer(opts.duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	ticker := time.NewTicker(opts.every)
+	defer ticker.Stop()
+
+	var last *frameSignature
+	captured, written, skipped, failed, consecutive := 0, 0, 0, 0, 0
+	var lastErr error
+loop:
+	for {
+		frameCtx, cancel := withCommandTimeout(ctx, opts.timeout)
+		data, err := capture(frameCtx)
+		cancel()
+		captured++
+		if err == nil && opts.onChange {
+			var img image.Image
+			if img, err = png.Decode(bytes.NewReader(data)); err == nil {
+				sig := signFrame(img)
+				if last != nil && last.changedShare(sig) <= opts.changeThreshold {
+					data = nil
+				} else {
+					last = &sig
+				}
+			}
+		}
+		switch {
+		case err != nil:
+			failed++
+			consecutive++
+			lastErr = err
+			fmt.Fprintf(os.Stderr, "warning: capture %d failed: %v\n", captured, err)
+			if consecutive >= maxWatchFailures {
+				break loop
+			}
+		case data == nil:
+			consecutive = 0
+			skipped++
+		default:
+			consecutive = 0
+			written++
+			path := filepath.Join(opts.dir, fmt.Sprintf("%s%04d.png", opts.prefix, written))
+			if opts.name != nil {
+				// Take the next free {seq} so a second run into the same
+				// directory continues after the first instead of overwriting it.
+				if path, err = opts.name.nextPath(opts.dir, time.Now()); err != nil {
+					return err
+				}
+			}
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("Saved %s (%d bytes)\n", path, len(data))
+		}
+		if opts.frames > 0 && captured >= opts.frames {
+			break
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			break loop
+		case <-sigCh:
+			break loop
+		case <-ctx.Done():
+			break loop
+		}
+	}
+
+	fmt.Printf("Captured %d frame(s): wrote %d to %s", captured, written, opts.dir)
+	if opts.onChange {
+		fmt.Printf(", skipped %d unchanged", skipped)
+	}
+	if failed > 0 {
+		fmt.Printf(", %d failed", failed)
+	}
+	fmt.Println()
+	if consecutive >= maxWatchFailures {
+		return fmt.Errorf("stopping after %d failed captures in a row: %w", consecutive, lastErr)
+	}
+	return nil
+}
+
+// frameSignature is a cheap fingerprint of a frame: its size and a coarse
+// grid of mean luminance values.
+type frameSignature struct {
+	width, height int
+	cells         []uint8
+}
+
+func signFrame(img image.Image) frameSignature {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	sig := frameSignature{width: w, height: h, cells: make([]uint8, frameSignatureSize*frameSignatureSize)}
+	if w == 0 || h == 0 {
+		return sig
+	}
+	sums := make([]uint64, len(sig.cells))
+	counts := make([]uint64, len(sig.cells))
+	for y := 0; y < h; y++ {
+		row := y * frameSignatureSize / h * frameSignatureSize
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			cell := row + x*frameSignatureSize/w
+			sums[cell] += uint64((r*30 + g*59 + bl*11) / 100 >> 8)
+			counts[cell]++
+		}
+	}
+	for i := range sig.cells {
+		if counts[i] > 0 {
+			sig.cells[i] = uint8(sums[i] / counts[i])
+		}
+	}
+	return sig
+}
+
+// changedShare returns the percentage of cells that differ between a and b
+// by more than frameCellTolerance; frames of different sizes differ entirely.
+func (a frameSignature) changedShare(b frameSignature) float64 {
+	if a.width != b.width || a.height != b.height {
+		return 100
+	}
+	changed := 0
+	for i := range a.cells {
+		if channelDiff(a.cells[i], b.cells[i]) > frameCellTolerance {
+			changed++
+		}
+	}
+	return 100 * float64(changed) / float64(len(a.cells))
+}
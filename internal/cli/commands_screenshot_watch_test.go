@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+)
+
+func solidPNG(t *testing.T, w, h int, c color.RGBA, patch image.Rectangle) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if image.Pt(x, y).In(patch) {
+				img.SetRGBA(x, y, color.RGBA{A: 255})
+				continue
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunScreenshotWatchOnChange(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	frames := [][]byte{
+		solidPNG(t, 128, 128, white, image.Rectangle{}),
+		solidPNG(t, 128, 128, white, image.Rectangle{}),
+		solidPNG(t, 128, 128, white, image.Rect(0, 0, 40, 40)),
+		solidPNG(t, 128, 128, white, image.Rect(0, 0, 40, 40)),
+		solidPNG(t, 64, 64, white, image.Rectangle{}),
+	}
+	next := 0
+	capture := func(context.Context) ([]byte, error) {
+		data := frames[next]
+		next++
+		return data, nil
+	}
+	dir := filepath.Join(t.TempDir(), "shots")
+	err := runScreenshotWatch(context.Background(), capture, screenshotWatchOptions{
+		every:           time.Millisecond,
+		frames:          len(frames),
+		dir:             dir,
+		prefix:          "step-",
+		onChange:        true,
+		changeThreshold: 0.1,
+		timeout:         time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"step-0001.png", "step-0002.png", "step-0003.png"}
+	if len(names) != len(want) {
+		t.Fatalf("wrote %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("wrote %v, want %v", names, want)
+		}
+	}
+}
+
+func TestRunScreenshotWatchNameContinuesSeq(t *testing.T) {
+	frame := solidPNG(t, 16, 16, color.RGBA{R: 255, A: 255}, image.Rectangle{})
+	capture := func(context.Context) ([]byte, error) { return frame, nil }
+	dir := t.TempDir()
+	name, err := parseOutputName("run-{seq}", "app", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for run := 0; run < 2; run++ {
+		err := runScreenshotWatch(context.Background(), capture, screenshotWatchOptions{
+			every:   time.Millisecond,
+			frames:  2,
+			dir:     dir,
+			name:    name,
+			timeout: time.Second,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[3].Name() != "run-0004.png" {
+		t.Fatalf("two runs of two frames left %d files ending in %s, want run-0001..0004.png", len(entries), entries[len(entries)-1].Name())
+	}
+}
+
+func TestFrameSignatureChangedShare(t *testing.T) {
+	decode := func(data []byte) frameSignature {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signFrame(img)
+	}
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	base := decode(solidPNG(t, 640, 320, gray, image.Rectangle{}))
+	if share := base.changedShare(decode(solidPNG(t, 640, 320, gray, image.Rectangle{}))); share != 0 {
+		t.Fatalf("identical frames changed %.2f%%", share)
+	}
+	// A single dark pixel barely moves its cell's mean.
+	if share := base.changedShare(decode(solidPNG(t, 640, 320, gray, image.Rect(5, 5, 6, 6)))); share != 0 {
+		t.Fatalf("one pixel changed %.2f%%", share)
+	}
+	if share := base.changedShare(decode(solidPNG(t, 640, 320, gray, image.Rect(0, 0, 320, 320)))); share < 40 || share > 60 {
+		t.Fatalf("half the frame changed %.2f%%, want ~50%%", share)
+	}
+	if share := base.changedShare(decode(solidPNG(t, 320, 320, gray, image.Rectangle{}))); share != 100 {
+		t.Fatalf("resized frame changed %.2f%%, want 100%%", share)
+	}
+}
+
+func TestSelectorScreenshotResolvesCropInOneEval(t *testing.T) {
+	shot := base64.StdEncoding.EncodeToString(solidPNG(t, 128, 128, color.RGBA{R: 255, A: 255}, image.Rectangle{}))
+	var mu sync.Mutex
+	var methods, expressions []string
+	wsURL := cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		mu.Lock()
+		methods = append(methods, req.Method)
+		expressions = append(expressions, req.Expression())
+		mu.Unlock()
+		var result interface{}
+		switch req.Method {
+		case "Runtime.evaluate":
+			result = cdptest.EvalResult(map[string]interface{}{"x": 10, "y": 20, "width": 30, "height": 40, "dpr": 1, "hidden": "", "viewportWidth": 128, "viewportHeight": 128})
+		case "Page.captureScreenshot":
+			result = map[string]interface{}{"data": shot}
+		}
+		c.Reply(req.ID, result)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := cdp.Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	data, err := captureScreenshot(ctx, client, ScreenshotOptions{Selector: "#chart"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 30 || b.Dy() != 40 {
+		t.Fatalf("cropped to %v, want 30x40", b)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(methods, ",") != "Runtime.evaluate,Page.captureScreenshot" {
+		t.Fatalf("round trips: %v", methods)
+	}
+	if !strings.Contains(expressions[0], "scrollIntoView") {
+		t.Fatal("the crop lookup should scroll the element into view itself")
+	}
+}
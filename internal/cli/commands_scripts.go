@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+const scriptExecutionMethod = "Emulation.setScriptExecutionDisabled"
+
+var jsHelp = commandHelp{
+	Description: `Tests how a page behaves without JavaScript, or without particular scripts.
+Both settings are recorded as overrides on the session; --reload applies them to
+a freshly loaded document.`,
+	Examples: []commandExample{
+		{"cdp js --session app --disable --reload", "Reload the page with JavaScript off."},
+		{`cdp js --session app --block-url "analytics|tagmanager" --reload`, "Reload without tracking scripts."},
+		{"cdp js --session app --enable", "Turn JavaScript back on."},
+	},
+}
+
+func cmdJS(args []string) error {
+	fs := newFlagSet("js", "usage: cdp js --session <name> [--disable|--enable] [--block-url REGEX] [--reload]\n\nToggles page JavaScript for no-JS testing. --disable records an\nEmulation.setScriptExecutionDisabled override (re-applied on later commands while\n'cdp overrides auto' is on; 'cdp state' reports it); --enable turns scripts back on\nand forgets it. --block-url fails script requests whose URL matches REGEX (e.g.\nthird-party tags) and keeps blocking until Ctrl+C, since interception ends with the\ncommand. --reload reloads the page afterwards so it starts from a clean document.")
+	sessionFlag := addSessionFlag(fs)
+	disable := fs.Bool("disable", false, "Disable script execution")
+	enable := fs.Bool("enable", false, "Re-enable script execution and forget the recorded override")
+	blockURL := fs.String("block-url", "", "Block script requests whose URL matches this regex until Ctrl+C")
+	reload := fs.Bool("reload", false, "Reload the page after toggling")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Timeout for connecting, toggling and reloading")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	if *enable && *disable {
+		return errors.New("use either --enable or --disable")
+	}
+	if !*enable && !*disable && *blockURL == "" {
+		fs.Usage()
+		return errors.New("pass --disable, --enable or --block-url")
+	}
+	var blockRe *regexp.Regexp
+	if *blockURL != "" {
+		if blockRe, err = compileRouteRegex(*blockURL, "--block-url"); err != nil {
+			return err
+		}
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	runCtx, stop := context.WithCancel(context.Background())
+	defer stop()
+	ctx, cancel := withCommandTimeout(runCtx, *timeout)
+	defer cancel()
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	switch {
+	case *disable:
+		if err := applyOverride(ctx, handle.client, scriptExecutionMethod, map[string]interface{}{"value": true}); err != nil {
+			return err
+		}
+		fmt.Printf("Script execution disabled for %s\n", name)
+		if !handle.session.AutoRestore && blockRe == nil {
+			fmt.Println("It lasts until this command exits (use --reload to load the page without scripts now); run 'cdp overrides auto on' to keep it for later commands.")
+		}
+	case *enable:
+		if err := handle.client.Call(ctx, scriptExecutionMethod, map[string]interface{}{"value": false}, nil); err != nil {
+			return err
+		}
+		forgetOverrides(&handle.session, scriptExecutionMethod)
+		fmt.Printf("Script execution enabled for %s\n", name)
+	}
+
+	var blocked *int64
+	if blockRe != nil {
+		unsubscribe, count, err := blockScriptRequests(ctx, handle.client, blockRe)
+		if err != nil {
+			return err
+		}
+		defer unsubscribe()
+		blocked = count
+	}
+	if *reload {
+		if err := reloadPage(ctx, handle.client); err != nil {
+			return err
+		}
+		fmt.Println("Reloaded")
+	}
+	if blockRe == nil {
+		return nil
+	}
+
+	fmt.Printf("Blocking script requests matching %s (Ctrl+C to stop)\n", blockRe)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	select {
+	case <-sigCh:
+	case <-handle.client.Done():
+		return errors.New("connection to the session closed")
+	}
+	fmt.Printf("Blocked %d script request(s)\n", atomic.LoadInt64(blocked))
+	return nil
+}
+
+// blockScriptRequests intercepts script requests only, failing those whose
+// URL matches re as BlockedByClient and continuing the rest. Interception
+// lasts until the returned func runs (or the connection closes).
+func blockScriptRequests(ctx context.Context, client *cdp.Client, re *regexp.Regexp) (func(), *int64, error) {
+	var blocked int64
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		if evt.Method != "Fetch.requestPaused" {
+			return
+		}
+		var payload fetchRequestPausedEvent
+		if err := json.Unmarshal(evt.Params, &payload); err != nil {
+			return
+		}
+		if !re.MatchString(payload.Request.URL) {
+			go continueFetchRequest(client, payload.RequestID)
+			return
+		}
+		atomic.AddInt64(&blocked, 1)
+		fmt.Fprintf(os.Stderr, "blocked %s\n", payload.Request.URL)
+		// Event handlers run on the read loop, so CDP calls must happen elsewhere.
+		go func() {
+			callCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			client.Call(callCtx, "Fetch.failRequest", map[string]interface{}{
+				"requestId":   payload.RequestID,
+				"errorReason": "BlockedByClient",
+			}, nil)
+		}()
+	})
+	if err := client.Call(ctx, "Fetch.enable", map[string]interface{}{
+		"patterns": []map[string]interface{}{{"urlPattern": "*", "resourceType": "Script", "requestStage": "Request"}},
+	}, nil); err != nil {
+		unsubscribe()
+		return nil, nil, fmt.Errorf("Fetch.enable: %w", err)
+	}
+	return func() {
+		unsubscribe()
+		disableCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		client.Call(disableCtx, "Fetch.disable", nil, nil)
+	}, &blocked, nil
+}
+
+// reloadPage reloads the tab and waits for its load event. It listens for
+// the event rather than polling document.readyState, which would need page
+// script to run.
+func reloadPage(ctx context.Context, client *cdp.Client) error {
+	if err := client.Call(ctx, "Page.enable", nil, nil); err != nil {
+		return err
+	}
+	loaded := make(chan struct{}, 1)
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		if evt.Method == "Page.loadEventFired" {
+			select {
+			case loaded <- struct{}{}:
+			default:
+			}
+		}
+	})
+	defer unsubscribe()
+	if err := client.Call(ctx, "Page.reload", nil, nil); err != nil {
+		return err
+	}
+	select {
+	case <-loaded:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("reload did not finish loading: %w", ctx.Err())
+	}
+}
+
+// scriptsDisabledRecorded reports whether the session has script execution
+// disabled among its recorded overrides.
+func scriptsDisabledRecorded(overrides []store.Override) bool {
+	disabled := false
+	for _, o := range overrides {
+		if o.Method == scriptExecutionMethod {
+			disabled, _ = o.Params["value"].(bool)
+		}
+	}
+	return disabled
+}
+
+// scriptsDisabledActive reports whether the session's recorded
+// script-execution override was re-applied when it was opened, which only
+// happens with auto-restore or --restore-overrides.
+func scriptsDisabledActive(session store.Session) bool {
+	return scriptsDisabledRecorded(session.Overrides) && (session.AutoRestore || restoreOverridesFlag)
+}
+
+// scriptsDisabledHint marks errors already explained by withScriptsDisabledHint.
+const scriptsDisabledHint = "script execution is disabled for this session"
+
+// withScriptsDisabledHint explains WebNav failures on a session whose
+// recorded script-execution override was re-applied when it was opened.
+func withScriptsDisabledHint(client *cdp.Client, err error) error {
+	if err == nil || strings.Contains(err.Error(), scriptsDisabledHint) {
+		return err
+	}
+	session := lookupSession(client)
+	if session == nil || !scriptsDisabledActive(*session) {
+		return err
+	}
+	return fmt.Errorf("%w (%s; run 'cdp js --session %s --enable')", err, scriptsDisabledHint, session.Name)
+}
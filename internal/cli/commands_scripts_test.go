@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+func TestWithScriptsDisabledHint(t *testing.T) {
+	client := &cdp.Client{}
+	session := &store.Session{
+		Name:        "manager",
+		AutoRestore: true,
+		Overrides:   []store.Override{{Method: scriptExecutionMethod, Params: map[string]interface{}{"value": true}}},
+	}
+	registerSession(client, session)
+	defer unregisterSession(client)
+
+	base := errors.New("WebNav is not available")
+	err := withScriptsDisabledHint(client, base)
+	if !errors.Is(err, base) || !strings.Contains(err.Error(), "cdp js --session manager --enable") {
+		t.Fatalf("missing hint: %v", err)
+	}
+	if again := withScriptsDisabledHint(client, err); again.Error() != err.Error() {
+		t.Fatalf("hint added twice: %v", again)
+	}
+
+	// Without auto-restore the recorded override isn't in effect.
+	session.AutoRestore = false
+	if err := withScriptsDisabledHint(client, base); err != base {
+		t.Fatalf("hinted without the override applied: %v", err)
+	}
+	session.AutoRestore = true
+	session.Overrides = append(session.Overrides, store.Override{Method: scriptExecutionMethod, Params: map[string]interface{}{"value": false}})
+	if err := withScriptsDisabledHint(client, base); err != base {
+		t.Fatalf("hinted after scripts were re-enabled: %v", err)
+	}
+
+	// cdp state uses the same condition: a recorded override that wasn't
+	// re-applied leaves scripts running.
+	disabled := store.Session{Overrides: []store.Override{{Method: scriptExecutionMethod, Params: map[string]interface{}{"value": true}}}}
+	if scriptsDisabledActive(disabled) {
+		t.Fatal("override without auto-restore reported as active")
+	}
+	disabled.AutoRestore = true
+	if !scriptsDisabledActive(disabled) {
+		t.Fatal("auto-restored override not reported as active")
+	}
+
+	if verdict, _ := stateVerdict(pageState{ScriptsDisabled: true, HasFocus: true}); !strings.Contains(verdict, "script execution is disabled") {
+		t.Fatalf("state verdict ignores disabled scripts: %q", verdict)
+	}
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var selectHelp = commandHelp{
+	Description: `Chooses an option in a native <select> by value, or by visible label when no
+value matches, and fires change. --index treats the argument as an option
+index. --keyboard drives the control with key presses for pages that ignore
+programmatic changes.`,
+	Examples: []commandExample{
+		{`cdp select --session app "select#country" DE`, "Pick the option with value DE."},
+		{`cdp select --session app "select[name=size]" Large`, "Pick the option labelled Large."},
+		{`cdp select --session app "select.month" 0 --index --keyboard`, "Pick the first option using the keyboard."},
+	},
+}
+
+func cmdSelect(args []string) error {
+	fs := newFlagSet("select", "usage: cdp select --session <name> \"select.selector\" <value|label> [--index] [--keyboard]\n\nPicks an option in a native <select>, matched by value, then visible label.\nWith --keyboard it focuses the select and presses ArrowUp/ArrowDown then Enter\nusing real key events, for pages that only commit on keyboard interaction.")
+	sessionFlag := addSessionFlag(fs)
+	byIndex := fs.Bool("index", false, "Treat the option argument as a 0-based option index")
+	keyboard := fs.Bool("keyboard", false, "Navigate with real ArrowUp/ArrowDown + Enter key events instead of setting the value")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) < 2 {
+		return usagef("usage: cdp select --session <name> \"select.selector\" <value|label>")
+	}
+	if len(pos) > 2 {
+		return usagef("unexpected argument: %s", pos[2])
+	}
+	selector, option := pos[0], pos[1]
+	if err := rejectUnsupportedSelector(selector, "select", false); err != nil {
+		return err
+	}
+	if *byIndex {
+		if n, err := strconv.Atoi(option); err != nil || n < 0 {
+			return fmt.Errorf("invalid option index %q", option)
+		}
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	value, err := handle.client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (!el) throw new Error("no element matched selector: " + %s);
+        if (el.tagName !== "SELECT") throw new Error("element is a <" + el.tagName.toLowerCase() + ">, not a <select>");
+        const opts = Array.from(el.options);
+        const want = %s;
+        let idx = -1;
+        if (%t) {
+            idx = Number(want);
+        } else {
+            idx = opts.findIndex(o => o.value === want);
+            if (idx < 0) idx = opts.findIndex(o => o.text.trim() === want.trim());
+        }
+        if (idx < 0 || idx >= opts.length) throw new Error("no option matches " + JSON.stringify(want));
+        if (opts[idx].disabled) throw new Error("option " + JSON.stringify(opts[idx].text.trim()) + " is disabled");
+        return {target: idx, current: el.selectedIndex, label: opts[idx].text.trim(), value: opts[idx].value};
+    })()`, strconv.Quote(selector), strconv.Quote(selector), strconv.Quote(option), *byIndex))
+	if err != nil {
+		return err
+	}
+	info, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected select lookup result %T", value)
+	}
+	target := int(info["target"].(float64))
+	label, _ := info["label"].(string)
+
+	if !*keyboard {
+		expression := fmt.Sprintf(`(() => {
+            const el = document.querySelector(%s);
+            el.selectedIndex = %d;
+            el.dispatchEvent(new Event("input", {bubbles: true}));
+            el.dispatchEvent(new Event("change", {bubbles: true}));
+            return el.selectedIndex;
+        })()`, strconv.Quote(selector), target)
+		if _, err := handle.client.Evaluate(ctx, expression); err != nil {
+			return err
+		}
+		fmt.Printf("Selected %q in %s\n", label, selector)
+		return nil
+	}
+
+	presses, err := selectByKeyboard(ctx, handle.client, selector, target)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Selected %q in %s by keyboard (%d arrow presses + Enter)\n", label, selector, presses)
+	return nil
+}
+
+// selectByKeyboard focuses the select and presses ArrowDown/ArrowUp until the
+// target index is selected, re-reading selectedIndex after each press since
+// the browser skips disabled options. It finishes with Enter.
+func selectByKeyboard(ctx context.Context, client *cdp.Client, selector string, target int) (int, error) {
+	readIndex := func() (int, error) {
+		value, err := client.Evaluate(ctx, fmt.Sprintf(`(() => { const el = document.querySelector(%s); return el ? el.selectedIndex : -2; })()`, strconv.Quote(selector)))
+		if err != nil {
+			return 0, err
+		}
+		n, _ := value.(float64)
+		if n == -2 {
+			return 0, fmt.Errorf("select %s disappeared", selector)
+		}
+		return int(n), nil
+	}
+
+	if err := client.Call(ctx, "Page.bringToFront", map[string]interface{}{}, nil); err != nil {
+		return 0, err
+	}
+	if _, err := client.Evaluate(ctx, fmt.Sprintf(`document.querySelector(%s).focus()`, strconv.Quote(selector))); err != nil {
+		return 0, err
+	}
+	down, err := parseKeySpec("ArrowDown")
+	if err != nil {
+		return 0, err
+	}
+	up, err := parseKeySpec("ArrowUp")
+	if err != nil {
+		return 0, err
+	}
+	enter, err := parseKeySpec("Enter")
+	if err != nil {
+		return 0, err
+	}
+
+	presses := 0
+	current, err := readIndex()
+	if err != nil {
+		return 0, err
+	}
+	for current != target {
+		key := down
+		if current > target {
+			key = up
+		}
+		if err := pressKey(ctx, client, key); err != nil {
+			return presses, err
+		}
+		presses++
+		next, err := readIndex()
+		if err != nil {
+			return presses, err
+		}
+		if next == current {
+			return presses, fmt.Errorf("keyboard navigation stuck at option %d (target %d); the page may intercept arrow keys", current, target)
+		}
+		current = next
+	}
+	if err := pressKey(ctx, client, enter); err != nil {
+		return presses, err
+	}
+	return presses, nil
+}
+
+// pressKey sends a keyDown/keyUp pair through Input.dispatchKeyEvent.
+func pressKey(ctx context.Context, client *cdp.Client, spec keySpec) error {
+	downType := "keyDown"
+	if spec.modifiers != 0 || spec.text == "" {
+		downType = "rawKeyDown"
+	}
+	if err := client.Call(ctx, "Input.dispatchKeyEvent", keyDispatchParams(downType, spec), nil); err != nil {
+		return err
+	}
+	return client.Call(ctx, "Input.dispatchKeyEvent", keyDispatchParams("keyUp", spec), nil)
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var statusHelp = commandHelp{
+	Description: `Connects to the session's tab and prints its current URL and title. A quick way
+to check that a session still points at a live tab after a browser restart.`,
+	Examples: []commandExample{
+		{"cdp status --session app", "Show where 'app' is now."},
+		{"cdp status", "Check the session from CDP_SESSION_NAME."},
+		{"cdp status --session app --timeout 2s", "Fail fast when the browser is gone."},
+	},
+}
+
+func cmdStatus(args []string) error {
+	fs := newFlagSet("status", "usage: cdp status --session <name>")
+	sessionFlag := addSessionFlag(fs)
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	if err := refreshSessionLocation(ctx, handle); err != nil {
+		return err
+	}
+	fmt.Printf("Session %s: %s (%s)\n", name, handle.session.Title, handle.session.URL)
+	return nil
+}
+
+// refreshSessionLocation reads the live URL/title from the page so the stored
+// session reflects manual navigation (persisted when the handle closes).
+func refreshSessionLocation(ctx context.Context, h *sessionHandle) error {
+	value, err := h.client.Evaluate(ctx, `(() => ({url: location.href, title: document.title}))()`)
+	if err != nil {
+		return err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected status result type %T", value)
+	}
+	if url, _ := m["url"].(string); url != "" {
+		h.session.URL = url
+	}
+	if title, ok := m["title"].(string); ok {
+		h.session.Title = title
+	}
+	return nil
+}
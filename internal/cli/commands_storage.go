@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var storageHelp = commandHelp{
+	Description: `Dumps what the page has stored, for debugging logins and feature flags: cookies
+from the browser plus localStorage and sessionStorage read in the page.`,
+	Examples: []commandExample{
+		{"cdp storage --session app", "Dump the top frame's storage."},
+		{"cdp storage --session app --all-frames", "Include every iframe origin."},
+		{"cdp storage --session app --pretty=false", "Print compact JSON for jq."},
+	},
+}
+
+func cmdStorage(args []string) error {
+	fs := newFlagSet("storage", "usage: cdp storage --session <name> [--all-frames]\n\nPrints cookies, localStorage and sessionStorage as JSON keyed by origin. Storage is\npartitioned by origin, so --all-frames also walks the frame tree and dumps the\nstorage of every iframe origin (payment and embed widgets keep their state there).\nFrames with opaque origins (sandboxed, about:blank) are skipped.")
+	sessionFlag := addSessionFlag(fs)
+	allFrames := fs.Bool("all-frames", false, "Include the origins of every frame, not just the main frame")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	var tree struct {
+		FrameTree frameTreeNode `json:"frameTree"`
+	}
+	if err := handle.client.Call(ctx, "Page.getFrameTree", nil, &tree); err != nil {
+		return err
+	}
+	frames := flattenFrames(tree.FrameTree, nil)
+	if !*allFrames {
+		frames = frames[:1]
+	}
+	origins, byOrigin := groupFramesByOrigin(frames)
+	if err := handle.client.Call(ctx, "DOMStorage.enable", nil, nil); err != nil {
+		return err
+	}
+	result := make(map[string]originStorage, len(origins))
+	for _, origin := range origins {
+		result[origin] = collectOriginStorage(ctx, handle.client, byOrigin[origin])
+	}
+	output, err := jsonStyle.JSON(result, *pretty, -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+// frameTreeNode mirrors Page.getFrameTree's result.
+type frameTreeNode struct {
+	Frame       storageFrame    `json:"frame"`
+	ChildFrames []frameTreeNode `json:"childFrames"`
+}
+
+type storageFrame struct {
+	ID             string `json:"id"`
+	URL            string `json:"url"`
+	SecurityOrigin string `json:"securityOrigin"`
+}
+
+// flattenFrames appends node's frames to out, parents before children.
+func flattenFrames(node frameTreeNode, out []storageFrame) []storageFrame {
+	out = append(out, node.Frame)
+	for _, child := range node.ChildFrames {
+		out = flattenFrames(child, out)
+	}
+	return out
+}
+
+// groupFramesByOrigin groups frames by security origin in first-seen order,
+// dropping opaque origins, which have no storage of their own.
+func groupFramesByOrigin(frames []storageFrame) ([]string, map[string][]storageFrame) {
+	var origins []string
+	byOrigin := make(map[string][]storageFrame)
+	for _, f := range frames {
+		origin := f.SecurityOrigin
+		if origin == "" || origin == "null" || !strings.Contains(origin, "://") {
+			continue
+		}
+		if _, ok := byOrigin[origin]; !ok {
+			origins = append(origins, origin)
+		}
+		byOrigin[origin] = append(byOrigin[origin], f)
+	}
+	return origins, byOrigin
+}
+
+// originStorage is the storage of one origin. Errors lists the parts that
+// could not be read, so one unreachable frame doesn't hide the others.
+type originStorage struct {
+	Frames         []string                 `json:"frames"`
+	Cookies        []map[string]interface{} `json:"cookies"`
+	LocalStorage   map[string]string        `json:"localStorage"`
+	SessionStorage map[string]string        `json:"sessionStorage"`
+	Errors         []string                 `json:"errors,omitempty"`
+}
+
+func collectOriginStorage(ctx context.Context, client *cdp.Client, frames []storageFrame) originStorage {
+	out := originStorage{Cookies: []map[string]interface{}{}}
+	urls := make([]string, 0, len(frames))
+	for _, f := range frames {
+		out.Frames = append(out.Frames, f.URL)
+		urls = append(urls, f.URL)
+	}
+	var cookies struct {
+		Cookies []map[string]interface{} `json:"cookies"`
+	}
+	if err := client.Call(ctx, "Network.getCookies", map[string]interface{}{"urls": urls}, &cookies); err != nil {
+		out.Errors = append(out.Errors, "cookies: "+err.Error())
+	} else if cookies.Cookies != nil {
+		out.Cookies = cookies.Cookies
+	}
+
+	// With storage partitioning an iframe's storage is keyed by more than its
+	// origin; ask for the frame's storage key and fall back to the origin.
+	storageID := map[string]interface{}{"securityOrigin": frames[0].SecurityOrigin}
+	var key struct {
+		StorageKey string `json:"storageKey"`
+	}
+	if err := client.Call(ctx, "Page.getStorageKey", map[string]interface{}{"frameId": frames[0].ID}, &key); err == nil && key.StorageKey != "" {
+		storageID = map[string]interface{}{"storageKey": key.StorageKey}
+	}
+	read := func(local bool) map[string]string {
+		id := map[string]interface{}{"isLocalStorage": local}
+		for k, v := range storageID {
+			id[k] = v
+		}
+		var items struct {
+			Entries [][]string `json:"entries"`
+		}
+		if err := client.Call(ctx, "DOMStorage.getDOMStorageItems", map[string]interface{}{"storageId": id}, &items); err != nil {
+			kind := map[bool]string{true: "localStorage", false: "sessionStorage"}[local]
+			out.Errors = append(out.Errors, kind+": "+err.Error())
+			return nil
+		}
+		entries := make(map[string]string, len(items.Entries))
+		for _, entry := range items.Entries {
+			if len(entry) == 2 {
+				entries[entry[0]] = entry[1]
+			}
+		}
+		return entries
+	}
+	out.LocalStorage = read(true)
+	out.SessionStorage = read(false)
+	return out
+}
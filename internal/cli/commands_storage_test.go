@@ -0,0 +1,36 @@
+package cli
+
+import "testing"
+
+func TestGroupFramesByOrigin(t *testing.T) {
+	tree := frameTreeNode{
+		Frame: storageFrame{ID: "main", URL: "https://shop.example/cart", SecurityOrigin: "https://shop.example"},
+		ChildFrames: []frameTreeNode{
+			{
+				Frame: storageFrame{ID: "pay", URL: "https://pay.example/widget", SecurityOrigin: "https://pay.example"},
+				ChildFrames: []frameTreeNode{
+					{Frame: storageFrame{ID: "pay-inner", URL: "https://pay.example/3ds", SecurityOrigin: "https://pay.example"}},
+				},
+			},
+			{Frame: storageFrame{ID: "sandbox", URL: "about:blank", SecurityOrigin: "null"}},
+			{Frame: storageFrame{ID: "ads", URL: "https://ads.example/", SecurityOrigin: "https://ads.example"}},
+		},
+	}
+	frames := flattenFrames(tree, nil)
+	if len(frames) != 5 || frames[0].ID != "main" || frames[2].ID != "pay-inner" {
+		t.Fatalf("unexpected frame order %+v", frames)
+	}
+	origins, byOrigin := groupFramesByOrigin(frames)
+	want := []string{"https://shop.example", "https://pay.example", "https://ads.example"}
+	if len(origins) != len(want) {
+		t.Fatalf("origins = %v, want %v", origins, want)
+	}
+	for i := range want {
+		if origins[i] != want[i] {
+			t.Fatalf("origins = %v, want %v", origins, want)
+		}
+	}
+	if len(byOrigin["https://pay.example"]) != 2 {
+		t.Fatalf("pay.example frames = %+v", byOrigin["https://pay.example"])
+	}
+}
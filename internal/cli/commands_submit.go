@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+var submitHelp = commandHelp{
+	Description: `Submits a form the way a user would, by default with requestSubmit() so that
+validation and submit handlers run. When validation blocks the submit, the
+invalid fields are reported. --containing finds the form around a field.`,
+	Examples: []commandExample{
+		{`cdp submit --session app "form#login"`, "Submit the login form."},
+		{`cdp submit --session app --containing "input[name=email]" --wait-nav`, "Submit the email field's form and wait for the next page."},
+		{`cdp submit --session app "form.search" --method enter`, "Submit by pressing Enter in the form."},
+	},
+}
+
+func cmdSubmit(args []string) error {
+	fs := newFlagSet("submit", "usage: cdp submit --session <name> [\"form.selector\" | --containing \"input[name=email]\"] [--method requestSubmit|submit|enter] [--wait-nav | --submit-wait-ms N]\n\nSubmits a form without clicking a button. requestSubmit (the default) runs constraint\nvalidation and submit handlers, falling back to submit() where unsupported; submit()\nskips both; enter presses Enter in the form's first text field. Invalid fields are\nlisted, and the command fails when validation blocked the submission.")
+	sessionFlag := addSessionFlag(fs)
+	containing := fs.String("containing", "", "Submit the form containing this element")
+	method := fs.String("method", "requestSubmit", "How to submit: requestSubmit, submit, or enter")
+	waitNav := fs.Bool("wait-nav", false, "Wait for the page to navigate (or change route) and finish loading")
+	submitWaitMS := fs.Int("submit-wait-ms", 700, "Without --wait-nav, wait N ms after submitting before returning (0 disables)")
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	selector := ""
+	switch {
+	case len(pos) > 1:
+		return usagef("unexpected argument: %s", pos[1])
+	case len(pos) == 1 && *containing != "":
+		return usagef("unexpected argument: %s (use either a form selector or --containing)", pos[0])
+	case len(pos) == 1:
+		selector = pos[0]
+	case *containing == "":
+		selector = "form"
+	}
+	switch *method {
+	case "requestSubmit", "submit", "enter":
+	default:
+		return fmt.Errorf("invalid --method %q (expected requestSubmit, submit, or enter)", *method)
+	}
+	for _, sel := range []string{selector, *containing} {
+		if sel == "" {
+			continue
+		}
+		if err := rejectUnsupportedSelector(sel, "submit", false); err != nil {
+			return err
+		}
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	// Subscribe before submitting so a fast navigation isn't missed.
+	var navigated <-chan string
+	if *waitNav {
+		urls, unsubscribe, err := watchMainFrameURL(ctx, handle.client)
+		if err != nil {
+			return err
+		}
+		defer unsubscribe()
+		navigated = urls
+	}
+
+	value, err := handle.client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const selector = %s, containing = %s;
+        let form;
+        if (containing) {
+            const inner = document.querySelector(containing);
+            if (!inner) throw new Error("no element matched --containing: " + containing);
+            form = inner.form || inner.closest("form");
+            if (!form) throw new Error(containing + " is not inside a <form>");
+        } else {
+            form = document.querySelector(selector);
+            if (!form) throw new Error("no element matched selector: " + selector);
+            if (form.tagName !== "FORM") throw new Error("element is a <" + form.tagName.toLowerCase() + ">, not a <form> (use --containing to find its form)");
+        }
+        %s
+        const formLabel = form.id ? "form#" + form.id : form.name ? "form[name=" + form.name + "]" : "form[action=" + (form.getAttribute("action") || "") + "]";
+        const invalid = Array.from(form.elements).filter(c => c.validity && c.willValidate && !c.validity.valid).map(describe);
+        let used = %s;
+        if (used === "requestSubmit" && typeof form.requestSubmit !== "function") used = "submit";
+        const out = {form: formLabel, used, invalid, blocked: false};
+        if (used === "requestSubmit") {
+            out.blocked = invalid.length > 0 && !form.noValidate;
+            form.requestSubmit();
+        } else if (used === "submit") {
+            form.submit();
+        } else {
+            const skip = ["hidden", "button", "submit", "reset", "checkbox", "radio", "file", "image", "range", "color"];
+            const field = Array.from(form.elements).find(c => c.tagName === "INPUT" && !skip.includes(c.type) && !c.disabled && !c.readOnly);
+            if (!field) throw new Error(formLabel + " has no text field to press Enter in (try --method requestSubmit)");
+            field.focus();
+            out.field = label(field, 0);
+        }
+        return out;
+    })()`, strconv.Quote(selector), strconv.Quote(*containing), fieldValidityJS(), strconv.Quote(*method)))
+	if err != nil {
+		return err
+	}
+	result, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected submit result type %T", value)
+	}
+	form, _ := result["form"].(string)
+	used, _ := result["used"].(string)
+	blocked, _ := result["blocked"].(bool)
+	invalid := parseFieldValidity(result["invalid"])
+
+	if used == "enter" {
+		enter, err := parseKeySpec("Enter")
+		if err != nil {
+			return err
+		}
+		if err := pressKey(ctx, handle.client, enter); err != nil {
+			return err
+		}
+		field, _ := result["field"].(string)
+		used = "Enter in " + field
+	}
+	if blocked {
+		fmt.Printf("Submission of %s blocked by constraint validation:\n", form)
+		printInvalidFields(invalid)
+		return fmt.Errorf("%d field(s) invalid", len(invalid))
+	}
+	note := ""
+	if *method == "requestSubmit" && used == "submit" {
+		note = " (requestSubmit unsupported)"
+	}
+	fmt.Printf("Submitted %s via %s%s\n", form, used, note)
+	if len(invalid) > 0 {
+		// submit() and Enter in a novalidate form go ahead regardless.
+		fmt.Fprintf(os.Stderr, "warning: submitted with %d invalid field(s):\n", len(invalid))
+		for _, f := range invalid {
+			fmt.Fprintf(os.Stderr, "  %s (%s) %s\n", f.Field, strings.Join(f.failing(), ","), f.Message)
+		}
+	}
+
+	switch {
+	case navigated != nil:
+		select {
+		case url := <-navigated:
+			fmt.Printf("Navigated: %s\n", url)
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return errors.New("timeout waiting for navigation after submit")
+			}
+			return ctx.Err()
+		}
+		return waitForReadyState(ctx, handle.client, 200*time.Millisecond)
+	case *submitWaitMS > 0:
+		time.Sleep(time.Duration(*submitWaitMS) * time.Millisecond)
+	}
+	return nil
+}
+
+func printInvalidFields(fields []fieldValidity) {
+	fmt.Printf("%-30s %-30s %s\n", "FIELD", "FAILING", "MESSAGE")
+	for _, f := range fields {
+		fmt.Printf("%-30s %-30s %s\n", abbreviate(f.Field, 30), abbreviate(strings.Join(f.failing(), ","), 30), f.Message)
+	}
+}
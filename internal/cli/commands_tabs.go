@@ -4,19 +4,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
-	"github.com/veilm/cdp-cli/internal/format"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var tabsHelp = commandHelp{
+	Description: `Works on the browser's tabs directly, over /json and Target.*. The close
+commands ask for confirmation unless --yes is given, skip tabs that saved sessions
+still use unless --force, and can preview their choices with --dry-run.`,
+	Examples: []commandExample{
+		{"cdp tabs list --port 9222 --plain", "List tabs as text."},
+		{"cdp tabs open https://example.com https://example.org --json", "Open two tabs and print their targets."},
+		{"cdp tabs switch docs --rebind app", "Activate the first tab matching docs and bind 'app' to it."},
+		{"cdp tabs close-others --session app --keep mail --dry-run", "Show which tabs would close."},
+		{"cdp tabs gc --max-age 2h --yes", "Close abandoned tabs older than two hours."},
+	},
+}
+
 func cmdTabs(args []string) error {
 	if len(args) == 0 {
 		printTabsUsage()
-		return errors.New("usage: cdp tabs <command> (list|switch|open|close)")
+		return usagef("usage: cdp tabs <command> (list|switch|open|close|close-others|gc)")
 	}
 	if isHelpArg(args[0]) {
 		printTabsUsage()
@@ -31,40 +46,46 @@ func cmdTabs(args []string) error {
 		return cmdTabsOpen(args[1:])
 	case "close":
 		return cmdTabsClose(args[1:])
+	case "close-others":
+		return cmdTabsCloseOthers(args[1:])
+	case "gc":
+		return cmdTabsGC(args[1:])
 	default:
-		return fmt.Errorf("unknown tabs command %q (expected list, switch, open, or close)", args[0])
+		return usagef("unknown tabs command %q (expected list, switch, open, close, close-others, or gc)", args[0])
 	}
 }
 
 func printTabsUsage() {
-	fmt.Println("usage: cdp tabs <command> (list|switch|open|close)")
+	fmt.Println("usage: cdp tabs <command> (list|switch|open|close|close-others|gc)")
 	fmt.Println("Commands:")
 	fmt.Println("  list    List available tabs from a remote debugging port")
 	fmt.Println("  switch  Activate a tab by index, id, or pattern")
-	fmt.Println("  open    Open a new tab")
+	fmt.Println("  open    Open new tabs (URLs or --file)")
 	fmt.Println("  close   Close a tab by reference or by saved session name")
+	fmt.Println("  close-others  Close every tab except a session's own (and --keep matches)")
+	fmt.Println("  gc      Close old tabs that no saved session points at")
 	fmt.Println("Run 'cdp tabs <command> --help' for details.")
 }
 
 func cmdTabsList(args []string) error {
 	fs := newFlagSet("tabs list", "usage: cdp tabs list [--host --port] [--plain] [--pretty=false]")
-	host := fs.String("host", "127.0.0.1", "DevTools host")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
 	port := fs.Int("port", portDefault(9222), "DevTools port")
 	plain := fs.Bool("plain", false, "Output plain text table instead of JSON")
 	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	pos, err := parseInterspersed(fs, args)
 	if err != nil {
 		return err
 	}
 	if len(pos) > 0 {
-		return fmt.Errorf("unexpected argument: %s", pos[0])
+		return usagef("unexpected argument: %s", pos[0])
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
-	tabs, err := fetchTabs(ctx, *host, *port)
+	tabs, err := fetchTabs(ctx, dialer(), *host, *port)
 	if err != nil {
 		return err
 	}
@@ -85,7 +106,7 @@ func cmdTabsList(args []string) error {
 		return nil
 	}
 
-	output, err := format.JSON(tabs, *pretty, -1)
+	output, err := jsonStyle.JSON(tabs, *pretty, -1)
 	if err != nil {
 		return err
 	}
@@ -94,23 +115,41 @@ func cmdTabsList(args []string) error {
 }
 
 func cmdTabsSwitch(args []string) error {
-	fs := newFlagSet("tabs switch", "usage: cdp tabs switch <index|id|pattern>")
-	host := fs.String("host", "127.0.0.1", "DevTools host")
+	fs := newFlagSet("tabs switch", "usage: cdp tabs switch <index|id|pattern> [--rebind <session>]")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
 	port := fs.Int("port", portDefault(9222), "DevTools port")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	rebind := fs.String("rebind", "", "Point this saved session at the activated tab")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	pos, err := parseInterspersed(fs, args)
 	if err != nil {
 		return err
 	}
 	if len(pos) != 1 {
-		return errors.New("usage: cdp tabs switch <index|id|pattern>")
+		return usagef("usage: cdp tabs switch <index|id|pattern>")
 	}
 	targetRef := pos[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	var st *store.Store
+	var session store.Session
+	if *rebind != "" {
+		st, err = store.Load()
+		if err != nil {
+			return err
+		}
+		var ok bool
+		session, ok = st.Get(*rebind)
+		if !ok {
+			return fmt.Errorf("unknown session %q", *rebind)
+		}
+		if session.Host != *host || session.Port != *port {
+			return fmt.Errorf("session %s is on %s:%d, not %s:%d (pass --host/--port to match)", *rebind, session.Host, session.Port, *host, *port)
+		}
+	}
+
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
-	tabs, err := fetchTabs(ctx, *host, *port)
+	tabs, err := fetchTabs(ctx, dialer(), *host, *port)
 	if err != nil {
 		return err
 	}
@@ -123,7 +162,7 @@ func cmdTabsSwitch(args []string) error {
 		return err
 	}
 
-	if err := cdp.ActivateTarget(ctx, *host, *port, tab.ID); err != nil {
+	if err := dialer().ActivateTarget(ctx, *host, *port, tab.ID); err != nil {
 		return err
 	}
 	title := tab.Title
@@ -131,67 +170,151 @@ func cmdTabsSwitch(args []string) error {
 		title = "<untitled>"
 	}
 	fmt.Printf("Activated tab: %s (%s)\n", abbreviate(title, 60), tab.URL)
+	if st == nil {
+		return nil
+	}
+	if tab.WebSocket != "" {
+		d, err := sessionDialer(session)
+		if err != nil {
+			return err
+		}
+		session.WebSocketURL = rewriteWebSocketURL(tab.WebSocket, session.Host, session.Port, d.Secure)
+	}
+	session.TargetID = tab.ID
+	session.URL = tab.URL
+	session.Title = tab.Title
+	session.Type = tab.Type
+	session.LastTargetInfo = tab.Description
+	if err := st.Set(session); err != nil {
+		return err
+	}
+	fmt.Printf("Rebound session %s -> %s\n", session.Name, tab.ID)
 	return nil
 }
 
 func cmdTabsOpen(args []string) error {
-	fs := newFlagSet("tabs open", "usage: cdp tabs open <url>")
-	host := fs.String("host", "127.0.0.1", "DevTools host")
+	fs := newFlagSet("tabs open", "usage: cdp tabs open <url>... [--file urls.txt] [--activate=false] [--json | --print-ws]")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
 	port := fs.Int("port", portDefault(9222), "DevTools port")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	file := fs.String("file", "", "Read URLs from this file, one per line (blank lines and # comments are skipped)")
 	activate := fs.Bool("activate", true, "Activate the tab after opening")
-	pageURL, flagArgs, err := splitTabsOpenArgs(args)
+	jsonOut := fs.Bool("json", false, "Print the opened targets' info (id, url, webSocketDebuggerUrl, ...) as a JSON array")
+	printWS := fs.Bool("print-ws", false, "Print only the webSocketDebuggerUrl of each opened tab")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Timeout per tab")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pageURLs, flagArgs, err := splitTabsOpenArgs(args)
 	if err != nil {
 		return err
 	}
-	fs.Parse(flagArgs)
+	if err := parseFlags(fs, flagArgs); err != nil {
+		return err
+	}
 	if fs.NArg() != 0 {
-		return errors.New("usage: cdp tabs open <url>")
+		return usagef("usage: cdp tabs open <url>... [--file urls.txt]")
+	}
+	if *file != "" {
+		fromFile, err := readURLList(*file)
+		if err != nil {
+			return err
+		}
+		pageURLs = append(pageURLs, fromFile...)
+	}
+	for i, pageURL := range pageURLs {
+		pageURLs[i] = strings.TrimSpace(pageURL)
+		if pageURLs[i] == "" {
+			return errors.New("url cannot be empty")
+		}
+	}
+	if len(pageURLs) == 0 {
+		return usagef("usage: cdp tabs open <url>... [--file urls.txt]")
 	}
-	pageURL = strings.TrimSpace(pageURL)
-	if pageURL == "" {
-		return errors.New("url cannot be empty")
+	if *jsonOut && *printWS {
+		return errors.New("use either --json or --print-ws, not both")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
-	defer cancel()
+	opened := make([]cdp.TargetInfo, 0, len(pageURLs))
+	failed := 0
+	for _, pageURL := range pageURLs {
+		ctx, cancel := commandContext(*timeout)
+		tab, err := openTab(ctx, *host, *port, pageURL, *activate)
+		cancel()
+		if err != nil {
+			if len(pageURLs) == 1 {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "failed to open %s: %v\n", pageURL, err)
+			failed++
+			continue
+		}
+		if tab.WebSocket != "" {
+			tab.WebSocket = rewriteWebSocketURL(tab.WebSocket, *host, *port, dialer().Secure)
+		}
+		opened = append(opened, tab)
+		switch {
+		case *jsonOut:
+		case *printWS:
+			fmt.Println(tab.WebSocket)
+		case len(pageURLs) > 1:
+			fmt.Printf("%s\t%s\n", tab.ID, tab.URL)
+		case *activate:
+			fmt.Printf("Opened and activated tab: %s (%s)\n", abbreviate(tabTitle(tab), 60), tab.URL)
+		default:
+			fmt.Printf("Opened tab: %s (%s)\n", abbreviate(tabTitle(tab), 60), tab.URL)
+		}
+	}
+	if *jsonOut && len(opened) > 0 {
+		// Always an array, so scripts parse one URL and many the same way.
+		out, err := jsonStyle.JSON(opened, *pretty, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tab(s) failed to open", failed, len(pageURLs))
+	}
+	return nil
+}
 
-	tab, err := cdp.CreateTarget(ctx, *host, *port, pageURL)
+func openTab(ctx context.Context, host string, port int, pageURL string, activate bool) (cdp.TargetInfo, error) {
+	tab, err := dialer().CreateTarget(ctx, host, port, pageURL)
 	if err != nil {
-		return err
+		return cdp.TargetInfo{}, err
 	}
 	if tab.URL == "" {
 		tab.URL = pageURL
 	}
-	title := tab.Title
-	if strings.TrimSpace(title) == "" {
-		title = "<untitled>"
-	}
-	if *activate {
-		if err := cdp.ActivateTarget(ctx, *host, *port, tab.ID); err != nil {
-			return err
+	if activate {
+		if err := dialer().ActivateTarget(ctx, host, port, tab.ID); err != nil {
+			return tab, err
 		}
-		fmt.Printf("Opened and activated tab: %s (%s)\n", abbreviate(title, 60), tab.URL)
-		return nil
 	}
-	fmt.Printf("Opened tab: %s (%s)\n", abbreviate(title, 60), tab.URL)
-	return nil
+	return tab, nil
 }
 
 func cmdTabsClose(args []string) error {
-	fs := newFlagSet("tabs close", "usage: cdp tabs close <index|id|pattern> [--host --port]\nor:    cdp tabs close --session <name>")
-	host := fs.String("host", "127.0.0.1", "DevTools host")
+	fs := newFlagSet("tabs close", "usage: cdp tabs close <index|id|pattern> [--host --port] [--force]\nor:    cdp tabs close --all <pattern> [--dry-run] [--yes] [--force]\nor:    cdp tabs close --session <name>")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
 	port := fs.Int("port", portDefault(9222), "DevTools port")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	sessionName := fs.String("session", "", "Close tab by saved session name")
+	allMatching := fs.Bool("all-matching", false, "Close every tab whose URL or title contains the pattern")
+	fs.BoolVar(allMatching, "all", false, "Alias for --all-matching")
+	dryRun := fs.Bool("dry-run", false, "With --all, list the matching tabs without closing them")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt for --all-matching")
+	force := fs.Bool("force", false, "Close tabs even if a saved session points at them")
 	pos, err := parseInterspersed(fs, args)
 	if err != nil {
 		return err
 	}
 
 	if *sessionName != "" {
-		if len(pos) != 0 {
-			return errors.New("usage: cdp tabs close --session <name>")
+		if len(pos) != 0 || *allMatching {
+			return usagef("usage: cdp tabs close --session <name>")
 		}
 		st, err := store.Load()
 		if err != nil {
@@ -201,10 +324,14 @@ func cmdTabsClose(args []string) error {
 		if !ok {
 			return fmt.Errorf("unknown session %q", *sessionName)
 		}
-		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		d, err := sessionDialer(session)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := commandContext(*timeout)
 		defer cancel()
 
-		client, updated, err := attachSession(ctx, session)
+		client, updated, err := attachSession(ctx, d, session)
 		if err != nil {
 			return err
 		}
@@ -222,37 +349,133 @@ func cmdTabsClose(args []string) error {
 	}
 
 	if len(pos) != 1 {
-		return errors.New("usage: cdp tabs close <index|id|pattern>")
+		if *allMatching {
+			return usagef("usage: cdp tabs close --all <pattern>")
+		}
+		return usagef("usage: cdp tabs close <index|id|pattern>")
 	}
 	targetRef := pos[0]
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
-	tabs, err := fetchTabs(ctx, *host, *port)
+	tabs, err := fetchTabs(ctx, dialer(), *host, *port)
 	if err != nil {
 		return err
 	}
 	if len(tabs) == 0 {
 		return errors.New("no tabs available (use 'cdp tabs list' to double-check)")
 	}
-	tab, err := matchTab(tabs, targetRef)
+
+	if *dryRun && !*allMatching {
+		return errors.New("--dry-run requires --all")
+	}
+	matches, err := matchTabs(tabs, targetRef, *allMatching)
 	if err != nil {
 		return err
 	}
-	if err := cdp.CloseTarget(ctx, *host, *port, tab.ID); err != nil {
-		return err
+	if !*allMatching {
+		tab := matches[0]
+		if owners := sessionsForTarget(st, *host, *port, tab.ID); len(owners) > 0 && !*force {
+			return fmt.Errorf("tab is used by session %s; pass --force to close it anyway", strings.Join(owners, ", "))
+		}
+		if err := dialer().CloseTarget(ctx, *host, *port, tab.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Closed tab: %s (%s)\n", abbreviate(tabTitle(tab), 60), tab.URL)
+		return nil
 	}
-	title := tab.Title
-	if strings.TrimSpace(title) == "" {
-		title = "<untitled>"
+
+	return closeTabsConfirmed(st, fmt.Sprintf("Tabs matching %q:", targetRef), matches, tabCloseOptions{
+		dialer: dialer(), host: *host, port: *port, timeout: *timeout, dryRun: *dryRun, yes: *yes, force: *force,
+	})
+}
+
+type tabCloseOptions struct {
+	dialer  cdp.Dialer
+	host    string
+	port    int
+	timeout time.Duration
+	dryRun  bool
+	yes     bool
+	force   bool
+}
+
+// closeTabsConfirmed lists tabs under heading, asks before closing them unless
+// opts.yes is set, and skips tabs a saved session points at unless opts.force.
+func closeTabsConfirmed(st *store.Store, heading string, tabs []cdp.TargetInfo, opts tabCloseOptions) error {
+	if len(tabs) == 0 {
+		fmt.Println("No tabs to close")
+		return nil
+	}
+	fmt.Println(heading)
+	for _, tab := range tabs {
+		fmt.Printf("  %s (%s)\n", abbreviate(tabTitle(tab), 60), tab.URL)
+	}
+	if opts.dryRun {
+		fmt.Printf("Would close %d tab(s) (dry run)\n", len(tabs))
+		return nil
+	}
+	if !opts.yes {
+		ok, err := confirmPrompt(fmt.Sprintf("Close %d tab(s)?", len(tabs)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("aborted")
+		}
+	}
+	// The prompt may outlast the caller's deadline; close with a fresh one.
+	ctx, cancel := commandContext(opts.timeout)
+	defer cancel()
+
+	closed, failed, skipped := 0, 0, 0
+	for _, tab := range tabs {
+		if owners := sessionsForTarget(st, opts.host, opts.port, tab.ID); len(owners) > 0 && !opts.force {
+			fmt.Fprintf(os.Stderr, "warning: skipping %s (used by session %s; pass --force to close)\n", tab.URL, strings.Join(owners, ", "))
+			skipped++
+			continue
+		}
+		if err := opts.dialer.CloseTarget(ctx, opts.host, opts.port, tab.ID); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close %s: %v\n", tab.URL, err)
+			failed++
+			continue
+		}
+		closed++
+	}
+	fmt.Printf("Closed %d tab(s), failed %d, skipped %d\n", closed, failed, skipped)
+	if failed > 0 {
+		return fmt.Errorf("%d tab(s) failed to close", failed)
 	}
-	fmt.Printf("Closed tab: %s (%s)\n", abbreviate(title, 60), tab.URL)
 	return nil
 }
 
-func fetchTabs(ctx context.Context, host string, port int) ([]cdp.TargetInfo, error) {
-	targets, err := cdp.ListTargets(ctx, host, port)
+// sessionsForTarget lists saved sessions bound to the given target on host:port.
+func sessionsForTarget(st *store.Store, host string, port int, targetID string) []string {
+	var names []string
+	for name, session := range st.List() {
+		if session.TargetID == targetID && session.Port == port && (session.Host == "" || session.Host == host) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tabTitle(tab cdp.TargetInfo) string {
+	if strings.TrimSpace(tab.Title) == "" {
+		return "<untitled>"
+	}
+	return tab.Title
+}
+
+func fetchTabs(ctx context.Context, d cdp.Dialer, host string, port int) ([]cdp.TargetInfo, error) {
+	targets, err := d.ListTargets(ctx, host, port)
 	if err != nil {
 		return nil, err
 	}
@@ -266,29 +489,212 @@ func fetchTabs(ctx context.Context, host string, port int) ([]cdp.TargetInfo, er
 }
 
 func matchTab(tabs []cdp.TargetInfo, ref string) (cdp.TargetInfo, error) {
-	if idx, err := strconv.Atoi(ref); err == nil {
+	matches, err := matchTabs(tabs, ref, false)
+	if err != nil {
+		return cdp.TargetInfo{}, err
+	}
+	return matches[0], nil
+}
+
+// matchTabs resolves ref as a 1-based index, a target id, or a URL/title
+// pattern. A pattern matching several tabs is an error unless all is set;
+// with all, a number is a pattern too, so --all 2024 finds URLs with 2024.
+func matchTabs(tabs []cdp.TargetInfo, ref string, all bool) ([]cdp.TargetInfo, error) {
+	if idx, err := strconv.Atoi(ref); err == nil && !all {
 		if idx <= 0 || idx > len(tabs) {
-			return cdp.TargetInfo{}, fmt.Errorf("index %d is out of range (tabs available: %d)", idx, len(tabs))
+			return nil, fmt.Errorf("index %d is out of range (tabs available: %d)", idx, len(tabs))
 		}
-		return tabs[idx-1], nil
+		return tabs[idx-1 : idx], nil
 	}
-	for _, tab := range tabs {
+	for i, tab := range tabs {
 		if tab.ID == ref {
-			return tab, nil
+			return tabs[i : i+1], nil
 		}
 	}
-	lowerRef := strings.ToLower(ref)
+	matches := filterTabs(tabs, ref)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no tab matches %q (try 'cdp tabs list')", ref)
+	}
+	if len(matches) > 1 && !all {
+		return nil, fmt.Errorf("pattern %q matches multiple tabs; be more specific", ref)
+	}
+	return matches, nil
+}
+
+// filterTabs returns tabs whose URL or title contains pattern (case-insensitive).
+func filterTabs(tabs []cdp.TargetInfo, pattern string) []cdp.TargetInfo {
+	lower := strings.ToLower(pattern)
 	matches := make([]cdp.TargetInfo, 0, 2)
 	for _, tab := range tabs {
-		if strings.Contains(strings.ToLower(tab.URL), lowerRef) || strings.Contains(strings.ToLower(tab.Title), lowerRef) {
+		if strings.Contains(strings.ToLower(tab.URL), lower) || strings.Contains(strings.ToLower(tab.Title), lower) {
 			matches = append(matches, tab)
 		}
 	}
-	if len(matches) == 1 {
-		return matches[0], nil
+	return matches
+}
+
+func cmdTabsCloseOthers(args []string) error {
+	fs := newFlagSet("tabs close-others", "usage: cdp tabs close-others --session <name> [--keep REGEX] [--dry-run] [--yes] [--force]\n\nCloses every tab on the session's port except the session's own tab and tabs whose\nURL matches --keep. Tabs other saved sessions point at are skipped unless --force.")
+	sessionFlag := addSessionFlag(fs)
+	keep := fs.String("keep", "", "Also keep tabs whose URL matches this regex")
+	dryRun := fs.Bool("dry-run", false, "List the tabs that would be closed without closing them")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt")
+	force := fs.Bool("force", false, "Also close tabs other saved sessions point at")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	var keepRe *regexp.Regexp
+	if *keep != "" {
+		if keepRe, err = regexp.Compile(*keep); err != nil {
+			return fmt.Errorf("invalid --keep: %w", err)
+		}
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	session, ok := st.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown session %q", name)
+	}
+	d, err := sessionDialer(session)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	// Reattach first so a session whose tab id went stale still protects its tab.
+	client, updated, err := attachSession(ctx, d, session)
+	if err != nil {
+		return err
+	}
+	client.Close()
+	tabs, err := fetchTabs(ctx, d, updated.Host, updated.Port)
+	if err != nil {
+		return err
+	}
+	others := tabsExcept(tabs, updated.TargetID, keepRe)
+	return closeTabsConfirmed(st, fmt.Sprintf("Tabs other than session %s's:", name), others, tabCloseOptions{
+		dialer: d, host: updated.Host, port: updated.Port, timeout: *timeout, dryRun: *dryRun, yes: *yes, force: *force,
+	})
+}
+
+// tabsExcept drops the tab with id keepID and tabs whose URL matches keep.
+func tabsExcept(tabs []cdp.TargetInfo, keepID string, keep *regexp.Regexp) []cdp.TargetInfo {
+	var others []cdp.TargetInfo
+	for _, tab := range tabs {
+		if tab.ID == keepID || (keep != nil && keep.MatchString(tab.URL)) {
+			continue
+		}
+		others = append(others, tab)
+	}
+	return others
+}
+
+func cmdTabsGC(args []string) error {
+	fs := newFlagSet("tabs gc", "usage: cdp tabs gc [--max-age 1h] [--url REGEX] [--host --port] [--dry-run] [--yes]\n\nCloses tabs whose document is older than --max-age and that no saved session points at.\nAge is measured from the page's performance.timeOrigin, so it restarts on navigation;\ntabs whose age cannot be read are left open.")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
+	port := fs.Int("port", portDefault(9222), "DevTools port")
+	maxAge := fs.Duration("max-age", time.Hour, "Only close tabs whose document is at least this old")
+	urlPattern := fs.String("url", "", "Only close tabs whose URL matches this regex")
+	sessionFlag := fs.String("session", "", "Never close this session's tab (defaults to CDP_SESSION_NAME)")
+	dryRun := fs.Bool("dry-run", false, "List the tabs that would be closed without closing them")
+	yes := fs.Bool("yes", false, "Skip the confirmation prompt")
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	var urlRe *regexp.Regexp
+	if *urlPattern != "" {
+		if urlRe, err = regexp.Compile(*urlPattern); err != nil {
+			return fmt.Errorf("invalid --url: %w", err)
+		}
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	// The invoking session's tab is never a candidate, whatever the filters say.
+	protected := ""
+	if name, err := resolveSessionName(*sessionFlag); err == nil {
+		session, ok := st.Get(name)
+		if !ok {
+			return fmt.Errorf("unknown session %q", name)
+		}
+		protected = session.TargetID
+	}
+
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+	tabs, err := fetchTabs(ctx, dialer(), *host, *port)
+	if err != nil {
+		return err
+	}
+	var stale []cdp.TargetInfo
+	for _, tab := range tabs {
+		if tab.ID == protected || len(sessionsForTarget(st, *host, *port, tab.ID)) > 0 {
+			continue
+		}
+		if urlRe != nil && !urlRe.MatchString(tab.URL) {
+			continue
+		}
+		age, err := tabDocumentAge(ctx, tab, *host, *port)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: keeping %s (age unknown: %v)\n", tab.URL, err)
+			continue
+		}
+		if age >= *maxAge {
+			stale = append(stale, tab)
+		}
+	}
+	return closeTabsConfirmed(st, fmt.Sprintf("Unreferenced tabs older than %s:", *maxAge), stale, tabCloseOptions{
+		dialer: dialer(), host: *host, port: *port, timeout: *timeout, dryRun: *dryRun, yes: *yes,
+	})
+}
+
+// tabDocumentAge reports how long ago the tab's current document started
+// loading. DevTools has no tab creation time, so this is the closest signal.
+func tabDocumentAge(ctx context.Context, tab cdp.TargetInfo, host string, port int) (time.Duration, error) {
+	if tab.WebSocket == "" {
+		return 0, errors.New("tab is already attached to another DevTools client")
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	client, err := dialer().Dial(probeCtx, rewriteWebSocketURL(tab.WebSocket, host, port, dialer().Secure))
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+	value, err := client.Evaluate(probeCtx, "Date.now() - performance.timeOrigin")
+	if err != nil {
+		return 0, err
 	}
-	if len(matches) > 1 {
-		return cdp.TargetInfo{}, fmt.Errorf("pattern %q matches multiple tabs; be more specific", ref)
+	ms, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected age %v", value)
 	}
-	return cdp.TargetInfo{}, fmt.Errorf("no tab matches %q (try 'cdp tabs list')", ref)
+	return time.Duration(ms * float64(time.Millisecond)), nil
 }
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+func TestMatchTabs(t *testing.T) {
+	tabs := []cdp.TargetInfo{
+		{ID: "A", URL: "https://example.com/ads/1", Title: "Ad one"},
+		{ID: "B", URL: "https://example.com/ads/2", Title: "Ad two"},
+		{ID: "C", URL: "https://example.com/home", Title: "Home"},
+	}
+	if _, err := matchTabs(tabs, "/ads/", false); err == nil {
+		t.Fatal("expected ambiguity error without all")
+	}
+	matches, err := matchTabs(tabs, "/ads/", true)
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("matchTabs all = %v, %v", matches, err)
+	}
+	tab, err := matchTab(tabs, "3")
+	if err != nil || tab.ID != "C" {
+		t.Fatalf("matchTab index = %v, %v", tab, err)
+	}
+	if _, err := matchTabs(tabs, "missing", true); err == nil {
+		t.Fatal("expected no-match error")
+	}
+}
+
+func TestMatchTabsAllTreatsNumbersAsPatterns(t *testing.T) {
+	tabs := []cdp.TargetInfo{
+		{ID: "A", URL: "https://news.example/2024/recap"},
+		{ID: "B", URL: "https://news.example/today"},
+		{ID: "C", URL: "https://archive.example/2024/"},
+	}
+	matches, err := matchTabs(tabs, "2024", true)
+	if err != nil || len(matches) != 2 || matches[0].ID != "A" || matches[1].ID != "C" {
+		t.Fatalf("matchTabs all 2024 = %v, %v", matches, err)
+	}
+	matches, err = matchTabs(tabs, "2", false)
+	if err != nil || len(matches) != 1 || matches[0].ID != "B" {
+		t.Fatalf("matchTabs index = %v, %v", matches, err)
+	}
+}
+
+func TestTabsExcept(t *testing.T) {
+	tabs := []cdp.TargetInfo{
+		{ID: "A", URL: "https://app.example/"},
+		{ID: "B", URL: "https://docs.example/guide"},
+		{ID: "C", URL: "https://app.example/retry"},
+	}
+	got := tabsExcept(tabs, "A", regexp.MustCompile(`docs\.`))
+	if len(got) != 1 || got[0].ID != "C" {
+		t.Fatalf("tabsExcept = %+v, want only C", got)
+	}
+	// The session's own tab stays even when nothing else is kept.
+	got = tabsExcept(tabs, "C", nil)
+	if len(got) != 2 || got[0].ID != "A" || got[1].ID != "B" {
+		t.Fatalf("tabsExcept without --keep = %+v, want A and B", got)
+	}
+}
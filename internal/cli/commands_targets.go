@@ -1,25 +1,38 @@
 package cli
 
 import (
-	"errors"
 	"fmt"
 	"sort"
 
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var targetsHelp = commandHelp{
+	Description: `Lists the saved sessions with the port, CSP bypass setting, title and URL they
+were saved with. It reads only the session store; use 'cdp status' to see where
+a session's tab is now.`,
+	Examples: []commandExample{
+		{"cdp targets", "List every saved session."},
+		{"cdp --json-errors targets", "Report a broken session store as JSON on stderr."},
+	},
+}
+
 func cmdTargets(args []string) error {
+	fs := newFlagSet("targets", "usage: cdp targets")
 	if len(args) == 1 && isHelpArg(args[0]) {
-		fmt.Println("usage: cdp targets")
+		fs.Usage()
 		return nil
 	}
 	if len(args) != 0 {
-		return errors.New("usage: cdp targets")
+		return usagef("usage: cdp targets")
 	}
 	st, err := store.Load()
 	if err != nil {
 		return err
 	}
+	if loc := store.Current(); loc.Source != "default" {
+		fmt.Printf("Store: %s (%s)\n", loc.Path, loc.Source)
+	}
 	sessions := st.List()
 	if len(sessions) == 0 {
 		fmt.Println("No saved sessions")
@@ -30,10 +43,14 @@ func cmdTargets(args []string) error {
 		names = append(names, name)
 	}
 	sort.Strings(names)
-	fmt.Printf("%-12s %-6s %-30s %s\n", "NAME", "PORT", "TITLE", "URL")
+	fmt.Printf("%-12s %-6s %-6s %-30s %s\n", "NAME", "PORT", "CSP", "TITLE", "URL")
 	for _, name := range names {
 		session := sessions[name]
-		fmt.Printf("%-12s %-6d %-30s %s\n", name, session.Port, abbreviate(session.Title, 30), session.URL)
+		csp := "-"
+		if session.BypassCSP {
+			csp = "bypass"
+		}
+		fmt.Printf("%-12s %-6d %-6s %-30s %s\n", name, session.Port, csp, abbreviate(session.Title, 30), session.URL)
 	}
 	return nil
 }
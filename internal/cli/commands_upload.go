@@ -2,22 +2,41 @@ package cli
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/veilm/cdp-cli/internal/cdp"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+var uploadHelp = commandHelp{
+	Description: `Sets files on an <input type=file> without opening a file dialog. The input
+can also be found by its label. --wait polls until it exists, for inputs that
+render late.`,
+	Examples: []commandExample{
+		{`cdp upload --session app "input[type=file]" report.pdf`, "Attach one file."},
+		{`cdp upload --session app "#photos" a.jpg b.jpg --wait`, "Attach two files once the input appears."},
+		{`cdp upload --session app --label "Attach files" big.csv --remote`, "Stream the file to a browser on another machine."},
+	},
+}
+
 func cmdUpload(args []string) error {
-	fs := newFlagSet("upload", "usage: cdp upload --session <name> \"input[type=file]\" <file1> [file2 ...] [--wait]")
+	fs := newFlagSet("upload", "usage: cdp upload --session <name> \"input[type=file]\" <file1> [file2 ...] [--wait] [--remote]\nor:    cdp upload --session <name> --label \"Attach files\" <file1> [file2 ...]\n\nBy default Chrome reads the files from their local paths. With --remote the contents\nare streamed over the DevTools connection instead, for browsers on another machine.")
 	sessionFlag := addSessionFlag(fs)
-	waitFlag := fs.Bool("wait", false, "Wait for the selector to exist before uploading")
+	waitFlag := fs.Bool("wait", false, "Wait for the selector (or --label) to exist before uploading")
+	label := fs.String("label", "", "Target the file input associated with this label text (or aria-label) instead of a selector")
 	poll := fs.Duration("poll", 200*time.Millisecond, "Polling interval when using --wait")
-	timeout := fs.Duration("timeout", 10*time.Second, "Command timeout")
+	remote := fs.Bool("remote", false, "Send file contents through the DevTools connection (for remote or containerized Chrome that cannot read local paths)")
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -26,13 +45,19 @@ func cmdUpload(args []string) error {
 	if err != nil {
 		return err
 	}
-	if len(pos) < 2 {
-		return errors.New("missing selector and files")
-	}
-	selector := pos[0]
-	filesRaw := pos[1:]
-	if err := rejectUnsupportedSelector(selector, "upload", false); err != nil {
-		return err
+	selector := ""
+	filesRaw := pos
+	if *label == "" {
+		if len(pos) < 2 {
+			return errors.New("missing selector and files")
+		}
+		selector = pos[0]
+		filesRaw = pos[1:]
+		if err := rejectUnsupportedSelector(selector, "upload", false); err != nil {
+			return err
+		}
+	} else if len(pos) == 0 {
+		return errors.New("missing files")
 	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
@@ -64,7 +89,7 @@ func cmdUpload(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -73,26 +98,35 @@ func cmdUpload(args []string) error {
 	}
 	defer handle.Close()
 
-	if *waitFlag {
+	if *label != "" {
+		selector, err = resolveFileInputByLabel(ctx, handle.client, *label, *waitFlag, *poll)
+		if err != nil {
+			return err
+		}
+		defer clearUploadMark(handle.client, selector)
+	} else if *waitFlag {
 		if err := waitForSelector(ctx, handle.client, selector, *poll); err != nil {
 			return err
 		}
 	}
 
-	if err := handle.client.Call(ctx, "DOM.enable", nil, nil); err != nil {
-		return err
+	if *remote {
+		if err := attachFilesInPage(ctx, handle.client, selector, files); err != nil {
+			return err
+		}
+	} else {
+		if err := setFileInputFiles(ctx, handle.client, selector, files); err != nil {
+			return err
+		}
 	}
-	nodeID, err := resolveNodeID(ctx, handle.client, selector)
+
+	// Read the files back before the change event: many upload widgets clear
+	// the input from their change handler once they have taken the files.
+	received, err := readInputFiles(ctx, handle.client, selector)
 	if err != nil {
 		return err
 	}
-	if nodeID == 0 {
-		return fmt.Errorf("no element matched selector: %s", selector)
-	}
-	if err := handle.client.Call(ctx, "DOM.setFileInputFiles", map[string]interface{}{
-		"nodeId": nodeID,
-		"files":  files,
-	}, nil); err != nil {
+	if err := verifyUploadedFiles(files, received); err != nil {
 		return err
 	}
 
@@ -110,6 +144,266 @@ func cmdUpload(args []string) error {
 		return err
 	}
 
-	fmt.Printf("Uploaded %d file(s) into %s\n", len(files), selector)
+	target := selector
+	if *label != "" {
+		target = fmt.Sprintf("input labelled %q", *label)
+	}
+	fmt.Printf("Uploaded %d file(s) into %s\n", len(received), target)
+	for _, f := range received {
+		fmt.Printf("  %s (%s)\n", f.Name, formatBytes(f.Size))
+	}
+	if after, err := readInputFiles(ctx, handle.client, selector); err == nil && len(after) == 0 {
+		fmt.Println("note: the page cleared the input after the change event (usual for upload widgets that take the files)")
+	}
 	return nil
 }
+
+// setFileInputFiles resolves selector right before DOM.setFileInputFiles and
+// retries once when the node went away in between (frameworks often re-render
+// file inputs), since the raw "No node with given id" reads like a bug.
+func setFileInputFiles(ctx context.Context, client *cdp.Client, selector string, files []string) error {
+	if err := client.Call(ctx, "DOM.enable", nil, nil); err != nil {
+		return err
+	}
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var nodeID int
+		nodeID, err = resolveNodeID(ctx, client, selector)
+		if err != nil {
+			return err
+		}
+		if nodeID == 0 {
+			return fmt.Errorf("no element matched selector: %s", selector)
+		}
+		err = client.Call(ctx, "DOM.setFileInputFiles", map[string]interface{}{
+			"nodeId": nodeID,
+			"files":  files,
+		}, nil)
+		if err == nil || !strings.Contains(err.Error(), "No node with given id") {
+			return err
+		}
+	}
+	return fmt.Errorf("file input %s was re-rendered while uploading (retried once): %w", selector, err)
+}
+
+// uploadLabelAttr marks the input found by --label so the rest of the upload
+// can address it with a plain selector. clearUploadMark removes it again.
+const uploadLabelAttr = "data-cdp-upload"
+
+// fileInputLabels is what names one file input on the page: the text of its
+// <label>s (el.labels covers both for= and nesting), its aria-label and title.
+type fileInputLabels struct {
+	Labels    []string `json:"labels"`
+	AriaLabel string   `json:"ariaLabel"`
+	Title     string   `json:"title"`
+}
+
+const fileInputLabelsExpr = `Array.from(document.querySelectorAll("input[type=file]")).map(el => ({
+        labels: Array.from(el.labels || []).map(l => l.textContent),
+        ariaLabel: el.getAttribute("aria-label") || "",
+        title: el.title || ""
+    }))`
+
+// matchFileInputLabel returns the index of the first input with a <label>
+// containing label, else the first whose aria-label or title contains it, or
+// -1. Matching ignores case and runs of whitespace.
+func matchFileInputLabel(inputs []fileInputLabels, label string) int {
+	norm := func(s string) string { return strings.ToLower(strings.Join(strings.Fields(s), " ")) }
+	want := norm(label)
+	for i, input := range inputs {
+		for _, text := range input.Labels {
+			if strings.Contains(norm(text), want) {
+				return i
+			}
+		}
+	}
+	for i, input := range inputs {
+		if strings.Contains(norm(input.AriaLabel), want) || strings.Contains(norm(input.Title), want) {
+			return i
+		}
+	}
+	return -1
+}
+
+// resolveFileInputByLabel finds the file input tied to a <label> (via for= or
+// nesting) or carrying a matching aria-label or title, marks it with
+// uploadLabelAttr and returns a selector for it.
+func resolveFileInputByLabel(ctx context.Context, client *cdp.Client, label string, wait bool, poll time.Duration) (string, error) {
+	for {
+		selector, err := markFileInputByLabel(ctx, client, label)
+		if err != nil || selector != "" {
+			return selector, err
+		}
+		if !wait {
+			return "", fmt.Errorf("no file input is labelled %q", label)
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for a file input labelled %q: %w", label, ctx.Err())
+		case <-time.After(poll):
+		}
+	}
+}
+
+// markFileInputByLabel is one attempt of resolveFileInputByLabel; it returns
+// "" when no input matches yet.
+func markFileInputByLabel(ctx context.Context, client *cdp.Client, label string) (string, error) {
+	value, err := client.Evaluate(ctx, fileInputLabelsExpr)
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	var inputs []fileInputLabels
+	if err := json.Unmarshal(raw, &inputs); err != nil {
+		return "", fmt.Errorf("unexpected file input list: %w", err)
+	}
+	index := matchFileInputLabel(inputs, label)
+	if index < 0 {
+		return "", nil
+	}
+	value, err = client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const input = document.querySelectorAll("input[type=file]")[%d];
+        if (!input) return null;
+        const token = input.getAttribute(%s) || String(Date.now()) + Math.random().toString(36).slice(2, 8);
+        input.setAttribute(%s, token);
+        return "input[" + %s + "=\"" + token + "\"]";
+    })()`, index, strconv.Quote(uploadLabelAttr), strconv.Quote(uploadLabelAttr), strconv.Quote(uploadLabelAttr)))
+	if err != nil {
+		return "", err
+	}
+	selector, _ := value.(string)
+	return selector, nil
+}
+
+// clearUploadMark removes the uploadLabelAttr that resolveFileInputByLabel
+// set, even when the upload failed or timed out.
+func clearUploadMark(client *cdp.Client, selector string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, _ = client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (el) el.removeAttribute(%s);
+        return true;
+    })()`, strconv.Quote(selector), strconv.Quote(uploadLabelAttr)))
+}
+
+// uploadChunkSize is how many file bytes go into each Runtime.evaluate when
+// streaming with --remote, keeping individual CDP messages small.
+const uploadChunkSize = 512 * 1024
+
+// attachFilesInPage streams each file into the page as base64 chunks, then
+// builds File objects and assigns them to the input through a DataTransfer.
+func attachFilesInPage(ctx context.Context, client *cdp.Client, selector string, files []string) error {
+	const buffer = "window.__cdpUpload"
+	if _, err := client.Evaluate(ctx, buffer+" = []; true"); err != nil {
+		return err
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, _ = client.Evaluate(cleanupCtx, "delete "+buffer+"; true")
+	}()
+	chunk := make([]byte, uploadChunkSize)
+	for i, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		if _, err := client.Evaluate(ctx, fmt.Sprintf("%s.push({name: %s, type: %s, parts: []}); true",
+			buffer, strconv.Quote(filepath.Base(path)), strconv.Quote(mime.TypeByExtension(filepath.Ext(path))))); err != nil {
+			f.Close()
+			return err
+		}
+		for {
+			n, readErr := io.ReadFull(f, chunk)
+			if n > 0 {
+				encoded := base64.StdEncoding.EncodeToString(chunk[:n])
+				if _, err := client.Evaluate(ctx, fmt.Sprintf(`(() => {
+                    const bin = atob(%q);
+                    const bytes = new Uint8Array(bin.length);
+                    for (let j = 0; j < bin.length; j++) bytes[j] = bin.charCodeAt(j);
+                    %s[%d].parts.push(bytes);
+                    return true;
+                })()`, encoded, buffer, i)); err != nil {
+					f.Close()
+					return fmt.Errorf("send %s: %w", filepath.Base(path), err)
+				}
+			}
+			if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+				break
+			}
+			if readErr != nil {
+				f.Close()
+				return readErr
+			}
+		}
+		f.Close()
+	}
+	_, err := client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (!el) throw new Error("no element matched selector: " + %s);
+        if (!el.files) throw new Error("element is not a file input: <" + el.tagName.toLowerCase() + ">");
+        const dt = new DataTransfer();
+        for (const f of %s) dt.items.add(new File(f.parts, f.name, {type: f.type}));
+        el.files = dt.files;
+        return el.files.length;
+    })()`, strconv.Quote(selector), strconv.Quote(selector), buffer))
+	return err
+}
+
+type uploadedFile struct {
+	Name string
+	Size float64
+}
+
+// readInputFiles returns el.files for a file input.
+func readInputFiles(ctx context.Context, client *cdp.Client, selector string) ([]uploadedFile, error) {
+	value, err := client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (!el) throw new Error("no element matched selector: " + %s);
+        if (!el.files) throw new Error("element is not a file input: <" + el.tagName.toLowerCase() + ">");
+        return Array.from(el.files).map(f => ({name: f.name, size: f.size}));
+    })()`, strconv.Quote(selector), strconv.Quote(selector)))
+	if err != nil {
+		return nil, err
+	}
+	items, _ := value.([]interface{})
+	files := make([]uploadedFile, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var f uploadedFile
+		f.Name, _ = m["name"].(string)
+		f.Size, _ = m["size"].(float64)
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// verifyUploadedFiles checks that the input holds exactly the requested files,
+// compared by base name.
+func verifyUploadedFiles(requested []string, received []uploadedFile) error {
+	want := make([]string, len(requested))
+	for i, p := range requested {
+		want[i] = filepath.Base(p)
+	}
+	got := make([]string, len(received))
+	for i, f := range received {
+		got[i] = f.Name
+	}
+	if strings.Join(want, "\x00") == strings.Join(got, "\x00") {
+		return nil
+	}
+	if len(got) == 0 {
+		return fmt.Errorf("upload not applied: the input has no files right after setting %s (the page may clear programmatically set files)", strings.Join(want, ", "))
+	}
+	if len(got) < len(want) {
+		return fmt.Errorf("upload incomplete: the input has %d of %d file(s) [%s] (is the input missing the multiple attribute?)", len(got), len(want), strings.Join(got, ", "))
+	}
+	return fmt.Errorf("upload mismatch: the input has [%s], expected [%s]", strings.Join(got, ", "), strings.Join(want, ", "))
+}
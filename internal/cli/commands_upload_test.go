@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+)
+
+func TestVerifyUploadedFiles(t *testing.T) {
+	requested := []string{"/tmp/a.png", "/home/me/b.txt"}
+	if err := verifyUploadedFiles(requested, []uploadedFile{{Name: "a.png"}, {Name: "b.txt"}}); err != nil {
+		t.Fatalf("matching files: %v", err)
+	}
+	cases := map[string][]uploadedFile{
+		"no files":   nil,
+		"multiple":   {{Name: "a.png"}},
+		"b.txt, a.p": {{Name: "b.txt"}, {Name: "a.png"}},
+	}
+	for want, received := range cases {
+		err := verifyUploadedFiles(requested, received)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("received %v: error %v should mention %q", received, err, want)
+		}
+	}
+}
+
+func dialFakeTab(t *testing.T, handler cdptest.Handler) *cdp.Client {
+	t.Helper()
+	client, err := cdp.Dial(context.Background(), cdptest.NewTab(t, handler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// fakeFileInputDOM answers the DOM calls setFileInputFiles makes. Each
+// DOM.querySelector returns a new node id, as after a re-render, and the
+// first failures calls to DOM.setFileInputFiles find the node gone.
+func fakeFileInputDOM(t *testing.T, failures int) (*cdp.Client, *[]int) {
+	var setNodes []int
+	nextNode := 1
+	client := dialFakeTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		switch req.Method {
+		case "DOM.getDocument":
+			c.Reply(req.ID, map[string]interface{}{"root": map[string]interface{}{"nodeId": 1}})
+		case "DOM.querySelector":
+			nextNode++
+			c.Reply(req.ID, map[string]interface{}{"nodeId": nextNode})
+		case "DOM.setFileInputFiles":
+			var params struct {
+				NodeID int `json:"nodeId"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			setNodes = append(setNodes, params.NodeID)
+			if len(setNodes) <= failures {
+				c.Fail(req.ID, "No node with given id found")
+				return
+			}
+			c.Reply(req.ID, nil)
+		default:
+			c.Reply(req.ID, nil)
+		}
+	})
+	return client, &setNodes
+}
+
+func TestSetFileInputFilesRetriesARerenderedInput(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, setNodes := fakeFileInputDOM(t, 1)
+	if err := setFileInputFiles(ctx, client, "#photo", []string{"/tmp/a.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := *setNodes; len(got) != 2 || got[0] == got[1] {
+		t.Fatalf("setFileInputFiles sent to nodes %v, want a retry on a freshly resolved node", got)
+	}
+
+	client, setNodes = fakeFileInputDOM(t, 2)
+	err := setFileInputFiles(ctx, client, "#photo", []string{"/tmp/a.png"})
+	if err == nil || !strings.Contains(err.Error(), "re-rendered while uploading (retried once)") {
+		t.Fatalf("err = %v", err)
+	}
+	if len(*setNodes) != 2 {
+		t.Fatalf("sent %d times, want 2", len(*setNodes))
+	}
+}
+
+func TestResolveFileInputByLabel(t *testing.T) {
+	inputs := []fileInputLabels{
+		{Labels: []string{"Profile photo"}},                     // <label for="photo">
+		{Labels: []string{"\n  Upload\n  your   Resume\n"}},     // <label><input type=file> Upload ...</label>
+		{AriaLabel: "Cover letter", Title: "Resume (optional)"}, // aria-label and title only
+		{Title: "Portfolio"},
+	}
+	markAt := regexp.MustCompile(`querySelectorAll\("input\[type=file\]"\)\[(\d+)\]`)
+	marked := -1
+	client := dialFakeTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		expression := req.Expression()
+		switch {
+		case strings.Contains(expression, "el.labels"):
+			c.Reply(req.ID, cdptest.EvalResult(inputs))
+		case markAt.MatchString(expression):
+			marked, _ = strconv.Atoi(markAt.FindStringSubmatch(expression)[1])
+			c.Reply(req.ID, cdptest.EvalResult(`input[data-cdp-upload="tok"]`))
+		default:
+			c.Reply(req.ID, cdptest.EvalResult(true))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for label, want := range map[string]int{
+		"profile PHOTO":      0,
+		"upload your resume": 1,
+		"resume":             1, // label text wins over a title
+		"cover":              2,
+		"portfolio":          3,
+	} {
+		marked = -1
+		selector, err := resolveFileInputByLabel(ctx, client, label, false, time.Millisecond)
+		if err != nil {
+			t.Fatalf("%q: %v", label, err)
+		}
+		if marked != want || selector != `input[data-cdp-upload="tok"]` {
+			t.Fatalf("%q marked input %d as %q, want input %d", label, marked, selector, want)
+		}
+	}
+	marked = -1
+	if _, err := resolveFileInputByLabel(ctx, client, "avatar", false, time.Millisecond); err == nil || marked != -1 {
+		t.Fatalf("unlabelled input: err = %v, marked %d", err, marked)
+	}
+}
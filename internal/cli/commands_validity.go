@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// validityFlags lists the ValidityState properties reported, in display order.
+var validityFlags = []string{
+	"valueMissing", "typeMismatch", "patternMismatch", "tooLong", "tooShort",
+	"rangeUnderflow", "rangeOverflow", "stepMismatch", "badInput", "customError",
+}
+
+type fieldValidity struct {
+	Field   string          `json:"field"`
+	Valid   bool            `json:"valid"`
+	Message string          `json:"validationMessage,omitempty"`
+	Flags   map[string]bool `json:"validity,omitempty"`
+}
+
+// failing returns the names of the ValidityState flags that are set.
+func (f fieldValidity) failing() []string {
+	var out []string
+	for _, name := range validityFlags {
+		if f.Flags[name] {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+var validityHelp = commandHelp{
+	Description: `Reports the constraint validation state of form controls (validity flags and
+the validation message) without submitting. --report also calls reportValidity()
+so the browser shows its bubbles.`,
+	Examples: []commandExample{
+		{`cdp validity --session app "input[name=email]"`, "Check one field."},
+		{`cdp validity --session app --form "form#signup" --json`, "List every invalid control in a form as JSON."},
+		{`cdp validity --session app "#age" --report`, "Show the browser's validation bubble."},
+	},
+}
+
+func cmdValidity(args []string) error {
+	fs := newFlagSet("validity", "usage: cdp validity --session <name> \"input.selector\" [--report]\nor:    cdp validity --session <name> --form \"form.selector\" [--report]\n\nReports checkValidity(), the ValidityState flags, and validationMessage. With --form\nevery control is checked and invalid ones are listed. Exits non-zero when anything is invalid.")
+	sessionFlag := addSessionFlag(fs)
+	form := fs.String("form", "", "Check every control in this form")
+	report := fs.Bool("report", false, "Call reportValidity() so the browser shows its native validation bubbles")
+	jsonOut := fs.Bool("json", false, "Output JSON")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	selector := *form
+	switch {
+	case *form != "" && len(pos) > 0:
+		return usagef("unexpected argument: %s (use either a selector or --form)", pos[0])
+	case *form == "" && len(pos) == 0:
+		return usagef("usage: cdp validity --session <name> \"input.selector\" | --form \"form.selector\"")
+	case len(pos) > 1:
+		return usagef("unexpected argument: %s", pos[1])
+	case *form == "":
+		selector = pos[0]
+	}
+	if err := rejectUnsupportedSelector(selector, "validity", false); err != nil {
+		return err
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	value, err := handle.client.Evaluate(ctx, fmt.Sprintf(`(() => {
+        const el = document.querySelector(%s);
+        if (!el) throw new Error("no element matched selector: " + %s);
+        %s
+        if (%t) {
+            if (el.tagName !== "FORM") throw new Error("element is a <" + el.tagName.toLowerCase() + ">, not a <form>");
+            const fields = Array.from(el.elements).filter(c => c.validity && c.willValidate).map(describe);
+            if (%t) el.reportValidity();
+            return {fields};
+        }
+        if (!el.validity || typeof el.checkValidity !== "function") {
+            return {noValidity: "<" + el.tagName.toLowerCase() + ">"};
+        }
+        const field = describe(el, 0);
+        if (%t) el.reportValidity();
+        return {fields: [field]};
+    })()`, strconv.Quote(selector), strconv.Quote(selector), fieldValidityJS(), *form != "", *report, *report))
+	if err != nil {
+		return err
+	}
+	result, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unexpected validity result type %T", value)
+	}
+	if tag, ok := result["noValidity"].(string); ok {
+		return fmt.Errorf("element has no validity state (%s is not a form control)", tag)
+	}
+	fields := parseFieldValidity(result["fields"])
+	if *form == "" && len(fields) == 0 {
+		return fmt.Errorf("unexpected validity result for %s", selector)
+	}
+
+	invalid := make([]fieldValidity, 0, len(fields))
+	for _, f := range fields {
+		if !f.Valid {
+			invalid = append(invalid, f)
+		}
+	}
+
+	if *jsonOut {
+		out, err := jsonStyle.JSON(fields, *pretty, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+	} else if *form == "" {
+		f := fields[0]
+		if f.Valid {
+			fmt.Printf("%s: valid\n", selector)
+		} else {
+			fmt.Printf("%s: invalid (%s)\n", selector, strings.Join(f.failing(), ", "))
+			if f.Message != "" {
+				fmt.Printf("  %s\n", f.Message)
+			}
+		}
+	} else if len(invalid) == 0 {
+		fmt.Printf("%s: all %d field(s) valid\n", selector, len(fields))
+	} else {
+		printInvalidFields(invalid)
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("%d of %d field(s) invalid", len(invalid), len(fields))
+	}
+	return nil
+}
+
+// fieldValidityJS defines describe(control, index) in page JS, which returns
+// the fieldValidity shape parseFieldValidity reads.
+func fieldValidityJS() string {
+	flagsJSON, _ := jsonStyle.JSON(validityFlags, false, -1)
+	return fmt.Sprintf(`const flags = %s;
+        const label = (c, i) => {
+            if (c.name) return c.tagName.toLowerCase() + "[name=" + c.name + "]";
+            if (c.id) return "#" + c.id;
+            return c.tagName.toLowerCase() + ":nth-of-type(" + (i + 1) + ")";
+        };
+        const describe = (c, i) => {
+            const state = {};
+            for (const f of flags) state[f] = !!c.validity[f];
+            return {field: label(c, i), valid: c.checkValidity(), validationMessage: c.validationMessage, validity: state};
+        };`, flagsJSON)
+}
+
+func parseFieldValidity(raw interface{}) []fieldValidity {
+	items, _ := raw.([]interface{})
+	fields := make([]fieldValidity, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		f := fieldValidity{Flags: map[string]bool{}}
+		f.Field, _ = m["field"].(string)
+		f.Valid, _ = m["valid"].(bool)
+		f.Message, _ = m["validationMessage"].(string)
+		if state, ok := m["validity"].(map[string]interface{}); ok {
+			for k, v := range state {
+				f.Flags[k], _ = v.(bool)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// version is overridden at build time with
+// -ldflags "-X github.com/veilm/cdp-cli/internal/cli.version=v1.2.3".
+var version = "dev"
+
+func cliVersion() string {
+	if version != "dev" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
+
+var versionHelp = commandHelp{
+	Description: `Prints the version of this binary, the one 'cdp commands --json' reports as
+well. Include it in bug reports.`,
+	Examples: []commandExample{
+		{"cdp version", "Print the version."},
+		{"cdp version --json", "Print it as JSON for scripts."},
+		{"cdp --version", "The same, as a flag."},
+	},
+}
+
+func cmdVersion(args []string) error {
+	fs := newFlagSet("version", "usage: cdp version [--json]")
+	asJSON := fs.Bool("json", false, "Print machine-readable JSON")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	if !*asJSON {
+		fmt.Printf("cdp-cli %s (%s %s/%s)\n", cliVersion(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+		return nil
+	}
+	output, err := jsonStyle.JSON(map[string]interface{}{
+		"version": cliVersion(),
+		"go":      runtime.Version(),
+		"os":      runtime.GOOS,
+		"arch":    runtime.GOARCH,
+	}, defaultPretty(), -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
+
+var browserInfoHelp = commandHelp{
+	Description: `Prints the browser's version info: product, protocol version, user agent and
+the browser-level websocket URL. It doubles as a check that a DevTools port is
+reachable.`,
+	Examples: []commandExample{
+		{"cdp browser-info", "Query the default host and port."},
+		{"cdp browser-info --port 9333", "Query another browser."},
+		{"cdp browser-info --host 10.0.0.5 --port 9222 --timeout 2s", "Check a remote port quickly."},
+	},
+}
+
+func cmdBrowserInfo(args []string) error {
+	fs := newFlagSet("browser-info", "usage: cdp browser-info [--host 127.0.0.1 --port 9222]")
+	host := fs.String("host", hostDefault("127.0.0.1"), "DevTools host")
+	port := fs.Int("port", portDefault(9222), "DevTools port")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := unexpectedArgs(pos); err != nil {
+		return err
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+
+	info, err := dialer().BrowserVersion(ctx, *host, *port)
+	if err != nil {
+		return fmt.Errorf("fetch /json/version (is the browser running with --remote-debugging-port=%d?): %w", *port, err)
+	}
+	output, err := jsonStyle.JSON(map[string]interface{}{
+		"browser":         info.Browser,
+		"protocolVersion": info.ProtocolVersion,
+		"userAgent":       info.UserAgent,
+		"v8Version":       info.V8Version,
+		"webKitVersion":   info.WebKitVersion,
+		"webSocketUrl":    info.WebSocket,
+		"cliVersion":      cliVersion(),
+	}, *pretty, -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
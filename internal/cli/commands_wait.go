@@ -1,21 +1,48 @@
 package cli
 
 import (
-	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
-func cmdWait(args []string) error {
-	fs := newFlagSet("wait", "usage: cdp wait --session <name> [--selector \".selector\"] [--visible]")
+var waitHelp = commandHelp{
+	Description: `Blocks until the page is ready: by default until the document has loaded, or
+until one or all --selector values exist (and are visible with --visible),
+until the URL matches --route, or, with --idle-ui, until no loading spinner or
+skeleton is showing. It exits 3 when --timeout passes first, so it composes
+with shell scripts.`,
+	Examples: []commandExample{
+		{"cdp wait --session app", "Wait for document.readyState to be complete."},
+		{`cdp wait --session app --selector ".toast" --selector ".error" --any`, "Wait for whichever of two elements appears first."},
+		{`cdp wait --session app --selector "#order-id" --print-text`, "Wait for an element and print its text."},
+		{`cdp wait --session app --route "/checkout/done" --timeout 30s`, "Wait for an SPA navigation."},
+		{`cdp wait --session app --idle-ui --busy-indicators ".skeleton, [aria-busy=true]"`, "Wait until no skeleton or busy region is showing."},
+	},
+}
+
+func cmdWait(args []string) (err error) {
+	fs := newFlagSet("wait", "usage: cdp wait --session <name> [--selector \".selector\" ...] [--any | --all] [--visible] [--print-text | --print-attr NAME] [--route REGEX] [--idle-ui [--busy-indicators LIST]]\n\nRepeat --selector to wait for whichever appears first (--any, the default) or for\nall of them (--all); the satisfying selectors are reported. With --print-text or\n--print-attr the first matched element's innerText (or attribute) is printed to\nstdout and the Found/Visible lines go to stderr.")
 	sessionFlag := addSessionFlag(fs)
-	selector := fs.String("selector", "", "CSS selector to wait for")
+	var selectors stringListFlag
+	fs.Var(&selectors, "selector", "CSS selector to wait for (repeatable)")
+	anyOf := fs.Bool("any", false, "With several --selector, wait for the first to appear (default)")
+	allOf := fs.Bool("all", false, "With several --selector, wait until all are present")
 	visible := fs.Bool("visible", false, "Wait for selector to be visible (requires --selector)")
+	printText := fs.Bool("print-text", false, "Print the matched element's innerText once found (requires --selector)")
+	printAttr := fs.String("print-attr", "", "Print this attribute of the matched element once found (requires --selector)")
+	route := fs.String("route", "", "Wait until location.href matches this regex (covers SPA pushState/popstate navigation)")
+	idleUI := fs.Bool("idle-ui", false, "Wait until no visible element matches --busy-indicators (spinners, skeletons)")
+	busyIndicators := fs.String("busy-indicators", defaultBusyIndicators, "CSS selector list of loading markers for --idle-ui (a [session.<name>] config section can set busy-indicators too)")
 	poll := fs.Duration("poll", 200*time.Millisecond, "Polling interval")
-	timeout := fs.Duration("timeout", 10*time.Second, "Command timeout")
+	attachConsole := addAttachConsoleFlag(fs)
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -32,11 +59,33 @@ func cmdWait(args []string) error {
 		fs.Usage()
 		return err
 	}
-	if *visible && *selector == "" {
+	if *visible && len(selectors) == 0 {
 		return errors.New("--visible requires --selector")
 	}
-	if *selector != "" {
-		if err := rejectUnsupportedSelector(*selector, "wait --selector", false); err != nil {
+	if *route != "" && len(selectors) > 0 {
+		return errors.New("use either --route or --selector, not both")
+	}
+	if *idleUI && (*route != "" || len(selectors) > 0) {
+		return errors.New("use --idle-ui on its own, not with --route or --selector")
+	}
+	if (*printText || *printAttr != "") && len(selectors) == 0 {
+		return errors.New("--print-text and --print-attr require --selector")
+	}
+	if *anyOf && *allOf {
+		return errors.New("use either --any or --all, not both")
+	}
+	if *printText && *printAttr != "" {
+		return errors.New("use either --print-text or --print-attr, not both")
+	}
+	var routeRe *regexp.Regexp
+	if *route != "" {
+		routeRe, err = compileRouteRegex(*route, "--route")
+		if err != nil {
+			return err
+		}
+	}
+	for _, sel := range selectors {
+		if err := rejectUnsupportedSelector(sel, "wait --selector", false); err != nil {
 			return err
 		}
 	}
@@ -45,7 +94,7 @@ func cmdWait(args []string) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -53,32 +102,85 @@ func cmdWait(args []string) error {
 		return err
 	}
 	defer handle.Close()
+	defer attachPageErrors(&err, watchPageErrors(ctx, handle.client, *attachConsole))
 
 	switch {
-	case *selector == "":
-		if err := waitForReadyState(ctx, handle.client, *poll); err != nil {
+	case *idleUI:
+		indicators, err := idleUIFlags{wait: idleUI, indicators: busyIndicators}.resolve(fs, name)
+		if err != nil {
 			return err
 		}
-		fmt.Println("Ready")
-	case *visible:
-		if err := waitForSelectorVisible(ctx, handle.client, *selector, *poll); err != nil {
+		busy, waited, err := waitIdleUI(ctx, handle.client, indicators, *poll)
+		if err != nil {
+			return err
+		}
+		if busy == "" {
+			fmt.Println("Idle")
+		} else {
+			fmt.Printf("Idle (waited %s for %s)\n", waited.Round(time.Millisecond), busy)
+		}
+	case routeRe != nil:
+		current, err := waitForRoute(ctx, handle.client, routeRe)
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Visible: %s\n", *selector)
+		fmt.Printf("Route: %s\n", current)
+	case len(selectors) == 0:
+		if err := waitForReadyState(ctx, handle.client, *poll); err != nil {
+			return err
+		}
+		fmt.Println("Ready")
 	default:
-		if err := waitForSelector(ctx, handle.client, *selector, *poll); err != nil {
+		printing := *printText || *printAttr != ""
+		read := "null"
+		switch {
+		case *printText:
+			read = "el.innerText"
+		case *printAttr != "":
+			read = fmt.Sprintf("el.getAttribute(%s)", strconv.Quote(*printAttr))
+		}
+		matched, value, err := waitForSelectors(ctx, handle.client, selectors, *visible, *allOf, read, *poll)
+		if err != nil {
 			return err
 		}
-		fmt.Printf("Found: %s\n", *selector)
+		out := os.Stdout
+		if printing {
+			out = os.Stderr
+		}
+		label := "Found"
+		if *visible {
+			label = "Visible"
+		}
+		for _, sel := range matched {
+			fmt.Fprintf(out, "%s: %s\n", label, sel)
+		}
+		if !printing {
+			return nil
+		}
+		if value == nil {
+			return fmt.Errorf("%s has no %s attribute", matched[0], *printAttr)
+		}
+		fmt.Println(value)
 	}
 	return nil
 }
 
-func cmdWaitVisible(args []string) error {
+var waitVisibleHelp = commandHelp{
+	Description: `Polls until the element exists, has a non-zero size and is not hidden by
+display, visibility or opacity. Use it before clicking things that animate in.`,
+	Examples: []commandExample{
+		{`cdp wait-visible --session app ".modal"`, "Wait for the modal to show."},
+		{`cdp wait-visible --session app "#results li" --timeout 20s`, "Wait up to 20s for the first result."},
+		{`cdp wait-visible --session app ".spinner" --poll 50ms`, "Poll more often for short-lived elements."},
+	},
+}
+
+func cmdWaitVisible(args []string) (err error) {
 	fs := newFlagSet("wait-visible", "usage: cdp wait-visible --session <name> \".selector\"")
 	sessionFlag := addSessionFlag(fs)
 	poll := fs.Duration("poll", 200*time.Millisecond, "Polling interval")
-	timeout := fs.Duration("timeout", 10*time.Second, "Command timeout")
+	attachConsole := addAttachConsoleFlag(fs)
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -92,7 +194,7 @@ func cmdWaitVisible(args []string) error {
 	}
 	selector := pos[0]
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	if err := rejectUnsupportedSelector(selector, "rect", false); err != nil {
 		return err
@@ -116,7 +218,7 @@ func cmdWaitVisible(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -124,6 +226,7 @@ func cmdWaitVisible(args []string) error {
 		return err
 	}
 	defer handle.Close()
+	defer attachPageErrors(&err, watchPageErrors(ctx, handle.client, *attachConsole))
 
 	if err := waitForSelectorVisible(ctx, handle.client, selector, *poll); err != nil {
 		return err
@@ -131,3 +234,97 @@ func cmdWaitVisible(args []string) error {
 	fmt.Printf("Visible: %s\n", selector)
 	return nil
 }
+
+var waitMutationHelp = commandHelp{
+	Description: `Watches a container with a MutationObserver and returns on the first matching
+change: any child-list change by default, or only added or removed children
+matching a selector, an attribute change, or a text change. This is more
+reliable than polling for content that is replaced in place.`,
+	Examples: []commandExample{
+		{`cdp wait-mutation --session app "#feed" --added-child ".post"`, "Wait for a new post in the feed."},
+		{`cdp wait-mutation --session app "#cart" --text`, "Wait for the cart's text to change."},
+		{`cdp wait-mutation --session app "button.save" --attribute disabled`, "Wait until the button toggles disabled."},
+		{`cdp wait-mutation --session app "ul.todo" --removed-child li --timeout 5s`, "Wait for an item to be removed."},
+	},
+}
+
+func cmdWaitMutation(args []string) error {
+	fs := newFlagSet("wait-mutation", "usage: cdp wait-mutation --session <name> \".container\" [--added-child \".row\"] [--removed-child SEL] [--attribute NAME] [--text]")
+	sessionFlag := addSessionFlag(fs)
+	addedChild := fs.String("added-child", "", "Resolve when a node matching this selector is inserted under the container")
+	removedChild := fs.String("removed-child", "", "Resolve when a node matching this selector is removed from the container")
+	attribute := fs.String("attribute", "", "Resolve when this attribute changes on the container or a descendant")
+	text := fs.Bool("text", false, "Resolve when text content under the container changes")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	timeout := addTimeoutFlag(fs, 10*time.Second, "Command timeout")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) < 1 {
+		return errors.New("missing selector")
+	}
+	selector := pos[0]
+	if len(pos) > 1 {
+		return usagef("unexpected argument: %s", pos[1])
+	}
+	for _, sel := range []string{selector, *addedChild, *removedChild} {
+		if sel == "" {
+			continue
+		}
+		if err := rejectUnsupportedSelector(sel, "wait-mutation", false); err != nil {
+			return err
+		}
+	}
+	name, err := resolveSessionName(*sessionFlag)
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	st, err := store.Load()
+	if err != nil {
+		return err
+	}
+	// Give the in-page observer its own deadline and keep a little slack on the
+	// CDP side so the page reports the timeout rather than the transport.
+	cdpTimeout := *timeout
+	if cdpTimeout > 0 {
+		cdpTimeout += 2 * time.Second
+	}
+	ctx, cancel := commandContext(cdpTimeout)
+	defer cancel()
+
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return err
+	}
+	defer handle.Close()
+
+	if err := ensureWebNavInjected(ctx, handle.client); err != nil {
+		return err
+	}
+
+	opts := map[string]interface{}{
+		"addedChild":   *addedChild,
+		"removedChild": *removedChild,
+		"attribute":    *attribute,
+		"text":         *text,
+		"timeoutMs":    timeout.Milliseconds(),
+	}
+	optsJSON, _ := json.Marshal(opts)
+	expression := fmt.Sprintf(`window.WebNavWaitMutation(%s, %s)`, strconv.Quote(selector), string(optsJSON))
+	value, err := handle.client.Evaluate(ctx, expression)
+	if err != nil {
+		return err
+	}
+	output, err := jsonStyle.JSON(value, *pretty, -1)
+	if err != nil {
+		return err
+	}
+	fmt.Println(output)
+	return nil
+}
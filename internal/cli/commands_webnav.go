@@ -4,23 +4,42 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/veilm/cdp-cli/internal/cdp"
 	"github.com/veilm/cdp-cli/internal/store"
 )
 
+// cropForTTY shortens s to at most limit runes (0 means no limit). When the
+// cut would land inside a line and an earlier line break exists, it crops at
+// that break instead so multi-line previews keep whole lines.
 func cropForTTY(s string, limit int) string {
 	if limit <= 0 {
-		return ""
+		return s
 	}
 	r := []rune(s)
 	if len(r) <= limit {
 		return s
 	}
-	return string(r[:limit]) + "[...]"
+	kept := r[:limit]
+	if r[limit] != '\n' {
+		for i := len(kept) - 1; i > 0; i-- {
+			if kept[i] == '\n' {
+				return string(kept[:i]) + "\n[...]"
+			}
+		}
+		return string(kept) + "[...]"
+	}
+	return string(kept) + "\n[...]"
 }
 
 func isBareTagSelector(selector string) bool {
@@ -39,10 +58,11 @@ func isBareTagSelector(selector string) bool {
 }
 
 // buildFilteredTargetExpr constructs a JS expression for element targeting.
-// When hasText or attValue are specified, it builds a querySelectorAll chain
-// with .hasText()/.hasAttValue() filters. Otherwise returns the selector(s) as-is.
-func buildFilteredTargetExpr(selectors []string, hasText, attValue string, preferInner bool) string {
-	if hasText == "" && attValue == "" {
+// When hasText, attValue or att are specified, it builds a querySelectorAll
+// chain with .hasText()/.hasAttValue()/.hasAtt() filters. Otherwise returns
+// the selector(s) as-is.
+func buildFilteredTargetExpr(selectors []string, hasText, attValue, att string, preferInner bool) string {
+	if hasText == "" && attValue == "" && att == "" {
 		if len(selectors) == 1 {
 			return strconv.Quote(selectors[0])
 		}
@@ -50,29 +70,15 @@ func buildFilteredTargetExpr(selectors []string, hasText, attValue string, prefe
 		return string(b)
 	}
 
-	addFilters := func(expr string) string {
-		if hasText != "" {
-			expr += fmt.Sprintf(`.hasText(%s)`, strconv.Quote(hasText))
-		}
-		if attValue != "" {
-			expr += fmt.Sprintf(`.hasAttValue(%s)`, strconv.Quote(attValue))
-		}
-		if preferInner {
-			expr += `.preferInner()`
-		}
-		return expr
-	}
-
 	if len(selectors) == 1 {
-		return addFilters(fmt.Sprintf(`document.querySelectorAll(%s)`, strconv.Quote(selectors[0])))
+		return filteredQueryExpr(selectors[0], hasText, attValue, att, preferInner)
 	}
 
 	// Multiple selectors: try each in order to preserve priority (e.g. "button" before "div").
 	var b strings.Builder
 	b.WriteString("(function(){var r;")
 	for i, sel := range selectors {
-		expr := addFilters(fmt.Sprintf(`document.querySelectorAll(%s)`, strconv.Quote(sel)))
-		fmt.Fprintf(&b, "r=%s;", expr)
+		fmt.Fprintf(&b, "r=%s;", filteredQueryExpr(sel, hasText, attValue, att, preferInner))
 		if i < len(selectors)-1 {
 			b.WriteString("if(r.length)return r;")
 		}
@@ -81,11 +87,79 @@ func buildFilteredTargetExpr(selectors []string, hasText, attValue string, prefe
 	return b.String()
 }
 
+// buildRankedTargetExpr gathers the filtered matches of every selector and
+// lets WebNav put the focused element, then visible ones, first (see
+// WebNav.rankCandidates); selector order breaks ties.
+func buildRankedTargetExpr(selectors []string, hasText, attValue, att string) string {
+	groups := make([]string, len(selectors))
+	for i, sel := range selectors {
+		groups[i] = fmt.Sprintf("[%s, %s]", strconv.Quote(sel), filteredQueryExpr(sel, hasText, attValue, att, false))
+	}
+	return fmt.Sprintf("window.WebNavRankCandidates([%s])", strings.Join(groups, ", "))
+}
+
+// filteredQueryExpr is querySelectorAll(sel) narrowed by the WebNav filters.
+// att is an --att "name=REGEX" filter already checked by parseAttFilter.
+func filteredQueryExpr(sel, hasText, attValue, att string, preferInner bool) string {
+	expr := fmt.Sprintf(`document.querySelectorAll(%s)`, strconv.Quote(sel))
+	if hasText != "" {
+		expr += fmt.Sprintf(`.hasText(%s)`, strconv.Quote(hasText))
+	}
+	if attValue != "" {
+		expr += fmt.Sprintf(`.hasAttValue(%s)`, strconv.Quote(attValue))
+	}
+	if att != "" {
+		name, pattern, _ := strings.Cut(att, "=")
+		expr += fmt.Sprintf(`.hasAtt(%s, %s)`, strconv.Quote(name), strconv.Quote(pattern))
+	}
+	if preferInner {
+		expr += `.preferInner()`
+	}
+	return expr
+}
+
+// buildMatchInfoExpr mirrors how buildFilteredTargetExpr picks an element (the
+// first match of the first selector with any) and describes that choice: the
+// selector, the element's index among all querySelectorAll(selector) results,
+// how many elements passed the filters, and how many candidates there were.
+func buildMatchInfoExpr(selectors []string, hasText, attValue, att string, preferInner bool) string {
+	var b strings.Builder
+	b.WriteString("(() => {")
+	for _, sel := range selectors {
+		fmt.Fprintf(&b, `
+        {
+            const list = Array.from(%s);
+            if (list.length) {
+                const all = Array.from(document.querySelectorAll(%s));
+                return {selector: %s, index: all.indexOf(list[0]), total: list.length, candidates: all.length};
+            }
+        }`, filteredQueryExpr(sel, hasText, attValue, att, preferInner), strconv.Quote(sel), strconv.Quote(sel))
+	}
+	b.WriteString("\n        return null;\n    })()")
+	return b.String()
+}
+
+var injectHelp = commandHelp{
+	Description: `Injects the WebNav helpers, which click, type and the other input commands use,
+along with the session's user scripts, so your own eval scripts can call
+window.WebNav. It normally happens automatically; --force re-injects after you
+edit a script.`,
+	Examples: []commandExample{
+		{"cdp inject --session app", "Make sure WebNav is present."},
+		{"cdp inject --session app --list", "Show the configured and injected scripts."},
+		{"cdp inject --session app --auto on", "Keep WebNav across navigations on later connections."},
+		{"cdp inject --session app --force --persist", "Re-inject and register for new documents."},
+	},
+}
+
 func cmdInject(args []string) error {
-	fs := newFlagSet("inject", "usage: cdp inject --session <name> [--force]")
+	fs := newFlagSet("inject", "usage: cdp inject --session <name> [--force] [--list] [--persist] [--auto on|off]\n\n--auto on makes every command that uses WebNav register it (and the user scripts)\nfor new documents on its connection, so a navigation partway through a long-lived\ncommand (cdp repl, --watch modes) doesn't lose the helpers. DevTools drops the\nregistration when a connection closes, so each connection registers again once.")
 	sessionFlag := addSessionFlag(fs)
 	force := fs.Bool("force", false, "Force re-injection even if WebNav is already present")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	list := fs.Bool("list", false, "Show configured user scripts and whether the page has them")
+	persist := fs.Bool("persist", false, "Also inject into every new document, staying attached until Ctrl+C")
+	auto := fs.String("auto", "", "on|off: register WebNav for new documents whenever this session is used")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -97,6 +171,12 @@ func cmdInject(args []string) error {
 	if err := unexpectedArgs(pos); err != nil {
 		return err
 	}
+	if *list && (*force || *persist) {
+		return errors.New("--list cannot be combined with --force or --persist")
+	}
+	if *auto != "" && *auto != "on" && *auto != "off" {
+		return fmt.Errorf("invalid --auto %q (expected on or off)", *auto)
+	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
 		fs.Usage()
@@ -107,7 +187,7 @@ func cmdInject(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -116,22 +196,124 @@ func cmdInject(args []string) error {
 	}
 	defer handle.Close()
 
+	if *list {
+		return listInjected(ctx, handle)
+	}
+	if *auto != "" {
+		handle.session.PersistWebNav = *auto == "on"
+		if *auto == "off" {
+			fmt.Printf("WebNav registration for new documents off for %s\n", name)
+			return nil
+		}
+		fmt.Printf("WebNav registration for new documents on for %s\n", name)
+	}
+
 	if err := injectWebNav(ctx, handle.client, *force); err != nil {
 		return err
 	}
+	if err := injectUserScripts(ctx, handle.client, *force); err != nil {
+		return err
+	}
 	fmt.Printf("Injected WebNav helpers into %s\n", name)
+	for _, script := range handle.session.UserScripts {
+		fmt.Printf("  user script: %s\n", script.Path)
+	}
+	if !*persist {
+		return nil
+	}
+
+	// Scripts added this way only live as long as our DevTools session, so stay
+	// attached until interrupted.
+	if err := registerWebNavOnNewDocument(ctx, handle.client, handle.session.UserScripts); err != nil {
+		return err
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	fmt.Fprintln(os.Stderr, "Injecting into new documents. Ctrl+C to stop.")
+	select {
+	case <-sigCh:
+	case <-handle.client.Done():
+	}
 	return nil
 }
 
-func cmdClick(args []string) error {
-	fs := newFlagSet("click", "usage: cdp click --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX] [--count N] [--submit-wait-ms N]\n(also supports inline :has-text(...) at the end of the selector)")
+// listInjected prints WebNav's presence plus each configured user script's
+// file state and whether the page has that exact version.
+func listInjected(ctx context.Context, handle *sessionHandle) error {
+	ok, err := isWebNavInjected(ctx, handle.client)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Printf("WebNav: present (v%d)\n", webNavVersion)
+	} else {
+		fmt.Printf("WebNav: not injected (current v%d)\n", webNavVersion)
+	}
+	if handle.session.PersistWebNav {
+		fmt.Println("New documents: WebNav registered on each connection (--auto on)")
+	}
+	if len(handle.session.UserScripts) == 0 {
+		fmt.Println("User scripts: none configured (see cdp connect --user-script)")
+		return nil
+	}
+	present, err := pageUserScripts(ctx, handle.client)
+	if err != nil {
+		return err
+	}
+	fmt.Println("User scripts:")
+	for _, configured := range handle.session.UserScripts {
+		state := "missing from page"
+		script, _, err := loadUserScript(configured.Path)
+		switch {
+		case err != nil:
+			state = "file unreadable"
+		case present[script.Path] == script.Hash:
+			state = "present"
+		case present[script.Path] != "":
+			state = "stale (file changed since injection)"
+		}
+		fmt.Printf("  %s [%s] %s\n", configured.Path, configured.Hash, state)
+	}
+	return nil
+}
+
+var clickHelp = commandHelp{
+	Description: `Clicks the first element that matches the selector and the --has-text and
+--att-value filters, dispatching real pointer and mouse events. After a submit
+button it waits briefly for the navigation; --assert-change turns a click that
+changed nothing into an error.`,
+	Examples: []commandExample{
+		{`cdp click --session app "button[type=submit]"`, "Click the submit button."},
+		{`cdp click --session app button --has-text "^Save$"`, "Click the button whose text is exactly Save."},
+		{`cdp click --session app ".row" --count 2`, "Double click a row."},
+		{`cdp click --session app "#export" --wait-idle-ui`, "Wait for spinners and loading placeholders to clear, then click."},
+		{`cdp click --session app ".menu a" --att-value /settings --assert-change --json`, "Click the settings link and fail if nothing happened."},
+		{`cdp click --session app button --att "data-testid=^submit$"`, "Click the button whose data-testid is exactly submit, ignoring other attributes."},
+		{`cdp click --session app "button.save" --attach-console`, "On failure, also print the page's recent console errors and exceptions."},
+	},
+}
+
+func cmdClick(args []string) (err error) {
+	fs := newFlagSet("click", "usage: cdp click --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX] [--att name=REGEX] [--count N] [--as-dblclick=false] [--submit-wait-ms N] [--assert-change] [--json]\n(also supports inline :has-text(...) at the end of the selector)\n\nWithout a selector, button then div elements are searched. --json reports which\nselector matched, the element's index among its matches, and the match counts.\n--count 2 is dispatched as a double click (two clicks with detail 1 and 2, then\ndblclick); other counts are plain repeated clicks.")
 	sessionFlag := addSessionFlag(fs)
 	hasText := fs.String("has-text", "", "Only match elements whose text matches this regex (JS RegExp; accepts /pat/flags or pat)")
 	attValue := fs.String("att-value", "", "Only match elements with at least one attribute value matching this regex (JS RegExp; accepts /pat/flags or pat)")
-	preferInner := fs.String("prefer-inner", "auto", "Prefer inner matches when using --has-text/--att-value (yes|no|auto)")
+	att := fs.String("att", "", "Only match elements whose named attribute matches the regex, as name=REGEX (a bare name only requires the attribute)")
+	preferInner := fs.String("prefer-inner", "auto", "Prefer inner matches when using --has-text/--att-value/--att (yes|no|auto)")
 	count := fs.Int("count", 1, "Number of clicks to perform")
+	asDblclick := fs.Bool("as-dblclick", true, "With --count 2, dispatch a double click (mousedown/mouseup/click twice, then dblclick)")
 	submitWaitMS := fs.Int("submit-wait-ms", 700, "If clicking a submit button inside a form, wait N ms before returning (0 disables)")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	assertChange := fs.Bool("assert-change", false, "Exit non-zero if the click caused no DOM mutation and no navigation")
+	assertWindow := fs.Duration("assert-window", 300*time.Millisecond, "How long --assert-change watches for mutations after the click")
+	jsonOut := fs.Bool("json", false, "Print the result as JSON, including which selector and element matched")
+	pretty := fs.Bool("pretty", defaultPretty(), "Pretty print JSON output")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	noReadyCheck := addReadyCheckFlag(fs)
+	previewLimit := addPreviewLimitFlag(fs)
+	attachConsole := addAttachConsoleFlag(fs)
+	idleUI := addIdleUIFlags(fs)
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -145,59 +327,29 @@ func cmdClick(args []string) error {
 		selector = pos[0]
 	}
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
-	inlineHasText := ""
-	hasInline := false
-	if selector != "" {
-		selector, inlineHasText, hasInline, err = parseInlineHasText(selector)
-		if err != nil {
-			return err
-		}
-		if err := rejectUnsupportedSelector(selector, "click", true); err != nil {
-			return err
-		}
-	} else if *hasText == "" {
-		return errors.New("usage: cdp click --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX] [--count N] [--submit-wait-ms N]")
+	if selector == "" && *hasText == "" {
+		return usagef("usage: cdp click --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX] [--att name=REGEX] [--count N] [--submit-wait-ms N]")
 	}
 	if *count < 1 {
 		return errors.New("--count must be >= 1")
 	}
-	selectors := []string{}
-	if selector != "" {
-		selectors = append(selectors, autoQuoteAttrValues(selector))
-	} else {
-		// Default element types when a selector isn't provided.
-		selectors = append(selectors, "button", "div")
-	}
-	for _, sel := range selectors {
-		if err := rejectUnsupportedSelector(sel, "click", true); err != nil {
-			return err
-		}
-	}
-	hasTextValue := *hasText
-	if hasInline {
-		hasTextValue = inlineHasText
+	clickOpts := ClickOptions{
+		Selector:    selector,
+		HasText:     *hasText,
+		AttValue:    *attValue,
+		Att:         *att,
+		PreferInner: *preferInner,
+		Count:       *count,
+		NoDblclick:  !*asDblclick,
+		Verbose:     *verbose,
 	}
-	attValueValue := *attValue
-
-	preferInnerMode := strings.ToLower(strings.TrimSpace(*preferInner))
-	if preferInnerMode == "" {
-		preferInnerMode = "auto"
-	}
-	if preferInnerMode != "yes" && preferInnerMode != "no" && preferInnerMode != "auto" {
-		return errors.New("--prefer-inner must be one of: yes, no, auto")
+	if *assertChange {
+		clickOpts.ObserveMS = int(assertWindow.Milliseconds())
 	}
-	usePreferInner := false
-	if hasTextValue != "" || attValueValue != "" {
-		switch preferInnerMode {
-		case "yes":
-			usePreferInner = true
-		case "no":
-			usePreferInner = false
-		case "auto":
-			usePreferInner = selector == "" || isBareTagSelector(selector)
-		}
+	if _, err := clickOpts.target(); err != nil {
+		return err
 	}
 
 	name, err := resolveSessionName(*sessionFlag)
@@ -209,7 +361,7 @@ func cmdClick(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -217,81 +369,79 @@ func cmdClick(args []string) error {
 		return err
 	}
 	defer handle.Close()
+	defer attachPageErrors(&err, watchPageErrors(ctx, handle.client, *attachConsole))
 
-	if err := ensureWebNavInjected(ctx, handle.client); err != nil {
-		return err
-	}
-
-	targetExpr := buildFilteredTargetExpr(selectors, hasTextValue, attValueValue, usePreferInner)
-
-	readOpts := map[string]interface{}{
-		"waitMs":     0,
-		"hasText":    "",
-		"attValue":   "",
-		"classLimit": 3,
+	if !*noReadyCheck {
+		warnIfLoading(ctx, handle.client, "Run 'cdp wait --session "+name+"' first")
 	}
-	readOptsJSON, _ := json.Marshal(readOpts)
-
-	expression := fmt.Sprintf(`window.WebNavClickWithRead(%s, %d, %s)`, targetExpr, *count, string(readOptsJSON))
-	raw, err := handle.client.EvaluateRaw(ctx, expression, false)
-	if err != nil {
+	if err := idleUI.waitBefore(ctx, handle.client, fs, name); err != nil {
 		return err
 	}
-	valueAny, err := handle.client.RemoteObjectValue(ctx, raw.Result)
+	clicked, err := ClickElement(ctx, handle.client, clickOpts)
 	if err != nil {
+		if *assertChange && classifyContextError(err) == contextErrDestroyed {
+			// A full navigation tore down the page mid-evaluation, which is a change.
+			fmt.Println("Clicked element; the page navigated")
+			return nil
+		}
 		return err
 	}
-	value, ok := valueAny.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("unexpected WebNavClickWithRead result type %T", valueAny)
-	}
+	beforeText, afterText := clicked.Before, clicked.After
 
-	beforeText := ""
-	if before, ok := value["before"].(map[string]interface{}); ok {
-		if linesAny, ok := before["lines"].([]interface{}); ok {
-			lines := make([]string, 0, len(linesAny))
-			for _, v := range linesAny {
-				if s, ok := v.(string); ok {
-					lines = append(lines, s)
-				} else if v != nil {
-					lines = append(lines, fmt.Sprint(v))
-				}
-			}
-			beforeText = strings.Join(lines, "\n")
-		}
-	}
-	afterText := ""
-	if after, ok := value["after"].(map[string]interface{}); ok {
-		if linesAny, ok := after["lines"].([]interface{}); ok {
-			lines := make([]string, 0, len(linesAny))
-			for _, v := range linesAny {
-				if s, ok := v.(string); ok {
-					lines = append(lines, s)
-				} else if v != nil {
-					lines = append(lines, fmt.Sprint(v))
-				}
-			}
-			afterText = strings.Join(lines, "\n")
+	if *submitWaitMS > 0 {
+		if clicked.SubmitForm {
+			time.Sleep(time.Duration(*submitWaitMS) * time.Millisecond)
 		}
 	}
 
-	beforeDisp := cropForTTY(beforeText, 300)
+	tag, match := clicked.Tag, clicked.Match
 
-	if *submitWaitMS > 0 {
-		if submit, _ := value["submitForm"].(bool); submit {
-			time.Sleep(time.Duration(*submitWaitMS) * time.Millisecond)
+	var changeErr error
+	change := ""
+	if *assertChange {
+		switch {
+		case clicked.URLBefore != clicked.URLAfter:
+			change = "navigated to " + clicked.URLAfter
+		case clicked.Mutations > 0 || beforeText != afterText:
+			change = fmt.Sprintf("%d DOM mutation(s)", clicked.Mutations)
+		default:
+			changeErr = fmt.Errorf("click had no effect: no DOM mutation or navigation within %s", *assertWindow)
 		}
 	}
 
-	tag, _ := value["tagName"].(string)
-	if tag == "" {
-		tag = "element"
+	if *jsonOut {
+		result := map[string]interface{}{
+			"tag":    tag,
+			"clicks": *count,
+			"events": clicked.Events,
+			"match":  match,
+			"before": beforeText,
+			"after":  afterText,
+		}
+		if clicked.SubmitForm {
+			result["submitForm"] = true
+		}
+		if change != "" {
+			result["change"] = change
+		}
+		output, err := jsonStyle.JSON(result, *pretty, -1)
+		if err != nil {
+			return err
+		}
+		fmt.Println(output)
+		return changeErr
 	}
+
 	if *count == 1 {
 		fmt.Printf("Clicked %s:\n", tag)
 	} else {
-		fmt.Printf("Clicked %s %d times:\n", tag, *count)
+		fmt.Printf("Clicked %s %d times (%s):\n", tag, *count, clicked.Events)
+	}
+	if total, _ := match["total"].(float64); total > 1 {
+		sel, _ := match["selector"].(string)
+		fmt.Fprintf(os.Stderr, "note: %d elements matched %s; clicked the first (use --json for details)\n", int(total), sel)
 	}
+	beforeDisp := cropForTTY(beforeText, *previewLimit)
 	if strings.TrimSpace(beforeDisp) != "" {
 		fmt.Print(beforeDisp)
 		if !strings.HasSuffix(beforeDisp, "\n") {
@@ -299,7 +449,7 @@ func cmdClick(args []string) error {
 		}
 	}
 
-	afterDisp := cropForTTY(afterText, 300)
+	afterDisp := cropForTTY(afterText, *previewLimit)
 	if beforeDisp != afterDisp && strings.TrimSpace(afterDisp) != "" {
 		fmt.Print("after the click, element updated to:\n")
 		fmt.Print(afterDisp)
@@ -307,17 +457,36 @@ func cmdClick(args []string) error {
 			fmt.Print("\n")
 		}
 	}
-	return nil
+	if change != "" {
+		fmt.Printf("change: %s\n", change)
+	}
+	return changeErr
+}
+
+var hoverHelp = commandHelp{
+	Description: `Moves the pointer over an element and dispatches the enter and over events that
+open menus and tooltips. --hold keeps the pointer there for a while. To capture
+the hover state, see 'cdp screenshot --hover'.`,
+	Examples: []commandExample{
+		{`cdp hover --session app ".nav .products"`, "Open the products menu."},
+		{`cdp hover --session app "[data-tooltip]" --has-text Help --hold 2s`, "Hover the Help tooltip trigger for two seconds."},
+		{"cdp hover --session app img --att-value avatar", "Hover the image whose attributes mention avatar."},
+		{"cdp hover --session app button --att data-testid=^menu-", "Hover the button whose data-testid starts with menu-."},
+	},
 }
 
-func cmdHover(args []string) error {
-	fs := newFlagSet("hover", "usage: cdp hover --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX]\n(also supports inline :has-text(...) at the end of the selector)")
+func cmdHover(args []string) (err error) {
+	fs := newFlagSet("hover", "usage: cdp hover --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX] [--att name=REGEX]\n(also supports inline :has-text(...) at the end of the selector)")
 	sessionFlag := addSessionFlag(fs)
 	hasText := fs.String("has-text", "", "Only match elements whose text matches this regex (JS RegExp; accepts /pat/flags or pat)")
 	attValue := fs.String("att-value", "", "Only match elements with at least one attribute value matching this regex (JS RegExp; accepts /pat/flags or pat)")
-	preferInner := fs.String("prefer-inner", "auto", "Prefer inner matches when using --has-text/--att-value (yes|no|auto)")
+	att := fs.String("att", "", "Only match elements whose named attribute matches the regex, as name=REGEX (a bare name only requires the attribute)")
+	preferInner := fs.String("prefer-inner", "auto", "Prefer inner matches when using --has-text/--att-value/--att (yes|no|auto)")
 	hold := fs.Duration("hold", 0, "Optional time to wait after hovering")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	previewLimit := addPreviewLimitFlag(fs)
+	attachConsole := addAttachConsoleFlag(fs)
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -331,7 +500,7 @@ func cmdHover(args []string) error {
 		selector = pos[0]
 	}
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	inlineHasText := ""
 	hasInline := false
@@ -344,7 +513,7 @@ func cmdHover(args []string) error {
 			return err
 		}
 	} else if *hasText == "" {
-		return errors.New("usage: cdp hover --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX]")
+		return usagef("usage: cdp hover --session <name> [\".selector\"] [--has-text REGEX] [--att-value REGEX] [--att name=REGEX]")
 	}
 	selectors := []string{}
 	if selector != "" {
@@ -362,6 +531,9 @@ func cmdHover(args []string) error {
 		hasTextValue = inlineHasText
 	}
 	attValueValue := *attValue
+	if err := checkAttFilter(*att); err != nil {
+		return err
+	}
 	preferInnerMode := strings.ToLower(strings.TrimSpace(*preferInner))
 	if preferInnerMode == "" {
 		preferInnerMode = "auto"
@@ -370,7 +542,7 @@ func cmdHover(args []string) error {
 		return errors.New("--prefer-inner must be one of: yes, no, auto")
 	}
 	usePreferInner := false
-	if hasTextValue != "" || attValueValue != "" {
+	if hasTextValue != "" || attValueValue != "" || *att != "" {
 		switch preferInnerMode {
 		case "yes":
 			usePreferInner = true
@@ -390,7 +562,7 @@ func cmdHover(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -398,12 +570,13 @@ func cmdHover(args []string) error {
 		return err
 	}
 	defer handle.Close()
+	defer attachPageErrors(&err, watchPageErrors(ctx, handle.client, *attachConsole))
 
 	if err := ensureWebNavInjected(ctx, handle.client); err != nil {
 		return err
 	}
 
-	targetExpr := buildFilteredTargetExpr(selectors, hasTextValue, attValueValue, usePreferInner)
+	targetExpr := buildFilteredTargetExpr(selectors, hasTextValue, attValueValue, *att, usePreferInner)
 	readOpts := map[string]interface{}{
 		"waitMs":     0,
 		"hasText":    "",
@@ -413,12 +586,11 @@ func cmdHover(args []string) error {
 	readOptsJSON, _ := json.Marshal(readOpts)
 	expression := fmt.Sprintf(`window.WebNavHoverWithRead(%s, %s, %d)`, targetExpr, string(readOptsJSON), hold.Milliseconds())
 
-	raw, err := handle.client.EvaluateRaw(ctx, expression, false)
-	if err != nil {
-		return err
-	}
-	valueAny, err := handle.client.RemoteObjectValue(ctx, raw.Result)
+	valueAny, err := runWebNavAction(ctx, handle.client, *verbose, "WebNavHoverWithRead", expression)
 	if err != nil {
+		if selector != "" && hasTextValue == "" && attValueValue == "" && *att == "" {
+			err = withSelectorSuggestions(ctx, handle.client, selectors[0], err)
+		}
 		return err
 	}
 	value, ok := valueAny.(map[string]interface{})
@@ -455,7 +627,7 @@ func cmdHover(args []string) error {
 		}
 	}
 
-	beforeDisp := cropForTTY(beforeText, 300)
+	beforeDisp := cropForTTY(beforeText, *previewLimit)
 	tag, _ := value["tagName"].(string)
 	if tag == "" {
 		tag = "element"
@@ -468,7 +640,7 @@ func cmdHover(args []string) error {
 		}
 	}
 
-	afterDisp := cropForTTY(afterText, 300)
+	afterDisp := cropForTTY(afterText, *previewLimit)
 	if beforeDisp != afterDisp && strings.TrimSpace(afterDisp) != "" {
 		fmt.Print("after the hover, element updated to:\n")
 		fmt.Print(afterDisp)
@@ -479,13 +651,27 @@ func cmdHover(args []string) error {
 	return nil
 }
 
+var dragHelp = commandHelp{
+	Description: `Drags one element onto another with a pointer-down, a series of moves and a
+pointer-up. This works with both HTML5 drag and drop and pointer-based sortable
+lists. --to-position picks where on the target to drop.`,
+	Examples: []commandExample{
+		{`cdp drag --session app ".card" ".column.done"`, "Move the first card to the Done column."},
+		{`cdp drag --session app "li.item" "li.item" --from-index 3 --to-index 0 --to-position top`, "Move the fourth list item above the first."},
+		{`cdp drag --session app ".slider-handle" ".slider-track" --to-position 90,0 --steps 20`, "Drag a slider handle in 20 steps."},
+	},
+}
+
 func cmdDrag(args []string) error {
-	fs := newFlagSet("drag", "usage: cdp drag --session <name> \".from\" \".to\"")
+	fs := newFlagSet("drag", "usage: cdp drag --session <name> \".from\" \".to\" [--to-position top|bottom|center|x,y] [--steps N]")
 	sessionFlag := addSessionFlag(fs)
 	fromIndex := fs.Int("from-index", 0, "Index within the source selector (0-based)")
 	toIndex := fs.Int("to-index", 0, "Index within the target selector (0-based)")
 	delay := fs.Duration("delay", 0, "Delay between drag events (e.g. 50ms)")
-	timeout := fs.Duration("timeout", 8*time.Second, "Command timeout")
+	toPosition := fs.String("to-position", "center", "Drop point in the target: top, bottom, left, right, center, or x,y fractions of its box (e.g. 0.5,0.1)")
+	steps := fs.Int("steps", 0, "Intermediate dragover events along the path (for libraries that track the hover sequence)")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	timeout := addTimeoutFlag(fs, 8*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -495,12 +681,12 @@ func cmdDrag(args []string) error {
 		return err
 	}
 	if len(pos) < 2 {
-		return errors.New("usage: cdp drag --session <name> \".from\" \".to\"")
+		return usagef("usage: cdp drag --session <name> \".from\" \".to\"")
 	}
 	fromSelector := pos[0]
 	toSelector := pos[1]
 	if len(pos) > 2 {
-		return fmt.Errorf("unexpected argument: %s", pos[2])
+		return usagef("unexpected argument: %s", pos[2])
 	}
 	if err := rejectUnsupportedSelector(fromSelector, "drag --from", false); err != nil {
 		return err
@@ -511,6 +697,13 @@ func cmdDrag(args []string) error {
 	if *fromIndex < 0 || *toIndex < 0 {
 		return errors.New("indices must be >= 0")
 	}
+	dropX, dropY, err := parseDropPosition(*toPosition)
+	if err != nil {
+		return err
+	}
+	if *steps < 0 {
+		return errors.New("--steps must be >= 0")
+	}
 
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
@@ -521,7 +714,7 @@ func cmdDrag(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -535,21 +728,129 @@ func cmdDrag(args []string) error {
 	}
 
 	delayMS := delay.Milliseconds()
-	expression := fmt.Sprintf(`window.WebNavDrag(%s, %s, %d, %d, %d)`, strconv.Quote(fromSelector), strconv.Quote(toSelector), *fromIndex, *toIndex, delayMS)
-
-	if _, err := handle.client.Evaluate(ctx, expression); err != nil {
+	dragOpts, _ := json.Marshal(map[string]interface{}{
+		"position": map[string]float64{"x": dropX, "y": dropY},
+		"steps":    *steps,
+	})
+	expression := fmt.Sprintf(`window.WebNavDrag(%s, %s, %d, %d, %d, %s)`, strconv.Quote(fromSelector), strconv.Quote(toSelector), *fromIndex, *toIndex, delayMS, dragOpts)
+
+	var value interface{}
+	if err := withWebNavRetry(ctx, handle.client, *verbose, func() error {
+		var err error
+		value, err = handle.client.Evaluate(ctx, expression)
+		return err
+	}); err != nil {
 		return err
 	}
 	fmt.Printf("Dragged: %s[%d] -> %s[%d]\n", fromSelector, *fromIndex, toSelector, *toIndex)
+	if m, ok := value.(map[string]interface{}); ok {
+		if drop, ok := m["drop"].(map[string]interface{}); ok {
+			fmt.Printf("Drop point: (%v, %v) [%s]\n", drop["x"], drop["y"], *toPosition)
+		}
+		if under, ok := m["underDrop"].([]interface{}); ok && len(under) > 0 {
+			labels := make([]string, 0, len(under))
+			for _, u := range under {
+				labels = append(labels, fmt.Sprint(u))
+			}
+			fmt.Printf("Under drop point: %s\n", strings.Join(labels, " < "))
+		}
+	}
 	return nil
 }
 
+// parseDropPosition maps --to-position to fractions of the target's box.
+func parseDropPosition(spec string) (float64, float64, error) {
+	switch strings.ToLower(strings.TrimSpace(spec)) {
+	case "", "center":
+		return 0.5, 0.5, nil
+	case "top":
+		return 0.5, 0, nil
+	case "bottom":
+		return 0.5, 1, nil
+	case "left":
+		return 0, 0.5, nil
+	case "right":
+		return 1, 0.5, nil
+	}
+	xs, ys, ok := strings.Cut(spec, ",")
+	if ok {
+		x, errX := strconv.ParseFloat(strings.TrimSpace(xs), 64)
+		y, errY := strconv.ParseFloat(strings.TrimSpace(ys), 64)
+		if errX == nil && errY == nil && x >= 0 && x <= 1 && y >= 0 && y <= 1 {
+			return x, y, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("invalid --to-position %q (use top, bottom, left, right, center, or x,y fractions between 0 and 1)", spec)
+}
+
+// gesturePoint is one step of a gesture path; pressure is 0-1 (0.5 if omitted).
+type gesturePoint struct{ x, y, pressure float64 }
+
+// parseGesturePath parses "x1,y1[,p1] x2,y2[,p2] ...". Relative points must lie
+// within the element (0-1); absolute ones are bounds-checked in the page.
+func parseGesturePath(pathStr string, absolute bool) ([]gesturePoint, error) {
+	parts := strings.Fields(pathStr)
+	if len(parts) < 2 {
+		return nil, errors.New("path must have at least 2 points (e.g. \"0.1,0.5 0.9,0.5\")")
+	}
+	points := make([]gesturePoint, 0, len(parts))
+	for _, p := range parts {
+		fields := strings.Split(p, ",")
+		if len(fields) != 2 && len(fields) != 3 {
+			return nil, fmt.Errorf("invalid point %q (expected x,y or x,y,pressure)", p)
+		}
+		vals := make([]float64, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid point %q (expected numeric x,y)", p)
+			}
+			vals[i] = v
+		}
+		pt := gesturePoint{x: vals[0], y: vals[1], pressure: 0.5}
+		if len(vals) == 3 {
+			if vals[2] < 0 || vals[2] > 1 {
+				return nil, fmt.Errorf("invalid pressure in %q (expected 0-1)", p)
+			}
+			pt.pressure = vals[2]
+		}
+		if absolute {
+			if pt.x < 0 || pt.y < 0 {
+				return nil, fmt.Errorf("point %q is outside the viewport", p)
+			}
+		} else if pt.x < 0 || pt.x > 1 || pt.y < 0 || pt.y > 1 {
+			return nil, fmt.Errorf("point %q is outside the element (relative coordinates are 0-1; use --absolute for pixels)", p)
+		}
+		points = append(points, pt)
+	}
+	return points, nil
+}
+
+var gestureHelp = commandHelp{
+	Description: `Presses at the first point, moves through the rest and releases. Points are
+fractions (0-1) of the element's box unless --absolute makes them viewport
+pixels, and an optional third component sets pressure (0-1). Use it to draw on
+canvases, swipe carousels or trace paths. --touch sends touch events instead of
+mouse events.`,
+	Examples: []commandExample{
+		{`cdp gesture --session app canvas "0.1,0.5 0.9,0.5"`, "Draw a horizontal stroke across a canvas."},
+		{`cdp gesture --session app ".slider" "0.0,0.5 1.0,0.5"`, "Slide a slider fully to the right."},
+		{`cdp gesture --session app ".pad" "0.2,0.2 0.8,0.2 0.8,0.8"`, "Trace an L-shaped path."},
+		{`cdp gesture --session app ".carousel" "0.9,0.5 0.1,0.5" --touch --delay 10ms`, "Swipe a carousel left with touch input."},
+		{`cdp gesture --session app --absolute --touch "300,600 300,200"`, "Swipe up in viewport pixels."},
+	},
+}
+
 func cmdGesture(args []string) error {
-	usage := "usage: cdp gesture --session <name> \".selector\" \"x1,y1 x2,y2 ...\"  (draw, swipe, slide, trace)"
-	fs := newFlagSet("gesture", usage+"\n\nPress-move-release along a path within an element.\nCoordinates are relative (0-1) to the element's bounding box.\n\nExamples:\n  cdp gesture mgr \"canvas\" \"0.1,0.5 0.9,0.5\"        # horizontal stroke\n  cdp gesture mgr \".slider\" \"0.0,0.5 1.0,0.5\"        # slide fully right\n  cdp gesture mgr \".pad\" \"0.2,0.2 0.8,0.2 0.8,0.8\"   # L-shaped path")
+	usage := "usage: cdp gesture --session <name> \".selector\" \"x1,y1[,p] x2,y2[,p] ...\"  (draw, swipe, slide, trace)\nor:    cdp gesture --session <name> --absolute \"x1,y1 x2,y2 ...\""
+	fs := newFlagSet("gesture", usage)
 	sessionFlag := addSessionFlag(fs)
 	delay := fs.Duration("delay", 50*time.Millisecond, "Delay between pointer events")
-	timeout := fs.Duration("timeout", 12*time.Second, "Command timeout")
+	absolute := fs.Bool("absolute", false, "Interpret points as viewport pixels (selector optional)")
+	touch := fs.Bool("touch", false, "Dispatch touch events instead of pointer/mouse events")
+	useCDP := fs.Bool("cdp", false, "Use CDP Input.dispatchMouseEvent/dispatchTouchEvent instead of JS events")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	timeout := addTimeoutFlag(fs, 12*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -558,16 +859,22 @@ func cmdGesture(args []string) error {
 	if err != nil {
 		return err
 	}
-	if len(pos) < 2 {
+	selector := ""
+	pathStr := ""
+	switch {
+	case len(pos) == 1 && *absolute:
+		pathStr = pos[0]
+	case len(pos) == 2:
+		selector, pathStr = pos[0], pos[1]
+	case len(pos) > 2:
+		return usagef("unexpected argument: %s", pos[2])
+	default:
 		return errors.New(usage)
 	}
-	selector := pos[0]
-	pathStr := pos[1]
-	if len(pos) > 2 {
-		return fmt.Errorf("unexpected argument: %s", pos[2])
-	}
-	if err := rejectUnsupportedSelector(selector, "gesture", false); err != nil {
-		return err
+	if selector != "" {
+		if err := rejectUnsupportedSelector(selector, "gesture", false); err != nil {
+			return err
+		}
 	}
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
@@ -575,41 +882,26 @@ func cmdGesture(args []string) error {
 		return err
 	}
 
-	// Parse path: "x1,y1 x2,y2 ..." where each coord is 0-1 relative to element bounds.
-	parts := strings.Fields(pathStr)
-	if len(parts) < 2 {
-		return errors.New("path must have at least 2 points (e.g. \"0.1,0.5 0.9,0.5\")")
-	}
-	type point struct{ x, y float64 }
-	points := make([]point, 0, len(parts))
-	for _, p := range parts {
-		xy := strings.SplitN(p, ",", 2)
-		if len(xy) != 2 {
-			return fmt.Errorf("invalid point %q (expected x,y)", p)
-		}
-		x, errX := strconv.ParseFloat(xy[0], 64)
-		y, errY := strconv.ParseFloat(xy[1], 64)
-		if errX != nil || errY != nil {
-			return fmt.Errorf("invalid point %q (expected numeric x,y)", p)
-		}
-		points = append(points, point{x, y})
+	points, err := parseGesturePath(pathStr, *absolute)
+	if err != nil {
+		return err
 	}
-
 	var pointsJSON strings.Builder
 	pointsJSON.WriteByte('[')
 	for i, pt := range points {
 		if i > 0 {
 			pointsJSON.WriteByte(',')
 		}
-		fmt.Fprintf(&pointsJSON, "[%g,%g]", pt.x, pt.y)
+		fmt.Fprintf(&pointsJSON, "[%g,%g,%g]", pt.x, pt.y, pt.pressure)
 	}
 	pointsJSON.WriteByte(']')
+	optsJSON := fmt.Sprintf(`{absolute: %t, touch: %t}`, *absolute, *touch)
 
 	st, err := store.Load()
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -622,23 +914,175 @@ func cmdGesture(args []string) error {
 		return err
 	}
 
-	delayMS := delay.Milliseconds()
-	expression := fmt.Sprintf(`window.WebNavGesture(%s, %s, %d)`, strconv.Quote(selector), pointsJSON.String(), delayMS)
+	var pathAny interface{}
+	if *useCDP {
+		expression := fmt.Sprintf(`window.WebNavGesturePath(%s, %s, %s).path`, strconv.Quote(selector), pointsJSON.String(), optsJSON)
+		if err := withWebNavRetry(ctx, handle.client, *verbose, func() error {
+			var err error
+			pathAny, err = handle.client.Evaluate(ctx, expression)
+			return err
+		}); err != nil {
+			return err
+		}
+		path, err := toGesturePixels(pathAny)
+		if err != nil {
+			return err
+		}
+		if err := dispatchCDPGesture(ctx, handle.client, path, *touch, *delay); err != nil {
+			return err
+		}
+	} else {
+		expression := fmt.Sprintf(`window.WebNavGesture(%s, %s, %d, %s)`, strconv.Quote(selector), pointsJSON.String(), delay.Milliseconds(), optsJSON)
+		if err := withWebNavRetry(ctx, handle.client, *verbose, func() error {
+			value, err := handle.client.Evaluate(ctx, expression)
+			if m, ok := value.(map[string]interface{}); ok {
+				pathAny = m["path"]
+			}
+			return err
+		}); err != nil {
+			return err
+		}
+	}
 
-	if _, err := handle.client.Evaluate(ctx, expression); err != nil {
-		return err
+	target := selector
+	if target == "" {
+		target = "viewport"
+	}
+	mode := "pointer"
+	if *touch {
+		mode = "touch"
+	}
+	fmt.Printf("Gesture (%d points, %s) on: %s\n", len(points), mode, target)
+	if path, err := toGesturePixels(pathAny); err == nil && len(path) > 0 {
+		steps := make([]string, len(path))
+		for i, pt := range path {
+			steps[i] = fmt.Sprintf("(%s,%s p=%g)", formatScrollNumber(math.Round(pt.x)), formatScrollNumber(math.Round(pt.y)), pt.pressure)
+		}
+		fmt.Printf("path: %s\n", strings.Join(steps, " -> "))
 	}
-	fmt.Printf("Gesture (%d points) on: %s\n", len(points), selector)
 	return nil
 }
 
+// toGesturePixels decodes the [[x, y, pressure], ...] path returned by the page.
+func toGesturePixels(value interface{}) ([]gesturePoint, error) {
+	raw, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected gesture path type %T", value)
+	}
+	path := make([]gesturePoint, 0, len(raw))
+	for _, item := range raw {
+		triple, ok := item.([]interface{})
+		if !ok || len(triple) < 3 {
+			return nil, fmt.Errorf("unexpected gesture point %v", item)
+		}
+		x, _ := triple[0].(float64)
+		y, _ := triple[1].(float64)
+		p, _ := triple[2].(float64)
+		path = append(path, gesturePoint{x: x, y: y, pressure: p})
+	}
+	return path, nil
+}
+
+// dispatchCDPGesture replays path through the Input domain: touchStart/
+// touchMove/touchEnd with a single touch point, or a pressed mouse drag.
+func dispatchCDPGesture(ctx context.Context, client *cdp.Client, path []gesturePoint, touch bool, delay time.Duration) error {
+	sleep := func() error {
+		if delay <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(delay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	last := path[len(path)-1]
+	if touch {
+		touchEvent := func(typ string, points []map[string]interface{}) error {
+			return client.Call(ctx, "Input.dispatchTouchEvent", map[string]interface{}{"type": typ, "touchPoints": points}, nil)
+		}
+		touchPoint := func(pt gesturePoint) []map[string]interface{} {
+			return []map[string]interface{}{{"x": pt.x, "y": pt.y, "force": pt.pressure, "id": 0}}
+		}
+		if err := touchEvent("touchStart", touchPoint(path[0])); err != nil {
+			return err
+		}
+		for _, pt := range path[1:] {
+			if err := sleep(); err != nil {
+				return err
+			}
+			if err := touchEvent("touchMove", touchPoint(pt)); err != nil {
+				return err
+			}
+		}
+		if err := sleep(); err != nil {
+			return err
+		}
+		// touchEnd lists the touches still active, i.e. none.
+		return touchEvent("touchEnd", []map[string]interface{}{})
+	}
+
+	mouseEvent := func(typ string, pt gesturePoint, buttons int) error {
+		params := map[string]interface{}{
+			"type":       typ,
+			"x":          pt.x,
+			"y":          pt.y,
+			"button":     "left",
+			"buttons":    buttons,
+			"clickCount": 1,
+			"force":      pt.pressure,
+		}
+		if typ == "mouseMoved" {
+			params["clickCount"] = 0
+		}
+		if buttons == 0 {
+			params["force"] = 0
+		}
+		return client.Call(ctx, "Input.dispatchMouseEvent", params, nil)
+	}
+	if err := mouseEvent("mousePressed", path[0], 1); err != nil {
+		return err
+	}
+	for _, pt := range path[1:] {
+		if err := sleep(); err != nil {
+			return err
+		}
+		if err := mouseEvent("mouseMoved", pt, 1); err != nil {
+			return err
+		}
+	}
+	if err := sleep(); err != nil {
+		return err
+	}
+	return mouseEvent("mouseReleased", last, 0)
+}
+
+var keyHelp = commandHelp{
+	Description: `Sends key presses to the focused element, or to --element after focusing it.
+Combos use + (Ctrl+A, Shift+Tab) and several keys can be given at once. --cdp
+sends them through Input.dispatchKeyEvent, as a real keyboard would, instead of
+synthetic DOM events.
+
+Key names: Enter, Escape, Tab, Backspace, Delete, Space, ArrowUp/Down/Left/Right,
+Home, End, PageUp, PageDown, F1-F12, Ctrl, Shift, Alt, Meta, or any character.`,
+	Examples: []commandExample{
+		{"cdp key --session app Enter", "Press Enter in the focused element."},
+		{`cdp key --session app Ctrl+A --element "#editor"`, "Select everything in the editor."},
+		{"cdp key --session app Ctrl+Shift+s", "Send a three-key combo."},
+		{"cdp key --session app ArrowDown", "Move down a list or menu."},
+		{"cdp key --session app Escape --cdp", "Send a real Escape key press."},
+	},
+}
+
 func cmdKey(args []string) error {
 	usage := "usage: cdp key --session <name> KEYS [--element \".selector\"] [--cdp]"
-	fs := newFlagSet("key", usage+"\n\nSend a key press. KEYS is key names joined by + for combos.\n\nExamples:\n  cdp key mgr Enter\n  cdp key mgr Ctrl+c\n  cdp key mgr Ctrl+Shift+s\n  cdp key mgr ArrowDown\n\nKey names: Enter, Escape, Tab, Backspace, Delete, Space, ArrowUp/Down/Left/Right, Home, End, PageUp, PageDown, F1-F12, Ctrl, Shift, Alt, Meta, or any character.")
+	fs := newFlagSet("key", usage)
 	sessionFlag := addSessionFlag(fs)
 	element := fs.String("element", "", "Focus this element before sending the key")
 	useCDP := fs.Bool("cdp", false, "Use CDP Input.dispatchKeyEvent instead of JS KeyboardEvent")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -652,7 +1096,7 @@ func cmdKey(args []string) error {
 	}
 	spec := pos[0]
 	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+		return usagef("unexpected argument: %s", pos[1])
 	}
 	if *element != "" {
 		if err := rejectUnsupportedSelector(*element, "key --element", false); err != nil {
@@ -674,7 +1118,7 @@ func cmdKey(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -689,26 +1133,26 @@ func cmdKey(args []string) error {
 
 	if *element != "" {
 		expression := fmt.Sprintf(`window.WebNavFocus(%s)`, strconv.Quote(*element))
-		if _, err := handle.client.Evaluate(ctx, expression); err != nil {
+		if err := withWebNavRetry(ctx, handle.client, *verbose, func() error {
+			_, err := handle.client.Evaluate(ctx, expression)
+			return err
+		}); err != nil {
 			return err
 		}
 	}
 
 	if !*useCDP {
 		expression := fmt.Sprintf(`window.WebNavKey(%s)`, strconv.Quote(spec))
-		if _, err := handle.client.Evaluate(ctx, expression); err != nil {
+		if err := withWebNavRetry(ctx, handle.client, *verbose, func() error {
+			_, err := handle.client.Evaluate(ctx, expression)
+			return err
+		}); err != nil {
 			return err
 		}
 		fmt.Printf("Key (js): %s\n", spec)
 		return nil
 	}
 
-	downType := "keyDown"
-	if keySpec.modifiers != 0 || keySpec.text == "" {
-		downType = "rawKeyDown"
-	}
-	downParams := keyDispatchParams(downType, keySpec)
-	upParams := keyDispatchParams("keyUp", keySpec)
 	if err := handle.client.Call(ctx, "Page.bringToFront", map[string]interface{}{}, nil); err != nil {
 		return err
 	}
@@ -720,10 +1164,7 @@ func cmdKey(args []string) error {
 		}
 	}
 
-	if err := handle.client.Call(ctx, "Input.dispatchKeyEvent", downParams, nil); err != nil {
-		return err
-	}
-	if err := handle.client.Call(ctx, "Input.dispatchKeyEvent", upParams, nil); err != nil {
+	if err := pressKey(ctx, handle.client, keySpec); err != nil {
 		return err
 	}
 
@@ -731,13 +1172,31 @@ func cmdKey(args []string) error {
 	return nil
 }
 
-func cmdType(args []string) error {
-	fs := newFlagSet("type", "usage: cdp type --session <name> [\".selector\"] \"text\" [--has-text REGEX] [--att-value REGEX]\n(also supports inline :has-text(...) at the end of the selector)")
+var typeHelp = commandHelp{
+	Description: `Focuses an input and types text into it, replacing its value unless --append is
+set, and fires the input and change events frameworks listen for. --expect checks
+the final value against a regex, which catches inputs that reformat or reject
+what was typed.`,
+	Examples: []commandExample{
+		{`cdp type --session app "input[name=email]" "ada@example.com"`, "Fill in the email field."},
+		{`cdp type --session app textarea " (edited)" --append`, "Add text to the end of a textarea."},
+		{`cdp type --session app "#phone" "5551234567" --expect "\\(555\\)"`, "Type a number and check the formatted result."},
+	},
+}
+
+func cmdType(args []string) (err error) {
+	fs := newFlagSet("type", "usage: cdp type --session <name> [\".selector\"] \"text\" [--has-text REGEX] [--att-value REGEX] [--att name=REGEX] [--expect REGEX]\n(also supports inline :has-text(...) at the end of the selector)\n\nWithout a selector, inputs, textareas, contenteditable elements and role=textbox\nelements are searched, preferring the focused one, then visible ones, then inputs\nover the rest. Prints the value before/after typing and warns if the page\nreverts or reformats it.")
 	sessionFlag := addSessionFlag(fs)
 	appendText := fs.Bool("append", false, "Append text instead of replacing")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	previewLimit := addPreviewLimitFlag(fs)
 	hasText := fs.String("has-text", "", "Only match elements whose text matches this regex (JS RegExp; accepts /pat/flags or pat)")
 	attValue := fs.String("att-value", "", "Only match elements with at least one attribute value matching this regex (JS RegExp; accepts /pat/flags or pat)")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	att := fs.String("att", "", "Only match elements whose named attribute matches the regex, as name=REGEX (a bare name only requires the attribute)")
+	expect := fs.String("expect", "", "Fail unless the settled value matches this regex (Go syntax)")
+	attachConsole := addAttachConsoleFlag(fs)
+	idleUI := addIdleUIFlags(fs)
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -750,7 +1209,7 @@ func cmdType(args []string) error {
 	text := ""
 	if len(pos) == 1 {
 		if *hasText == "" {
-			return errors.New("usage: cdp type --session <name> [\".selector\"] \"text\" [--has-text REGEX] [--att-value REGEX]")
+			return usagef("usage: cdp type --session <name> [\".selector\"] \"text\" [--has-text REGEX] [--att-value REGEX] [--att name=REGEX]")
 		}
 		text = pos[0]
 	} else {
@@ -761,35 +1220,26 @@ func cmdType(args []string) error {
 		text = pos[1]
 	}
 	if len(pos) > 2 {
-		return fmt.Errorf("unexpected argument: %s", pos[2])
+		return usagef("unexpected argument: %s", pos[2])
 	}
-	inlineHasText := ""
-	hasInline := false
-	if selector != "" {
-		selector, inlineHasText, hasInline, err = parseInlineHasText(selector)
-		if err != nil {
-			return err
-		}
-		if err := rejectUnsupportedSelector(selector, "type", true); err != nil {
-			return err
-		}
+	typeOpts := TypeOptions{
+		Selector: selector,
+		HasText:  *hasText,
+		AttValue: *attValue,
+		Att:      *att,
+		Append:   *appendText,
+		Verbose:  *verbose,
 	}
-	selectors := []string{}
-	if selector != "" {
-		selectors = append(selectors, autoQuoteAttrValues(selector))
-	} else {
-		selectors = append(selectors, "input", "textarea")
+	if _, _, _, err := typeOpts.target(); err != nil {
+		return err
 	}
-	for _, sel := range selectors {
-		if err := rejectUnsupportedSelector(sel, "type", true); err != nil {
-			return err
+	var expectRe *regexp.Regexp
+	if *expect != "" {
+		expectRe, err = regexp.Compile(*expect)
+		if err != nil {
+			return fmt.Errorf("invalid --expect regex: %w", err)
 		}
 	}
-	hasTextValue := *hasText
-	if hasInline {
-		hasTextValue = inlineHasText
-	}
-	attValueValue := *attValue
 
 	name, err := resolveSessionName(*sessionFlag)
 	if err != nil {
@@ -800,7 +1250,7 @@ func cmdType(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -808,65 +1258,162 @@ func cmdType(args []string) error {
 		return err
 	}
 	defer handle.Close()
+	defer attachPageErrors(&err, watchPageErrors(ctx, handle.client, *attachConsole))
 
-	if err := ensureWebNavInjected(ctx, handle.client); err != nil {
+	if err := idleUI.waitBefore(ctx, handle.client, fs, name); err != nil {
+		return err
+	}
+	typed, err := TypeText(ctx, handle.client, text, typeOpts)
+	if err != nil {
 		return err
 	}
+	expected := text
+	if *appendText {
+		expected = typed.Before + text
+	}
+	if selector == "" {
+		fmt.Printf("Typed into: %s (default match; pass it as the selector to pin it)\n", typed.Selector)
+	} else {
+		fmt.Printf("Typed into: %s\n", typed.Selector)
+	}
+	return reportTypedValue(ctx, handle.client, typed.targetExpr, typed.Before, expected, expectRe, *previewLimit)
+}
 
-	targetExpr := buildFilteredTargetExpr(selectors, hasTextValue, attValueValue, false)
-	expression := fmt.Sprintf(`window.WebNavTypePrepare(%s, %s, %t)`, targetExpr, strconv.Quote(text), *appendText)
+// typeSettleDelay is how long cmdType waits before re-reading the value, to
+// catch frameworks that revert or reformat input after the events fire.
+const typeSettleDelay = 200 * time.Millisecond
 
-	value, err := handle.client.Evaluate(ctx, expression)
+// reportTypedValue prints the element's value before and after typing, warns
+// if it differs from what was typed or changes again once settled, and fails
+// when expectRe is set and the settled value does not match it.
+func reportTypedValue(ctx context.Context, client *cdp.Client, targetExpr, before, expected string, expectRe *regexp.Regexp, previewLimit int) error {
+	read := func() (string, error) {
+		value, err := client.Evaluate(ctx, fmt.Sprintf(`window.WebNavReadValue(%s)`, targetExpr))
+		if err != nil {
+			return "", err
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok || m["found"] != true {
+			return "", errors.New("element disappeared after typing")
+		}
+		v, _ := m["value"].(string)
+		return v, nil
+	}
+	after, err := read()
 	if err != nil {
 		return err
 	}
-	state, ok := value.(map[string]interface{})
-	if !ok || state["found"] != true {
-		return errors.New("selector not found")
+	select {
+	case <-time.After(typeSettleDelay):
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	usedSelector := selector
-	if sel, _ := state["selector"].(string); sel != "" {
-		usedSelector = sel
+	settled, err := read()
+	if err != nil {
+		return err
 	}
-	if handled, _ := state["handled"].(bool); handled {
-		fmt.Printf("Typed into: %s\n", usedSelector)
-		return nil
+	quote := func(v string) string { return strconv.Quote(cropForTTY(v, previewLimit)) }
+	fmt.Printf("value: %s -> %s\n", quote(before), quote(after))
+	switch {
+	case settled != after && settled == before:
+		fmt.Fprintf(os.Stderr, "warning: value reverted to %s after %s\n", quote(settled), typeSettleDelay)
+	case settled != after:
+		fmt.Fprintf(os.Stderr, "warning: value changed to %s after %s\n", quote(settled), typeSettleDelay)
+	case settled != expected:
+		fmt.Fprintf(os.Stderr, "warning: value is %s, not the typed %s (transformed by the page?)\n", quote(settled), quote(expected))
 	}
-	editable, _ := state["editable"].(bool)
-	if editable {
-		if err := handle.client.Call(ctx, "Input.insertText", map[string]interface{}{
-			"text": text,
-		}, nil); err != nil {
-			return err
+	if expectRe != nil && !expectRe.MatchString(settled) {
+		return fmt.Errorf("value %s does not match --expect %s", strconv.Quote(settled), expectRe)
+	}
+	return nil
+}
+
+// parseScrollAmount validates a scroll amount and returns it as a JS literal:
+// plain pixel counts stay numbers, while "N%", "Nvh", "Nvw", "page" and
+// "-page" are passed as strings for WebNav.scroll to resolve in the page
+// (percentages against the container height, vh/vw against the viewport).
+// Numbers are normalized, so the page only ever sees plain decimals.
+func parseScrollAmount(spec string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	switch s {
+	case "page", "+page", "-page":
+		return strconv.Quote(s), nil
+	}
+	for _, unit := range []string{"%", "vh", "vw"} {
+		if num, ok := strings.CutSuffix(s, unit); ok {
+			n, err := parseScrollNumber(num)
+			if err != nil {
+				return "", fmt.Errorf("invalid scroll amount %q (want N%s with a finite number)", spec, unit)
+			}
+			return strconv.Quote(strconv.FormatFloat(n, 'f', -1, 64) + unit), nil
 		}
-		fmt.Printf("Typed into: %s\n", usedSelector)
-		return nil
 	}
+	px, err := parseScrollNumber(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid yPx %q (want pixels, N%%, Nvh, Nvw, page, or -page)", spec)
+	}
+	return strconv.FormatFloat(px, 'f', -1, 64), nil
+}
 
-	fallback := fmt.Sprintf(`window.WebNavTypeFallback(%s, %s, %t)`, targetExpr, strconv.Quote(text), *appendText)
-	fallbackValue, err := handle.client.Evaluate(ctx, fallback)
+// parseScrollNumber is strconv.ParseFloat without the spellings a page
+// can't take as a pixel count: NaN, infinities and hex floats.
+func parseScrollNumber(s string) (float64, error) {
+	n, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	if m, ok := fallbackValue.(map[string]interface{}); ok {
-		if okVal, _ := m["ok"].(bool); !okVal {
-			return errors.New("selector not found")
-		}
-		if sel, _ := m["selector"].(string); sel != "" {
-			usedSelector = sel
-		}
+	if math.IsNaN(n) || math.IsInf(n, 0) || strings.ContainsAny(s, "xX") {
+		return 0, fmt.Errorf("not a finite decimal number: %q", s)
 	}
-	fmt.Printf("Typed into: %s\n", usedSelector)
-	return nil
+	return n, nil
+}
+
+// parseScrollTarget validates a --to position: "top", "bottom", or an
+// absolute scrollTop in pixels or vh/vw.
+func parseScrollTarget(spec string) (string, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	if s == "top" || s == "bottom" {
+		return strconv.Quote(s), nil
+	}
+	if strings.HasSuffix(s, "%") || strings.HasSuffix(s, "page") {
+		return "", fmt.Errorf("invalid --to %q (want top, bottom, or a scrollTop in px, vh or vw)", spec)
+	}
+	js, err := parseScrollAmount(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid --to %q (want top, bottom, or a scrollTop in px, vh or vw)", spec)
+	}
+	return js, nil
+}
+
+var scrollHelp = commandHelp{
+	Description: `Scrolls the page or an element by pixels, by viewport units (vh, vw), by a
+percentage of the scroll height, or by a page up or down. --to scrolls to an
+absolute position instead, and --to-element brings an element to the start,
+center or end of the view. The output shows scrollTop before and after, so a
+loop can tell when it stopped moving. --emit also fires scroll events for
+listeners that do not react to programmatic scrolling.`,
+	Examples: []commandExample{
+		{"cdp scroll --session app 600", "Scroll the page down 600px."},
+		{"cdp scroll --session app 100vh", "Scroll down one viewport height."},
+		{`cdp scroll --session app 100% --element ".chat-log"`, "Scroll a chat log to the bottom."},
+		{"cdp scroll --session app --to top", "Jump back to the top of the page."},
+		{`cdp scroll --session app --to-element "#pricing" --align center`, "Center the pricing section in the viewport."},
+		{"cdp scroll --session app page --emit", "Scroll one page down and fire scroll events."},
+		{`cdp scroll --session app 0 --x 400 --element ".table-wrap"`, "Scroll a wide table sideways."},
+	},
 }
 
 func cmdScroll(args []string) error {
-	fs := newFlagSet("scroll", "usage: cdp scroll --session <name> <yPx> [--x <xPx>] [--element \".selector\"] [--emit]")
+	fs := newFlagSet("scroll", "usage: cdp scroll --session <name> <yPx|Nvh|Nvw|N%|page|-page> [--x <xPx>] [--element \".selector\"] [--emit]\n       cdp scroll --session <name> --to top|bottom|N [--element \".selector\"]\n       cdp scroll --session <name> --to-element \".selector\" [--align start|center|end] [--element \".selector\"]")
 	sessionFlag := addSessionFlag(fs)
 	scrollX := fs.Float64("x", 0, "Horizontal scroll delta in pixels (can be negative)")
 	element := fs.String("element", "", "Scroll inside an element matched by selector")
+	to := fs.String("to", "", "Scroll to an absolute position: top, bottom, or a scrollTop in px, vh or vw")
+	toElement := fs.String("to-element", "", "Scroll until the element matched by this selector is aligned per --align")
+	align := fs.String("align", "start", "Where --to-element puts the element's top in the view: start, center, or end")
 	emit := fs.Bool("emit", true, "Dispatch scroll events after scrolling")
-	timeout := fs.Duration("timeout", 5*time.Second, "Command timeout")
+	verbose := fs.Bool("verbose", false, "Log automatic recovery retries to stderr")
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
 	if len(args) == 1 && isHelpArg(args[0]) {
 		fs.Usage()
 		return nil
@@ -875,22 +1422,52 @@ func cmdScroll(args []string) error {
 	if err != nil {
 		return err
 	}
-	if len(pos) < 1 {
+	absolute := *to != "" || *toElement != ""
+	switch {
+	case *to != "" && *toElement != "":
+		return errors.New("--to and --to-element are mutually exclusive")
+	case absolute && len(pos) > 0:
+		return usagef("unexpected argument: %s (--to and --to-element replace yPx)", pos[0])
+	case absolute && *scrollX != 0:
+		return errors.New("--x only applies to relative scrolls, not --to or --to-element")
+	case !absolute && len(pos) < 1:
 		return errors.New("missing yPx")
+	case len(pos) > 1:
+		return usagef("unexpected argument: %s", pos[1])
 	}
-	yStr := pos[0]
-	if len(pos) > 1 {
-		return fmt.Errorf("unexpected argument: %s", pos[1])
+	alignMode := strings.ToLower(strings.TrimSpace(*align))
+	if alignMode != "start" && alignMode != "center" && alignMode != "end" {
+		return errors.New("--align must be one of: start, center, end")
 	}
-	if *element != "" {
-		if err := rejectUnsupportedSelector(*element, "scroll --element", false); err != nil {
-			return err
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "align" && *toElement == "" {
+			err = errors.New("--align only applies to --to-element")
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, sel := range []struct{ value, context string }{{*element, "scroll --element"}, {*toElement, "scroll --to-element"}} {
+		if sel.value != "" {
+			if err := rejectUnsupportedSelector(sel.value, sel.context, false); err != nil {
+				return err
+			}
 		}
 	}
 
-	scrollY, err := strconv.ParseFloat(yStr, 64)
+	var yStr, yJS string
+	switch {
+	case *toElement != "":
+		yStr, yJS = *toElement, "0"
+	case *to != "":
+		yStr = *to
+		yJS, err = parseScrollTarget(*to)
+	default:
+		yStr = pos[0]
+		yJS, err = parseScrollAmount(yStr)
+	}
 	if err != nil {
-		return fmt.Errorf("invalid yPx %q: %w", yStr, err)
+		return err
 	}
 
 	name, err := resolveSessionName(*sessionFlag)
@@ -902,7 +1479,7 @@ func cmdScroll(args []string) error {
 	if err != nil {
 		return err
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	ctx, cancel := commandContext(*timeout)
 	defer cancel()
 
 	handle, err := openSession(ctx, st, name)
@@ -915,19 +1492,42 @@ func cmdScroll(args []string) error {
 		return err
 	}
 
-	yJS := strconv.FormatFloat(scrollY, 'f', -1, 64)
 	xJS := strconv.FormatFloat(*scrollX, 'f', -1, 64)
-	expression := fmt.Sprintf(`window.WebNavScroll(%s, %s, %s, %t)`, yJS, xJS, strconv.Quote(*element), *emit)
-
-	value, err := handle.client.Evaluate(ctx, expression)
-	if err != nil {
+	scrollOpts, _ := json.Marshal(map[string]interface{}{
+		"absolute":  absolute,
+		"toElement": *toElement,
+		"align":     alignMode,
+	})
+	expression := fmt.Sprintf(`window.WebNavScroll(%s, %s, %s, %t, %s)`, yJS, xJS, strconv.Quote(*element), *emit, scrollOpts)
+
+	var value interface{}
+	if err := withWebNavRetry(ctx, handle.client, *verbose, func() error {
+		var err error
+		value, err = handle.client.Evaluate(ctx, expression)
+		return err
+	}); err != nil {
 		return err
 	}
 	posMap, ok := value.(map[string]interface{})
 	if !ok {
-		fmt.Printf("Scrolled by y=%s x=%s\n", yJS, xJS)
+		fmt.Printf("Scrolled by y=%s x=%s\n", yStr, xJS)
 		return nil
 	}
-	fmt.Printf("Scrolled by y=%s x=%s -> scrollTop=%s scrollLeft=%s\n", yJS, xJS, formatScrollNumber(posMap["scrollTop"]), formatScrollNumber(posMap["scrollLeft"]))
+	atBottom, _ := posMap["atBottom"].(bool)
+	atRight, _ := posMap["atRight"].(bool)
+	var what string
+	switch {
+	case *toElement != "":
+		what = fmt.Sprintf("to %s (%s)", strconv.Quote(*toElement), alignMode)
+	case *to != "":
+		what = "to y=" + yStr
+	default:
+		yDesc := yStr
+		if deltaY, ok := posMap["deltaY"]; ok && strings.HasPrefix(yJS, `"`) {
+			yDesc = fmt.Sprintf("%s (%spx)", yStr, formatScrollNumber(deltaY))
+		}
+		what = fmt.Sprintf("by y=%s x=%s", yDesc, xJS)
+	}
+	fmt.Printf("Scrolled %s -> scrollTop=%s->%s scrollLeft=%s scrollHeight=%s atBottom=%t atRight=%t\n", what, formatScrollNumber(posMap["beforeTop"]), formatScrollNumber(posMap["scrollTop"]), formatScrollNumber(posMap["scrollLeft"]), formatScrollNumber(posMap["scrollHeight"]), atBottom, atRight)
 	return nil
 }
@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScrollAmount(t *testing.T) {
+	cases := map[string]string{
+		"800":   "800",
+		"-200":  "-200",
+		"50%":   `"50%"`,
+		"-25%":  `"-25%"`,
+		"page":  `"page"`,
+		"-Page": `"-page"`,
+		"100vh": `"100vh"`,
+		"-50VW": `"-50vw"`,
+		"1e2vh": `"100vh"`,
+		"1.50%": `"1.5%"`,
+	}
+	for in, want := range cases {
+		got, err := parseScrollAmount(in)
+		if err != nil {
+			t.Fatalf("parseScrollAmount(%q): %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("parseScrollAmount(%q) = %s, want %s", in, got, want)
+		}
+	}
+	for _, bad := range []string{"abc", "x%", "pages", "10vx", "vh", "NaN", "inf", "-Infvh", "0x10", "100ms"} {
+		if _, err := parseScrollAmount(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestScrollToRejectsBadInputBeforeConnecting(t *testing.T) {
+	if got, err := parseScrollTarget("Bottom"); err != nil || got != `"bottom"` {
+		t.Fatalf("parseScrollTarget(Bottom) = %s, %v", got, err)
+	}
+	if got, err := parseScrollTarget("50vh"); err != nil || got != `"50vh"` {
+		t.Fatalf("parseScrollTarget(50vh) = %s, %v", got, err)
+	}
+	for _, args := range [][]string{
+		{"10vx"},
+		{"--to", "50%"},
+		{"--to", "middle"},
+		{"--to", "top", "200"},
+		{"--to", "top", "--to-element", "#footer"},
+		{"--to-element", "#footer", "--align", "left"},
+		{"200", "--align", "center"},
+		{"--to", "bottom", "--x", "10"},
+	} {
+		// No --session and no store: every case must fail while parsing.
+		err := cmdScroll(args)
+		if err == nil || strings.Contains(err.Error(), "session") {
+			t.Errorf("cmdScroll(%q) = %v, want a parse error", args, err)
+		}
+	}
+}
+
+func TestParseGesturePath(t *testing.T) {
+	points, err := parseGesturePath("0.1,0.5 0.9,0.5,0.8", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(points) != 2 || points[0].pressure != 0.5 || points[1].pressure != 0.8 {
+		t.Fatalf("unexpected points: %+v", points)
+	}
+	if _, err := parseGesturePath("0.1,0.5 1.5,0.5", false); err == nil {
+		t.Fatal("expected out-of-bounds error for relative point")
+	}
+	if _, err := parseGesturePath("100,200 400,200", true); err != nil {
+		t.Fatalf("absolute points rejected: %v", err)
+	}
+	if _, err := parseGesturePath("0.1,0.5,2 0.2,0.5", false); err == nil {
+		t.Fatal("expected pressure range error")
+	}
+}
+
+func TestCropForTTY(t *testing.T) {
+	if got := cropForTTY("héllo wörld", 0); got != "héllo wörld" {
+		t.Fatalf("limit 0 should not crop, got %q", got)
+	}
+	if got := cropForTTY("héllo wörld", 5); got != "héllo[...]" {
+		t.Fatalf("rune crop = %q", got)
+	}
+	lines := "  line one\n  line two\n  line three"
+	if got := cropForTTY(lines, 15); got != "  line one\n[...]" {
+		t.Fatalf("newline-aware crop = %q", got)
+	}
+	if got := cropForTTY(lines, 10); got != "  line one\n[...]" {
+		t.Fatalf("crop at line break = %q", got)
+	}
+}
+
+func TestParseDropPosition(t *testing.T) {
+	cases := map[string][2]float64{
+		"center":    {0.5, 0.5},
+		"top":       {0.5, 0},
+		"Bottom":    {0.5, 1},
+		"0.25, 0.9": {0.25, 0.9},
+	}
+	for in, want := range cases {
+		x, y, err := parseDropPosition(in)
+		if err != nil || x != want[0] || y != want[1] {
+			t.Fatalf("parseDropPosition(%q) = %v, %v, %v", in, x, y, err)
+		}
+	}
+	for _, bad := range []string{"middle", "1.5,0", "0.5"} {
+		if _, _, err := parseDropPosition(bad); err == nil {
+			t.Fatalf("parseDropPosition(%q) should fail", bad)
+		}
+	}
+}
+
+func TestBuildMatchInfoExprFollowsSelectorPriority(t *testing.T) {
+	expr := buildMatchInfoExpr([]string{"button", "div"}, "Save", "", "", true)
+	button := strings.Index(expr, `document.querySelectorAll("button").hasText("Save").preferInner()`)
+	div := strings.Index(expr, `document.querySelectorAll("div").hasText("Save").preferInner()`)
+	if button < 0 || div < 0 || button > div {
+		t.Fatalf("expected button then div filtered queries, got:\n%s", expr)
+	}
+	if got := filteredQueryExpr("a", "", "x", "", false); got != `document.querySelectorAll("a").hasAttValue("x")` {
+		t.Fatalf("filteredQueryExpr = %s", got)
+	}
+}
+
+func TestAttFilterNamesTheAttribute(t *testing.T) {
+	if got := filteredQueryExpr("button", "", "", "data-testid=^submit$", false); got != `document.querySelectorAll("button").hasAtt("data-testid", "^submit$")` {
+		t.Fatalf("filteredQueryExpr = %s", got)
+	}
+	if got := buildFilteredTargetExpr([]string{"a"}, "", "", "download", false); got != `document.querySelectorAll("a").hasAtt("download", "")` {
+		t.Fatalf("bare name = %s", got)
+	}
+	if _, err := (ClickOptions{Selector: "button", Att: "=submit"}).target(); err == nil {
+		t.Fatal("expected an error for an empty attribute name")
+	}
+	if tgt, err := (ClickOptions{Selector: "div", Att: "role=button"}).target(); err != nil || !tgt.preferInner {
+		t.Fatalf("bare tag with --att should prefer inner matches: %+v %v", tgt, err)
+	}
+}
+
+func TestClickExpressionAsksForADoubleClick(t *testing.T) {
+	target, err := ClickOptions{Selector: "#row"}.target()
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(opts ClickOptions) string {
+		expr := target.clickExpression(opts)
+		start := strings.Index(expr, "window.WebNavClickWithRead(")
+		if start < 0 {
+			t.Fatalf("no WebNavClickWithRead call in:\n%s", expr)
+		}
+		return expr[start:]
+	}
+	if got := call(ClickOptions{}); !strings.Contains(got, `("#row", 1, `) {
+		t.Errorf("default count: %s", got)
+	}
+	if got := call(ClickOptions{Count: 2}); !strings.Contains(got, `("#row", 2, `) || !strings.Contains(got, "dblclick: true}") {
+		t.Errorf("count 2: %s", got)
+	}
+	if got := call(ClickOptions{Count: 2, NoDblclick: true}); !strings.Contains(got, "dblclick: false}") {
+		t.Errorf("NoDblclick: %s", got)
+	}
+	if got := call(ClickOptions{Count: 3, ObserveMS: 250}); !strings.Contains(got, `("#row", 3, `) || !strings.Contains(got, "observeMs: 250") {
+		t.Errorf("count 3: %s", got)
+	}
+}
+
+func TestTypeDefaultTargetsRankEditors(t *testing.T) {
+	selector, selectors, hasText, err := TypeOptions{HasText: "Message"}.target()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selector != "" || hasText != "Message" || len(selectors) != len(typeDefaultSelectors) || selectors[0] != "input" {
+		t.Fatalf("unexpected default target %q %v %q", selector, selectors, hasText)
+	}
+	expr := buildRankedTargetExpr(selectors, hasText, "", "")
+	input := strings.Index(expr, `["input", document.querySelectorAll("input").hasText("Message")]`)
+	editable := strings.Index(expr, `document.querySelectorAll("[contenteditable=\"true\"]").hasText("Message")`)
+	textbox := strings.Index(expr, `["[role=\"textbox\"]", `)
+	if !strings.HasPrefix(expr, "window.WebNavRankCandidates([") || input < 0 || editable < input || textbox < editable {
+		t.Fatalf("expected inputs, then editors, then textboxes, got:\n%s", expr)
+	}
+}
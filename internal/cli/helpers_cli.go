@@ -1,12 +1,110 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// usageError is returned for bad command-line flags so callers get a normal
+// error (instead of the flag package exiting) that still carries the usage line.
+type usageError struct {
+	err   error
+	usage string
+}
+
+func (e *usageError) Error() string {
+	if e.usage == "" {
+		return e.err.Error()
+	}
+	return e.err.Error() + "\n" + e.usage
+}
+
+func (e *usageError) Unwrap() error {
+	return e.err
+}
+
+// stringListFlag collects every value of a repeatable flag.
+type stringListFlag []string
+
+func (l *stringListFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringListFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// timeoutValue is the value behind every --timeout flag: a duration where 0
+// means no timeout and negative values are rejected at parse time.
+type timeoutValue time.Duration
+
+func (d *timeoutValue) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *timeoutValue) Set(value string) error {
+	v, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if v < 0 {
+		return errors.New("must not be negative (use 0 for no timeout)")
+	}
+	*d = timeoutValue(v)
+	return nil
+}
+
+func (d *timeoutValue) Get() interface{} {
+	return time.Duration(*d)
+}
+
+// addTimeoutFlag registers --timeout on fs, noting the 0 convention in its
+// description.
+func addTimeoutFlag(fs *flag.FlagSet, value time.Duration, usage string) *time.Duration {
+	return addTimeoutVar(fs, "timeout", value, usage)
+}
+
+// addTimeoutVar registers a timeoutValue flag called name. The backquoted
+// word names its type in --help, which otherwise shows "value" for fs.Var.
+func addTimeoutVar(fs *flag.FlagSet, name string, value time.Duration, usage string) *time.Duration {
+	fs.Var((*timeoutValue)(&value), name, usage+" (`duration`; 0 = no timeout)")
+	return &value
+}
+
+// commandContext returns the context a command runs under: bounded by
+// timeout, or merely cancellable when timeout is 0.
+func commandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return withCommandTimeout(context.Background(), timeout)
+}
+
+// withCommandTimeout is commandContext for a context derived from parent.
+func withCommandTimeout(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(parent)
+	}
+	return context.WithTimeout(parent, timeout)
+}
+
+// flagSetUsage is the usage text newFlagSet gave fs, without the options
+// list. Its fs.Usage closure holds the text, so it is read back from there.
+func flagSetUsage(fs *flag.FlagSet) string {
+	out := fs.Output()
+	var buf strings.Builder
+	fs.SetOutput(&buf)
+	fs.Usage()
+	fs.SetOutput(out)
+	usage, _, _ := strings.Cut(buf.String(), "\n\nOptions:\n")
+	return strings.TrimSuffix(usage, "\n")
+}
+
 func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
 	flags := make([]string, 0, len(args))
 	positionals := make([]string, 0, len(args))
@@ -26,6 +124,10 @@ func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
 		}
 		if strings.HasPrefix(arg, "-") && arg != "-" {
 			name, hasValue := splitFlagName(arg)
+			if isHelpArg(arg) {
+				flags = append(flags, arg)
+				continue
+			}
 			if isBool, ok := flagInfo[name]; ok {
 				flags = append(flags, arg)
 				if hasValue {
@@ -39,21 +141,67 @@ func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
 					continue
 				}
 				if i+1 >= len(args) {
-					return nil, fmt.Errorf("flag %s requires a value", arg)
+					return nil, newUsageError(fs, fmt.Errorf("flag %s requires a value", arg))
 				}
 				flags = append(flags, args[i+1])
 				i++
 				continue
 			}
+			if looksLikeLongFlag(arg) {
+				return nil, newUsageError(fs, fmt.Errorf("unknown flag %s", arg))
+			}
 		}
 		positionals = append(positionals, arg)
 	}
-	if err := fs.Parse(flags); err != nil {
+	if err := parseFlags(fs, flags); err != nil {
 		return nil, err
 	}
 	return positionals, nil
 }
 
+// parseFlags runs fs.Parse without letting the flag package print on failure.
+// Help requests print the usage and return flag.ErrHelp (which Run treats as
+// success); other failures come back as a usageError.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := applyConfigDefaults(fs, activeConfig); err != nil {
+		return err
+	}
+	applyGlobalFlagDefaults(fs)
+	out := fs.Output()
+	fs.SetOutput(io.Discard)
+	err := fs.Parse(args)
+	fs.SetOutput(out)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		fs.Usage()
+		return err
+	}
+	return newUsageError(fs, err)
+}
+
+// usagef is a usage error for bad positional arguments, whose message already
+// says what was expected.
+func usagef(format string, args ...interface{}) error {
+	return &usageError{err: fmt.Errorf(format, args...)}
+}
+
+func newUsageError(fs *flag.FlagSet, err error) error {
+	return &usageError{err: err, usage: flagSetUsage(fs)}
+}
+
+// looksLikeLongFlag reports whether arg is shaped like "--name" so typos such as
+// --sesion are rejected instead of silently becoming positionals. Single-dash
+// args are left alone since they're often negative numbers (e.g. scroll -200).
+func looksLikeLongFlag(arg string) bool {
+	if !strings.HasPrefix(arg, "--") || len(arg) < 3 {
+		return false
+	}
+	ch := arg[2]
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
 func splitFlagName(arg string) (string, bool) {
 	name := strings.TrimLeft(arg, "-")
 	if name == "" {
@@ -65,9 +213,16 @@ func splitFlagName(arg string) (string, bool) {
 	return name, false
 }
 
+// flagSetObserver, when set, is handed every flag set newFlagSet creates. The
+// command catalog uses it to collect flag definitions by running --help.
+var flagSetObserver func(fs *flag.FlagSet)
+
 func newFlagSet(name, usage string) *flag.FlagSet {
-	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
+	if flagSetObserver != nil {
+		flagSetObserver(fs)
+	}
 	fs.Usage = func() {
 		fmt.Fprintln(fs.Output(), usage)
 		if flagHasOptions(fs) {
@@ -89,3 +244,82 @@ func flagHasOptions(fs *flag.FlagSet) bool {
 func isHelpArg(arg string) bool {
 	return arg == "-h" || arg == "--help"
 }
+
+// addPreviewLimitFlag registers --preview-limit for commands that print
+// element read previews.
+func addPreviewLimitFlag(fs *flag.FlagSet) *int {
+	return fs.Int("preview-limit", defaultPreviewLimit(), "Crop previews to N characters (0 = unlimited; default ~4 terminal lines, or CDP_PREVIEW_LIMIT)")
+}
+
+// globalFlagNames are the flags accepted before the command name. profile
+// selects a connection profile, store points at another sessions file,
+// timings enables the CDP timing summary, json-errors switches failures to
+// JSON, restore-overrides re-applies recorded session overrides and
+// ignore-origin skips the sessions' expected-origin check; the others become
+// the defaults of the subcommand's flag of the same name, which still wins
+// when given.
+var globalFlagNames = map[string]bool{"profile": true, "store": true, "host": true, "port": true, "timeout": true, "timings": true, "json-errors": true, "restore-overrides": true, "ignore-origin": true}
+
+// globalSwitches are global flags that take no separate value argument.
+var globalSwitches = map[string]string{"timings": "text", "json-errors": "true", "restore-overrides": "true", "ignore-origin": "true"}
+
+// globalFlagValues holds the host/port/timeout given before the command name.
+var globalFlagValues = map[string]string{}
+
+// extractGlobalFlags pulls leading global flags (--name VALUE or --name=VALUE)
+// off the command line and returns them with the remaining args.
+func extractGlobalFlags(args []string) (map[string]string, []string, error) {
+	values := map[string]string{}
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(args[0], "--"), "=")
+		if !globalFlagNames[name] {
+			break
+		}
+		if implicit, ok := globalSwitches[name]; ok || hasValue {
+			if !hasValue {
+				value = implicit
+			}
+			args = args[1:]
+		} else {
+			if len(args) < 2 {
+				return nil, nil, fmt.Errorf("--%s requires a value", name)
+			}
+			value, args = args[1], args[2:]
+		}
+		switch name {
+		case "port":
+			if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+				return nil, nil, fmt.Errorf("invalid --port %q", value)
+			}
+		case "timeout":
+			var d timeoutValue
+			if err := d.Set(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid --timeout %q: %v", value, err)
+			}
+		case "timings":
+			if value != "text" && value != "json" {
+				return nil, nil, fmt.Errorf("invalid --timings %q (expected text or json)", value)
+			}
+		case "json-errors", "restore-overrides", "ignore-origin":
+			if _, err := strconv.ParseBool(value); err != nil {
+				return nil, nil, fmt.Errorf("invalid --%s %q", name, value)
+			}
+		}
+		values[name] = value
+	}
+	return values, args, nil
+}
+
+// applyGlobalFlagDefaults layers the global flags over fs's defaults (after
+// config, before the command line).
+func applyGlobalFlagDefaults(fs *flag.FlagSet) {
+	for name, value := range globalFlagValues {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if err := f.Value.Set(value); err == nil {
+			f.DefValue = f.Value.String()
+		}
+	}
+}
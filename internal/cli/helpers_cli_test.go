@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCmdClickUnknownFlagReturnsError(t *testing.T) {
+	err := cmdClick([]string{"--sesion", "x", ".btn"})
+	if err == nil {
+		t.Fatal("expected error for unknown flag")
+	}
+	if !strings.Contains(err.Error(), "unknown flag --sesion") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(err.Error(), "usage: cdp click") {
+		t.Fatalf("expected usage text in error, got: %v", err)
+	}
+}
+
+func TestCmdClickBadFlagValueReturnsError(t *testing.T) {
+	err := cmdClick([]string{"--session", "x", "--count", "abc", ".btn"})
+	var ue *usageError
+	if !errors.As(err, &ue) {
+		t.Fatalf("expected usageError, got %v", err)
+	}
+}
+
+func TestFlagSetUsageLeavesOutOptions(t *testing.T) {
+	fs := newFlagSet("demo", "usage: demo <x>\n\nDoes a demo.")
+	fs.Bool("fast", false, "Go fast")
+	out := new(strings.Builder)
+	fs.SetOutput(out)
+	if got := flagSetUsage(fs); got != "usage: demo <x>\n\nDoes a demo." {
+		t.Fatalf("flagSetUsage = %q", got)
+	}
+	if out.Len() != 0 || fs.Output() != out {
+		t.Fatalf("flagSetUsage wrote %q or changed the output", out.String())
+	}
+}
+
+func TestParseInterspersedHelp(t *testing.T) {
+	fs := newFlagSet("test", "usage: test")
+	fs.SetOutput(new(strings.Builder))
+	_, err := parseInterspersed(fs, []string{"--help"})
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("expected flag.ErrHelp, got %v", err)
+	}
+}
+
+func TestParseInterspersedKeepsNegativeNumbers(t *testing.T) {
+	fs := newFlagSet("test", "usage: test")
+	pos, err := parseInterspersed(fs, []string{"-200"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pos) != 1 || pos[0] != "-200" {
+		t.Fatalf("unexpected positionals: %v", pos)
+	}
+}
+
+func TestApplyGlobalFlagDefaults(t *testing.T) {
+	saved := globalFlagValues
+	defer func() { globalFlagValues = saved }()
+	globalFlagValues = map[string]string{"timeout": "30s", "port": "9333"}
+
+	fs := newFlagSet("demo", "usage: demo")
+	timeout := fs.Duration("timeout", 5*time.Second, "")
+	port := fs.Int("port", 9222, "")
+	if _, err := parseInterspersed(fs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 30*time.Second || *port != 9333 {
+		t.Fatalf("global defaults not applied: timeout=%s port=%d", *timeout, *port)
+	}
+
+	fs = newFlagSet("demo", "usage: demo")
+	timeout = fs.Duration("timeout", 5*time.Second, "")
+	if _, err := parseInterspersed(fs, []string{"--timeout", "2s"}); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 2*time.Second {
+		t.Fatalf("command flag should win over global, got %s", *timeout)
+	}
+}
+
+func TestTimeoutFlagZeroMeansNoTimeout(t *testing.T) {
+	fs := newFlagSet("demo", "usage: demo")
+	fs.SetOutput(io.Discard)
+	timeout := addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	if got := fs.Lookup("timeout").Usage; !strings.Contains(got, "0 = no timeout") {
+		t.Fatalf("--timeout description should document 0, got %q", got)
+	}
+	if _, err := parseInterspersed(fs, []string{"--timeout", "0"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := commandContext(*timeout)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("--timeout 0 should give a context without a deadline")
+	}
+	ctx, cancel = commandContext(time.Second)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Fatal("a positive timeout should set a deadline")
+	}
+
+	fs = newFlagSet("demo", "usage: demo")
+	fs.SetOutput(io.Discard)
+	addTimeoutFlag(fs, 5*time.Second, "Command timeout")
+	_, err := parseInterspersed(fs, []string{"--timeout", "-1s"})
+	if err == nil || errorCode(err) != exitUsage {
+		t.Fatalf("negative --timeout should be a usage error, got %v", err)
+	}
+	if _, _, err := extractGlobalFlags([]string{"--timeout", "-1s", "eval"}); err == nil {
+		t.Fatal("negative global --timeout should be rejected")
+	}
+}
+
+func TestStoreIsAGlobalFlag(t *testing.T) {
+	globals, args, err := extractGlobalFlags([]string{"--store", "/tmp/ci/sessions.json", "targets"})
+	if err != nil || globals["store"] != "/tmp/ci/sessions.json" || len(args) != 1 || args[0] != "targets" {
+		t.Fatalf("got %v %v %v", globals, args, err)
+	}
+}
@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/format"
+)
+
+// cliConfig holds defaults read from config.toml. Top-level keys (host, port,
+// pretty, and the JSON layout keys indent and key-order) apply everywhere; a
+// [command] section sets flag defaults for that command only, e.g.
+//
+//	port = 9222
+//
+//	[eval]
+//	timeout = "30s"
+//
+//	["tabs open"]
+//	activate = false
+//
+// Precedence is explicit flag > env (CDP_PORT, CDP_PRETTY) > config > built-in.
+type cliConfig struct {
+	global   map[string]string
+	commands map[string]map[string]string
+}
+
+var globalConfigKeys = map[string]bool{"host": true, "port": true, "pretty": true, "indent": true, "key-order": true}
+
+var activeConfig cliConfig
+
+func configPath() (string, error) {
+	if path := strings.TrimSpace(os.Getenv("CDP_CONFIG")); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cdp-cli", "config.toml"), nil
+}
+
+// loadConfig reads the config file into activeConfig. A missing file is fine.
+func loadConfig() error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+	activeConfig = cfg
+	return nil
+}
+
+// readConfig parses the config file; a missing file is an empty config.
+func readConfig() (cliConfig, error) {
+	empty := cliConfig{global: map[string]string{}, commands: map[string]map[string]string{}}
+	path, err := configPath()
+	if err != nil {
+		return empty, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return empty, nil
+		}
+		return empty, fmt.Errorf("read config: %w", err)
+	}
+	cfg, err := parseConfig(string(data))
+	if err != nil {
+		return empty, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// currentConfig is activeConfig, or the config file read afresh when nothing
+// loaded it: library callers such as pkg/session never go through Run.
+func currentConfig() (cliConfig, error) {
+	if activeConfig.commands != nil {
+		return activeConfig, nil
+	}
+	return readConfig()
+}
+
+// parseConfig understands the flat subset of TOML the config needs: comments,
+// [section] / ["quoted section"] headers and key = value pairs with string,
+// number, or boolean values.
+func parseConfig(data string) (cliConfig, error) {
+	cfg := cliConfig{global: map[string]string{}, commands: map[string]map[string]string{}}
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripConfigComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			name, err := parseSectionHeader(line)
+			if err != nil {
+				return cfg, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			section = name
+			if cfg.commands[section] == nil {
+				cfg.commands[section] = map[string]string{}
+			}
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return cfg, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		raw = strings.TrimSpace(raw)
+		if key == "" || raw == "" {
+			return cfg, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		value := raw
+		if strings.HasPrefix(raw, "\"") || strings.HasPrefix(raw, "'") {
+			if raw[0] == '\'' && len(raw) >= 2 && strings.HasSuffix(raw, "'") {
+				value = raw[1 : len(raw)-1]
+			} else {
+				unquoted, err := strconv.Unquote(raw)
+				if err != nil {
+					return cfg, fmt.Errorf("line %d: bad string value %s", lineNo, raw)
+				}
+				value = unquoted
+			}
+		}
+		if section == "" {
+			if !globalConfigKeys[key] {
+				return cfg, fmt.Errorf("line %d: unknown top-level key %q (use host, port, pretty, indent, key-order, or a [command] section)", lineNo, key)
+			}
+			cfg.global[key] = value
+		} else {
+			cfg.commands[section][key] = value
+		}
+	}
+	return cfg, scanner.Err()
+}
+
+// parseSectionHeader returns the section a [header] line opens.
+func parseSectionHeader(line string) (string, error) {
+	if !strings.HasSuffix(line, "]") {
+		return "", errors.New("unterminated section header")
+	}
+	name := strings.TrimSpace(line[1 : len(line)-1])
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	} else {
+		// [tabs.open] is shorthand for ["tabs open"].
+		name = strings.ReplaceAll(name, ".", " ")
+	}
+	if name == "" {
+		return "", errors.New("empty section name")
+	}
+	return name, nil
+}
+
+func stripConfigComment(line string) string {
+	inQuote := byte(0)
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case inQuote != 0:
+			if ch == '\\' && inQuote == '"' {
+				i++
+			} else if ch == inQuote {
+				inQuote = 0
+			}
+		case ch == '"' || ch == '\'':
+			inQuote = ch
+		case ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// envOverridesFlag reports whether an environment variable already supplies the
+// default for this flag, in which case config values must not replace it.
+func envOverridesFlag(name string) bool {
+	switch name {
+	case "port":
+		_, ok := envDefaultPort()
+		return ok
+	case "pretty":
+		return strings.TrimSpace(os.Getenv("CDP_PRETTY")) != ""
+	}
+	return false
+}
+
+// applyConfigDefaults swaps in the command's [section] values as the defaults
+// of fs's flags before parsing, so anything given on the command line still
+// wins. Top-level keys are picked up by portDefault, hostDefault and
+// defaultPretty instead.
+func applyConfigDefaults(fs *flag.FlagSet, cfg cliConfig) error {
+	values := cfg.commands[fs.Name()]
+	for key := range values {
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("config [%s]: unknown flag %q", fs.Name(), key)
+		}
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || envOverridesFlag(f.Name) {
+			return
+		}
+		value, ok := values[f.Name]
+		if !ok {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = fmt.Errorf("config [%s]: invalid value %q for %s: %v", fs.Name(), value, f.Name, setErr)
+			return
+		}
+		f.DefValue = f.Value.String()
+	})
+	return err
+}
+
+// jsonStyle is the layout of JSON output, set from the config by
+// applyJSONStyle.
+var jsonStyle = format.DefaultStyle
+
+// applyJSONStyle sets the layout of pretty JSON output from the indent
+// ("tab" or a number of spaces) and key-order ("sorted" or "preserve") keys.
+func applyJSONStyle() error {
+	style := format.DefaultStyle
+	if raw, ok := configGlobal("indent"); ok {
+		indent, err := format.ParseIndent(raw)
+		if err != nil {
+			return fmt.Errorf("config: %w", err)
+		}
+		style.Indent = indent
+	}
+	if raw, ok := configGlobal("key-order"); ok {
+		switch raw {
+		case "sorted":
+		case "preserve":
+			style.KeepOrder = true
+		default:
+			return fmt.Errorf("config: invalid key-order %q (expected sorted or preserve)", raw)
+		}
+	}
+	jsonStyle = style
+	return nil
+}
+
+// printedValue resolves obj for printing with jsonStyle: under key-order =
+// "preserve", page objects keep the key order the page gave them.
+func printedValue(ctx context.Context, client *cdp.Client, obj cdp.RemoteObject) (interface{}, error) {
+	if jsonStyle.KeepOrder {
+		return client.RemoteObjectOrdered(ctx, obj)
+	}
+	return client.RemoteObjectValue(ctx, obj)
+}
+
+// evaluatePrinted is client.Evaluate for a result that is only printed.
+func evaluatePrinted(ctx context.Context, client *cdp.Client, expression string) (interface{}, error) {
+	res, err := client.EvaluateRaw(ctx, expression, true)
+	if err != nil {
+		return nil, err
+	}
+	return printedValue(ctx, client, res.Result)
+}
+
+func configGlobal(key string) (string, bool) {
+	value, ok := activeConfig.global[key]
+	return value, ok
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/format"
+)
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := parseConfig(`
+# defaults
+port = 9333
+host = "10.0.0.2" # inline comment
+
+[eval]
+timeout = "30s"
+
+[tabs.open]
+activate = false
+`)
+	if err != nil {
+		t.Fatalf("parseConfig: %v", err)
+	}
+	if cfg.global["port"] != "9333" || cfg.global["host"] != "10.0.0.2" {
+		t.Fatalf("unexpected globals: %v", cfg.global)
+	}
+	if cfg.commands["eval"]["timeout"] != "30s" {
+		t.Fatalf("unexpected eval section: %v", cfg.commands["eval"])
+	}
+	if cfg.commands["tabs open"]["activate"] != "false" {
+		t.Fatalf("unexpected tabs open section: %v", cfg.commands["tabs open"])
+	}
+}
+
+func TestParseConfigRejectsUnknownTopLevelKey(t *testing.T) {
+	if _, err := parseConfig("timeout = \"5s\"\n"); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("expected line-numbered error, got %v", err)
+	}
+}
+
+func TestApplyConfigDefaultsKeepsExplicitFlags(t *testing.T) {
+	cfg, err := parseConfig("[test]\ntimeout = \"30s\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := newFlagSet("test", "usage: test")
+	timeout := fs.Duration("timeout", 5*time.Second, "")
+	if err := applyConfigDefaults(fs, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 30*time.Second {
+		t.Fatalf("expected config default, got %s", *timeout)
+	}
+	if err := fs.Parse([]string{"--timeout", "2s"}); err != nil {
+		t.Fatal(err)
+	}
+	if *timeout != 2*time.Second {
+		t.Fatalf("expected explicit flag to win, got %s", *timeout)
+	}
+}
+
+func TestKeyOrderPreserveReachesEvalResults(t *testing.T) {
+	wsURL := fakeEvalTab(t, func(string) interface{} {
+		return json.RawMessage(`{"zeta":1,"alpha":{"y":2,"x":3}}`)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := cdp.Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	saved := activeConfig
+	defer func() { activeConfig, jsonStyle = saved, format.DefaultStyle }()
+	for _, tc := range []struct{ config, want string }{
+		{"", `{"alpha":{"x":3,"y":2},"zeta":1}`},
+		{"key-order = preserve\n", `{"zeta":1,"alpha":{"y":2,"x":3}}`},
+	} {
+		if activeConfig, err = parseConfig(tc.config); err != nil {
+			t.Fatal(err)
+		}
+		if err := applyJSONStyle(); err != nil {
+			t.Fatal(err)
+		}
+		value, err := evaluatePrinted(ctx, client, "({zeta: 1, alpha: {y: 2, x: 3}})")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := jsonStyle.JSON(value, false, -1); got != tc.want {
+			t.Errorf("config %q: got %s, want %s", tc.config, got, tc.want)
+		}
+	}
+}
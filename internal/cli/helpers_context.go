@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+type contextErrorKind int
+
+const (
+	contextErrNone contextErrorKind = iota
+	// contextErrReset means the execution context we evaluated in is gone
+	// (navigation, reload, or the frame's document was replaced).
+	contextErrReset
+	// contextErrDestroyed means the context went away while our evaluation was
+	// running, so the action may already have taken effect.
+	contextErrDestroyed
+	// contextErrDetached means the frame itself was removed from the page.
+	contextErrDetached
+	// contextErrWebNavMissing means the helpers vanished between the injection
+	// check and the call (typically a navigation in between).
+	contextErrWebNavMissing
+)
+
+var contextErrorPatterns = []struct {
+	substr string
+	kind   contextErrorKind
+}{
+	{"cannot find context with specified id", contextErrReset},
+	{"cannot find default execution context", contextErrReset},
+	{"execution context was destroyed", contextErrDestroyed},
+	{"inspected target navigated or closed", contextErrDestroyed},
+	{"frame with the given id was not found", contextErrDetached},
+	{"no frame for given id found", contextErrDetached},
+	{"frame was detached", contextErrDetached},
+}
+
+func classifyContextError(err error) contextErrorKind {
+	if err == nil {
+		return contextErrNone
+	}
+	msg := strings.ToLower(err.Error())
+	var protoErr *cdp.Error
+	if errors.As(err, &protoErr) {
+		msg = strings.ToLower(protoErr.Message + " " + protoErr.Data)
+	}
+	for _, p := range contextErrorPatterns {
+		if strings.Contains(msg, p.substr) {
+			return p.kind
+		}
+	}
+	if strings.Contains(msg, "webnav") && strings.Contains(msg, "is not a function") {
+		return contextErrWebNavMissing
+	}
+	return contextErrNone
+}
+
+func (k contextErrorKind) describe() string {
+	switch k {
+	case contextErrReset:
+		return "the page's JavaScript world was reset (navigation or frame removal)"
+	case contextErrDestroyed:
+		return "the page navigated while the command was running (the action may already have taken effect)"
+	case contextErrDetached:
+		return "the frame being evaluated was detached from the page"
+	case contextErrWebNavMissing:
+		return "the WebNav helpers disappeared (the page likely navigated)"
+	}
+	return ""
+}
+
+// withWebNavRetry runs fn and, if it fails because the page's JS context was
+// reset or a frame was detached, re-injects WebNav and retries once. A context
+// destroyed mid-evaluation is reported but not retried, since repeating a click
+// or keypress on the next page would be worse than failing.
+func withWebNavRetry(ctx context.Context, client *cdp.Client, verbose bool, fn func() error) error {
+	return withScriptsDisabledHint(client, retryWebNavOnce(ctx, client, verbose, fn))
+}
+
+func retryWebNavOnce(ctx context.Context, client *cdp.Client, verbose bool, fn func() error) error {
+	err := fn()
+	kind := classifyContextError(err)
+	if kind == contextErrNone {
+		return err
+	}
+	if kind == contextErrDestroyed {
+		return fmt.Errorf("%s: %w", kind.describe(), err)
+	}
+	if verbose {
+		fmt.Fprintf(os.Stderr, "retry 1/1: %s; WebNav will be re-injected automatically (%v)\n", kind.describe(), err)
+	}
+	if injectErr := ensureWebNavInjected(ctx, client); injectErr != nil {
+		return fmt.Errorf("%s and re-injecting WebNav failed: %w", kind.describe(), injectErr)
+	}
+	retryErr := fn()
+	if retryErr == nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, "retry 1/1 succeeded")
+		}
+		return nil
+	}
+	if retryKind := classifyContextError(retryErr); retryKind != contextErrNone {
+		return fmt.Errorf("%s; retried once after re-injecting WebNav (try 'cdp read' or 'cdp inject --force'): %w", retryKind.describe(), retryErr)
+	}
+	return retryErr
+}
+
+// runWebNavAction evaluates expression, an action built on window.<fn>, and
+// reads back its result. Only the check that fn exists goes through
+// withWebNavRetry, so a context reset before the action re-injects WebNav and
+// checks again. The action itself is sent exactly once: if it navigates and
+// a later step fails, running it again would click or hover on the next page.
+func runWebNavAction(ctx context.Context, client *cdp.Client, verbose bool, fn, expression string) (interface{}, error) {
+	if err := withWebNavRetry(ctx, client, verbose, func() error {
+		kind, err := client.Evaluate(ctx, "typeof window."+fn)
+		if err != nil {
+			return err
+		}
+		if kind != "function" {
+			return fmt.Errorf("window.%s is not a function", fn)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	raw, err := client.EvaluateRaw(ctx, expression, false)
+	if err != nil {
+		if kind := classifyContextError(err); kind != contextErrNone {
+			err = fmt.Errorf("%s: %w", kind.describe(), err)
+		}
+		return nil, withScriptsDisabledHint(client, err)
+	}
+	value, err := client.RemoteObjectValue(ctx, raw.Result)
+	if err != nil && classifyContextError(err) != contextErrNone {
+		return nil, fmt.Errorf("the action ran, but the page navigated before its result could be read: %w", err)
+	}
+	return value, err
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+)
+
+func TestClassifyContextError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want contextErrorKind
+	}{
+		{"nil", nil, contextErrNone},
+		{"unrelated", errors.New("no element matched selectors: .btn"), contextErrNone},
+		{"missing context", &cdp.Error{Code: -32000, Message: "Cannot find context with specified id"}, contextErrReset},
+		{"destroyed context", errors.New("Execution context was destroyed."), contextErrDestroyed},
+		{"default context", &cdp.Error{Code: -32000, Message: "Cannot find default execution context"}, contextErrReset},
+		{"detached frame", &cdp.Error{Code: -32000, Message: "Frame with the given id was not found."}, contextErrDetached},
+		{"wrapped detached", fmt.Errorf("eval: %w", &cdp.Error{Code: -32000, Message: "No frame for given id found"}), contextErrDetached},
+		{"webnav missing", errors.New("Uncaught (TypeError: window.WebNavClickWithRead is not a function)"), contextErrWebNavMissing},
+		{"other not a function", errors.New("TypeError: foo is not a function"), contextErrNone},
+	}
+	for _, tc := range cases {
+		if got := classifyContextError(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestHoverIsNotRepeatedWhenTheResultIsLost(t *testing.T) {
+	hovers := 0
+	wsURL := cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		expression := req.Expression()
+		switch {
+		case req.Method == "Runtime.callFunctionOn":
+			// The hover navigated, so its result object went with the page.
+			c.Fail(req.ID, "Cannot find context with specified id")
+		case strings.HasPrefix(expression, "typeof window."):
+			c.Reply(req.ID, cdptest.EvalResult("function"))
+		case strings.HasPrefix(expression, "window.WebNavHoverWithRead("):
+			hovers++
+			c.Reply(req.ID, map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "1"}})
+		case req.Method == "Runtime.evaluate":
+			c.Reply(req.ID, cdptest.EvalResult(true))
+		default:
+			c.Reply(req.ID, nil)
+		}
+	})
+	holdFakeSession(t, "app", wsURL)
+
+	err := cmdHover([]string{"--session", "app", "#menu"})
+	if err == nil || !strings.Contains(err.Error(), "the action ran") {
+		t.Fatalf("err = %v", err)
+	}
+	if hovers != 1 {
+		t.Fatalf("hovered %d times, want once", hovers)
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -8,6 +9,13 @@ import (
 
 func defaultPretty() bool {
 	val := strings.ToLower(strings.TrimSpace(os.Getenv("CDP_PRETTY")))
+	if val == "" {
+		if raw, ok := configGlobal("pretty"); ok {
+			if b, err := strconv.ParseBool(raw); err == nil {
+				return b
+			}
+		}
+	}
 	switch val {
 	case "", "1", "true", "yes", "on":
 		return true
@@ -31,8 +39,58 @@ func envDefaultPort() (int, bool) {
 }
 
 func portDefault(fallback int) int {
+	if activeProfile != nil && activeProfile.Port > 0 {
+		return activeProfile.Port
+	}
 	if val, ok := envDefaultPort(); ok {
 		return val
 	}
+	if raw, ok := configGlobal("port"); ok {
+		if val, err := strconv.Atoi(raw); err == nil && val > 0 {
+			return val
+		}
+	}
+	return fallback
+}
+
+func hostDefault(fallback string) string {
+	if activeProfile != nil && activeProfile.Host != "" {
+		return activeProfile.Host
+	}
+	if raw, ok := configGlobal("host"); ok && strings.TrimSpace(raw) != "" {
+		return strings.TrimSpace(raw)
+	}
 	return fallback
 }
+
+// defaultPreviewLimit is the --preview-limit default: CDP_PREVIEW_LIMIT if
+// set, otherwise about four lines of the terminal, or 300 when not a TTY.
+func defaultPreviewLimit() int {
+	if raw := strings.TrimSpace(os.Getenv("CDP_PREVIEW_LIMIT")); raw != "" {
+		if val, err := strconv.Atoi(raw); err == nil && val >= 0 {
+			return val
+		}
+	}
+	if width := terminalWidth(); width > 0 {
+		return 4 * width
+	}
+	return 300
+}
+
+// useColor resolves a --color mode: "auto" colors only when stdout is a
+// terminal and NO_COLOR is unset or empty, as no-color.org specifies.
+func useColor(mode string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "", "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		return terminalWidth() > 0, nil
+	default:
+		return false, fmt.Errorf("invalid --color %q (expected auto, always, or never)", mode)
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+// Exit codes returned by ReportError, also used as the "code" in
+// --json-errors output.
+const (
+	exitError      = 1 // anything not classified below
+	exitUsage      = 2 // bad flags or arguments
+	exitTimeout    = 3 // a deadline or wait expired
+	exitConnection = 4 // the DevTools endpoint could not be reached, or stopped answering
+	exitProtocol   = 5 // the browser rejected a CDP command
+	exitNoMatch    = 6 // a read, click, type or hover selector matched nothing
+)
+
+var exitKinds = map[int]string{
+	exitError:      "error",
+	exitUsage:      "usage",
+	exitTimeout:    "timeout",
+	exitConnection: "connection",
+	exitProtocol:   "protocol",
+	exitNoMatch:    "no-match",
+}
+
+// jsonErrors is set by the global --json-errors flag.
+var jsonErrors bool
+
+func errorCode(err error) int {
+	var usage *usageError
+	var endpoint *cdp.EndpointError
+	var protocol *cdp.Error
+	var noMatch *noMatchError
+	var notFound *selectorNotFoundError
+	switch {
+	case errors.As(err, &noMatch), errors.As(err, &notFound):
+		return exitNoMatch
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &endpoint), errors.Is(err, cdp.ErrConnectionLost):
+		return exitConnection
+	case errors.Is(err, context.DeadlineExceeded), strings.HasPrefix(err.Error(), "timeout waiting for"):
+		return exitTimeout
+	case errors.As(err, &protocol):
+		return exitProtocol
+	}
+	return exitError
+}
+
+// errorRemedy pairs a failure signature (matched against the lowercased
+// error text) with the Chrome switch or setup change that avoids it. Several
+// CDP features fail obscurely, or not at all, unless Chrome was started right.
+type errorRemedy struct {
+	match  func(msg string) bool
+	remedy string
+}
+
+var errorRemedies = []errorRemedy{
+	{
+		// Chrome 111+ rejects DevTools websockets from unlisted origins.
+		match: func(msg string) bool {
+			return strings.Contains(msg, "status code 101 but got 403") || (strings.Contains(msg, "websocket") && strings.Contains(msg, "forbidden"))
+		},
+		remedy: "Chrome refused the DevTools websocket (HTTP 403); restart it with --remote-allow-origins=* (or list this origin explicitly)",
+	},
+	{
+		match: func(msg string) bool {
+			return strings.Contains(msg, "printtopdf is not implemented")
+		},
+		remedy: "this Chrome only prints to PDF when headless; restart it with --headless=new",
+	},
+	{
+		match: func(msg string) bool {
+			return strings.Contains(msg, "fetch.enable") && (strings.Contains(msg, "not allowed") || strings.Contains(msg, "permission") || strings.Contains(msg, "wasn't found"))
+		},
+		remedy: "request interception is unavailable on this target; connect to a page tab of a Chrome started with --remote-debugging-port (not an extension, worker, or --remote-debugging-pipe target)",
+	},
+}
+
+// remedyError is an error with a remediation line from errorRemedies.
+type remedyError struct {
+	err    error
+	remedy string
+}
+
+func (e *remedyError) Error() string { return e.err.Error() + "\nhint: " + e.remedy }
+
+func (e *remedyError) Unwrap() error { return e.err }
+
+// withRemedy appends the remedy for the first matching signature to err, or
+// returns err unchanged when none matches.
+func withRemedy(err error) error {
+	var already *remedyError
+	if err == nil || errors.As(err, &already) {
+		return err
+	}
+	msg := strings.ToLower(err.Error())
+	for _, r := range errorRemedies {
+		if r.match(msg) {
+			return &remedyError{err: err, remedy: r.remedy}
+		}
+	}
+	return err
+}
+
+// ReportError prints err to stderr (as JSON with --json-errors) and returns
+// the process exit code for it.
+func ReportError(err error) int {
+	err = withRemedy(err)
+	code := errorCode(err)
+	if !jsonErrors {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return code
+	}
+	payload := map[string]interface{}{
+		"error": err.Error(),
+		"code":  code,
+		"kind":  exitKinds[code],
+	}
+	var remedy *remedyError
+	if errors.As(err, &remedy) {
+		payload["error"] = remedy.err.Error()
+		payload["hint"] = remedy.remedy
+	}
+	var usage *usageError
+	if errors.As(err, &usage) {
+		payload["error"] = usage.err.Error()
+		if usage.usage != "" {
+			payload["usage"] = usage.usage
+		}
+	}
+	var recent *pageErrorsError
+	if errors.As(err, &recent) {
+		payload["error"] = strings.Replace(payload["error"].(string), recent.section(), "", 1)
+		payload["pageErrors"] = recent.entries
+	}
+	var notFound *selectorNotFoundError
+	if errors.As(err, &notFound) && len(notFound.suggestions) > 0 {
+		payload["error"] = strings.Replace(payload["error"].(string), notFound.section(), "", 1)
+		payload["suggestions"] = notFound.suggestions
+	}
+	out, jsonErr := jsonStyle.JSON(payload, false, -1)
+	if jsonErr != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return code
+	}
+	fmt.Fprintln(os.Stderr, out)
+	return code
+}
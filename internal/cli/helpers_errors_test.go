@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+func TestErrorCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), exitError},
+		{cmdClick([]string{"--sesion", "x", ".btn"}), exitUsage},
+		{cmdClick([]string{"--session", "x"}), exitUsage},
+		{cmdHover([]string{"--session", "x", ".a", ".b"}), exitUsage},
+		{cmdTargets([]string{"extra"}), exitUsage},
+		{fmt.Errorf("eval: %w", context.DeadlineExceeded), exitTimeout},
+		{errors.New("timeout waiting for selector .x"), exitTimeout},
+		{&cdp.EndpointError{Endpoint: "/json/list", Attempts: 5, Err: errors.New("refused")}, exitConnection},
+		{fmt.Errorf("reconnecting after %w: refused", cdp.ErrConnectionLost), exitConnection},
+		{fmt.Errorf("call: %w", &cdp.Error{Code: -32000, Message: "bad"}), exitProtocol},
+	}
+	for _, tc := range cases {
+		if got := errorCode(tc.err); got != tc.want {
+			t.Fatalf("errorCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestWithRemedyForRejectedWebSocketOrigin(t *testing.T) {
+	// Chrome 111+ answers the upgrade with 403 when the Origin isn't allowed.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Rejected an incoming WebSocket connection from the http://localhost origin.", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	_, err := cdp.Dial(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")+"/devtools/page/1")
+	if err == nil {
+		t.Fatal("expected the dial to fail")
+	}
+	enriched := withRemedy(err)
+	if !strings.Contains(enriched.Error(), "--remote-allow-origins=*") {
+		t.Fatalf("expected a --remote-allow-origins hint, got %q", enriched)
+	}
+	if !errors.Is(enriched, err) {
+		t.Fatal("expected the original error to stay wrapped")
+	}
+	if again := withRemedy(enriched); again != enriched {
+		t.Fatalf("expected the hint to be added once, got %q", again)
+	}
+}
+
+func TestWithRemedyLeavesUnknownErrors(t *testing.T) {
+	err := errors.New("selector .x not found")
+	if got := withRemedy(err); got != err {
+		t.Fatalf("withRemedy(%v) = %v, want it unchanged", err, got)
+	}
+	pdf := &cdp.Error{Code: -32000, Message: "PrintToPDF is not implemented"}
+	if got := withRemedy(pdf); !strings.Contains(got.Error(), "--headless") || errorCode(got) != exitProtocol {
+		t.Fatalf("unexpected printToPDF enrichment %q (code %d)", got, errorCode(got))
+	}
+}
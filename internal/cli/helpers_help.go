@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// commandHelp is the long-form help a command registers next to its code:
+// a description and worked examples. `cdp help <command>` and --help print
+// it after the usage line and options, and `cdp commands --json` exposes it.
+type commandHelp struct {
+	Description string
+	Examples    []commandExample
+}
+
+// commandExample is one worked example: a full command line, quoted the way
+// a shell would take it, and a one-line explanation.
+type commandExample struct {
+	Command     string `json:"command"`
+	Explanation string `json:"explanation"`
+}
+
+var helpHelp = commandHelp{
+	Description: `Prints a command's usage and options followed by a longer description and
+worked examples. 'cdp help <command>' is the same as 'cdp <command> --help';
+for group commands such as tabs, name the subcommand to see only its examples.`,
+	Examples: []commandExample{
+		{"cdp help eval", "Describe eval and show how to pass arguments to scripts."},
+		{"cdp help tabs open", "Show the options and examples for opening tabs."},
+		{"cdp help", "List every command, the same as 'cdp --help'."},
+	},
+}
+
+func cmdHelp(args []string) error {
+	fs := newFlagSet("help", "usage: cdp help [command [subcommand]]")
+	if len(args) == 1 && isHelpArg(args[0]) {
+		fs.Usage()
+		return nil
+	}
+	pos, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		printUsage()
+		return nil
+	}
+	if len(pos) > 2 {
+		return unexpectedArgs(pos[2:])
+	}
+	c, ok := lookupCommand(pos[0])
+	if !ok {
+		return usagef("unknown command %q", pos[0])
+	}
+	topic := []string{"--help"}
+	if len(pos) == 2 {
+		if !hasSubcommand(c, pos[1]) {
+			return fmt.Errorf("unknown %s command %q", c.Name, pos[1])
+		}
+		topic = []string{pos[1], "--help"}
+	}
+	return runRegistered(c, topic)
+}
+
+// runRegistered runs a registry command. When the arguments ask for help
+// ("--help", or "<subcommand> --help" for group commands) the command's
+// description and examples follow its usage. Parse errors and missing
+// arguments still print only the short usage.
+func runRegistered(c cliCommand, args []string) error {
+	sub, help := helpTopic(c, args)
+	if err := c.run(args); err != nil {
+		return err
+	}
+	if help {
+		printCommandHelp(os.Stdout, c.Help, c.Name, sub)
+	}
+	return nil
+}
+
+func helpTopic(c cliCommand, args []string) (sub string, ok bool) {
+	switch {
+	case len(args) == 1 && isHelpArg(args[0]):
+		return "", true
+	case len(args) == 2 && isHelpArg(args[1]) && hasSubcommand(c, args[0]):
+		return args[0], true
+	}
+	return "", false
+}
+
+func hasSubcommand(c cliCommand, name string) bool {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// commandExamples returns the examples for a command, or only those that
+// run the given subcommand.
+func commandExamples(h commandHelp, name, sub string) []commandExample {
+	if sub == "" {
+		return h.Examples
+	}
+	prefix := "cdp " + name + " " + sub
+	var out []commandExample
+	for _, ex := range h.Examples {
+		if ex.Command == prefix || strings.HasPrefix(ex.Command, prefix+" ") {
+			out = append(out, ex)
+		}
+	}
+	return out
+}
+
+// printCommandHelp prints the description (for the command itself) and the
+// matching examples, each explanation indented under its command line.
+func printCommandHelp(w io.Writer, h commandHelp, name, sub string) {
+	if sub == "" && h.Description != "" {
+		fmt.Fprintln(w, "\nDescription:")
+		for _, line := range strings.Split(h.Description, "\n") {
+			fmt.Fprintln(w, strings.TrimRight("  "+line, " "))
+		}
+	}
+	examples := commandExamples(h, name, sub)
+	if len(examples) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nExamples:")
+	for _, ex := range examples {
+		fmt.Fprintf(w, "  %s\n      %s\n", ex.Command, ex.Explanation)
+	}
+}
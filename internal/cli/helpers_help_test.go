@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEveryCommandHasParsableExamples(t *testing.T) {
+	for _, c := range commandRegistry {
+		if c.Help.Description == "" || len(c.Help.Examples) == 0 {
+			t.Errorf("%s: needs a description and at least one example", c.Name)
+			continue
+		}
+		for _, ex := range c.Help.Examples {
+			if ex.Explanation == "" {
+				t.Errorf("%s: example %q has no explanation", c.Name, ex.Command)
+			}
+			words, err := splitReplLine(ex.Command)
+			if err != nil || len(words) < 2 || words[0] != "cdp" {
+				t.Errorf("%s: example %q is not a cdp command line (%v)", c.Name, ex.Command, err)
+				continue
+			}
+			_, args, err := extractGlobalFlags(words[1:])
+			if err != nil || len(args) == 0 {
+				t.Errorf("%s: example %q: bad global flags (%v)", c.Name, ex.Command, err)
+				continue
+			}
+			if named, ok := lookupCommand(args[0]); !ok || named.Name != c.Name {
+				t.Errorf("%s: example %q runs a different command", c.Name, ex.Command)
+				continue
+			}
+			if err := parseExample(c, args[1:]); err != nil {
+				t.Errorf("%s: example %q: %v", c.Name, ex.Command, err)
+			}
+		}
+	}
+}
+
+// parseExample parses an example's arguments with the flag set the command
+// (or the subcommand it names) defines.
+func parseExample(c cliCommand, args []string) error {
+	help := []string{"--help"}
+	if len(args) > 0 && hasSubcommand(c, args[0]) {
+		help = []string{args[0], "--help"}
+		args = args[1:]
+	}
+	fs := captureFlagSet(c.run, help)
+	if fs == nil {
+		if len(c.Subcommands) > 0 {
+			return errors.New("does not name a subcommand")
+		}
+		return errors.New("command defines no flag set")
+	}
+	_, err := parseInterspersed(fs, args)
+	return err
+}
+
+func TestHelpPrintsDescriptionAndExamples(t *testing.T) {
+	var out bytes.Buffer
+	tabs, _ := lookupCommand("tabs")
+	printCommandHelp(&out, tabs.Help, "tabs", "gc")
+	if strings.Contains(out.String(), "Description:") || !strings.Contains(out.String(), "cdp tabs gc --max-age 2h") || strings.Contains(out.String(), "tabs list") {
+		t.Fatalf("tabs gc help:\n%s", out.String())
+	}
+
+	out.Reset()
+	eval, _ := lookupCommand("eval")
+	printCommandHelp(&out, eval.Help, "eval", "")
+	if !strings.Contains(out.String(), "Description:\n  Evaluates") || strings.Count(out.String(), "\n  cdp eval ") != len(eval.Help.Examples) {
+		t.Fatalf("eval help:\n%s", out.String())
+	}
+
+	if sub, ok := helpTopic(tabs, []string{"open", "--help"}); !ok || sub != "open" {
+		t.Fatalf("helpTopic(tabs open --help) = %q, %v", sub, ok)
+	}
+	if _, ok := helpTopic(eval, []string{"--session", "--help"}); ok {
+		t.Fatal("a flag value is not a help request")
+	}
+	if err := cmdHelp([]string{"tabs", "nope"}); err == nil {
+		t.Fatal("unknown subcommand accepted")
+	}
+}
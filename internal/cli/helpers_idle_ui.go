@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+// defaultBusyIndicators are the loading markers --wait-idle-ui waits out.
+const defaultBusyIndicators = "[aria-busy=true], .spinner, .loading, [data-loading=true]"
+
+// idleUIFlags are --wait-idle-ui and --busy-indicators, shared by the
+// commands that act on the page and by `cdp wait --idle-ui`.
+type idleUIFlags struct {
+	wait       *bool
+	indicators *string
+}
+
+func addIdleUIFlags(fs *flag.FlagSet) idleUIFlags {
+	return idleUIFlags{
+		wait:       fs.Bool("wait-idle-ui", false, "First wait (within --timeout) until no visible element matches --busy-indicators"),
+		indicators: fs.String("busy-indicators", defaultBusyIndicators, "CSS selector list of loading markers for --wait-idle-ui (a [session.<name>] config section can set busy-indicators too)"),
+	}
+}
+
+// resolve picks the indicator list: --busy-indicators when given on the
+// command line, else busy-indicators from the session's [session.<name>]
+// config section, else the command's default.
+func (f idleUIFlags) resolve(fs *flag.FlagSet, session string) ([]string, error) {
+	given := false
+	fs.Visit(func(fl *flag.Flag) {
+		given = given || fl.Name == "busy-indicators"
+	})
+	list := *f.indicators
+	if !given {
+		section := activeConfig.commands["session "+session]
+		for key := range section {
+			if key != "busy-indicators" {
+				return nil, fmt.Errorf("config [session.%s]: unknown key %q (only busy-indicators)", session, key)
+			}
+		}
+		if value, ok := section["busy-indicators"]; ok {
+			list = value
+		}
+	}
+	indicators := splitSelectorList(list)
+	if len(indicators) == 0 {
+		return nil, errors.New("--busy-indicators must name at least one selector")
+	}
+	return indicators, nil
+}
+
+// waitBefore runs the --wait-idle-ui wait ahead of a command's action and
+// says on stderr what it waited on. It does nothing without the flag.
+func (f idleUIFlags) waitBefore(ctx context.Context, client *cdp.Client, fs *flag.FlagSet, session string) error {
+	if !*f.wait {
+		return nil
+	}
+	indicators, err := f.resolve(fs, session)
+	if err != nil {
+		return err
+	}
+	busy, waited, err := waitIdleUI(ctx, client, indicators, 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	if busy != "" {
+		fmt.Fprintf(os.Stderr, "Waited %s for %s to go away\n", waited.Round(time.Millisecond), busy)
+	}
+	return nil
+}
+
+// waitIdleUI polls until no rendered element matches any indicator, using
+// WebNav's visibility check. It returns the first indicator it saw showing
+// ("" when the page was idle straight away) and how long it waited.
+func waitIdleUI(ctx context.Context, client *cdp.Client, indicators []string, poll time.Duration) (string, time.Duration, error) {
+	if err := ensureWebNavInjected(ctx, client); err != nil {
+		return "", 0, err
+	}
+	list, _ := json.Marshal(indicators)
+	expression := fmt.Sprintf(`(window.WebNav && window.WebNav.busyIndicator) ? window.WebNav.busyIndicator(%s) : null`, list)
+	start := time.Now()
+	busy := ""
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		value, err := client.Evaluate(ctx, expression)
+		switch sel, ok := value.(string); {
+		case err != nil:
+			// Usually a navigation in progress; try again on the next tick.
+		case !ok:
+			// A new document without WebNav.
+			_ = ensureWebNavInjected(ctx, client)
+		case sel == "":
+			return busy, time.Since(start), nil
+		case busy == "":
+			busy = sel
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				if busy == "" {
+					busy = strings.Join(indicators, ", ")
+				}
+				return busy, time.Since(start), fmt.Errorf("timeout waiting for busy indicator %s to go away", busy)
+			}
+			return busy, time.Since(start), ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
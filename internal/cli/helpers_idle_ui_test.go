@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+func TestWaitIdleUIReportsTheIndicator(t *testing.T) {
+	polls, stuck := 0, false
+	wsURL := fakeEvalTab(t, func(expression string) interface{} {
+		if !strings.Contains(expression, "busyIndicator") {
+			return true
+		}
+		polls++
+		if stuck || polls < 3 {
+			return ".spinner"
+		}
+		return ""
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := cdp.Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	busy, waited, err := waitIdleUI(ctx, client, []string{".spinner"}, 10*time.Millisecond)
+	if err != nil || busy != ".spinner" || waited < 20*time.Millisecond {
+		t.Fatalf("busy %q waited %s err %v", busy, waited, err)
+	}
+
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	stuck = true
+	_, _, err = waitIdleUI(short, client, []string{".spinner"}, 10*time.Millisecond)
+	if err == nil || err.Error() != "timeout waiting for busy indicator .spinner to go away" || errorCode(err) != exitTimeout {
+		t.Fatalf("timeout: %v", err)
+	}
+}
+
+func TestBusyIndicatorsFromSessionConfig(t *testing.T) {
+	cfg, err := parseConfig("[session.app]\nbusy-indicators = \".skeleton, .shimmer\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := activeConfig
+	activeConfig = cfg
+	defer func() { activeConfig = saved }()
+
+	fs := newFlagSet("click", "")
+	flags := addIdleUIFlags(fs)
+	if _, err := parseInterspersed(fs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := flags.resolve(fs, "app"); strings.Join(got, "|") != ".skeleton|.shimmer" {
+		t.Fatalf("session config: %q", got)
+	}
+	if got, _ := flags.resolve(fs, "other"); len(got) != 4 {
+		t.Fatalf("default: %q", got)
+	}
+	if _, err := parseInterspersed(fs, []string{"--busy-indicators", ".busy"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := flags.resolve(fs, "app"); strings.Join(got, "|") != ".busy" {
+		t.Fatalf("flag: %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -51,3 +52,14 @@ func writeJSONFile(path string, payload interface{}) error {
 	data = append(data, '\n')
 	return os.WriteFile(path, data, 0o644)
 }
+
+// confirmPrompt asks a yes/no question on stderr and reads the answer from stdin.
+func confirmPrompt(question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return false, fmt.Errorf("read confirmation (pass --yes to skip): %w", err)
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
@@ -3,11 +3,15 @@ package cli
 import (
 	"fmt"
 	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"unicode"
 )
 
+// jsIdentifierPattern matches names that are safe to use as window properties.
+var jsIdentifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
 func containsReturnKeyword(input string) bool {
 	// Look for a "return " token preceded by a non-alphanumeric or start-of-string.
 	prev := rune(0)
@@ -50,3 +54,14 @@ func formatScrollNumber(value interface{}) string {
 	}
 	return s
 }
+
+// splitCommaList splits "a, b,,c" into ["a" "b" "c"].
+func splitCommaList(input string) []string {
+	var out []string
+	for _, part := range strings.Split(input, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
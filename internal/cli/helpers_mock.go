@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"net/url"
+	"strings"
+)
+
+// mockRule is one canned response in a mock rules file: a request matching
+// Method and URL is answered with Status, Headers and the contents of BodyFile
+// (relative to the rules file). With IgnoreQuery the URL is compared without
+// its query string.
+type mockRule struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"`
+	IgnoreQuery bool              `json:"ignoreQuery,omitempty"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	BodyFile    string            `json:"bodyFile,omitempty"`
+}
+
+type mockRuleFile struct {
+	Rules []mockRule `json:"rules"`
+}
+
+// volatileMockHeaders are response headers dropped from generated rules: they
+// change on every response, or describe the wire encoding rather than the
+// decoded body that captures store.
+var volatileMockHeaders = map[string]bool{
+	"date":              true,
+	"etag":              true,
+	"age":               true,
+	"expires":           true,
+	"last-modified":     true,
+	"content-length":    true,
+	"content-encoding":  true,
+	"transfer-encoding": true,
+	"set-cookie":        true,
+}
+
+func stableMockHeaders(headers map[string]string) map[string]string {
+	out := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || volatileMockHeaders[name] || strings.HasPrefix(name, ":") {
+			continue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+func stripURLQuery(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		if i := strings.IndexAny(raw, "?#"); i != -1 {
+			return raw[:i]
+		}
+		return raw
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+// networkSampleWindow bounds the latency `read --with-network` adds.
+const networkSampleWindow = 250 * time.Millisecond
+
+// networkSample is a best-effort snapshot of page network activity. InFlight
+// only counts requests seen starting during the sample window, since Network
+// events are not replayed for requests issued before Network.enable.
+type networkSample struct {
+	InFlight          int      `json:"inFlight"`
+	LastResponseAgoMs *float64 `json:"lastResponseAgoMs,omitempty"`
+	WindowMs          float64  `json:"windowMs"`
+	Error             string   `json:"error,omitempty"`
+}
+
+// sampleNetwork listens to Network events for networkSampleWindow and reads
+// resource timing for the age of the last response. It never fails; problems
+// are reported in the Error field.
+func sampleNetwork(ctx context.Context, client *cdp.Client) networkSample {
+	sample := networkSample{WindowMs: durationMs(networkSampleWindow)}
+	var (
+		mu           sync.Mutex
+		pending      = map[string]bool{}
+		lastResponse time.Time
+	)
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		var params struct {
+			RequestID string `json:"requestId"`
+		}
+		switch evt.Method {
+		case "Network.requestWillBeSent", "Network.responseReceived", "Network.loadingFinished", "Network.loadingFailed":
+			if err := json.Unmarshal(evt.Params, &params); err != nil {
+				return
+			}
+		default:
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch evt.Method {
+		case "Network.requestWillBeSent":
+			pending[params.RequestID] = true
+		case "Network.responseReceived":
+			lastResponse = time.Now()
+		default:
+			delete(pending, params.RequestID)
+		}
+	})
+	defer unsubscribe()
+
+	if err := client.Call(ctx, "Network.enable", nil, nil); err != nil {
+		sample.Error = fmt.Sprintf("Network.enable: %v", err)
+	} else {
+		select {
+		case <-time.After(networkSampleWindow):
+		case <-ctx.Done():
+		}
+	}
+
+	ago := -1.0
+	if value, err := client.Evaluate(ctx, `(() => {
+        let last = 0;
+        for (const e of performance.getEntriesByType("navigation").concat(performance.getEntriesByType("resource"))) {
+            if (e.responseEnd > last) last = e.responseEnd;
+        }
+        return last > 0 ? performance.now() - last : -1;
+    })()`); err == nil {
+		if v, ok := value.(float64); ok {
+			ago = v
+		}
+	} else if sample.Error == "" {
+		sample.Error = fmt.Sprintf("resource timing: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	sample.InFlight = len(pending)
+	if !lastResponse.IsZero() {
+		if seen := durationMs(time.Since(lastResponse)); ago < 0 || seen < ago {
+			ago = seen
+		}
+	}
+	if ago >= 0 {
+		sample.LastResponseAgoMs = &ago
+	}
+	return sample
+}
+
+func (s networkSample) String() string {
+	last := "no responses yet"
+	if s.LastResponseAgoMs != nil {
+		last = fmt.Sprintf("last response %.1fs ago", *s.LastResponseAgoMs/1000)
+	}
+	line := fmt.Sprintf("network: %d in-flight, %s", s.InFlight, last)
+	if s.Error != "" {
+		line += " (partial: " + s.Error + ")"
+	}
+	return line
+}
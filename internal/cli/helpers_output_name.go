@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var outputNameField = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// outputName expands a --name template such as "{session}-{seq}.png".
+// {session} and {selector} go through sanitizePathFragment, so a selector
+// like "#main > .card" can't add directories; {ts} is the local time of the
+// capture and {seq} a four-digit counter. A name without an extension gets
+// ".png".
+type outputName struct {
+	template string
+	session  string
+	selector string
+}
+
+func parseOutputName(template, session, selector string) (*outputName, error) {
+	if strings.TrimSpace(template) == "" {
+		return nil, errors.New("--name must not be empty")
+	}
+	for _, m := range outputNameField.FindAllStringSubmatch(template, -1) {
+		switch m[1] {
+		case "session", "ts", "selector", "seq":
+		default:
+			return nil, fmt.Errorf("--name: unknown field %s (use {session}, {ts}, {selector}, or {seq})", m[0])
+		}
+	}
+	return &outputName{template: template, session: session, selector: selector}, nil
+}
+
+func (n *outputName) hasSeq() bool {
+	return strings.Contains(n.template, "{seq}")
+}
+
+func (n *outputName) expand(seq int, at time.Time) string {
+	selector := sanitizePathFragment(n.selector)
+	if selector == "" {
+		selector = "page"
+	}
+	name := outputNameField.ReplaceAllStringFunc(n.template, func(field string) string {
+		switch field {
+		case "{session}":
+			return sanitizePathFragment(n.session)
+		case "{ts}":
+			return at.Format("20060102-150405")
+		case "{selector}":
+			return selector
+		case "{seq}":
+			return fmt.Sprintf("%04d", seq)
+		}
+		return field
+	})
+	if filepath.Ext(name) == "" {
+		name += ".png"
+	}
+	return name
+}
+
+// nextPath returns dir/name with the lowest {seq} from 1 whose file doesn't
+// exist yet, so repeated runs into the same directory never clobber each
+// other. Without {seq} in the template it is simply the expanded name.
+func (n *outputName) nextPath(dir string, at time.Time) (string, error) {
+	if !n.hasSeq() {
+		return filepath.Join(dir, n.expand(0, at)), nil
+	}
+	for seq := 1; seq < 100000; seq++ {
+		path := filepath.Join(dir, n.expand(seq, at))
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no free {seq} left for %s in %s", n.template, dir)
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutputNameTemplate(t *testing.T) {
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	n, err := parseOutputName("{session}-{selector}-{ts}", "app/one", "#main > .card")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.expand(0, at); got != "app_one-main____card-20260102-150405.png" {
+		t.Fatalf("expand = %q", got)
+	}
+	if _, err := parseOutputName("{session}-{when}", "app", ""); err == nil {
+		t.Fatal("unknown field accepted")
+	}
+
+	dir := t.TempDir()
+	n, _ = parseOutputName("{session}-{selector}-{seq}.png", "app", "")
+	for _, want := range []string{"app-page-0001.png", "app-page-0002.png"} {
+		path, err := n.nextPath(dir, at)
+		if err != nil || path != filepath.Join(dir, want) {
+			t.Fatalf("nextPath = %q, %v; want %s", path, err, want)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// restoreOverridesFlag is set by the global --restore-overrides flag.
+var restoreOverridesFlag bool
+
+// overrideEnableDomains must be enabled before their overrides take effect.
+var overrideEnableDomains = map[string]bool{"Network": true}
+
+func callOverride(ctx context.Context, client *cdp.Client, o store.Override) error {
+	domain, _, _ := strings.Cut(o.Method, ".")
+	if overrideEnableDomains[domain] {
+		if err := client.Call(ctx, domain+".enable", nil, nil); err != nil {
+			return err
+		}
+	}
+	return client.Call(ctx, o.Method, o.Params, nil)
+}
+
+// applyOverride runs an override command and records it on the client's
+// session (replacing an earlier one with the same method), so it can be
+// re-applied after the DevTools session detaches. The handle persists it.
+func applyOverride(ctx context.Context, client *cdp.Client, method string, params map[string]interface{}) error {
+	o := store.Override{Method: method, Params: params, AppliedAt: time.Now()}
+	if err := callOverride(ctx, client, o); err != nil {
+		return err
+	}
+	session := lookupSession(client)
+	if session == nil {
+		return nil
+	}
+	for i := range session.Overrides {
+		if session.Overrides[i].Method == method {
+			session.Overrides[i] = o
+			return nil
+		}
+	}
+	session.Overrides = append(session.Overrides, o)
+	return nil
+}
+
+// forgetOverrides drops recorded overrides for method (all when empty) and
+// reports how many were removed.
+func forgetOverrides(session *store.Session, method string) int {
+	kept := session.Overrides[:0]
+	removed := 0
+	for _, o := range session.Overrides {
+		if method == "" || o.Method == method {
+			removed++
+			continue
+		}
+		kept = append(kept, o)
+	}
+	session.Overrides = kept
+	return removed
+}
+
+// reapplyOverrides re-runs the session's recorded overrides, logging each one
+// to stderr. Failures are warnings so the requested command still runs.
+func reapplyOverrides(ctx context.Context, client *cdp.Client, session store.Session) {
+	for _, o := range session.Overrides {
+		if err := callOverride(ctx, client, o); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unable to restore override %s: %v\n", o.Method, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "restored override %s\n", o.Method)
+	}
+}
@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+func TestForgetOverrides(t *testing.T) {
+	session := store.Session{Overrides: []store.Override{
+		{Method: "Emulation.setDeviceMetricsOverride"},
+		{Method: "Network.setExtraHTTPHeaders"},
+	}}
+	if n := forgetOverrides(&session, "Network.setExtraHTTPHeaders"); n != 1 || len(session.Overrides) != 1 {
+		t.Fatalf("removed %d, left %+v", n, session.Overrides)
+	}
+	if n := forgetOverrides(&session, "Missing.method"); n != 0 {
+		t.Fatalf("expected nothing removed, got %d", n)
+	}
+	if n := forgetOverrides(&session, ""); n != 1 || len(session.Overrides) != 0 {
+		t.Fatalf("clear all removed %d, left %+v", n, session.Overrides)
+	}
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+// pageErrorLimit is how many console errors and exceptions --attach-console
+// keeps for the failure report.
+const pageErrorLimit = 20
+
+func addAttachConsoleFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("attach-console", false, "On failure, append the page's recent console errors and uncaught exceptions to the error")
+}
+
+// pageErrorWatch records console errors and exceptions while an element
+// command runs. Runtime.enable replays the messages V8 has kept, so errors
+// from just before the command (a crashed click handler) are included.
+type pageErrorWatch struct {
+	ring *cdp.EventRing
+}
+
+// watchPageErrors starts recording when enabled; a nil watch costs nothing.
+func watchPageErrors(ctx context.Context, client *cdp.Client, enabled bool) *pageErrorWatch {
+	if !enabled {
+		return nil
+	}
+	ring := client.RecordEvents(pageErrorLimit, isPageError)
+	if err := client.Call(ctx, "Runtime.enable", nil, nil); err != nil {
+		ring.Stop()
+		return nil
+	}
+	return &pageErrorWatch{ring: ring}
+}
+
+// attachPageErrors stops the watch and, when the command failed with page
+// errors on record, adds them to *errp. Use it as
+// `defer attachPageErrors(&err, watchPageErrors(...))`.
+func attachPageErrors(errp *error, w *pageErrorWatch) {
+	if w == nil {
+		return
+	}
+	w.ring.Stop()
+	if *errp == nil {
+		return
+	}
+	var entries []string
+	for _, evt := range w.ring.Events() {
+		if line := describePageError(evt); line != "" {
+			entries = append(entries, line)
+		}
+	}
+	if len(entries) > 0 {
+		*errp = &pageErrorsError{err: *errp, entries: entries}
+	}
+}
+
+func isPageError(evt cdp.Event) bool {
+	switch evt.Method {
+	case "Runtime.exceptionThrown":
+		return true
+	case "Runtime.consoleAPICalled":
+		var payload struct {
+			Type string `json:"type"`
+		}
+		return json.Unmarshal(evt.Params, &payload) == nil && (payload.Type == "error" || payload.Type == "assert")
+	}
+	return false
+}
+
+// describePageError renders one recorded event as a single line: the
+// exception's first line with its location, or the console.error arguments.
+func describePageError(evt cdp.Event) string {
+	switch evt.Method {
+	case "Runtime.exceptionThrown":
+		var payload struct {
+			Details cdp.ExceptionDetails `json:"exceptionDetails"`
+		}
+		if json.Unmarshal(evt.Params, &payload) != nil {
+			return ""
+		}
+		d := payload.Details
+		msg := strings.TrimSpace(d.Text)
+		if d.Exception != nil && d.Exception.Description != "" {
+			first, _, _ := strings.Cut(d.Exception.Description, "\n")
+			if msg == "" || msg == "Uncaught" {
+				msg = strings.TrimSpace("Uncaught " + first)
+			} else {
+				msg += ": " + first
+			}
+		}
+		if d.URL != "" {
+			msg += fmt.Sprintf(" (%s:%d:%d)", d.URL, d.LineNumber+1, d.ColumnNumber+1)
+		}
+		return msg
+	case "Runtime.consoleAPICalled":
+		var payload struct {
+			Type string             `json:"type"`
+			Args []cdp.RemoteObject `json:"args"`
+		}
+		if json.Unmarshal(evt.Params, &payload) != nil {
+			return ""
+		}
+		parts := make([]string, 0, len(payload.Args))
+		for _, arg := range payload.Args {
+			parts = append(parts, remoteObjectText(arg))
+		}
+		return "console." + payload.Type + ": " + strings.Join(parts, " ")
+	}
+	return ""
+}
+
+func remoteObjectText(obj cdp.RemoteObject) string {
+	if obj.Value != nil {
+		var s string
+		if json.Unmarshal(*obj.Value, &s) == nil {
+			return s
+		}
+		return string(*obj.Value)
+	}
+	if obj.UnserializableValue != "" {
+		return obj.UnserializableValue
+	}
+	if obj.Description != "" {
+		first, _, _ := strings.Cut(obj.Description, "\n")
+		return first
+	}
+	return obj.Type
+}
+
+// pageErrorsError is a command failure with the page errors recorded while
+// it ran. Unwrap keeps the exit code of the underlying error.
+type pageErrorsError struct {
+	err     error
+	entries []string
+}
+
+func (e *pageErrorsError) Error() string { return e.err.Error() + e.section() }
+
+func (e *pageErrorsError) Unwrap() error { return e.err }
+
+func (e *pageErrorsError) section() string {
+	return "\nrecent page errors:\n  " + strings.Join(e.entries, "\n  ")
+}
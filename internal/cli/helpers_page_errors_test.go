@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+)
+
+// fakeCrashedTab replays one uncaught exception and one console.error when
+// Runtime is enabled, and reports every element as not there yet.
+func fakeCrashedTab(t *testing.T) string {
+	t.Helper()
+	return cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		var result interface{}
+		switch req.Method {
+		case "Runtime.enable":
+			c.Send(map[string]interface{}{"method": "Runtime.exceptionThrown", "params": map[string]interface{}{
+				"exceptionDetails": map[string]interface{}{
+					"text": "Uncaught", "url": "https://app.example/main.js", "lineNumber": 41, "columnNumber": 9,
+					"exception": map[string]interface{}{"type": "object", "description": "TypeError: order is undefined\n    at save (main.js:42:10)"},
+				},
+			}})
+			c.Send(map[string]interface{}{"method": "Runtime.consoleAPICalled", "params": map[string]interface{}{
+				"type": "error", "args": []map[string]interface{}{{"type": "string", "value": "save failed"}, {"type": "number", "value": 500}},
+			}})
+			c.Send(map[string]interface{}{"method": "Runtime.consoleAPICalled", "params": map[string]interface{}{
+				"type": "log", "args": []map[string]interface{}{{"type": "string", "value": "noise"}},
+			}})
+		case "Runtime.evaluate":
+			result = map[string]interface{}{"result": map[string]interface{}{"type": "boolean", "value": false}}
+		}
+		c.Reply(req.ID, result)
+	})
+}
+
+func TestAttachConsoleAddsRecentPageErrors(t *testing.T) {
+	holdFakeSession(t, "crashed", fakeCrashedTab(t))
+
+	err := cmdWaitVisible([]string{"--session", "crashed", "--timeout", "300ms", "--attach-console", ".saved"})
+	if errorCode(err) != exitTimeout {
+		t.Fatalf("want a timeout, got %v", err)
+	}
+	want := "\nrecent page errors:\n" +
+		"  Uncaught TypeError: order is undefined (https://app.example/main.js:42:10)\n" +
+		"  console.error: save failed 500"
+	if !strings.HasSuffix(err.Error(), want) {
+		t.Fatalf("error = %q, want suffix %q", err, want)
+	}
+
+	err = cmdWaitVisible([]string{"--session", "crashed", "--timeout", "300ms", ".saved"})
+	if err == nil || strings.Contains(err.Error(), "recent page errors") {
+		t.Fatalf("without --attach-console: %v", err)
+	}
+}
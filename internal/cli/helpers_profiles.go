@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// connectionProfile is a named DevTools endpoint selected with `cdp --profile`.
+// Profiles live in config.toml as [profiles.NAME] sections whose keys match
+// the flags of `cdp profile add`:
+//
+//	[profiles.staging]
+//	host = "browsers.internal"
+//	port = 443
+//	secure = true
+//	auth = "Bearer TOKEN"
+type connectionProfile struct {
+	Name          string
+	Host          string
+	Port          int
+	Secure        bool
+	Authorization string
+}
+
+func (p connectionProfile) scheme() string {
+	if p.Secure {
+		return "https"
+	}
+	return "http"
+}
+
+// profileSectionPrefix starts the config section name of every profile.
+const profileSectionPrefix = "profiles "
+
+// activeProfile is set when --profile (or CDP_PROFILE) picks a profile, or when
+// a session connected through one is opened. It feeds hostDefault/portDefault
+// and dialer.
+var activeProfile *connectionProfile
+
+func (p connectionProfile) dialer() cdp.Dialer {
+	return cdp.Dialer{Secure: p.Secure, Authorization: p.Authorization}
+}
+
+// dialer reaches the browser the way the active profile says to, or over plain
+// http/ws without one.
+func dialer() cdp.Dialer {
+	if activeProfile == nil {
+		return cdp.Dialer{}
+	}
+	return activeProfile.dialer()
+}
+
+// sessionDialer reaches session's browser through the profile it was
+// connected with, or the way this command reaches browsers when it has none.
+// It leaves activeProfile alone, so sessions on different profiles can be
+// open in one process.
+func sessionDialer(session store.Session) (cdp.Dialer, error) {
+	if session.Profile == "" {
+		return dialer(), nil
+	}
+	profile, err := lookupProfile(session.Profile)
+	if err != nil {
+		return cdp.Dialer{}, fmt.Errorf("session %s: %w", session.Name, err)
+	}
+	return profile.dialer(), nil
+}
+
+// configProfiles returns the profiles defined in cfg.
+func configProfiles(cfg cliConfig) (map[string]connectionProfile, error) {
+	profiles := make(map[string]connectionProfile)
+	for section, values := range cfg.commands {
+		name, ok := strings.CutPrefix(section, profileSectionPrefix)
+		if !ok {
+			continue
+		}
+		profile := connectionProfile{Name: name}
+		for key, value := range values {
+			var err error
+			switch key {
+			case "host":
+				profile.Host = value
+			case "port":
+				profile.Port, err = strconv.Atoi(value)
+			case "secure":
+				profile.Secure, err = strconv.ParseBool(value)
+			case "auth":
+				profile.Authorization = value
+			default:
+				return nil, fmt.Errorf("config [%s]: unknown key %q (use host, port, secure, or auth)", section, key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("config [%s]: invalid value %q for %s", section, value, key)
+			}
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// profileSectionHeader is the [header] line of a profile's config section.
+func profileSectionHeader(name string) string {
+	if bareProfileName.MatchString(name) {
+		return "[profiles." + name + "]"
+	}
+	return "[" + strconv.Quote(profileSectionPrefix+name) + "]"
+}
+
+var bareProfileName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// writeProfile replaces the config section of profile name with section, or
+// drops it when section is empty. The rest of config.toml, comments included,
+// is kept as it was.
+func writeProfile(name, section string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	var kept []string
+	skipping := false
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header := strings.TrimSpace(stripConfigComment(line)); strings.HasPrefix(header, "[") {
+			current, err := parseSectionHeader(header)
+			skipping = err == nil && current == profileSectionPrefix+name
+		}
+		if !skipping {
+			kept = append(kept, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for len(kept) > 0 && strings.TrimSpace(kept[len(kept)-1]) == "" {
+		kept = kept[:len(kept)-1]
+	}
+	if section != "" {
+		if len(kept) > 0 {
+			kept = append(kept, "")
+		}
+		kept = append(kept, section)
+	}
+	out := strings.Join(kept, "\n")
+	if out != "" {
+		out += "\n"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(out), 0o600); err != nil {
+		return err
+	}
+	// Profiles may hold credentials, so keep the file private.
+	return os.Chmod(path, 0o600)
+}
+
+// profileSection renders p as a config section.
+func profileSection(p connectionProfile) string {
+	lines := []string{
+		profileSectionHeader(p.Name),
+		"host = " + strconv.Quote(p.Host),
+		"port = " + strconv.Itoa(p.Port),
+	}
+	if p.Secure {
+		lines = append(lines, "secure = true")
+	}
+	if p.Authorization != "" {
+		lines = append(lines, "auth = "+strconv.Quote(p.Authorization))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// useProfile makes name the active profile, so hostDefault, portDefault and
+// dialer follow it.
+func useProfile(name string) error {
+	profile, err := lookupProfile(name)
+	if err != nil {
+		return err
+	}
+	activeProfile = &profile
+	return nil
+}
+
+// lookupProfile finds profile name in the config.
+func lookupProfile(name string) (connectionProfile, error) {
+	cfg, err := currentConfig()
+	if err != nil {
+		return connectionProfile{}, err
+	}
+	profiles, err := configProfiles(cfg)
+	if err != nil {
+		return connectionProfile{}, err
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return connectionProfile{}, fmt.Errorf("unknown profile %q (see 'cdp profile list')", name)
+	}
+	return profile, nil
+}
+
+func sortedProfileNames(profiles map[string]connectionProfile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+func TestExtractGlobalFlags(t *testing.T) {
+	cases := []struct {
+		args    []string
+		profile string
+		rest    int
+	}{
+		{[]string{"--profile", "remote", "tabs", "list"}, "remote", 2},
+		{[]string{"--profile=remote", "tabs"}, "remote", 1},
+		{[]string{"tabs", "list", "--profile", "x"}, "", 4},
+		{[]string{"--timeout", "30s", "--profile", "remote", "read"}, "remote", 1},
+		{[]string{"--timings", "--profile=remote", "read", "--session", "x"}, "remote", 3},
+	}
+	for _, tc := range cases {
+		globals, rest, err := extractGlobalFlags(tc.args)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.args, err)
+		}
+		if globals["profile"] != tc.profile || len(rest) != tc.rest {
+			t.Fatalf("%v: got globals %v rest %v", tc.args, globals, rest)
+		}
+	}
+	if _, _, err := extractGlobalFlags([]string{"--profile"}); err == nil {
+		t.Fatal("expected error for missing profile name")
+	}
+	if _, _, err := extractGlobalFlags([]string{"--timeout", "soon", "read"}); err == nil {
+		t.Fatal("expected error for invalid timeout")
+	}
+}
+
+func TestProfilesLiveInConfigSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv("CDP_CONFIG", path)
+	if err := os.WriteFile(path, []byte("# mine\nport = 9333\n\n[eval]\ntimeout = \"30s\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profile := connectionProfile{Name: "staging", Host: "browsers.internal", Port: 443, Secure: true, Authorization: "Bearer T"}
+	if err := writeProfile(profile.Name, profileSection(profile)); err != nil {
+		t.Fatal(err)
+	}
+	profile.Port = 8443
+	if err := writeProfile(profile.Name, profileSection(profile)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# mine\nport = 9333\n\n[eval]\n") || strings.Count(string(data), "[profiles.staging]") != 1 {
+		t.Fatalf("config after two saves:\n%s", data)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("config mode: %v, %v", info.Mode(), err)
+	}
+	cfg, err := parseConfig(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := configProfiles(cfg)
+	if err != nil || profiles["staging"] != profile {
+		t.Fatalf("profiles = %+v, %v", profiles, err)
+	}
+
+	if err := writeProfile("staging", ""); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# mine\nport = 9333\n\n[eval]\ntimeout = \"30s\"\n" {
+		t.Fatalf("config after remove:\n%s", data)
+	}
+	if _, err := configProfiles(cliConfig{commands: map[string]map[string]string{"profiles x": {"token": "t"}}}); err == nil {
+		t.Fatal("unknown profile key accepted")
+	}
+}
+
+func TestSessionDialerFollowsEachSessionsProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv("CDP_CONFIG", path)
+	config := "[profiles.a]\nhost = \"a.internal\"\nport = 443\nsecure = true\nauth = \"Bearer A\"\n\n[profiles.b]\nhost = \"b.internal\"\nport = 9222\nauth = \"Bearer B\"\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Library callers never run loadConfig.
+	savedConfig, savedProfile := activeConfig, activeProfile
+	activeConfig, activeProfile = cliConfig{}, nil
+	defer func() { activeConfig, activeProfile = savedConfig, savedProfile }()
+
+	for _, tc := range []struct {
+		session store.Session
+		want    cdp.Dialer
+	}{
+		{store.Session{Name: "one", Profile: "a"}, cdp.Dialer{Secure: true, Authorization: "Bearer A"}},
+		{store.Session{Name: "two", Profile: "b"}, cdp.Dialer{Authorization: "Bearer B"}},
+		{store.Session{Name: "three"}, cdp.Dialer{}},
+	} {
+		got, err := sessionDialer(tc.session)
+		if err != nil || got != tc.want {
+			t.Fatalf("sessionDialer(%s) = %+v, %v; want %+v", tc.session.Name, got, err, tc.want)
+		}
+	}
+	if activeProfile != nil {
+		t.Fatalf("sessionDialer set the process-wide profile to %s", activeProfile.Name)
+	}
+	if _, err := sessionDialer(store.Session{Name: "four", Profile: "gone"}); err == nil || !strings.Contains(err.Error(), `unknown profile "gone"`) {
+		t.Fatalf("missing profile: %v", err)
+	}
+}
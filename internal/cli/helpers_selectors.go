@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
 )
 
 func parseInlineHasText(selector string) (string, string, bool, error) {
@@ -134,6 +139,20 @@ func rejectUnsupportedSelector(selector, context string, allowHasTextLiteral boo
 	return nil
 }
 
+// checkAttFilter validates an --att filter: "name=REGEX" matches elements
+// whose name attribute matches REGEX (a JS RegExp, /pat/flags or pat), and a
+// bare "name" matches elements that have the attribute at all.
+func checkAttFilter(att string) error {
+	if att == "" {
+		return nil
+	}
+	name, _, _ := strings.Cut(att, "=")
+	if name == "" || strings.ContainsAny(name, " \t\n\"'<>/") {
+		return fmt.Errorf("--att %q: want name=REGEX with a plain attribute name, e.g. data-testid=submit", att)
+	}
+	return nil
+}
+
 func escapeLeadingPlusRegexSpec(spec string) string {
 	if spec == "" {
 		return spec
@@ -161,3 +180,88 @@ func escapeLeadingPlusRegexSpec(spec string) string {
 	}
 	return spec
 }
+
+// selectorNotFoundError is an element command whose selector matched
+// nothing, with the near misses WebNav found for it. errorCode treats it
+// like a read with no matches.
+type selectorNotFoundError struct {
+	err         error
+	selector    string
+	suggestions []selectorSuggestion
+}
+
+type selectorSuggestion struct {
+	Selector string `json:"selector"`
+	Matches  int    `json:"matches"`
+}
+
+func (e *selectorNotFoundError) Error() string { return e.err.Error() + e.section() }
+
+func (e *selectorNotFoundError) Unwrap() error { return e.err }
+
+func (e *selectorNotFoundError) section() string {
+	var b strings.Builder
+	for _, s := range e.suggestions {
+		noun := "matches"
+		if s.Matches == 1 {
+			noun = "match"
+		}
+		fmt.Fprintf(&b, "\ndid you mean %q (%d %s)", s.Selector, s.Matches, noun)
+	}
+	return b.String()
+}
+
+// isSelectorMiss reports whether err is WebNav (or TypeText) saying the
+// selector matched no element.
+func isSelectorMiss(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no element matched selector") || msg == "selector not found"
+}
+
+// withSelectorSuggestions turns a selector miss into a selectorNotFoundError,
+// asking WebNav for near misses: the selector with trailing classes dropped,
+// then the bare tag. Other errors, and misses WebNav can't explain, pass
+// through unchanged.
+func withSelectorSuggestions(ctx context.Context, client *cdp.Client, selector string, err error) error {
+	if err == nil || selector == "" || !isSelectorMiss(err) {
+		return err
+	}
+	value, evalErr := client.Evaluate(ctx, fmt.Sprintf(`(window.WebNav && window.WebNav.suggestSelectors) ? window.WebNav.suggestSelectors(%s) : []`, strconv.Quote(selector)))
+	if evalErr != nil {
+		return &selectorNotFoundError{err: err, selector: selector}
+	}
+	raw, _ := json.Marshal(value)
+	var suggestions []selectorSuggestion
+	_ = json.Unmarshal(raw, &suggestions)
+	return &selectorNotFoundError{err: err, selector: selector, suggestions: suggestions}
+}
+
+// splitSelectorList splits a CSS selector list on its top-level commas,
+// leaving commas inside brackets, parentheses and quotes alone.
+func splitSelectorList(list string) []string {
+	var out []string
+	depth, quote, start := 0, rune(0), 0
+	for i, r := range list {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '[' || r == '(':
+			depth++
+		case r == ']' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			if sel := strings.TrimSpace(list[start:i]); sel != "" {
+				out = append(out, sel)
+			}
+			start = i + 1
+		}
+	}
+	if sel := strings.TrimSpace(list[start:]); sel != "" {
+		out = append(out, sel)
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeMissSuggestsSelectors(t *testing.T) {
+	holdFakeSession(t, "app", fakeEvalTab(t, func(expression string) interface{} {
+		switch {
+		case strings.Contains(expression, "suggestSelectors"):
+			return []map[string]interface{}{{"selector": "input.email", "matches": 1}, {"selector": "input", "matches": 3}}
+		case strings.HasPrefix(expression, "typeof window."):
+			return "function"
+		case strings.Contains(expression, "WebNavTypePrepare"):
+			return map[string]interface{}{"found": false}
+		case strings.Contains(expression, "readyState"):
+			return "complete"
+		}
+		return true
+	}))
+
+	err := cmdType([]string{"--session", "app", "input.email.wide", "me@example.com"})
+	want := "selector not found\n" +
+		`did you mean "input.email" (1 match)` + "\n" +
+		`did you mean "input" (3 matches)`
+	if err == nil || err.Error() != want {
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+	if errorCode(err) != exitNoMatch {
+		t.Fatalf("errorCode = %d, want no-match", errorCode(err))
+	}
+}
+
+func TestSplitSelectorList(t *testing.T) {
+	got := splitSelectorList(` [aria-busy=true], .spinner,, [data-x="a,b"], :is(.a, .b) `)
+	want := []string{"[aria-busy=true]", ".spinner", `[data-x="a,b"]`, ":is(.a, .b)"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
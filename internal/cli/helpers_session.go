@@ -2,9 +2,12 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"regexp"
+	"sync"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
@@ -15,7 +18,18 @@ type sessionHandle struct {
 	client  *cdp.Client
 	store   *store.Store
 	session store.Session
+	// dialer reaches the session's browser, through its profile if it has one.
+	dialer  cdp.Dialer
 	persist bool
+	// held handles borrow the connection `cdp repl` keeps open; Close leaves it up.
+	held bool
+}
+
+// heldConnection is the attached client `cdp repl` keeps for its session, so
+// every line it runs reuses one connection instead of dialing again.
+var heldConnection struct {
+	name   string
+	client *cdp.Client
 }
 
 func openSession(ctx context.Context, st *store.Store, name string) (*sessionHandle, error) {
@@ -23,21 +37,251 @@ func openSession(ctx context.Context, st *store.Store, name string) (*sessionHan
 	if !ok {
 		return nil, fmt.Errorf("unknown session %q", name)
 	}
-	client, updated, err := attachSession(ctx, session)
+	d, err := sessionDialer(session)
 	if err != nil {
 		return nil, err
 	}
-	return &sessionHandle{client: client, store: st, session: updated, persist: true}, nil
+	holding := heldConnection.name != "" && heldConnection.name == name
+	if holding && heldConnection.client != nil {
+		select {
+		case <-heldConnection.client.Done():
+			// The target went away; fall through and attach afresh.
+			heldConnection.client = nil
+		default:
+			if err := checkExpectedOrigin(ctx, heldConnection.client, session); err != nil {
+				return nil, err
+			}
+			h := &sessionHandle{client: heldConnection.client, store: st, session: session, dialer: d, persist: true, held: true}
+			registerSession(h.client, &h.session)
+			return h, nil
+		}
+	}
+	client, updated, err := attachSession(ctx, d, session)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkExpectedOrigin(ctx, client, updated); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if updated.BypassCSP {
+		// The override only lasts while a DevTools session is attached.
+		if err := setBypassCSP(ctx, client, true); err != nil {
+			fmt.Fprintln(os.Stderr, "warning: unable to bypass CSP:", err)
+		}
+	}
+	if updated.AutoRestore || restoreOverridesFlag {
+		reapplyOverrides(ctx, client, updated)
+	}
+	h := &sessionHandle{client: client, store: st, session: updated, dialer: d, persist: true, held: holding}
+	if holding {
+		heldConnection.client = client
+	}
+	registerSession(client, &h.session)
+	return h, nil
+}
+
+// ignoreOriginFlag is set by the global --ignore-origin flag.
+var ignoreOriginFlag bool
+
+// checkExpectedOrigin fails when the session was connected with
+// --expect-origin and its tab is now on another origin, e.g. after
+// reattaching fell back to a different tab. It costs one evaluation, and
+// nothing for sessions without an expected origin.
+func checkExpectedOrigin(ctx context.Context, client *cdp.Client, session store.Session) error {
+	if session.ExpectedOrigin == "" || ignoreOriginFlag {
+		return nil
+	}
+	value, err := client.Evaluate(ctx, "location.origin")
+	if err != nil {
+		return fmt.Errorf("check expected origin of session %s: %w", session.Name, err)
+	}
+	origin, _ := value.(string)
+	return matchExpectedOrigin(session, origin)
+}
+
+func matchExpectedOrigin(session store.Session, origin string) error {
+	re, err := compileExpectedOrigin(session.ExpectedOrigin)
+	if err != nil {
+		return fmt.Errorf("session %s: %w", session.Name, err)
+	}
+	if re.MatchString(origin) {
+		return nil
+	}
+	return fmt.Errorf("session %s is bound to %s which does not match expected origin %s; reconnect it with cdp connect, or pass --ignore-origin", session.Name, origin, session.ExpectedOrigin)
+}
+
+// compileExpectedOrigin anchors the pattern, so https://app\.example\.com
+// doesn't also accept https://app.example.com.evil.test.
+func compileExpectedOrigin(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --expect-origin regex: %w", err)
+	}
+	return re, nil
+}
+
+// openSessions lets helpers that only get a client (WebNav injection, error
+// hints) find the session it was opened for, without threading it everywhere.
+var (
+	openSessionsMu sync.Mutex
+	openSessions   = make(map[*cdp.Client]*store.Session)
+)
+
+func registerSession(client *cdp.Client, session *store.Session) {
+	openSessionsMu.Lock()
+	openSessions[client] = session
+	openSessionsMu.Unlock()
+}
+
+func unregisterSession(client *cdp.Client) {
+	openSessionsMu.Lock()
+	delete(openSessions, client)
+	openSessionsMu.Unlock()
+}
+
+func lookupSession(client *cdp.Client) *store.Session {
+	openSessionsMu.Lock()
+	defer openSessionsMu.Unlock()
+	return openSessions[client]
+}
+
+// A session idle longer than staleSessionAge has probably outlived its
+// browser, so its stored websocket URL only gets staleDialShare of the
+// remaining budget before the /json/list lookup is relied on instead.
+const (
+	staleSessionAge = 30 * time.Minute
+	staleDialShare  = 0.3
+)
+
+// Attach paths recorded in store.Session.LastAttach.
+const (
+	attachDirect   = "direct"   // the stored websocket URL still worked
+	attachRelisted = "relisted" // the target was found again via /json/list
+)
+
+// attachResult is one attempt of the attachSession race.
+type attachResult struct {
+	client  *cdp.Client
+	session store.Session
+	err     error
+}
+
+// attachSession dials the stored websocket URL and, at the same time, looks
+// the target up in /json/list, so a stale URL after a browser restart costs
+// one round trip instead of a failed dial followed by a fresh one. The
+// lookup only dials when the target's URL has changed; the first usable
+// client wins and the other attempt is cancelled.
+func attachSession(ctx context.Context, d cdp.Dialer, session store.Session) (*cdp.Client, store.Session, error) {
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	direct := make(chan attachResult, 1)
+	relisted := make(chan attachResult, 1)
+	go func() {
+		dialCtx, dialCancel := raceCtx, context.CancelFunc(func() {})
+		if limit, ok := directDialBudget(ctx, session.LastConnected, time.Now()); ok {
+			dialCtx, dialCancel = context.WithTimeout(raceCtx, limit)
+		}
+		defer dialCancel()
+		client, err := d.Dial(dialCtx, session.WebSocketURL)
+		updated := session
+		updated.LastAttach = attachDirect
+		direct <- attachResult{client: client, session: updated, err: err}
+	}()
+	go func() {
+		client, updated, err := relistSession(raceCtx, d, session)
+		relisted <- attachResult{client: client, session: updated, err: err}
+	}()
+
+	var dialErr, listErr error
+	sameURL := false
+	for direct != nil || relisted != nil {
+		var res attachResult
+		var fromDirect bool
+		select {
+		case res = <-direct:
+			direct, fromDirect = nil, true
+		case res = <-relisted:
+			relisted = nil
+		}
+		if res.err == nil && res.client != nil {
+			cancel()
+			discardAttach(direct)
+			discardAttach(relisted)
+			noteAttachPath(res.session.LastAttach)
+			return res.client, res.session, nil
+		}
+		switch {
+		case fromDirect:
+			dialErr = res.err
+		case res.err != nil:
+			listErr = res.err
+		default:
+			sameURL = true
+		}
+	}
+	if sameURL && ctx.Err() == nil {
+		// The stored URL is current after all; the capped dial was just slow.
+		client, err := d.Dial(ctx, session.WebSocketURL)
+		if err == nil {
+			session.LastAttach = attachDirect
+			noteAttachPath(attachDirect)
+			return client, session, nil
+		}
+		dialErr = err
+	}
+	var missing *targetMissingError
+	switch {
+	case errors.As(listErr, &missing):
+		return nil, session, listErr
+	case listErr != nil:
+		return nil, session, fmt.Errorf("connect failed (%v) and retry listing targets failed: %w", dialErr, listErr)
+	}
+	return nil, session, dialErr
 }
 
-func attachSession(ctx context.Context, session store.Session) (*cdp.Client, store.Session, error) {
-	client, err := cdp.Dial(ctx, session.WebSocketURL)
-	if err == nil {
-		return client, session, nil
+// discardAttach closes the client of an attempt that lost the race, once it
+// finishes.
+func discardAttach(ch <-chan attachResult) {
+	if ch == nil {
+		return
 	}
-	targets, listErr := cdp.ListTargets(ctx, session.Host, session.Port)
-	if listErr != nil {
-		return nil, session, fmt.Errorf("connect failed (%v) and retry listing targets failed: %w", err, listErr)
+	go func() {
+		if res := <-ch; res.client != nil {
+			res.client.Close()
+		}
+	}()
+}
+
+// directDialBudget caps the direct dial of a session idle since
+// lastConnected at staleDialShare of ctx's remaining time. It reports false
+// when the dial should get the whole budget.
+func directDialBudget(ctx context.Context, lastConnected, now time.Time) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok || lastConnected.IsZero() || now.Sub(lastConnected) < staleSessionAge {
+		return 0, false
+	}
+	return time.Duration(float64(deadline.Sub(now)) * staleDialShare), true
+}
+
+// targetMissingError is a session whose target isn't in /json/list anymore.
+type targetMissingError struct {
+	url string
+}
+
+func (e *targetMissingError) Error() string {
+	return fmt.Sprintf("target %s is no longer available", e.url)
+}
+
+// relistSession finds session's target in /json/list by id, then by URL. It
+// dials only when the target's websocket URL differs from the stored one;
+// otherwise the direct dial is the attempt that counts and it returns
+// neither client nor error.
+func relistSession(ctx context.Context, d cdp.Dialer, session store.Session) (*cdp.Client, store.Session, error) {
+	targets, err := d.ListTargets(ctx, session.Host, session.Port)
+	if err != nil {
+		return nil, session, err
 	}
 	var target cdp.TargetInfo
 	found := false
@@ -55,10 +299,13 @@ func attachSession(ctx context.Context, session store.Session) (*cdp.Client, sto
 		}
 	}
 	if !found {
-		return nil, session, fmt.Errorf("target %s is no longer available", session.URL)
+		return nil, session, &targetMissingError{url: session.URL}
+	}
+	wsURL := rewriteWebSocketURL(target.WebSocket, session.Host, session.Port, d.Secure)
+	if wsURL == session.WebSocketURL {
+		return nil, session, nil
 	}
-	wsURL := rewriteWebSocketURL(target.WebSocket, session.Host, session.Port)
-	client, err = cdp.Dial(ctx, wsURL)
+	client, err := d.Dial(ctx, wsURL)
 	if err != nil {
 		return nil, session, err
 	}
@@ -68,11 +315,16 @@ func attachSession(ctx context.Context, session store.Session) (*cdp.Client, sto
 	session.Title = target.Title
 	session.Type = target.Type
 	session.LastTargetInfo = target.Description
+	session.LastAttach = attachRelisted
 	return client, session, nil
 }
 
 func (h *sessionHandle) Close() {
-	h.client.Close()
+	unregisterSession(h.client)
+	if !h.held {
+		webNavResident.Delete(h.client)
+		h.client.Close()
+	}
 	if !h.persist {
 		return
 	}
@@ -82,7 +334,9 @@ func (h *sessionHandle) Close() {
 	}
 }
 
-func rewriteWebSocketURL(raw, host string, port int) string {
+// rewriteWebSocketURL points a target's websocket URL at host:port, switching
+// ws to wss for a secure dialer.
+func rewriteWebSocketURL(raw, host string, port int, secure bool) string {
 	if raw == "" {
 		return raw
 	}
@@ -93,6 +347,9 @@ func rewriteWebSocketURL(raw, host string, port int) string {
 	if u.Scheme == "" {
 		u.Scheme = "ws"
 	}
+	if secure && u.Scheme == "ws" {
+		u.Scheme = "wss"
+	}
 	if host != "" && port != 0 {
 		u.Host = fmt.Sprintf("%s:%d", host, port)
 	}
@@ -3,11 +3,10 @@ package cli
 import (
 	"errors"
 	"flag"
-	"fmt"
 	"os"
 )
 
-var errMissingSessionName = errors.New("missing --session (or set CDP_SESSION_NAME/WEB_SESSION/WEB_SESSION_ID)")
+var errMissingSessionName error = &usageError{err: errors.New("missing --session (or set CDP_SESSION_NAME/WEB_SESSION/WEB_SESSION_ID)")}
 
 // addSessionFlag adds the standard --session flag used by commands that operate on
 // a saved CDP session.
@@ -31,6 +30,6 @@ func unexpectedArgs(pos []string) error {
 	if len(pos) == 0 {
 		return nil
 	}
-	return fmt.Errorf("unexpected argument: %s", pos[0])
+	return usagef("unexpected argument: %s", pos[0])
 }
 
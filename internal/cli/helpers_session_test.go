@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+func TestAttachSessionRacesDialAndRelist(t *testing.T) {
+	// T1's websocket accepts calls but never answers them.
+	browser := cdptest.New(t, []cdptest.Target{{ID: "T1", URL: "https://app.example/", Title: "App"}}, func(*cdptest.Conn, cdptest.Request) {})
+	host, port, wsURL := browser.Host, browser.Port, browser.WebSocketURL("T1")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A current URL is dialed once; the lookup only confirms it.
+	session := store.Session{Name: "app", Host: host, Port: port, TargetID: "T1", URL: "https://app.example/", WebSocketURL: wsURL}
+	client, updated, err := attachSession(ctx, cdp.Dialer{}, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if updated.LastAttach != attachDirect || browser.Dials("T1") != 1 {
+		t.Fatalf("fresh URL: path %q after %d dials, want direct after 1", updated.LastAttach, browser.Dials("T1"))
+	}
+
+	// A stale URL from before a browser restart is replaced via /json/list.
+	session.WebSocketURL = "ws://127.0.0.1:" + strconv.Itoa(port) + "/devtools/page/OLD"
+	session.LastConnected = time.Now().Add(-2 * staleSessionAge)
+	client, updated, err = attachSession(ctx, cdp.Dialer{}, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Close()
+	if updated.LastAttach != attachRelisted || updated.WebSocketURL != wsURL {
+		t.Fatalf("stale URL: got path %q, ws %q", updated.LastAttach, updated.WebSocketURL)
+	}
+
+	session.TargetID, session.URL = "GONE", "https://gone.example/"
+	if _, _, err := attachSession(ctx, cdp.Dialer{}, session); err == nil || err.Error() != "target https://gone.example/ is no longer available" {
+		t.Fatalf("missing target: got %v", err)
+	}
+}
+
+func TestDirectDialBudget(t *testing.T) {
+	now := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(10*time.Second))
+	defer cancel()
+	if _, ok := directDialBudget(ctx, now.Add(-time.Minute), now); ok {
+		t.Fatal("a recently used session should get the whole budget")
+	}
+	limit, ok := directDialBudget(ctx, now.Add(-time.Hour), now)
+	if !ok || limit != 3*time.Second {
+		t.Fatalf("stale session budget = %s, %v; want 3s", limit, ok)
+	}
+	if _, ok := directDialBudget(context.Background(), now.Add(-time.Hour), now); ok {
+		t.Fatal("without a deadline there is nothing to cap")
+	}
+}
+
+func TestExpectedOriginIsAnchored(t *testing.T) {
+	session := store.Session{Name: "mgr", ExpectedOrigin: `https://app\.example\.com`}
+	if err := matchExpectedOrigin(session, "https://app.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	err := matchExpectedOrigin(session, "https://app.example.com.evil.test")
+	if err == nil || !strings.Contains(err.Error(), "session mgr is bound to https://app.example.com.evil.test which does not match expected origin") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestOpenSessionChecksExpectedOrigin(t *testing.T) {
+	holdFakeSession(t, "mgr", fakeEvalTab(t, func(string) interface{} { return "https://mail.example" }))
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, _ := st.Get("mgr")
+	session.ExpectedOrigin = `https://app\.example`
+	if err := st.Set(session); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := openSession(ctx, st, "mgr"); err == nil {
+		t.Fatal("opened a session on the wrong origin")
+	}
+	ignoreOriginFlag = true
+	defer func() { ignoreOriginFlag = false }()
+	handle, err := openSession(ctx, st, "mgr")
+	if err != nil {
+		t.Fatalf("--ignore-origin: %v", err)
+	}
+	handle.Close()
+}
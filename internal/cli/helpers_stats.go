@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+type pageStats struct {
+	Requests      int     `json:"requests"`
+	TransferBytes float64 `json:"transferBytes"`
+	DOMNodes      int     `json:"domNodes"`
+	LoadMs        float64 `json:"loadMs"`
+}
+
+// collectPageStats summarizes page weight from the Resource Timing API. Transfer
+// sizes are 0 for cross-origin resources without Timing-Allow-Origin, so the
+// total is a lower bound.
+func collectPageStats(ctx context.Context, client *cdp.Client) (pageStats, error) {
+	value, err := client.Evaluate(ctx, `(() => {
+        const nav = performance.getEntriesByType("navigation")[0];
+        const resources = performance.getEntriesByType("resource");
+        let transfer = nav ? (nav.transferSize || 0) : 0;
+        for (const r of resources) transfer += r.transferSize || 0;
+        let load = 0;
+        if (nav) {
+            load = nav.loadEventEnd > 0 ? nav.loadEventEnd : (nav.domContentLoadedEventEnd || 0);
+        }
+        return {
+            requests: resources.length + (nav ? 1 : 0),
+            transferBytes: transfer,
+            domNodes: document.getElementsByTagName("*").length,
+            loadMs: load,
+        };
+    })()`)
+	if err != nil {
+		return pageStats{}, err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return pageStats{}, fmt.Errorf("unexpected stats result type %T", value)
+	}
+	var stats pageStats
+	if v, ok := m["requests"].(float64); ok {
+		stats.Requests = int(v)
+	}
+	if v, ok := m["transferBytes"].(float64); ok {
+		stats.TransferBytes = v
+	}
+	if v, ok := m["domNodes"].(float64); ok {
+		stats.DOMNodes = int(v)
+	}
+	if v, ok := m["loadMs"].(float64); ok {
+		stats.LoadMs = v
+	}
+	return stats, nil
+}
+
+func (s pageStats) String() string {
+	load := "n/a"
+	if s.LoadMs > 0 {
+		load = fmt.Sprintf("%.0fms", s.LoadMs)
+	}
+	return fmt.Sprintf("stats: requests=%d transfer=%s nodes=%d load=%s", s.Requests, formatBytes(s.TransferBytes), s.DOMNodes, load)
+}
+
+func formatBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%.0fB", n)
+	}
+	div, exp := float64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", n/div, "KMGTPE"[exp])
+}
@@ -1,19 +1,21 @@
 package cli
 
 import (
-	"errors"
 	"fmt"
+	"os"
 	"strings"
 )
 
-func splitTabsOpenArgs(args []string) (string, []string, error) {
+// splitTabsOpenArgs separates URLs from flags so URLs containing "-" or
+// query strings don't trip the flag parser.
+func splitTabsOpenArgs(args []string) ([]string, []string, error) {
 	if len(args) == 0 {
-		return "", nil, errors.New("usage: cdp tabs open <url>")
+		return nil, nil, usagef("usage: cdp tabs open <url>... [--file urls.txt]")
 	}
 	if len(args) == 1 && isHelpArg(args[0]) {
-		return "", nil, errors.New("usage: cdp tabs open <url>")
+		return nil, nil, usagef("usage: cdp tabs open <url>... [--file urls.txt]")
 	}
-	var url string
+	var urls []string
 	flags := make([]string, 0, len(args))
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
@@ -24,9 +26,9 @@ func splitTabsOpenArgs(args []string) (string, []string, error) {
 				continue
 			}
 			switch name {
-			case "host", "port", "timeout":
+			case "host", "port", "timeout", "file":
 				if i+1 >= len(args) {
-					return "", nil, fmt.Errorf("flag %s requires a value", arg)
+					return nil, nil, fmt.Errorf("flag %s requires a value", arg)
 				}
 				flags = append(flags, args[i+1])
 				i++
@@ -38,13 +40,24 @@ func splitTabsOpenArgs(args []string) (string, []string, error) {
 			}
 			continue
 		}
-		if url != "" {
-			return "", nil, fmt.Errorf("unexpected argument: %s", arg)
-		}
-		url = arg
+		urls = append(urls, arg)
+	}
+	return urls, flags, nil
+}
+
+// readURLList reads one URL per line, skipping blank lines and # comments.
+func readURLList(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
-	if url == "" {
-		return "", nil, errors.New("usage: cdp tabs open <url>")
+	var urls []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
 	}
-	return url, flags, nil
+	return urls, nil
 }
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package cli
+
+func terminalWidth() int {
+	return 0
+}
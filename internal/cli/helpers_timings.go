@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+)
+
+// callTimings aggregates CDP traffic for `cdp --timings`.
+type callTimings struct {
+	mu       sync.Mutex
+	start    time.Time
+	dials    int
+	dialTime time.Duration
+	calls    int
+	callTime time.Duration
+	methods  map[string]*methodTiming
+	// attach is how the session was reached (see attachSession).
+	attach string
+}
+
+// activeTimings is set while --timings is recording.
+var activeTimings *callTimings
+
+// noteAttachPath records which attachSession path won, for --timings.
+func noteAttachPath(path string) {
+	if t := activeTimings; t != nil {
+		t.mu.Lock()
+		t.attach = path
+		t.mu.Unlock()
+	}
+}
+
+type methodTiming struct {
+	Method string        `json:"method"`
+	Calls  int           `json:"calls"`
+	Total  time.Duration `json:"-"`
+	Max    time.Duration `json:"-"`
+	// Millisecond copies for the JSON summary.
+	TotalMs float64 `json:"totalMs"`
+	MaxMs   float64 `json:"maxMs"`
+}
+
+// enableTimings installs cdp hooks that record dial and per-call durations.
+func enableTimings() *callTimings {
+	t := &callTimings{start: time.Now(), methods: map[string]*methodTiming{}}
+	activeTimings = t
+	cdp.SetHooks(cdp.Hooks{
+		OnDial: func(_ string, d time.Duration, _ error) {
+			t.mu.Lock()
+			t.dials++
+			t.dialTime += d
+			t.mu.Unlock()
+		},
+		OnReceive: func(_ int64, method string, d time.Duration, _ error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.calls++
+			t.callTime += d
+			m := t.methods[method]
+			if m == nil {
+				m = &methodTiming{Method: method}
+				t.methods[method] = m
+			}
+			m.Calls++
+			m.Total += d
+			if d > m.Max {
+				m.Max = d
+			}
+		},
+	})
+	return t
+}
+
+// slowest returns up to n methods ordered by total time spent in them.
+func (t *callTimings) slowest(n int) []methodTiming {
+	out := make([]methodTiming, 0, len(t.methods))
+	for _, m := range t.methods {
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].Method < out[j].Method
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	for i := range out {
+		out[i].TotalMs = durationMs(out[i].Total)
+		out[i].MaxMs = durationMs(out[i].Max)
+	}
+	return out
+}
+
+// print writes the summary to stderr, as one JSON object when asJSON is set.
+func (t *callTimings) print(asJSON bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	wall := time.Since(t.start)
+	slowest := t.slowest(3)
+	if asJSON {
+		out, err := jsonStyle.JSON(map[string]interface{}{
+			"timings": true,
+			"wallMs":  durationMs(wall),
+			"dials":   t.dials,
+			"dialMs":  durationMs(t.dialTime),
+			"calls":   t.calls,
+			"callMs":  durationMs(t.callTime),
+			"slowest": slowest,
+			"attach":  t.attach,
+		}, false, -1)
+		if err == nil {
+			fmt.Fprintln(os.Stderr, out)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "timings: wall %s, dial %s (%d), %d CDP calls in %s\n",
+		roundDuration(wall), roundDuration(t.dialTime), t.dials, t.calls, roundDuration(t.callTime))
+	if t.attach != "" {
+		fmt.Fprintf(os.Stderr, "  attach: %s\n", t.attach)
+	}
+	for _, m := range slowest {
+		fmt.Fprintf(os.Stderr, "  %-32s %3dx %s (max %s)\n", m.Method, m.Calls, roundDuration(m.Total), roundDuration(m.Max))
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(10 * time.Microsecond)
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// userScriptsGlobal records which user scripts (path -> hash) a page has run,
+// so unchanged scripts aren't re-evaluated on every command.
+const userScriptsGlobal = "__cdpUserScripts"
+
+func hashUserScript(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// loadUserScript resolves path to an absolute path and hashes its contents.
+func loadUserScript(path string) (store.UserScript, []byte, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return store.UserScript{}, nil, err
+	}
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return store.UserScript{}, nil, fmt.Errorf("user script: %w", err)
+	}
+	return store.UserScript{Path: abs, Hash: hashUserScript(data)}, data, nil
+}
+
+// userScriptSource appends a sourceURL (so stack traces name the file) and the
+// marker that records the script as present in the page.
+func userScriptSource(script store.UserScript, data []byte) string {
+	return fmt.Sprintf("%s\n;(window[%s] = window[%s] || {})[%s] = %s;\n//# sourceURL=%s",
+		data,
+		strconv.Quote(userScriptsGlobal), strconv.Quote(userScriptsGlobal),
+		strconv.Quote(script.Path), strconv.Quote(script.Hash),
+		filepath.Base(script.Path))
+}
+
+// pageUserScripts returns the path -> hash map of user scripts the page has run.
+func pageUserScripts(ctx context.Context, client *cdp.Client) (map[string]string, error) {
+	value, err := client.Evaluate(ctx, fmt.Sprintf(`(() => Object.assign({}, window[%s] || {}))()`, strconv.Quote(userScriptsGlobal)))
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	if m, ok := value.(map[string]interface{}); ok {
+		for k, v := range m {
+			out[k] = fmt.Sprint(v)
+		}
+	}
+	return out, nil
+}
+
+// injectUserScripts evaluates the session's user scripts whose file hash isn't
+// already present in the page (or all of them with force). Hash changes are
+// written back to the session, which the handle persists on Close.
+func injectUserScripts(ctx context.Context, client *cdp.Client, force bool) error {
+	session := lookupSession(client)
+	if session == nil || len(session.UserScripts) == 0 {
+		return nil
+	}
+	present, err := pageUserScripts(ctx, client)
+	if err != nil {
+		return err
+	}
+	for i, configured := range session.UserScripts {
+		script, data, err := loadUserScript(configured.Path)
+		if err != nil {
+			return err
+		}
+		if !force && present[script.Path] == script.Hash {
+			continue
+		}
+		res, err := client.EvaluateRaw(ctx, userScriptSource(script, data), true)
+		if err != nil {
+			if details := res.ExceptionDetails; details != nil {
+				err = fmt.Errorf("user script %s failed at line %d: %w", script.Path, details.LineNumber+1, err)
+			} else {
+				err = fmt.Errorf("user script %s: %w", script.Path, err)
+			}
+			return withCSPHint(client, err)
+		}
+		session.UserScripts[i].Hash = script.Hash
+	}
+	return nil
+}
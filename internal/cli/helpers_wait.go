@@ -2,9 +2,14 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
@@ -14,25 +19,113 @@ func waitForReadyState(ctx context.Context, client *cdp.Client, poll time.Durati
 	return waitForCondition(ctx, client, `document.readyState === "complete"`, "document.readyState == 'complete'", poll)
 }
 
+// addReadyCheckFlag registers --no-ready-check for commands that warn when
+// they start before the page has finished loading.
+func addReadyCheckFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("no-ready-check", false, "Don't warn when document.readyState isn't 'complete' yet")
+}
+
+// warnIfLoading is a cheap pre-flight check: results read from a page that is
+// still loading are often empty, so say so on stderr along with hint (how to
+// wait instead). It never fails the command.
+func warnIfLoading(ctx context.Context, client *cdp.Client, hint string) {
+	value, err := client.Evaluate(ctx, "document.readyState")
+	if err != nil {
+		return
+	}
+	state, _ := value.(string)
+	if state == "" || state == "complete" {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "warning: page is still loading (document.readyState=%s); results may be empty or stale. %s, or pass --no-ready-check to silence this.\n", state, hint)
+}
+
 func waitForSelector(ctx context.Context, client *cdp.Client, selector string, poll time.Duration) error {
-	expression := fmt.Sprintf(`(() => {
-        return document.querySelector(%s) !== null;
-    })()`, strconv.Quote(selector))
-	return waitForCondition(ctx, client, expression, fmt.Sprintf("selector %s", selector), poll)
+	return waitForCondition(ctx, client, selectorWaitExpression([]string{selector}, false, true, "true"), fmt.Sprintf("selector %s", selector), poll)
 }
 
 func waitForSelectorVisible(ctx context.Context, client *cdp.Client, selector string, poll time.Duration) error {
-	expression := fmt.Sprintf(`(() => {
-        const el = document.querySelector(%s);
-        if (!el) { return false; }
-        const style = window.getComputedStyle(el);
-        if (style && (style.display === "none" || style.visibility === "hidden" || style.opacity === "0")) {
-            return false;
+	return waitForCondition(ctx, client, selectorWaitExpression([]string{selector}, true, true, "true"), fmt.Sprintf("visible selector %s", selector), poll)
+}
+
+// waitForSelectors waits until any (or, with all, every) selector matches an
+// element (a visible one, if asked). It returns the selectors that matched on
+// the satisfying poll and read, a JS expression over the first matched
+// element el, from that same evaluation.
+func waitForSelectors(ctx context.Context, client *cdp.Client, selectors []string, visible, all bool, read string, poll time.Duration) ([]string, interface{}, error) {
+	description := "selector " + selectors[0]
+	if len(selectors) > 1 {
+		quantifier := "any"
+		if all {
+			quantifier = "all"
+		}
+		description = fmt.Sprintf("%s of selectors %s", quantifier, strings.Join(selectors, ", "))
+	}
+	if visible {
+		description = "visible " + description
+	}
+	expression := selectorWaitExpression(selectors, visible, all, "{matched, value: "+read+"}")
+	if poll <= 0 {
+		poll = 200 * time.Millisecond
+	}
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		value, err := client.Evaluate(ctx, expression)
+		if m, ok := value.(map[string]interface{}); err == nil && ok {
+			var matched []string
+			indexes, _ := m["matched"].([]interface{})
+			for _, idx := range indexes {
+				if i, ok := idx.(float64); ok && int(i) < len(selectors) {
+					matched = append(matched, selectors[int(i)])
+				}
+			}
+			return matched, m["value"], nil
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, nil, fmt.Errorf("timeout waiting for %s", description)
+			}
+			return nil, nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// selectorWaitExpression evaluates to false until any (or, with all, every)
+// selector matches, and is visible if asked, then to result. result may refer
+// to the first matched element as el and to matched, the matched indexes.
+func selectorWaitExpression(selectors []string, visible, all bool, result string) string {
+	quoted := make([]string, len(selectors))
+	for i, sel := range selectors {
+		quoted[i] = strconv.Quote(sel)
+	}
+	visibility := ""
+	if visible {
+		visibility = `
+            const style = window.getComputedStyle(found);
+            if (style && (style.display === "none" || style.visibility === "hidden" || style.opacity === "0")) { continue; }
+            const rect = found.getBoundingClientRect();
+            if (!(rect.width > 0 && rect.height > 0)) { continue; }`
+	}
+	want := "matched.length > 0"
+	if all {
+		want = "matched.length === selectors.length"
+	}
+	return fmt.Sprintf(`(() => {
+        const selectors = [%s];
+        const matched = [];
+        let el = null;
+        for (let i = 0; i < selectors.length; i++) {
+            const found = document.querySelector(selectors[i]);
+            if (!found) { continue; }%s
+            matched.push(i);
+            if (!el) { el = found; }
         }
-        const rect = el.getBoundingClientRect();
-        return rect.width > 0 && rect.height > 0;
-    })()`, strconv.Quote(selector))
-	return waitForCondition(ctx, client, expression, fmt.Sprintf("visible selector %s", selector), poll)
+        if (!(%s)) { return false; }
+        return %s;
+    })()`, strings.Join(quoted, ", "), visibility, want, result)
 }
 
 func waitForCondition(ctx context.Context, client *cdp.Client, expression, description string, poll time.Duration) error {
@@ -68,3 +161,133 @@ func evalBool(ctx context.Context, client *cdp.Client, expression string) (bool,
 	}
 	return result, nil
 }
+
+// waitForRoute returns once the main frame's URL matches re. SPA router changes
+// (pushState/replaceState/popstate) surface as Page.navigatedWithinDocument, so
+// this works without load events and without patching history in the page.
+func waitForRoute(ctx context.Context, client *cdp.Client, re *regexp.Regexp) (string, error) {
+	urls, unsubscribe, err := watchMainFrameURL(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	defer unsubscribe()
+
+	// Check the current URL only after subscribing so a change in between isn't lost.
+	value, err := client.Evaluate(ctx, "location.href")
+	if err != nil {
+		return "", err
+	}
+	if current, _ := value.(string); re.MatchString(current) {
+		return current, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return "", fmt.Errorf("timeout waiting for route %s", re.String())
+			}
+			return "", ctx.Err()
+		case next := <-urls:
+			if re.MatchString(next) {
+				return next, nil
+			}
+		}
+	}
+}
+
+// watchMainFrameURL reports the main frame's URL after every navigation,
+// including same-document (SPA) ones, until unsubscribe is called.
+func watchMainFrameURL(ctx context.Context, client *cdp.Client) (<-chan string, func(), error) {
+	if err := client.Call(ctx, "Page.enable", nil, nil); err != nil {
+		return nil, nil, err
+	}
+	var tree struct {
+		FrameTree struct {
+			Frame struct {
+				ID string `json:"id"`
+			} `json:"frame"`
+		} `json:"frameTree"`
+	}
+	if err := client.Call(ctx, "Page.getFrameTree", nil, &tree); err != nil {
+		return nil, nil, err
+	}
+	mainFrame := tree.FrameTree.Frame.ID
+
+	urls := make(chan string, 16)
+	unsubscribe := client.SubscribeEvents(func(evt cdp.Event) {
+		var next string
+		switch evt.Method {
+		case "Page.navigatedWithinDocument":
+			var payload struct {
+				FrameID string `json:"frameId"`
+				URL     string `json:"url"`
+			}
+			if err := json.Unmarshal(evt.Params, &payload); err != nil || payload.FrameID != mainFrame {
+				return
+			}
+			next = payload.URL
+		case "Page.frameNavigated":
+			var payload struct {
+				Frame struct {
+					ParentID string `json:"parentId"`
+					URL      string `json:"url"`
+				} `json:"frame"`
+			}
+			if err := json.Unmarshal(evt.Params, &payload); err != nil || payload.Frame.ParentID != "" {
+				return
+			}
+			next = payload.Frame.URL
+		default:
+			return
+		}
+		select {
+		case urls <- next:
+		default:
+		}
+	})
+	return urls, unsubscribe, nil
+}
+
+func compileRouteRegex(spec, flagName string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(escapeLeadingPlusRegexSpec(spec))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s regex: %w", flagName, err)
+	}
+	return re, nil
+}
+
+// waitForPageSettled polls until the page is complete (when ready is set) and
+// its title and URL match the given regexes (either may be nil). Evaluation
+// errors from in-flight navigations are retried until ctx expires.
+func waitForPageSettled(ctx context.Context, client *cdp.Client, ready bool, titleRe, urlRe *regexp.Regexp, poll time.Duration) error {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	var state struct {
+		ReadyState string `json:"readyState"`
+		Title      string `json:"title"`
+		URL        string `json:"url"`
+	}
+	for {
+		value, err := client.Evaluate(ctx, `({readyState: document.readyState, title: document.title, url: location.href})`)
+		if err == nil {
+			if m, ok := value.(map[string]interface{}); ok {
+				state.ReadyState, _ = m["readyState"].(string)
+				state.Title, _ = m["title"].(string)
+				state.URL, _ = m["url"].(string)
+				if (!ready || state.ReadyState == "complete") &&
+					(titleRe == nil || titleRe.MatchString(state.Title)) &&
+					(urlRe == nil || urlRe.MatchString(state.URL)) {
+					return nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("timeout waiting for the tab to settle (readyState=%q title=%q url=%q)", state.ReadyState, state.Title, state.URL)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
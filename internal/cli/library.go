@@ -0,0 +1,389 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
+)
+
+// The exported functions below are the operations behind read, click, type
+// and screenshot, shared by the commands and by pkg/session. They print
+// nothing; reporting stays with the commands.
+
+// OpenSession attaches to a saved session the way the commands do (following
+// a rebound tab and re-applying recorded overrides). close detaches.
+func OpenSession(ctx context.Context, name string) (client *cdp.Client, close func(), err error) {
+	st, err := store.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	handle, err := openSession(ctx, st, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return handle.client, handle.Close, nil
+}
+
+// ReadOptions select what ReadPage extracts. The zero value reads the whole
+// page with the read command's defaults.
+type ReadOptions struct {
+	Selector   string // root element(s) to read; empty reads the page
+	HasText    string // only elements whose subtree text matches this text/regex
+	AttValue   string // only elements with an attribute value matching this text/regex
+	ClassLimit int    // classes kept in element labels (0 = the default of 3, -1 = none)
+	WaitMS     int    // extra wait before parsing
+}
+
+// ReadResult is the page as the read command prints it. When the selector
+// (or the text filters) matched nothing, Matched is false, Lines is empty
+// and Diagnostics holds the "no matches" line and any "did you mean"
+// suggestion.
+type ReadResult struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Lines       []string `json:"lines"`
+	Matched     bool     `json:"matched"`
+	MatchCount  int      `json:"matchCount"`
+	Diagnostics []string `json:"diagnostics,omitempty"`
+}
+
+// ReadPage renders the page (or opts.Selector) as readable lines via WebNav.
+func ReadPage(ctx context.Context, client *cdp.Client, opts ReadOptions) (ReadResult, error) {
+	var result ReadResult
+	if err := ensureWebNavInjected(ctx, client); err != nil {
+		return result, err
+	}
+	classLimit := opts.ClassLimit
+	switch {
+	case classLimit == 0:
+		classLimit = 3
+	case classLimit < 0:
+		classLimit = 0
+	}
+	var root interface{}
+	if opts.Selector != "" {
+		root = normalizeSelector(opts.Selector)
+	}
+	optsJSON, _ := json.Marshal(map[string]interface{}{
+		"waitMs":       opts.WaitMS,
+		"rootSelector": root,
+		"hasText":      opts.HasText,
+		"attValue":     opts.AttValue,
+		"classLimit":   classLimit,
+	})
+	expression := fmt.Sprintf("window.WebNavRead(%s)", string(optsJSON))
+	// Use the "by reference" eval path (returnByValue=false) since read results can be
+	// large and some Chromium builds are flaky about returning them by value.
+	raw, err := client.EvaluateRaw(ctx, expression, false)
+	if err != nil {
+		return result, err
+	}
+	value, err := client.RemoteObjectValue(ctx, raw.Result)
+	if err != nil {
+		return result, err
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return result, fmt.Errorf("unexpected WebNavRead result type %T", value)
+	}
+	result.URL, _ = m["url"].(string)
+	result.Title, _ = m["title"].(string)
+	result.Lines = webNavLines(m)
+	result.Matched, _ = m["matched"].(bool)
+	if count, ok := m["matchCount"].(float64); ok {
+		result.MatchCount = int(count)
+	}
+	result.Diagnostics = webNavLines(map[string]interface{}{"lines": m["diagnostics"]})
+	return result, nil
+}
+
+// webNavLines extracts the "lines" array of a WebNavRead result.
+func webNavLines(m map[string]interface{}) []string {
+	linesAny, _ := m["lines"].([]interface{})
+	lines := make([]string, 0, len(linesAny))
+	for _, v := range linesAny {
+		if s, ok := v.(string); ok {
+			lines = append(lines, s)
+		} else if v != nil {
+			lines = append(lines, fmt.Sprint(v))
+		}
+	}
+	return lines
+}
+
+// ClickOptions pick the element ClickElement clicks. Without a Selector,
+// button then div elements are searched (HasText is then required).
+type ClickOptions struct {
+	Selector    string // may end in an inline :has-text(...)
+	HasText     string
+	AttValue    string
+	Att         string // name=REGEX: only elements whose name attribute matches
+	PreferInner string // yes, no, or auto (the default)
+	Count       int    // clicks to perform (default 1)
+	NoDblclick  bool   // with Count 2, send two plain clicks instead of a double click
+	ObserveMS   int    // watch for DOM mutations this long after clicking
+	Verbose     bool   // log automatic recovery retries to stderr
+}
+
+// ClickResult describes the clicked element and the page around the click.
+type ClickResult struct {
+	Tag        string                 `json:"tag"`
+	Match      map[string]interface{} `json:"match,omitempty"`
+	Before     string                 `json:"before"`
+	After      string                 `json:"after"`
+	SubmitForm bool                   `json:"submitForm,omitempty"`
+	Events     string                 `json:"events,omitempty"` // e.g. "2 clicks + dblclick"
+	Mutations  int                    `json:"mutations"`
+	URLBefore  string                 `json:"urlBefore"`
+	URLAfter   string                 `json:"urlAfter"`
+}
+
+// clickTarget is a validated ClickOptions.
+type clickTarget struct {
+	selectors   []string
+	hasText     string
+	attValue    string
+	att         string
+	preferInner bool
+}
+
+func (opts ClickOptions) target() (clickTarget, error) {
+	var t clickTarget
+	selector := opts.Selector
+	t.hasText, t.attValue, t.att = opts.HasText, opts.AttValue, opts.Att
+	if err := checkAttFilter(t.att); err != nil {
+		return t, err
+	}
+	if selector != "" {
+		sel, inlineHasText, hasInline, err := parseInlineHasText(selector)
+		if err != nil {
+			return t, err
+		}
+		selector = sel
+		if hasInline {
+			t.hasText = inlineHasText
+		}
+		if err := rejectUnsupportedSelector(selector, "click", true); err != nil {
+			return t, err
+		}
+		t.selectors = []string{autoQuoteAttrValues(selector)}
+	} else if t.hasText == "" {
+		return t, errors.New("a selector or has-text is required")
+	} else {
+		// Default element types when a selector isn't provided.
+		t.selectors = []string{"button", "div"}
+	}
+	for _, sel := range t.selectors {
+		if err := rejectUnsupportedSelector(sel, "click", true); err != nil {
+			return t, err
+		}
+	}
+	mode := strings.ToLower(strings.TrimSpace(opts.PreferInner))
+	if mode == "" {
+		mode = "auto"
+	}
+	if mode != "yes" && mode != "no" && mode != "auto" {
+		return t, errors.New("--prefer-inner must be one of: yes, no, auto")
+	}
+	if t.hasText != "" || t.attValue != "" || t.att != "" {
+		switch mode {
+		case "yes":
+			t.preferInner = true
+		case "auto":
+			t.preferInner = selector == "" || isBareTagSelector(selector)
+		}
+	}
+	return t, nil
+}
+
+// clickExpression is the page call ClickElement makes: it describes the match
+// before clicking, since the click may change the page, then clicks
+// opts.Count times (a double click for 2 unless opts.NoDblclick).
+func (t clickTarget) clickExpression(opts ClickOptions) string {
+	count := opts.Count
+	if count < 1 {
+		count = 1
+	}
+	targetExpr := buildFilteredTargetExpr(t.selectors, t.hasText, t.attValue, t.att, t.preferInner)
+	readOptsJSON, _ := json.Marshal(map[string]interface{}{
+		"waitMs":     0,
+		"hasText":    "",
+		"attValue":   "",
+		"classLimit": 3,
+	})
+	return fmt.Sprintf(`(() => {
+        const match = %s;
+        return Promise.resolve(window.WebNavClickWithRead(%s, %d, %s, {observeMs: %d, dblclick: %t})).then(r => Object.assign(r, {match}));
+    })()`, buildMatchInfoExpr(t.selectors, t.hasText, t.attValue, t.att, t.preferInner), targetExpr, count, string(readOptsJSON), opts.ObserveMS, !opts.NoDblclick)
+}
+
+// ClickElement clicks the first element matching opts and reports what
+// changed. A navigation that destroys the page mid-click surfaces as an
+// error classified as contextErrDestroyed.
+func ClickElement(ctx context.Context, client *cdp.Client, opts ClickOptions) (ClickResult, error) {
+	var result ClickResult
+	t, err := opts.target()
+	if err != nil {
+		return result, err
+	}
+	if err := ensureWebNavInjected(ctx, client); err != nil {
+		return result, err
+	}
+	expression := t.clickExpression(opts)
+	valueAny, err := runWebNavAction(ctx, client, opts.Verbose, "WebNavClickWithRead", expression)
+	if err != nil {
+		if opts.Selector != "" && t.hasText == "" && t.attValue == "" && t.att == "" {
+			err = withSelectorSuggestions(ctx, client, t.selectors[0], err)
+		}
+		return result, err
+	}
+	value, ok := valueAny.(map[string]interface{})
+	if !ok {
+		return result, fmt.Errorf("unexpected WebNavClickWithRead result type %T", valueAny)
+	}
+	if before, ok := value["before"].(map[string]interface{}); ok {
+		result.Before = strings.Join(webNavLines(before), "\n")
+	}
+	if after, ok := value["after"].(map[string]interface{}); ok {
+		result.After = strings.Join(webNavLines(after), "\n")
+	}
+	result.Tag, _ = value["tagName"].(string)
+	if result.Tag == "" {
+		result.Tag = "element"
+	}
+	result.Match, _ = value["match"].(map[string]interface{})
+	result.SubmitForm, _ = value["submitForm"].(bool)
+	result.Events, _ = value["events"].(string)
+	mutations, _ := value["mutations"].(float64)
+	result.Mutations = int(mutations)
+	result.URLBefore, _ = value["urlBefore"].(string)
+	result.URLAfter, _ = value["urlAfter"].(string)
+	return result, nil
+}
+
+// typeDefaultSelectors are searched when type gets no selector. Real inputs
+// come first so they win over editors among equally ranked matches.
+var typeDefaultSelectors = []string{"input", "textarea", `[contenteditable=""]`, `[contenteditable="true"]`, `[role="textbox"]`}
+
+// TypeOptions pick the field TypeText types into. Without a Selector, the
+// typeDefaultSelectors are searched (HasText is then required) and the
+// focused match, then the first visible one, is used.
+type TypeOptions struct {
+	Selector string // may end in an inline :has-text(...)
+	HasText  string
+	AttValue string
+	Att      string // name=REGEX: only elements whose name attribute matches
+	Append   bool   // append to the current value instead of replacing it
+	Verbose  bool   // log automatic recovery retries to stderr
+}
+
+// target validates opts, returning the bare selector, the selectors to
+// search, and the effective has-text filter.
+func (opts TypeOptions) target() (string, []string, string, error) {
+	selector, hasText := opts.Selector, opts.HasText
+	if err := checkAttFilter(opts.Att); err != nil {
+		return "", nil, "", err
+	}
+	if selector != "" {
+		sel, inlineHasText, hasInline, err := parseInlineHasText(selector)
+		if err != nil {
+			return "", nil, "", err
+		}
+		selector = sel
+		if hasInline {
+			hasText = inlineHasText
+		}
+		if err := rejectUnsupportedSelector(selector, "type", true); err != nil {
+			return "", nil, "", err
+		}
+	} else if hasText == "" {
+		return "", nil, "", errors.New("a selector or has-text is required")
+	}
+	selectors := typeDefaultSelectors
+	if selector != "" {
+		selectors = []string{autoQuoteAttrValues(selector)}
+	}
+	for _, sel := range selectors {
+		if err := rejectUnsupportedSelector(sel, "type", true); err != nil {
+			return "", nil, "", err
+		}
+	}
+	return selector, selectors, hasText, nil
+}
+
+// TypeResult identifies the field typed into and its value beforehand.
+type TypeResult struct {
+	Selector string `json:"selector"`
+	Before   string `json:"before"`
+	// targetExpr re-finds the element, for reading the value back.
+	targetExpr string
+}
+
+// TypeText types text into the matched field: through the page's own
+// handler when WebNav has one, else Input.insertText into the focused
+// editable element, else by setting the value directly.
+func TypeText(ctx context.Context, client *cdp.Client, text string, opts TypeOptions) (TypeResult, error) {
+	var result TypeResult
+	selector, selectors, hasText, err := opts.target()
+	if err != nil {
+		return result, err
+	}
+	if err := ensureWebNavInjected(ctx, client); err != nil {
+		return result, err
+	}
+
+	targetExpr := buildFilteredTargetExpr(selectors, hasText, opts.AttValue, opts.Att, false)
+	if selector == "" {
+		targetExpr = buildRankedTargetExpr(selectors, hasText, opts.AttValue, opts.Att)
+	}
+	result.targetExpr = targetExpr
+	expression := fmt.Sprintf(`window.WebNavTypePrepare(%s, %s, %t)`, targetExpr, strconv.Quote(text), opts.Append)
+	// WebNavTypePrepare may type the text itself, so it is sent only once.
+	value, err := runWebNavAction(ctx, client, opts.Verbose, "WebNavTypePrepare", expression)
+	if err != nil {
+		return result, err
+	}
+	state, ok := value.(map[string]interface{})
+	if !ok || state["found"] != true {
+		err := errors.New("selector not found")
+		if selector != "" && hasText == "" && opts.AttValue == "" && opts.Att == "" {
+			err = withSelectorSuggestions(ctx, client, selector, err)
+		}
+		return result, err
+	}
+	result.Selector = selector
+	if sel, _ := state["selector"].(string); sel != "" {
+		result.Selector = sel
+	}
+	result.Before, _ = state["before"].(string)
+	if handled, _ := state["handled"].(bool); handled {
+		return result, nil
+	}
+	if editable, _ := state["editable"].(bool); editable {
+		err := client.Call(ctx, "Input.insertText", map[string]interface{}{
+			"text": text,
+		}, nil)
+		return result, err
+	}
+
+	fallback := fmt.Sprintf(`window.WebNavTypeFallback(%s, %s, %t)`, targetExpr, strconv.Quote(text), opts.Append)
+	fallbackValue, err := client.Evaluate(ctx, fallback)
+	if err != nil {
+		return result, err
+	}
+	if m, ok := fallbackValue.(map[string]interface{}); ok {
+		if okVal, _ := m["ok"].(bool); !okVal {
+			return result, errors.New("selector not found")
+		}
+		if sel, _ := m["selector"].(string); sel != "" {
+			result.Selector = sel
+		}
+	}
+	return result, nil
+}
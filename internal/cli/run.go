@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+
+	"github.com/veilm/cdp-cli/internal/store"
 )
 
 func Run() error {
@@ -10,64 +16,171 @@ func Run() error {
 		printUsage()
 		return nil
 	}
-	cmd := os.Args[1]
-	args := os.Args[2:]
+	globals, args, err := extractGlobalFlags(os.Args[1:])
+	if err != nil {
+		return err
+	}
+	if value, ok := globals["json-errors"]; ok {
+		jsonErrors, _ = strconv.ParseBool(value)
+		delete(globals, "json-errors")
+	}
+	if value, ok := globals["restore-overrides"]; ok {
+		restoreOverridesFlag, _ = strconv.ParseBool(value)
+		delete(globals, "restore-overrides")
+	}
+	if value, ok := globals["ignore-origin"]; ok {
+		ignoreOriginFlag, _ = strconv.ParseBool(value)
+		delete(globals, "ignore-origin")
+	}
+	if value, ok := globals["store"]; ok {
+		store.Configure(store.Options{Path: value})
+		delete(globals, "store")
+	}
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	if err := applyJSONStyle(); err != nil {
+		return err
+	}
+	profile := globals["profile"]
+	if profile == "" {
+		profile = strings.TrimSpace(os.Getenv("CDP_PROFILE"))
+	}
+	delete(globals, "profile")
+	if mode, ok := globals["timings"]; ok {
+		timings := enableTimings()
+		defer timings.print(mode == "json")
+		delete(globals, "timings")
+	}
+	globalFlagValues = globals
+	if profile != "" && (len(args) == 0 || args[0] != "profile") {
+		if err := useProfile(profile); err != nil {
+			return err
+		}
+	}
+	if len(args) == 0 {
+		printUsage()
+		return nil
+	}
+	err = runCommand(args[0], args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		// Usage was already printed by parseFlags.
+		return nil
+	}
+	return err
+}
+
+// cliCommand is one entry of the command registry. runCommand dispatches through
+// it and `cdp commands` builds its catalog from it, so the two cannot drift.
+type cliCommand struct {
+	Name        string
+	Aliases     []string
+	Group       string
+	Summary     string
+	Args        string // positional arguments, e.g. "<selector> <text>"
+	Stability   string // "stable" or "experimental"
+	Subcommands []cliCommand
+	Help        commandHelp // description and examples, declared next to the command
+	run         func([]string) error
+}
+
+// Command groups, in the order `cdp commands` prints them.
+const (
+	groupSession = "Sessions"
+	groupRead    = "Reading"
+	groupInput   = "Interaction"
+	groupInspect = "Inspection"
+	groupMonitor = "Monitoring"
+	groupBrowser = "Browser"
+	groupMeta    = "Meta"
+	stable       = "stable"
+	experimental = "experimental"
+)
+
+var commandGroups = []string{groupSession, groupRead, groupInput, groupInspect, groupMonitor, groupBrowser, groupMeta}
+
+var commandRegistry []cliCommand
 
+func init() {
+	// Assigned in init because cmdCommands reads the registry itself.
+	commandRegistry = []cliCommand{
+		{Name: "connect", Group: groupSession, Summary: "Attach a named session to a tab (by URL, index/id/pattern, or a new tab)", Stability: stable, Help: connectHelp, run: cmdConnect},
+		{Name: "disconnect", Group: groupSession, Summary: "Forget a saved session (the tab stays open)", Stability: stable, Help: disconnectHelp, run: cmdDisconnect},
+		{Name: "status", Group: groupSession, Summary: "Show a session's current URL and title", Stability: stable, Help: statusHelp, run: cmdStatus},
+		{Name: "targets", Group: groupSession, Summary: "List saved sessions", Stability: stable, Help: targetsHelp, run: cmdTargets},
+		{Name: "state", Group: groupSession, Summary: "Check whether a tab is hidden, unfocused or throttled (read-only)", Stability: experimental, Help: stateHelp, run: cmdState},
+		{Name: "repl", Group: groupSession, Summary: "Run cdp commands or JS lines from stdin over one connection", Stability: experimental, Help: replHelp, run: cmdRepl},
+		{Name: "keep-alive", Group: groupSession, Summary: "Keep a session's tab active (focus emulation, lifecycle state, bring to front)", Stability: stable, Help: keepAliveHelp, run: cmdKeepAlive},
+		{Name: "overrides", Group: groupSession, Summary: "Record CDP overrides and re-apply them on reconnect", Stability: experimental, Subcommands: []cliCommand{{Name: "list", Summary: "Show a session's recorded overrides"}, {Name: "set", Summary: "Apply a CDP override command and record it", Args: "<Domain.method> [json]"}, {Name: "clear", Summary: "Forget recorded overrides", Args: "[Domain.method]"}, {Name: "auto", Summary: "Turn auto-restore on or off for a session", Args: "on|off"}}, Help: overridesHelp, run: cmdOverrides},
+		{Name: "read", Group: groupRead, Summary: "Print the page (or selected elements) as readable text", Args: "[selector...]", Stability: stable, Help: readHelp, run: cmdRead},
+		{Name: "eval", Group: groupRead, Summary: "Evaluate JavaScript and print the result as JSON", Args: "<expr>", Stability: stable, Help: evalHelp, run: cmdEval},
+		{Name: "wait", Group: groupRead, Summary: "Wait for page load, a selector, or a route", Stability: stable, Help: waitHelp, run: cmdWait},
+		{Name: "wait-visible", Group: groupRead, Summary: "Wait until an element is visible", Args: "<selector>", Stability: stable, Help: waitVisibleHelp, run: cmdWaitVisible},
+		{Name: "wait-mutation", Group: groupRead, Summary: "Wait for a DOM mutation under a container", Args: "<selector>", Stability: stable, Help: waitMutationHelp, run: cmdWaitMutation},
+		{Name: "click", Group: groupInput, Summary: "Click an element", Args: "[selector]", Stability: stable, Help: clickHelp, run: cmdClick},
+		{Name: "hover", Group: groupInput, Summary: "Move the pointer over an element", Args: "[selector]", Stability: stable, Help: hoverHelp, run: cmdHover},
+		{Name: "drag", Group: groupInput, Summary: "Drag one element onto another", Args: "<from> <to>", Stability: stable, Help: dragHelp, run: cmdDrag},
+		{Name: "gesture", Group: groupInput, Summary: "Press, move and release along a path", Args: "[selector] <points>", Stability: experimental, Help: gestureHelp, run: cmdGesture},
+		{Name: "key", Group: groupInput, Summary: "Send a key press or combo", Args: "<keys>", Stability: stable, Help: keyHelp, run: cmdKey},
+		{Name: "scroll", Group: groupInput, Summary: "Scroll the page or an element", Args: "<yPx|N%|page|-page>", Stability: stable, Help: scrollHelp, run: cmdScroll},
+		{Name: "type", Group: groupInput, Summary: "Type text into an input", Args: "[selector] <text>", Stability: stable, Help: typeHelp, run: cmdType},
+		{Name: "select", Group: groupInput, Summary: "Pick an option in a native <select>", Args: "<selector> <value|label>", Stability: stable, Help: selectHelp, run: cmdSelect},
+		{Name: "submit", Group: groupInput, Summary: "Submit a form via requestSubmit, submit() or Enter, reporting validation", Args: "[form-selector]", Stability: experimental, Help: submitHelp, run: cmdSubmit},
+		{Name: "upload", Group: groupInput, Summary: "Set files on a file input", Args: "<selector> <file>...", Stability: stable, Help: uploadHelp, run: cmdUpload},
+		{Name: "validity", Group: groupInspect, Summary: "Report form control validity", Args: "[selector]", Stability: experimental, Help: validityHelp, run: cmdValidity},
+		{Name: "dom", Group: groupInspect, Summary: "Print an element's outer HTML and text as JSON", Args: "<selector>", Stability: stable, Help: domHelp, run: cmdDOM},
+		{Name: "dom-edit", Group: groupInspect, Summary: "Remove nodes or edit attributes/outer HTML via the DOM agent", Args: "<selector>", Stability: experimental, Help: domEditHelp, run: cmdDOMEdit},
+		{Name: "styles", Group: groupInspect, Summary: "Print (or watch) computed styles", Args: "<selector>", Stability: stable, Help: stylesHelp, run: cmdStyles},
+		{Name: "rect", Group: groupInspect, Summary: "Print an element's bounding box", Args: "<selector>", Stability: stable, Help: rectHelp, run: cmdRect},
+		{Name: "hit-test", Group: groupInspect, Summary: "List the elements stacked at a viewport point", Args: "<x> <y>", Stability: stable, Help: hitTestHelp, run: cmdHitTest},
+		{Name: "screenshot", Group: groupInspect, Summary: "Capture the page or an element as PNG", Stability: stable, Help: screenshotHelp, run: cmdScreenshot},
+		{Name: "screenshot-diff", Group: groupInspect, Summary: "Compare two PNGs and write a highlighted diff image", Args: "<a.png> <b.png>", Stability: stable, Help: screenshotDiffHelp, run: cmdScreenshotDiff},
+		{Name: "storage", Group: groupInspect, Summary: "Dump cookies and web storage by origin", Stability: experimental, Help: storageHelp, run: cmdStorage},
+		{Name: "info", Group: groupInspect, Summary: "Collect a state snapshot for bug reports", Stability: experimental, Help: infoHelp, run: cmdInfo},
+		{Name: "inject", Group: groupInspect, Summary: "Inject (or list) the WebNav helpers", Stability: stable, Help: injectHelp, run: cmdInject},
+		{Name: "csp", Group: groupInspect, Summary: "Toggle Content-Security-Policy bypass", Stability: stable, Subcommands: []cliCommand{{Name: "bypass", Summary: "Toggle Page.setBypassCSP for a session (remembered across commands)"}}, Help: cspHelp, run: cmdCSP},
+		{Name: "log", Group: groupMonitor, Summary: "Stream console output", Args: "[setup-script]", Stability: stable, Help: logHelp, run: cmdLog},
+		{Name: "network-log", Group: groupMonitor, Summary: "Record network requests and responses", Stability: stable, Subcommands: []cliCommand{{Name: "grep", Summary: "Search a capture directory's bodies (and headers)", Args: "<dir> <pattern>"}}, Help: networkLogHelp, run: cmdNetworkLog},
+		{Name: "har-to-mock", Group: groupMonitor, Summary: "Convert network-log captures or a HAR file into mock rules", Args: "<capture-dir|file.har>", Stability: experimental, Help: harToMockHelp, run: cmdHarToMock},
+		{Name: "listen", Group: groupMonitor, Summary: "Print messages the page sends through a binding", Stability: stable, Help: listenHelp, run: cmdListen},
+		{Name: "tabs", Group: groupBrowser, Summary: "List, open, switch and close tabs", Stability: stable, Subcommands: []cliCommand{{Name: "list", Summary: "List available tabs from a remote debugging port"}, {Name: "switch", Summary: "Activate a tab by index, id, or pattern", Args: "<index|id|pattern>"}, {Name: "open", Summary: "Open new tabs (URLs or --file)", Args: "<url>..."}, {Name: "close", Summary: "Close a tab by reference or by saved session name", Args: "[index|id|pattern]"}, {Name: "close-others", Summary: "Close every tab except a session's own (and --keep matches)"}, {Name: "gc", Summary: "Close old tabs that no saved session points at"}}, Help: tabsHelp, run: cmdTabs},
+		{Name: "extensions", Group: groupBrowser, Summary: "List extension targets by extension id", Stability: experimental, Subcommands: []cliCommand{{Name: "list", Summary: "List extension service workers, background, popup and options pages"}}, Help: extensionsHelp, run: cmdExtensions},
+		{Name: "browser-info", Group: groupBrowser, Summary: "Print the browser's /json/version info", Stability: stable, Help: browserInfoHelp, run: cmdBrowserInfo},
+		{Name: "js", Group: groupBrowser, Summary: "Disable page JavaScript or block script URLs", Stability: experimental, Help: jsHelp, run: cmdJS},
+		{Name: "auth", Group: groupBrowser, Summary: "Answer HTTP basic auth challenges from one origin", Stability: experimental, Help: authHelp, run: cmdAuth},
+		{Name: "profile", Group: groupBrowser, Summary: "Manage saved connection profiles", Stability: stable, Subcommands: []cliCommand{{Name: "list", Summary: "Show saved connection profiles"}, {Name: "add", Summary: "Save (or replace) a profile", Args: "<name>"}, {Name: "remove", Summary: "Delete a profile", Args: "<name>"}}, Help: profileHelp, run: cmdProfile},
+		{Name: "version", Aliases: []string{"--version"}, Group: groupMeta, Summary: "Print the cdp-cli version", Stability: stable, Help: versionHelp, run: cmdVersion},
+		{Name: "help", Group: groupMeta, Summary: "Show a command's description and worked examples", Args: "[command [subcommand]]", Stability: stable, Help: helpHelp, run: cmdHelp},
+		{Name: "commands", Group: groupMeta, Summary: "List every command and its flags (--json for tools)", Stability: stable, Help: commandsHelp, run: cmdCommands},
+	}
+}
+
+// lookupCommand finds a registry entry by name or alias.
+func lookupCommand(name string) (cliCommand, bool) {
+	for _, c := range commandRegistry {
+		if c.Name == name {
+			return c, true
+		}
+		for _, alias := range c.Aliases {
+			if alias == name {
+				return c, true
+			}
+		}
+	}
+	return cliCommand{}, false
+}
+
+func runCommand(cmd string, args []string) error {
 	switch cmd {
-	case "help", "--help", "-h":
+	case "--help", "-h":
 		printUsage()
 		return nil
-	case "connect":
-		return cmdConnect(args)
-	case "read":
-		return cmdRead(args)
-	case "eval":
-		return cmdEval(args)
-	case "wait":
-		return cmdWait(args)
-	case "wait-visible":
-		return cmdWaitVisible(args)
-	case "click":
-		return cmdClick(args)
-	case "hover":
-		return cmdHover(args)
-	case "drag":
-		return cmdDrag(args)
-	case "gesture":
-		return cmdGesture(args)
-	case "key":
-		return cmdKey(args)
-	case "scroll":
-		return cmdScroll(args)
-	case "type":
-		return cmdType(args)
-	case "upload":
-		return cmdUpload(args)
-	case "inject":
-		return cmdInject(args)
-	case "dom":
-		return cmdDOM(args)
-	case "styles":
-		return cmdStyles(args)
-	case "rect":
-		return cmdRect(args)
-	case "screenshot":
-		return cmdScreenshot(args)
-	case "log":
-		return cmdLog(args)
-	case "network-log":
-		return cmdNetworkLog(args)
-	case "keep-alive":
-		return cmdKeepAlive(args)
-	case "tabs":
-		return cmdTabs(args)
-	case "targets":
-		return cmdTargets(args)
-	case "disconnect":
-		return cmdDisconnect(args)
-	default:
-		return fmt.Errorf("unknown command %q", cmd)
 	}
+	c, ok := lookupCommand(cmd)
+	if !ok {
+		return usagef("unknown command %q", cmd)
+	}
+	return runRegistered(c, args)
 }
 
 func printUsage() {
@@ -77,36 +190,81 @@ func printUsage() {
 	fmt.Println("  cdp connect --session <name> --port 9222 --url https://example")
 	fmt.Println("  \t  cdp connect --session <name> --port 9222 --tab 3")
 	fmt.Println("  \t  cdp connect --session <name> --port 9222 --new [--new-url https://example]")
-	fmt.Println("  \t  cdp read --session <name> [options] [selector...]")
-	fmt.Println("  \t  cdp eval --session <name> \"JS expression\" [--pretty=false] [--depth N] [--json] [--wait]")
-	fmt.Println("  \t  cdp wait --session <name> [--selector \".selector\"] [--visible]")
+	fmt.Println("  \t  cdp connect --session <name> --port 9222 --target-id <id>")
+	fmt.Println("  \t  cdp connect ... --user-script helpers.js [--user-script more.js] [--bypass-csp]")
+	fmt.Println("  \t  cdp connect ... [--wait-ready] [--wait-title REGEX] [--wait-url REGEX]")
+	fmt.Println("  \t  cdp read --session <name> [options] [selector...] [--stats] [--with-network]")
+	fmt.Println("  \t  cdp eval --session <name> \"JS expression\" [--pretty=false] [--depth N] [--max-array N] [--max-string N] [--json] [--wait] [--set NAME] [--own-props] [--deep N] [--color auto|always|never]")
+	fmt.Println("  \t  cdp eval --session <name> --file script.js [--arg VALUE]...")
+	fmt.Println("  \t  cdp wait --session <name> [--selector \".selector\" ...] [--any | --all] [--visible] [--print-text | --print-attr NAME] [--route REGEX]")
 	fmt.Println("  \t  cdp wait-visible --session <name> \".selector\"")
-	fmt.Println("  \t  cdp click --session <name> \".selector\" [--has-text REGEX] [--att-value REGEX] [--count N] [--submit-wait-ms N]")
-	fmt.Println("  \t  cdp hover --session <name> \".selector\" [--has-text REGEX] [--att-value REGEX] [--hold DURATION]")
-	fmt.Println("  \t  cdp drag --session <name> \".from\" \".to\" [--from-index N] [--to-index N] [--delay DURATION]")
-	fmt.Println("  \t  cdp gesture --session <name> \".selector\" \"x1,y1 x2,y2 ...\" [--delay DURATION]  (draw, swipe, slide, trace)")
+	fmt.Println("  \t  cdp wait-mutation --session <name> \".container\" [--added-child \".row\"] [--removed-child SEL] [--attribute NAME] [--text]")
+	fmt.Println("  \t  cdp click --session <name> \".selector\" [--has-text REGEX] [--att-value REGEX] [--att name=REGEX] [--count N] [--as-dblclick=false] [--submit-wait-ms N] [--assert-change] [--json]")
+	fmt.Println("  \t  cdp hover --session <name> \".selector\" [--has-text REGEX] [--att-value REGEX] [--att name=REGEX] [--hold DURATION]")
+	fmt.Println("  \t  cdp drag --session <name> \".from\" \".to\" [--from-index N] [--to-index N] [--delay DURATION] [--to-position top|bottom|center|x,y] [--steps N]")
+	fmt.Println("  \t  cdp gesture --session <name> \".selector\" \"x1,y1[,p] x2,y2[,p] ...\" [--delay DURATION] [--touch] [--cdp]  (draw, swipe, slide, trace)")
+	fmt.Println("  \t  cdp gesture --session <name> --absolute \"x1,y1 x2,y2 ...\"  (viewport pixels)")
 	fmt.Println("  \t  cdp key --session <name> KEYS [--element \".selector\"] [--cdp]")
-	fmt.Println("  \t  cdp scroll --session <name> <yPx> [--x <xPx>] [--element \".selector\"] [--emit]")
-	fmt.Println("  \t  cdp type --session <name> \".selector\" \"text\" [--has-text REGEX] [--att-value REGEX] [--append]")
-	fmt.Println("  \t  cdp upload --session <name> \"input[type=file]\" <file1> [file2 ...] [--wait]")
-	fmt.Println("  \t  cdp inject --session <name> [--force]")
-	fmt.Println("  \t  cdp dom --session <name> \"CSS selector\"")
+	fmt.Println("  \t  cdp scroll --session <name> <yPx|Nvh|Nvw|N%|page|-page> [--x <xPx>] [--element \".selector\"] [--emit]")
+	fmt.Println("  \t  cdp scroll --session <name> --to top|bottom|N | --to-element \".selector\" [--align start|center|end]")
+	fmt.Println("  \t  cdp type --session <name> \".selector\" \"text\" [--has-text REGEX] [--att-value REGEX] [--att name=REGEX] [--append] [--expect REGEX]")
+	fmt.Println("  \t  cdp select --session <name> \"select\" <value|label> [--index] [--keyboard]")
+	fmt.Println("  \t  cdp submit --session <name> [\"form.selector\" | --containing \".field\"] [--method requestSubmit|submit|enter] [--wait-nav]")
+	fmt.Println("  \t  cdp validity --session <name> \".selector\" | --form \"form.selector\" [--report] [--json]")
+	fmt.Println("  \t  cdp upload --session <name> \"input[type=file]\" <file1> [file2 ...] [--wait] [--remote]")
+	fmt.Println("  \t  cdp upload --session <name> --label \"Attach files\" <file1> [file2 ...] [--wait]")
+	fmt.Println("  \t  cdp inject --session <name> [--force] [--list] [--persist] [--auto on|off]")
+	fmt.Println("  \t  cdp storage --session <name> [--all-frames]")
+	fmt.Println("  \t  cdp csp bypass --session <name> --enable|--disable")
+	fmt.Println("  \t  cdp overrides list|set <Domain.method> [json]|clear [method]|auto on|off --session <name>")
+	fmt.Println("  \t  cdp version [--json]")
+	fmt.Println("  \t  cdp browser-info [--host 127.0.0.1 --port 9222]")
+	fmt.Println("  \t  cdp js --session <name> [--disable|--enable] [--block-url REGEX] [--reload]")
+	fmt.Println("  \t  cdp auth --session <name> --origin https://internal.example --user USER (--pass PASS | --pass-env VAR) [--watch | --for 30s]")
+	fmt.Println("  \t  cdp profile list | add <name> --host --port [--secure] [--auth ...] | remove <name>")
+	fmt.Println("  \t  cdp --profile <name> <command> ...   (fills --host/--port from a saved profile)")
+	fmt.Println("  \t  cdp --store <path> <command> ...   (use another sessions file; or CDP_STORE)")
+	fmt.Println("  \t  cdp [--host H] [--port N] [--timeout D] <command> ...   (defaults for every command's own flags)")
+	fmt.Println("  \t  cdp --timings[=json] <command> ...   (print dial/CDP call timing summary to stderr)")
+	fmt.Println("  \t  cdp --json-errors <command> ...   (failures print {\"error\", \"code\", \"kind\"} JSON to stderr)")
+	fmt.Println("  \t  cdp --ignore-origin <command> ...   (skip the session's connect --expect-origin check)")
+	fmt.Println("  \t  cdp dom --session <name> \"CSS selector\" [--raw-text] [--block-sep SEP]")
+	fmt.Println("  \t  cdp dom-edit --session <name> \"CSS selector\" (--remove | --set-attr name=value | --remove-attr name | --outer-html file.html) [--all] [--force]")
 	fmt.Println("  \t  cdp styles --session <name> \"CSS selector\"")
-	fmt.Println("  \t  cdp rect --session <name> \"CSS selector\"")
-	fmt.Println("  \t  cdp screenshot --session <name> [--selector \".composer\"] [--output file.png] [--full-page] [--cdp-clip]")
-	fmt.Println("  \t  cdp log --session <name> [\"setup script\"] [--level REGEX] [--limit N] [--timeout DURATION]")
+	fmt.Println("  \t  cdp styles --session <name> \"CSS selector\" --watch 500ms [--duration 10s] [--props width,box.top]")
+	fmt.Println("  \t  cdp styles --session <name> \"CSS selector\" [--pseudo before|after|placeholder|marker | --include-pseudo] [--all-props] [--props a,b]")
+	fmt.Println("  \t  cdp rect --session <name> \"CSS selector\" [--occlusion] [--follow [--interval 100ms] [--duration 5s] [--epsilon 0.5] [--json]]")
+	fmt.Println("  \t  cdp hit-test --session <name> <x> <y>")
+	fmt.Println("  \t  cdp screenshot --session <name> [--selector \".composer\"] [--output file.png] [--full-page] [--cdp-clip] [--scale 2] [--hover \".trigger\" [--hover-wait 400ms | --hover-until \".tooltip\"]]")
+	fmt.Println("  \t  cdp screenshot --session <name> --selector \".x\" --baseline base.png [--fail-threshold 0.5%] [--diff-output diff.png]")
+	fmt.Println("  \t  cdp screenshot --session <name> --every 2s [--frames 30 | --duration 1m] [--output-dir shots/] [--prefix step-] [--on-change]")
+	fmt.Println("  \t  cdp screenshot-diff a.png b.png [--out diff.png] [--threshold PCT] [--tolerance N]")
+	fmt.Println("  \t  cdp log --session <name> [\"setup script\"] [--level REGEX] [--limit N] [--timeout DURATION] [--jsonl]")
 	fmt.Println("  \t  cdp network-log --session <name> [--dir PATH] [--url REGEX] [--method REGEX] [--status REGEX] [--mime REGEX]")
+	fmt.Println("  \t  cdp network-log grep <dir> <pattern> [--regex] [--ignore-case] [--headers] [--request-body] [--json-path $.data.items] [--binary]")
+	fmt.Println("  \t  cdp har-to-mock <capture-dir|file.har> --output rules.json [--url-filter REGEX] [--strip-query]")
+	fmt.Println("  \t  cdp listen --session <name> --binding <name> [--jsonl] [--persist]")
 	fmt.Println("  \t  cdp keep-alive --session <name>")
+	fmt.Println("  \t  cdp state --session <name> [--json]   (visibility, focus, timer throttling)")
 	fmt.Println("  \t  cdp tabs list [--host 127.0.0.1 --port 9222] [--plain]")
-	fmt.Println("  \t  cdp tabs open <url> [--host 127.0.0.1 --port 9222] [--activate=false]")
-	fmt.Println("  \t  cdp tabs switch <index|id|pattern> [--host 127.0.0.1 --port 9222]")
-	fmt.Println("  \t  cdp tabs close <index|id|pattern> [--host 127.0.0.1 --port 9222]")
+	fmt.Println("  \t  cdp tabs open <url>... [--file urls.txt] [--host 127.0.0.1 --port 9222] [--activate=false] [--json | --print-ws]")
+	fmt.Println("  \t  cdp tabs switch <index|id|pattern> [--rebind <session>] [--host 127.0.0.1 --port 9222]")
+	fmt.Println("  \t  cdp tabs close <index|id|pattern> [--host 127.0.0.1 --port 9222] [--force]")
+	fmt.Println("  \t  cdp tabs close --all <pattern> [--dry-run] [--yes] [--force]")
+	fmt.Println("  \t  cdp tabs close-others --session <name> [--keep REGEX] [--dry-run] [--yes] [--force]")
+	fmt.Println("  \t  cdp tabs gc [--max-age 1h] [--url REGEX] [--dry-run] [--yes]")
+	fmt.Println("  \t  cdp extensions list [--host 127.0.0.1 --port 9222] [--plain]")
 	fmt.Println("  \t  cdp targets")
+	fmt.Println("  \t  cdp status --session <name>")
+	fmt.Println("  \t  cdp repl --session <name>   (commands or JS per line from stdin; exit/EOF to quit)")
+	fmt.Println("  \t  cdp info --session <name> [--output dir/] [--console-window 30s]")
+	fmt.Println("  \t  cdp commands [--json]   (catalog of commands and flags)")
+	fmt.Println("  \t  cdp help <command> [subcommand]   (description and worked examples)")
 	fmt.Println("  cdp disconnect --session <name>")
 	fmt.Println()
 	if port, ok := envDefaultPort(); ok {
 		fmt.Printf("Configured default port (CDP_PORT): %d\n\n", port)
 	}
 	fmt.Println("Session name defaults can come from CDP_SESSION_NAME, WEB_SESSION, or WEB_SESSION_ID.")
-	fmt.Println("Run 'cdp <command> --help' for command-specific usage.")
+	fmt.Println("Run 'cdp help <command>' (or 'cdp <command> --help') for usage, a description and examples.")
 }
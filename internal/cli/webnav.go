@@ -3,11 +3,13 @@ package cli
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/store"
 )
 
-const webNavVersion = 16
+const webNavVersion = 31
 
 var webNavScript = fmt.Sprintf(`(function(){
   var WEBNAV_VERSION = %d;
@@ -36,6 +38,18 @@ var webNavScript = fmt.Sprintf(`(function(){
     return String(value || "").replace(/[.*+?^${}()|[\]\\]/g, "\\$&");
   }
 
+  // Build a RegExp from a pattern spec: /pat/flags, or a plain value that is
+  // escaped when literal is set. A /.../ spec whose flags don't parse, such as
+  // a path like /settings/profile, is matched as written.
+  function webNavRegExpSpec(value, literal) {
+    var raw = String(value);
+    var last = raw.lastIndexOf("/");
+    if (raw[0] === "/" && last > 0) {
+      try { return new RegExp(raw.slice(1, last), raw.slice(last + 1)); } catch (e) { return new RegExp(raw); }
+    }
+    return new RegExp(literal ? webNavEscapeRegExp(raw) : raw);
+  }
+
   class WebNavElements extends Array {
     hasText(text, {
       caseSensitive = true,
@@ -92,6 +106,22 @@ var webNavScript = fmt.Sprintf(`(function(){
       }));
     }
 
+    hasAtt(name, valueRegex) {
+      // Without valueRegex, having the attribute at all is enough. The
+      // pattern may be written /pat/flags.
+      const attName = String(name);
+      let re = null;
+      if (valueRegex !== undefined && valueRegex !== null && String(valueRegex) !== "") {
+        re = webNavRegExpSpec(valueRegex, false);
+      }
+      return new WebNavElements(...this.filter((el) => {
+        if (!el.hasAttribute || !el.hasAttribute(attName)) return false;
+        if (!re) return true;
+        re.lastIndex = 0;
+        return re.test(el.getAttribute(attName) || "");
+      }));
+    }
+
     querySelectorAll(sel) {
       return new WebNavElements(
         ...this.flatMap((el) => Array.from(el.querySelectorAll(sel)))
@@ -130,6 +160,12 @@ var webNavScript = fmt.Sprintf(`(function(){
     };
   }
 
+  if (!NodeList.prototype.hasAtt) {
+    NodeList.prototype.hasAtt = function (name, valueRegex) {
+      return toWebNavElements(this).hasAtt(name, valueRegex);
+    };
+  }
+
   if (!NodeList.prototype.querySelectorAll) {
     NodeList.prototype.querySelectorAll = function (sel) {
       return toWebNavElements(this).querySelectorAll(sel);
@@ -159,7 +195,7 @@ var webNavScript = fmt.Sprintf(`(function(){
     // Iterable of elements (NodeList, WebNavElements from .hasText() chains, etc.)
     if (isIterable(input)) {
       const list = toArray(input).filter((item) => item && item.nodeType === 1);
-      if (list.length > 0) return { el: list[0], selector: "" };
+      if (list.length > 0) return { el: list[0], selector: input.matchedSelector || "" };
       return { el: null, selector: "" };
     }
 
@@ -172,6 +208,16 @@ var webNavScript = fmt.Sprintf(`(function(){
     return { el: null, selector: "" };
   }
 
+  // isRendered reports whether el takes up space and isn't hidden by
+  // display, visibility or opacity.
+  function isRendered(el) {
+    if (!el.isConnected || !el.getClientRects().length) return false;
+    const style = window.getComputedStyle(el);
+    if (style.display === "none" || style.visibility === "hidden" || style.visibility === "collapse" || style.opacity === "0") return false;
+    const rect = el.getBoundingClientRect();
+    return rect.width > 0 && rect.height > 0;
+  }
+
   function focusElement(el) {
     if (!el) return;
     if (el.scrollIntoView) {
@@ -245,6 +291,42 @@ var webNavScript = fmt.Sprintf(`(function(){
     throw new Error("unknown key: " + keyToken);
   }
 
+  // dispatchClicks clicks el count times. Two clicks (unless asDblclick is
+  // false) are dispatched the way a browser reports a double click:
+  // down/up/click with detail 1, then 2, then dblclick. el.click() alone
+  // never produces a dblclick.
+  function dispatchClicks(el, count, asDblclick) {
+    if (count !== 2 || !asDblclick) {
+      for (let i = 0; i < count; i++) el.click();
+      return count === 1 ? "click" : count + " click events, no dblclick";
+    }
+    const rect = el.getBoundingClientRect();
+    const init = (type, detail) => ({
+      bubbles: true,
+      cancelable: true,
+      composed: true,
+      view: window,
+      detail,
+      button: 0,
+      buttons: type === "down" ? 1 : 0,
+      clientX: rect.left + rect.width / 2,
+      clientY: rect.top + rect.height / 2,
+    });
+    const pointer = (type, detail) => {
+      if (typeof PointerEvent === "undefined") return;
+      el.dispatchEvent(new PointerEvent("pointer" + type, Object.assign(init(type, detail), {detail: 0, pointerType: "mouse", isPrimary: true})));
+    };
+    for (let detail = 1; detail <= 2; detail++) {
+      pointer("down", detail);
+      el.dispatchEvent(new MouseEvent("mousedown", init("down", detail)));
+      pointer("up", detail);
+      el.dispatchEvent(new MouseEvent("mouseup", init("up", detail)));
+      el.dispatchEvent(new MouseEvent("click", init("up", detail)));
+    }
+    el.dispatchEvent(new MouseEvent("dblclick", init("up", 2)));
+    return "2 clicks + dblclick";
+  }
+
   const WebNav = {};
 
   WebNav.focus = function(target) {
@@ -256,6 +338,7 @@ var webNavScript = fmt.Sprintf(`(function(){
 
   WebNav.click = function(target, count, opts) {
     const clicks = (count && count > 0) ? count : 1;
+    const asDblclick = !(opts && opts.dblclick === false);
 
     // If target is a direct iterable of elements and opts.all, click all
     if (opts && opts.all && isIterable(target)) {
@@ -264,6 +347,7 @@ var webNavScript = fmt.Sprintf(`(function(){
         throw new Error("no element matched");
       }
       let submitForm = false;
+      let events = "";
       for (const el of list) {
         focusElement(el);
         const tag = el.tagName ? el.tagName.toLowerCase() : "";
@@ -277,9 +361,9 @@ var webNavScript = fmt.Sprintf(`(function(){
         }
         const inForm = !!(el.closest && el.closest("form"));
         if (isSubmit && inForm) submitForm = true;
-        for (let i = 0; i < clicks; i++) el.click();
+        events = dispatchClicks(el, clicks, asDblclick);
       }
-      return { submitForm, selector: "" };
+      return { submitForm, selector: "", events };
     }
 
     const resolved = resolveElement(target);
@@ -299,11 +383,12 @@ var webNavScript = fmt.Sprintf(`(function(){
       isSubmit = String(t || "").toLowerCase() === "submit";
     }
     const inForm = !!(el.closest && el.closest("form"));
-    for (let i = 0; i < clicks; i++) el.click();
-    return { submitForm: isSubmit && inForm, selector: resolved.selector };
+    const events = dispatchClicks(el, clicks, asDblclick);
+    return { submitForm: isSubmit && inForm, selector: resolved.selector, events };
   };
 
-  WebNav.clickWithRead = async function(target, count, readOpts) {
+  WebNav.clickWithRead = async function(target, count, readOpts, opts) {
+    opts = opts || {};
     // Resolve target once and keep a stable element reference for both reads.
     const resolved = resolveElement(target);
     if (!resolved.el) {
@@ -313,14 +398,32 @@ var webNavScript = fmt.Sprintf(`(function(){
     const el = resolved.el;
 
     const before = await WebNav.read(Object.assign({}, readOpts || {}, { rootSelector: el }));
-    const clickResult = WebNav.click(el, count);
+    const urlBefore = location.href;
+    // Count document-wide mutations caused by the click, not just changes
+    // inside the clicked element.
+    let mutations = 0;
+    const observer = new MutationObserver((records) => { mutations += records.length; });
+    observer.observe(document.documentElement, { subtree: true, childList: true, attributes: true, characterData: true });
+    let clickResult;
+    try {
+      clickResult = WebNav.click(el, count, { dblclick: opts.dblclick });
+      const observeMs = Number(opts.observeMs || 0);
+      if (observeMs > 0) await new Promise((resolve) => setTimeout(resolve, observeMs));
+      mutations += observer.takeRecords().length;
+    } finally {
+      observer.disconnect();
+    }
     const after = await WebNav.read(Object.assign({}, readOpts || {}, { rootSelector: el }));
     return {
       selector: resolved.selector || "",
       tagName: (el && el.tagName) ? String(el.tagName).toLowerCase() : "",
       submitForm: !!(clickResult && clickResult.submitForm),
+      events: (clickResult && clickResult.events) || "",
       before: before,
       after: after,
+      mutations: mutations,
+      urlBefore: urlBefore,
+      urlAfter: location.href,
     };
   };
 
@@ -385,7 +488,11 @@ var webNavScript = fmt.Sprintf(`(function(){
     };
   };
 
-  WebNav.drag = async function(fromTarget, toTarget, fromIndex, toIndex, delayMs) {
+  // opts.position is the drop point as fractions of the target's box
+  // ({x: 0.5, y: 0.5} is the center); opts.steps adds intermediate dragover
+  // events along the path for libraries that track the hover sequence.
+  WebNav.drag = async function(fromTarget, toTarget, fromIndex, toIndex, delayMs, opts) {
+    opts = opts || {};
     function sleep(ms) {
       if (!ms || ms <= 0) return Promise.resolve();
       return new Promise(resolve => setTimeout(resolve, ms));
@@ -414,12 +521,27 @@ var webNavScript = fmt.Sprintf(`(function(){
     const fromEl = fromPick.el;
     const toEl = toPick.el;
 
-    const fromRect = fromEl.getBoundingClientRect();
-    const toRect = toEl.getBoundingClientRect();
-    const fromPt = {x: fromRect.left + Math.max(2, Math.min(fromRect.width - 2, fromRect.width / 2)),
-                    y: fromRect.top + Math.max(2, Math.min(fromRect.height - 2, fromRect.height / 2))};
-    const toPt = {x: toRect.left + Math.max(2, Math.min(toRect.width - 2, toRect.width / 2)),
-                  y: toRect.top + Math.max(2, Math.min(toRect.height - 2, toRect.height / 2))};
+    if (fromEl.scrollIntoView) fromEl.scrollIntoView({block: "center", inline: "center"});
+    if (toEl.scrollIntoView) toEl.scrollIntoView({block: "center", inline: "center"});
+
+    // pointIn keeps the point 2px inside the box so edge drops still hit el.
+    function pointIn(rect, fx, fy) {
+      return {x: rect.left + Math.max(2, Math.min(rect.width - 2, rect.width * fx)),
+              y: rect.top + Math.max(2, Math.min(rect.height - 2, rect.height * fy))};
+    }
+    const position = opts.position || {x: 0.5, y: 0.5};
+    const fromPt = pointIn(fromEl.getBoundingClientRect(), 0.5, 0.5);
+    const toPt = pointIn(toEl.getBoundingClientRect(), position.x, position.y);
+    const steps = Math.max(0, opts.steps || 0);
+
+    function label(el) {
+      let out = el.tagName.toLowerCase();
+      if (el.id) out += "#" + el.id;
+      if (typeof el.className === "string" && el.className.trim()) {
+        out += "." + el.className.trim().split(/\s+/).slice(0, 2).join(".");
+      }
+      return out;
+    }
 
     let dataTransfer = null;
     if (typeof DataTransfer !== "undefined") {
@@ -459,79 +581,151 @@ var webNavScript = fmt.Sprintf(`(function(){
       el.dispatchEvent(evt);
     }
 
-    if (fromEl.scrollIntoView) fromEl.scrollIntoView({block: "center", inline: "center"});
-    if (toEl.scrollIntoView) toEl.scrollIntoView({block: "center", inline: "center"});
-
     dispatchMouse(fromEl, "mousedown", fromPt);
     dispatchDrag(fromEl, "dragstart", fromPt);
     await sleep(delayMs);
-    dispatchDrag(toEl, "dragenter", toPt);
+    let over = null;
+    for (let i = 1; i <= steps; i++) {
+      const t = i / (steps + 1);
+      const pt = {x: fromPt.x + (toPt.x - fromPt.x) * t, y: fromPt.y + (toPt.y - fromPt.y) * t};
+      const el = document.elementFromPoint(pt.x, pt.y) || toEl;
+      if (el !== over) {
+        if (over) dispatchDrag(over, "dragleave", pt);
+        dispatchDrag(el, "dragenter", pt);
+        over = el;
+      }
+      dispatchDrag(el, "dragover", pt);
+      await sleep(delayMs);
+    }
+    if (over !== toEl) {
+      if (over) dispatchDrag(over, "dragleave", toPt);
+      dispatchDrag(toEl, "dragenter", toPt);
+    }
     dispatchDrag(toEl, "dragover", toPt);
     await sleep(delayMs);
+    const underDrop = (document.elementsFromPoint ? document.elementsFromPoint(toPt.x, toPt.y) : []).slice(0, 4).map(label);
     dispatchDrag(toEl, "drop", toPt);
     dispatchDrag(fromEl, "dragend", toPt);
     dispatchMouse(toEl, "mouseup", toPt);
-    return {fromIndex: fromIndex || 0, toIndex: toIndex || 0, fromCount: fromPick.list.length, toCount: toPick.list.length};
+    return {
+      fromIndex: fromIndex || 0, toIndex: toIndex || 0, fromCount: fromPick.list.length, toCount: toPick.list.length,
+      from: {x: Math.round(fromPt.x), y: Math.round(fromPt.y)},
+      drop: {x: Math.round(toPt.x), y: Math.round(toPt.y)},
+      underDrop: underDrop,
+    };
   };
 
-  WebNav.gesture = async function(target, points, delayMs) {
+  // gesturePath maps gesture points to viewport pixels. Points are [x, y] or
+  // [x, y, pressure]; x/y are 0-1 of the element's box, or viewport pixels with
+  // opts.absolute (where target may be empty).
+  WebNav.gesturePath = function(target, points, opts) {
+    opts = opts || {};
+    let el = null;
+    let rect = null;
+    if (target) {
+      const resolved = resolveElement(target);
+      if (!resolved.el) throw new Error("no element matched selector: " + target);
+      el = resolved.el;
+      rect = el.getBoundingClientRect();
+    } else if (!opts.absolute) {
+      throw new Error("a selector is required unless points are absolute");
+    }
+    const vw = window.innerWidth;
+    const vh = window.innerHeight;
+    const path = points.map(function(pt, i) {
+      const pressure = pt.length > 2 ? pt[2] : 0.5;
+      let x = pt[0];
+      let y = pt[1];
+      if (!opts.absolute) {
+        x = rect.left + x * rect.width;
+        y = rect.top + y * rect.height;
+      }
+      if (x < 0 || y < 0 || x > vw || y > vh) {
+        throw new Error("point " + (i + 1) + " (" + Math.round(x) + "," + Math.round(y) + ") is outside the " + vw + "x" + vh + " viewport");
+      }
+      return [x, y, pressure];
+    });
+    if (!el) el = document.elementFromPoint(path[0][0], path[0][1]) || document.body;
+    return { el: el, path: path };
+  };
+
+  WebNav.gesture = async function(target, points, delayMs, opts) {
+    opts = opts || {};
     function sleep(ms) {
       if (!ms || ms <= 0) return Promise.resolve();
       return new Promise(resolve => setTimeout(resolve, ms));
     }
 
-    const resolved = resolveElement(target);
-    if (!resolved.el) throw new Error("no element matched selector: " + target);
+    const resolved = WebNav.gesturePath(target, points, opts);
     const el = resolved.el;
+    const path = resolved.path;
     focusElement(el);
 
-    const rect = el.getBoundingClientRect();
-    function toAbs(rx, ry) {
-      return { x: rect.left + rx * rect.width, y: rect.top + ry * rect.height };
-    }
-
-    function dispatchPointer(type, x, y, isDown) {
+    function dispatchPointer(type, pt, isDown) {
       if (typeof PointerEvent !== "undefined") {
         el.dispatchEvent(new PointerEvent(type, {
           bubbles: true,
           cancelable: true,
-          clientX: x,
-          clientY: y,
+          clientX: pt[0],
+          clientY: pt[1],
           pointerType: "mouse",
           button: 0,
           buttons: isDown ? 1 : 0,
+          pressure: isDown ? pt[2] : 0,
         }));
       }
     }
 
-    function dispatchMouse(type, x, y, isDown) {
+    function dispatchMouse(type, pt, isDown) {
       el.dispatchEvent(new MouseEvent(type, {
         bubbles: true,
         cancelable: true,
-        clientX: x,
-        clientY: y,
+        clientX: pt[0],
+        clientY: pt[1],
         button: 0,
         buttons: isDown ? 1 : 0,
       }));
     }
 
-    const first = toAbs(points[0][0], points[0][1]);
-    dispatchPointer("pointerdown", first.x, first.y, true);
-    dispatchMouse("mousedown", first.x, first.y, true);
+    function dispatchTouch(type, pt) {
+      const touch = new Touch({ identifier: 0, target: el, clientX: pt[0], clientY: pt[1], force: pt[2] });
+      const active = type === "touchend" ? [] : [touch];
+      el.dispatchEvent(new TouchEvent(type, {
+        bubbles: true,
+        cancelable: true,
+        touches: active,
+        targetTouches: active,
+        changedTouches: [touch],
+      }));
+    }
 
-    for (let i = 1; i < points.length; i++) {
-      await sleep(delayMs);
-      const pt = toAbs(points[i][0], points[i][1]);
-      dispatchPointer("pointermove", pt.x, pt.y, true);
-      dispatchMouse("mousemove", pt.x, pt.y, true);
+    if (opts.touch && (typeof Touch === "undefined" || typeof TouchEvent === "undefined")) {
+      throw new Error("this page does not support Touch events (try --cdp)");
     }
 
-    const last = toAbs(points[points.length - 1][0], points[points.length - 1][1]);
-    await sleep(delayMs);
-    dispatchPointer("pointerup", last.x, last.y, false);
-    dispatchMouse("mouseup", last.x, last.y, false);
+    const last = path[path.length - 1];
+    if (opts.touch) {
+      dispatchTouch("touchstart", path[0]);
+      for (let i = 1; i < path.length; i++) {
+        await sleep(delayMs);
+        dispatchTouch("touchmove", path[i]);
+      }
+      await sleep(delayMs);
+      dispatchTouch("touchend", last);
+    } else {
+      dispatchPointer("pointerdown", path[0], true);
+      dispatchMouse("mousedown", path[0], true);
+      for (let i = 1; i < path.length; i++) {
+        await sleep(delayMs);
+        dispatchPointer("pointermove", path[i], true);
+        dispatchMouse("mousemove", path[i], true);
+      }
+      await sleep(delayMs);
+      dispatchPointer("pointerup", last, false);
+      dispatchMouse("mouseup", last, false);
+    }
 
-    return { points: points.length };
+    return { points: path.length, path: path };
   };
 
   WebNav.key = function(spec) {
@@ -562,12 +756,53 @@ var webNavScript = fmt.Sprintf(`(function(){
     return true;
   };
 
+  function elementValue(el) {
+    const tag = el.tagName ? el.tagName.toLowerCase() : "";
+    if (tag === "input" || tag === "textarea" || tag === "select") {
+      return String(el.value || "");
+    }
+    return String(el.textContent || "");
+  }
+
+  WebNav.readValue = function(target) {
+    const resolved = resolveElement(target);
+    if (!resolved.el) return { found: false };
+    return { found: true, value: elementValue(resolved.el) };
+  };
+
+  // WebNav.rankCandidates merges [selector, elements] groups given in
+  // priority order, moving the focused element and then visible ones to the
+  // front (group order breaks ties). matchedSelector on the result names the
+  // group of its first element.
+  WebNav.rankCandidates = function(groups) {
+    const seen = new Set();
+    const entries = [];
+    for (const [selector, list] of groups) {
+      for (const el of toArray(list)) {
+        if (!el || el.nodeType !== 1 || seen.has(el)) continue;
+        seen.add(el);
+        entries.push({ el, selector });
+      }
+    }
+    const active = document.activeElement;
+    const rank = (entry) => {
+      if (active && active !== document.body && (entry.el === active || entry.el.contains(active))) return 0;
+      return isRendered(entry.el) ? 1 : 2;
+    };
+    entries.sort((a, b) => rank(a) - rank(b));
+    const out = new WebNavElements();
+    for (const entry of entries) out.push(entry.el);
+    out.matchedSelector = entries.length ? entries[0].selector : "";
+    return out;
+  };
+
   WebNav.typePrepare = function(target, inputText, append) {
     const resolved = resolveElement(target);
     if (!resolved.el) {
       throw new Error("no element matched");
     }
     const el = resolved.el;
+    const before = elementValue(el);
     focusElement(el);
 
     const tag = el.tagName ? el.tagName.toLowerCase() : "";
@@ -588,7 +823,7 @@ var webNavScript = fmt.Sprintf(`(function(){
           el.dispatchEvent(new Event("input", {bubbles: true}));
           el.dispatchEvent(new Event("change", {bubbles: true}));
         } catch (e) {}
-        return { found: true, editable: true, contentEditable: false, handled: true, selector: resolved.selector };
+        return { found: true, editable: true, contentEditable: false, handled: true, selector: resolved.selector, before };
       }
       if (!append) {
         el.value = "";
@@ -599,7 +834,7 @@ var webNavScript = fmt.Sprintf(`(function(){
           el.setSelectionRange(end, end);
         } catch (e) {}
       }
-      return { found: true, editable: true, contentEditable: false, handled: false, selector: resolved.selector };
+      return { found: true, editable: true, contentEditable: false, handled: false, selector: resolved.selector, before };
     }
     if (el.isContentEditable) {
       if (!append) {
@@ -611,9 +846,9 @@ var webNavScript = fmt.Sprintf(`(function(){
       const sel = window.getSelection();
       sel.removeAllRanges();
       sel.addRange(range);
-      return { found: true, editable: true, contentEditable: true, handled: false, selector: resolved.selector };
+      return { found: true, editable: true, contentEditable: true, handled: false, selector: resolved.selector, before };
     }
-    return { found: true, editable: false, contentEditable: false, handled: false, selector: resolved.selector };
+    return { found: true, editable: false, contentEditable: false, handled: false, selector: resolved.selector, before };
   };
 
   WebNav.typeFallback = function(target, inputText, append) {
@@ -669,8 +904,28 @@ var webNavScript = fmt.Sprintf(`(function(){
     return { ok: true, selector: resolved.selector };
   };
 
-  WebNav.scroll = function(yPx, xPx, elementTarget, emit) {
-    const SCROLL_Y_PX = yPx || 0;
+  // resolveScrollDelta turns "page", "-page" or a percentage string into pixels
+  // of extent, and "Nvh"/"Nvw" into pixels of the viewport.
+  function resolveScrollDelta(spec, extent) {
+    if (typeof spec === "number") return spec;
+    if (typeof spec !== "string" || spec.trim() === "") return 0;
+    const s = spec.trim().toLowerCase();
+    if (s === "page" || s === "+page") return extent;
+    if (s === "-page") return -extent;
+    const pct = /^([+-]?\d+(?:\.\d+)?)\u0025$/.exec(s);
+    if (pct) return extent * parseFloat(pct[1]) / 100;
+    const vp = /^([+-]?\d+(?:\.\d+)?)(vh|vw)$/.exec(s);
+    if (vp) return (vp[2] === "vh" ? window.innerHeight : window.innerWidth) * parseFloat(vp[1]) / 100;
+    const n = Number(s);
+    if (!isFinite(n)) throw new Error("invalid scroll amount: " + spec);
+    return n;
+  }
+
+  // With opts.absolute, yPx is a scrollTop to go to ("top", "bottom", or an
+  // amount) rather than a delta; opts.toElement instead aligns that element's
+  // top with the start, center or end (opts.align) of the view.
+  WebNav.scroll = function(yPx, xPx, elementTarget, emit, opts) {
+    opts = opts || {};
     const SCROLL_X_PX = xPx || 0;
     const EMIT = emit !== false;
     let el = null;
@@ -684,8 +939,32 @@ var webNavScript = fmt.Sprintf(`(function(){
     } else {
       el = document.scrollingElement || document.documentElement;
     }
+    const isElement = !!(elementTarget && (typeof elementTarget === "string" || elementTarget.nodeType === 1));
+    const extent = isElement ? el.clientHeight : window.innerHeight;
+    const beforeTop = el.scrollTop;
+    const beforeLeft = el.scrollLeft;
+    let SCROLL_Y_PX;
+    if (opts.toElement) {
+      const target = document.querySelector(opts.toElement);
+      if (!target) {
+        throw new Error("no element matched selector: " + opts.toElement);
+      }
+      const box = target.getBoundingClientRect();
+      const view = isElement ? el.getBoundingClientRect() : { top: 0, height: window.innerHeight };
+      SCROLL_Y_PX = box.top - view.top;
+      if (opts.align === "center") SCROLL_Y_PX -= (view.height - box.height) / 2;
+      else if (opts.align === "end") SCROLL_Y_PX -= view.height - box.height;
+    } else if (opts.absolute) {
+      let top;
+      if (yPx === "top") top = 0;
+      else if (yPx === "bottom") top = Math.max(0, el.scrollHeight - el.clientHeight);
+      else top = resolveScrollDelta(yPx, extent);
+      SCROLL_Y_PX = top - beforeTop;
+    } else {
+      SCROLL_Y_PX = resolveScrollDelta(yPx, extent);
+    }
 
-    if (elementTarget && (typeof elementTarget === "string" || elementTarget.nodeType === 1)) {
+    if (isElement) {
       try {
         el.scrollBy({ top: SCROLL_Y_PX, left: SCROLL_X_PX, behavior: "instant" });
       } catch (e) {
@@ -706,7 +985,7 @@ var webNavScript = fmt.Sprintf(`(function(){
 
     if (EMIT) {
       const evt = new Event("scroll", { bubbles: true });
-      if (elementTarget && (typeof elementTarget === "string" || elementTarget.nodeType === 1)) {
+      if (isElement) {
         el.dispatchEvent(evt);
       } else {
         window.dispatchEvent(evt);
@@ -714,7 +993,205 @@ var webNavScript = fmt.Sprintf(`(function(){
       }
     }
 
-    return { scrollTop: el.scrollTop, scrollLeft: el.scrollLeft };
+    // Allow 1px of slack for fractional scroll positions on zoomed/HiDPI pages.
+    const maxTop = Math.max(0, el.scrollHeight - el.clientHeight);
+    const maxLeft = Math.max(0, el.scrollWidth - el.clientWidth);
+    return {
+      deltaY: SCROLL_Y_PX,
+      beforeTop: beforeTop,
+      beforeLeft: beforeLeft,
+      scrollTop: el.scrollTop,
+      scrollLeft: el.scrollLeft,
+      scrollHeight: el.scrollHeight,
+      scrollWidth: el.scrollWidth,
+      clientHeight: el.clientHeight,
+      clientWidth: el.clientWidth,
+      atTop: el.scrollTop <= 1,
+      atBottom: el.scrollTop + 1 >= maxTop,
+      atLeft: el.scrollLeft <= 1,
+      atRight: el.scrollLeft + 1 >= maxLeft,
+    };
+  };
+
+  WebNav.waitMutation = function(target, opts) {
+    opts = opts || {};
+    const resolved = resolveElement(target);
+    if (!resolved.el) throw new Error("no element matched selector: " + target);
+    const root = resolved.el;
+    const addedSel = opts.addedChild || "";
+    const removedSel = opts.removedChild || "";
+    const attrName = opts.attribute || "";
+    const wantText = !!opts.text;
+    const anyChange = !addedSel && !removedSel && !attrName && !wantText;
+    const timeoutMs = Number(opts.timeoutMs || 0);
+
+    function describe(node) {
+      if (!node) return "";
+      if (node.nodeType !== 1) return "#" + (node.nodeName || "node").toLowerCase().replace(/^#/, "");
+      let s = node.tagName.toLowerCase();
+      if (node.id) s += "#" + node.id;
+      const cls = (node.getAttribute("class") || "").trim();
+      if (cls) s += "." + cls.split(/\s+/).slice(0, 3).join(".");
+      return s;
+    }
+
+    function matchNodes(nodes, sel) {
+      const out = [];
+      for (const node of nodes) {
+        if (!node || node.nodeType !== 1) continue;
+        if (node.matches(sel)) {
+          out.push(node);
+        } else if (node.querySelector) {
+          const inner = node.querySelector(sel);
+          if (inner) out.push(inner);
+        }
+      }
+      return out;
+    }
+
+    function summarize(m, matched) {
+      const summary = { type: m.type, target: describe(m.target) };
+      if (m.type === "childList") {
+        summary.added = Array.from(m.addedNodes).map(describe).filter(Boolean);
+        summary.removed = Array.from(m.removedNodes).map(describe).filter(Boolean);
+      } else if (m.type === "attributes") {
+        summary.attribute = m.attributeName;
+        summary.oldValue = m.oldValue;
+        summary.value = m.target.getAttribute ? m.target.getAttribute(m.attributeName) : null;
+      } else if (m.type === "characterData") {
+        summary.oldValue = m.oldValue;
+        summary.value = m.target.nodeValue;
+      }
+      if (matched && matched.length) {
+        summary.matched = describe(matched[0]);
+        if (matched[0].innerText !== undefined) {
+          summary.text = String(matched[0].innerText || "").replace(/\s+/g, " ").trim().slice(0, 200);
+        }
+      }
+      return summary;
+    }
+
+    function check(m) {
+      if (anyChange) return summarize(m, null);
+      if (m.type === "childList") {
+        if (addedSel) {
+          const hits = matchNodes(m.addedNodes, addedSel);
+          if (hits.length) return summarize(m, hits);
+        }
+        if (removedSel) {
+          const hits = matchNodes(m.removedNodes, removedSel);
+          if (hits.length) return summarize(m, hits);
+        }
+        if (wantText && m.target.nodeType === 1) return summarize(m, null);
+      } else if (m.type === "attributes") {
+        if (attrName && m.attributeName === attrName) return summarize(m, null);
+      } else if (m.type === "characterData") {
+        if (wantText) return summarize(m, null);
+      }
+      return null;
+    }
+
+    return new Promise((resolve, reject) => {
+      let timer = null;
+      const observer = new MutationObserver((mutations) => {
+        for (const m of mutations) {
+          const hit = check(m);
+          if (hit) {
+            observer.disconnect();
+            if (timer) clearTimeout(timer);
+            resolve(hit);
+            return;
+          }
+        }
+      });
+      const init = { childList: true, subtree: true };
+      if (anyChange || attrName) {
+        init.attributes = true;
+        init.attributeOldValue = true;
+        if (attrName) init.attributeFilter = [attrName];
+      }
+      if (anyChange || wantText) {
+        init.characterData = true;
+        init.characterDataOldValue = true;
+      }
+      observer.observe(root, init);
+      if (timeoutMs > 0) {
+        timer = setTimeout(() => {
+          observer.disconnect();
+          reject(new Error("timeout waiting for mutation"));
+        }, timeoutMs);
+      }
+    });
+  };
+
+  function isSimpleClassSelector(sel) {
+    if (!sel) return false;
+    if (/[\s>#\[:]/.test(sel)) return false;
+    if (sel.indexOf(".") === -1) return false;
+    return true;
+  }
+
+  function escapeSelectorSlashes(sel) { return sel.replace(/\//g, "\\/"); }
+
+  // suggestFallbackSelector drops up to two trailing classes from a simple
+  // tag.class selector until something matches. prefix (ancestors and a
+  // combinator) is kept in front of every candidate.
+  function suggestFallbackSelector(sel, prefix) {
+    prefix = prefix || "";
+    if (!isSimpleClassSelector(sel)) return null;
+    var parts = sel.split(".");
+    var tag = parts[0] || "";
+    var classes = parts.slice(1).filter(Boolean);
+    if (classes.length === 0) return null;
+    var attempts = 0;
+    var currentClasses = classes.slice();
+    while (attempts < 2 && currentClasses.length > 1) {
+      currentClasses = currentClasses.slice(0, -1);
+      var candidateDisplay = prefix + (tag ? tag : "") + "." + currentClasses.join(".");
+      var candidate = escapeSelectorSlashes(candidateDisplay);
+      var matches = [];
+      try { matches = Array.from(document.querySelectorAll(candidate)); } catch (e) {}
+      if (matches.length > 0) {
+        return { selector: candidateDisplay, matches: matches };
+      }
+      attempts += 1;
+    }
+    return null;
+  }
+
+  // suggestSelectors offers near misses for a selector that matched nothing:
+  // its last compound with trailing classes dropped, then the tag alone,
+  // each with its match count. Element commands put them in their errors.
+  function suggestSelectors(sel) {
+    var m = /^(.*[\s>+~]\s*)?([^\s>+~]+)$/.exec(String(sel || "").trim());
+    if (!m || !isSimpleClassSelector(m[2])) return [];
+    var prefix = m[1] || "";
+    var out = [];
+    var fallback = suggestFallbackSelector(m[2], prefix);
+    if (fallback) out.push({ selector: fallback.selector, matches: fallback.matches.length });
+    var tag = m[2].split(".")[0];
+    if (tag) {
+      var count = 0;
+      try { count = document.querySelectorAll(escapeSelectorSlashes(prefix + tag)).length; } catch (e) {}
+      if (count > 0) out.push({ selector: prefix + tag, matches: count });
+    }
+    return out;
+  }
+
+  WebNav.suggestSelectors = suggestSelectors;
+
+  // busyIndicator returns the first selector with a rendered match, or ""
+  // when none is showing. Hidden template spinners (display:none) don't
+  // count.
+  WebNav.busyIndicator = function(selectors) {
+    for (const sel of selectors || []) {
+      let found = [];
+      try { found = document.querySelectorAll(sel); } catch (e) { continue; }
+      for (const el of found) {
+        if (isRendered(el)) return sel;
+      }
+    }
+    return "";
   };
 
 	  WebNav.read = async function(opts) {
@@ -836,15 +1313,7 @@ var webNavScript = fmt.Sprintf(`(function(){
     }
 
     function buildRegex(value) {
-      if (!value) return null;
-      if (value[0] === "/" && value.lastIndexOf("/") > 0) {
-        var last = value.lastIndexOf("/");
-        var pattern = value.slice(1, last);
-        var flags = value.slice(last + 1);
-        try { return new RegExp(pattern, flags); } catch (e) { return new RegExp(value); }
-      }
-      var escaped = webNavEscapeRegExp(value);
-      return new RegExp(escaped);
+      return value ? webNavRegExpSpec(value, true) : null;
     }
 
     var hasTextRegex = buildRegex(hasTextRaw);
@@ -854,36 +1323,6 @@ var webNavScript = fmt.Sprintf(`(function(){
     var displaySelector = rootSelector ? rootSelector.replace(/\\\//g, "/") : "";
     var noMatchLine = rootSelector ? ("no matches in the DOM for " + displaySelector) : "no-matches";
 
-    function isSimpleClassSelector(sel) {
-      if (!sel) return false;
-      if (/[\s>#\[:]/.test(sel)) return false;
-      if (sel.indexOf(".") === -1) return false;
-      return true;
-    }
-
-    function escapeSelectorSlashes(sel) { return sel.replace(/\\//g, "\\\\/"); }
-
-    function suggestFallbackSelector(sel) {
-      if (!isSimpleClassSelector(sel)) return null;
-      var parts = sel.split(".");
-      var tag = parts[0] || "";
-      var classes = parts.slice(1).filter(Boolean);
-      if (classes.length === 0) return null;
-      var attempts = 0;
-      var currentClasses = classes.slice();
-      while (attempts < 2 && currentClasses.length > 1) {
-        currentClasses = currentClasses.slice(0, -1);
-        var candidateDisplay = (tag ? tag : "") + "." + currentClasses.join(".");
-        var candidate = escapeSelectorSlashes(candidateDisplay);
-        var matches = Array.from(document.querySelectorAll(candidate));
-        if (matches.length > 0) {
-          return { selector: candidateDisplay, matches: matches };
-        }
-        attempts += 1;
-      }
-      return null;
-    }
-
     function shouldSerializeElement(el) {
       if (!el || el.nodeType !== Node.ELEMENT_NODE) return false;
       var tag = el.tagName.toLowerCase();
@@ -1115,7 +1554,13 @@ var webNavScript = fmt.Sprintf(`(function(){
       if (!nested) uniqueRoots.push(el);
     }
 
-    if (uniqueRoots.length === 0) {
+    // A miss goes to diagnostics rather than lines, so callers can keep the
+    // "did you mean" block off stdout.
+    var diagnostics = [];
+    var matchCount = 0;
+    function reportNoMatch() {
+      var content = lines;
+      lines = diagnostics;
       emit(0, noMatchLine);
       var suggestion = suggestFallbackSelector(displaySelector);
       if (suggestion) {
@@ -1128,6 +1573,11 @@ var webNavScript = fmt.Sprintf(`(function(){
           serialize(suggestion.matches[0], 1);
         }
       }
+      lines = content;
+    }
+
+    if (uniqueRoots.length === 0) {
+      reportNoMatch();
     } else {
       var renderedRoots = [];
       for (var i = 0; i < uniqueRoots.length; i++) {
@@ -1142,19 +1592,9 @@ var webNavScript = fmt.Sprintf(`(function(){
         renderedRoots.push(root);
       }
 
+      matchCount = renderedRoots.length;
       if (renderedRoots.length === 0) {
-        emit(0, noMatchLine);
-        var suggestion2 = suggestFallbackSelector(displaySelector);
-        if (suggestion2) {
-          if (suggestion2.matches.length === 1) {
-            emit(0, "did you mean \"" + suggestion2.selector + "\", which has 1 match:");
-            serialize(suggestion2.matches[0], 1);
-          } else {
-            emit(0, "did you mean \"" + suggestion2.selector + "\", which has " + suggestion2.matches.length + " matches");
-            emit(0, "first match:");
-            serialize(suggestion2.matches[0], 1);
-          }
-        }
+        reportNoMatch();
       } else if (renderedRoots.length === 1) {
         serialize(renderedRoots[0], 0);
       } else {
@@ -1169,7 +1609,7 @@ var webNavScript = fmt.Sprintf(`(function(){
       }
     }
 
-    return { url: location.href, title: document.title, lines: lines };
+    return { url: location.href, title: document.title, lines: lines, matched: matchCount > 0, matchCount: matchCount, diagnostics: diagnostics };
   };
 
   window.WebNav = WebNav;
@@ -1177,28 +1617,53 @@ var webNavScript = fmt.Sprintf(`(function(){
   window.WebNavHover = WebNav.hover;
   window.WebNavDrag = WebNav.drag;
   window.WebNavGesture = WebNav.gesture;
+  window.WebNavGesturePath = WebNav.gesturePath;
   window.WebNavKey = WebNav.key;
   window.WebNavType = WebNav.type;
   window.WebNavTypePrepare = WebNav.typePrepare;
+  window.WebNavRankCandidates = WebNav.rankCandidates;
   window.WebNavTypeFallback = WebNav.typeFallback;
+  window.WebNavReadValue = WebNav.readValue;
   window.WebNavScroll = WebNav.scroll;
   window.WebNavFocus = WebNav.focus;
   window.WebNavRead = WebNav.read;
   window.WebNavClickWithRead = WebNav.clickWithRead;
   window.WebNavHoverWithRead = WebNav.hoverWithRead;
+  window.WebNavWaitMutation = WebNav.waitMutation;
   window.WebNavInjected = true;
   window.WebNavInjectedVersion = WEBNAV_VERSION;
 })();`, webNavVersion)
 
+// webNavResident holds clients whose connection registered WebNav for new
+// documents and injected the current one; they skip the per-call check.
+// Registrations end with the DevTools session, so this is per connection.
+var webNavResident sync.Map
+
 func ensureWebNavInjected(ctx context.Context, client *cdp.Client) error {
+	return withScriptsDisabledHint(client, injectWebNavIfMissing(ctx, client))
+}
+
+func injectWebNavIfMissing(ctx context.Context, client *cdp.Client) error {
+	if _, ok := webNavResident.Load(client); ok {
+		return nil
+	}
+	if session := lookupSession(client); session != nil && session.PersistWebNav {
+		if err := registerWebNavOnNewDocument(ctx, client, session.UserScripts); err != nil {
+			return err
+		}
+		webNavResident.Store(client, true)
+		return nil
+	}
 	ok, err := isWebNavInjected(ctx, client)
 	if err != nil {
 		return err
 	}
-	if ok {
-		return nil
+	if !ok {
+		if err := injectWebNav(ctx, client, true); err != nil {
+			return err
+		}
 	}
-	return injectWebNav(ctx, client, true)
+	return injectUserScripts(ctx, client, false)
 }
 
 func isWebNavInjected(ctx context.Context, client *cdp.Client) (bool, error) {
@@ -1225,7 +1690,33 @@ func injectWebNav(ctx context.Context, client *cdp.Client, force bool) error {
 		_, _ = client.Evaluate(ctx, `(() => { try { window.WebNavInjected = false; window.WebNavInjectedVersion = 0; } catch (e) {} })()`)
 	}
 	if _, err := client.Evaluate(ctx, webNavScript); err != nil {
-		return fmt.Errorf("webnav inject failed: %w", err)
+		return withCSPHint(client, fmt.Errorf("webnav inject failed: %w", err))
 	}
 	return nil
 }
+
+// registerWebNavOnNewDocument adds WebNav and the user scripts via
+// Page.addScriptToEvaluateOnNewDocument, so every document loaded over this
+// connection gets them, then injects them into the current document.
+func registerWebNavOnNewDocument(ctx context.Context, client *cdp.Client, userScripts []store.UserScript) error {
+	if err := client.Call(ctx, "Page.enable", nil, nil); err != nil {
+		return err
+	}
+	sources := []string{webNavScript}
+	for _, configured := range userScripts {
+		script, data, err := loadUserScript(configured.Path)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, userScriptSource(script, data))
+	}
+	for _, source := range sources {
+		if err := client.Call(ctx, "Page.addScriptToEvaluateOnNewDocument", map[string]interface{}{"source": source}, nil); err != nil {
+			return err
+		}
+	}
+	if err := injectWebNav(ctx, client, false); err != nil {
+		return err
+	}
+	return injectUserScripts(ctx, client, false)
+}
@@ -0,0 +1,78 @@
+package format
+
+import "strings"
+
+// ANSI colors used by Colorize.
+const (
+	colorKey     = "\x1b[34;1m"
+	colorString  = "\x1b[32m"
+	colorNumber  = "\x1b[36m"
+	colorLiteral = "\x1b[35m"
+	colorReset   = "\x1b[0m"
+)
+
+// Colorize adds ANSI colors to JSON text produced by JSON: object keys,
+// strings, numbers, and true/false/null each get their own color. Only use it
+// for terminal output; the result is no longer valid JSON.
+func Colorize(text string) string {
+	var b strings.Builder
+	b.Grow(len(text) * 2)
+	for i := 0; i < len(text); {
+		ch := text[i]
+		switch {
+		case ch == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(text) {
+				end++
+			}
+			color := colorString
+			if isKey(text, end) {
+				color = colorKey
+			}
+			b.WriteString(color)
+			b.WriteString(text[i:end])
+			b.WriteString(colorReset)
+			i = end
+		case ch == '-' || (ch >= '0' && ch <= '9'):
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) >= 0 {
+				end++
+			}
+			b.WriteString(colorNumber)
+			b.WriteString(text[i:end])
+			b.WriteString(colorReset)
+			i = end
+		case strings.HasPrefix(text[i:], "true"), strings.HasPrefix(text[i:], "null"):
+			b.WriteString(colorLiteral + text[i:i+4] + colorReset)
+			i += 4
+		case strings.HasPrefix(text[i:], "false"):
+			b.WriteString(colorLiteral + text[i:i+5] + colorReset)
+			i += 5
+		default:
+			b.WriteByte(ch)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// isKey reports whether the string ending at end is followed by a colon.
+func isKey(text string, end int) bool {
+	for end < len(text) {
+		switch text[end] {
+		case ' ', '\n', '\t', '\r':
+			end++
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,13 @@
+package format
+
+import "testing"
+
+func TestColorize(t *testing.T) {
+	got := Colorize(`{"a": "x:\"y\"", "n": -1.5e3, "ok": [true, null]}`)
+	want := `{` + colorKey + `"a"` + colorReset + `: ` + colorString + `"x:\"y\""` + colorReset +
+		`, ` + colorKey + `"n"` + colorReset + `: ` + colorNumber + `-1.5e3` + colorReset +
+		`, ` + colorKey + `"ok"` + colorReset + `: [` + colorLiteral + `true` + colorReset + `, ` + colorLiteral + `null` + colorReset + `]}`
+	if got != want {
+		t.Fatalf("Colorize mismatch:\n got %q\nwant %q", got, want)
+	}
+}
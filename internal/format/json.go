@@ -4,12 +4,77 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
 )
 
+// Style controls how pretty JSON is laid out. The package-level JSON,
+// JSONLimited and WriteJSON use DefaultStyle.
+type Style struct {
+	// Indent is the per-level indentation of pretty output.
+	Indent string
+	// KeepOrder keeps the key order of objects that arrive as
+	// json.RawMessage instead of sorting them like Go maps.
+	KeepOrder bool
+}
+
+// DefaultStyle is two-space indentation with sorted keys.
+var DefaultStyle = Style{Indent: "  "}
+
+// ParseIndent turns "tab" or a number of spaces (0-8) into an indent string.
+func ParseIndent(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "tab") {
+		return "\t", nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 8 {
+		return "", fmt.Errorf("invalid indent %q (expected tab or 0-8 spaces)", value)
+	}
+	return strings.Repeat(" ", n), nil
+}
+
+// Limits bounds how much of a value is encoded. Negative fields mean unlimited.
+type Limits struct {
+	Depth  int // nesting depth; deeper values become "..."
+	Array  int // items kept per array; the rest become a "[+N more]" marker
+	String int // runes kept per string; the rest become a "[+N more]" suffix
+}
+
+// DepthLimit returns Limits that only bound nesting depth.
+func DepthLimit(depth int) Limits {
+	return Limits{Depth: depth, Array: -1, String: -1}
+}
+
 // JSON returns a string representation of the provided value.
 func JSON(value interface{}, pretty bool, maxDepth int) (string, error) {
-	pruned := prune(value, maxDepth)
-	data, err := json.Marshal(pruned)
+	return DefaultStyle.JSON(value, pretty, maxDepth)
+}
+
+// JSONLimited is JSON with array and string length limits as well as depth.
+func JSONLimited(value interface{}, pretty bool, limits Limits) (string, error) {
+	return DefaultStyle.JSONLimited(value, pretty, limits)
+}
+
+// WriteJSON encodes value straight to w followed by a newline, avoiding the
+// intermediate string (and, without limits, the pruned copy) that JSON
+// builds. Use it for potentially huge results.
+func WriteJSON(w io.Writer, value interface{}, pretty bool, limits Limits) error {
+	return DefaultStyle.WriteJSON(w, value, pretty, limits)
+}
+
+// JSON is the package-level JSON laid out in style s.
+func (s Style) JSON(value interface{}, pretty bool, maxDepth int) (string, error) {
+	return s.JSONLimited(value, pretty, DepthLimit(maxDepth))
+}
+
+// JSONLimited is the package-level JSONLimited laid out in style s.
+func (s Style) JSONLimited(value interface{}, pretty bool, limits Limits) (string, error) {
+	if needsPrune(value, limits) {
+		value = prune(value, limits, s.KeepOrder)
+	}
+	data, err := json.Marshal(value)
 	if err != nil {
 		return "", err
 	}
@@ -17,42 +82,206 @@ func JSON(value interface{}, pretty bool, maxDepth int) (string, error) {
 		return string(data), nil
 	}
 	var buf bytes.Buffer
-	if err := json.Indent(&buf, data, "", "  "); err != nil {
+	if err := json.Indent(&buf, data, "", s.Indent); err != nil {
 		return "", err
 	}
 	return buf.String(), nil
 }
 
-func prune(value interface{}, depth int) interface{} {
-	if depth == 0 {
+// WriteJSON is the package-level WriteJSON laid out in style s.
+func (s Style) WriteJSON(w io.Writer, value interface{}, pretty bool, limits Limits) error {
+	if needsPrune(value, limits) {
+		value = prune(value, limits, s.KeepOrder)
+	}
+	enc := json.NewEncoder(w)
+	if pretty {
+		enc.SetIndent("", s.Indent)
+	}
+	return enc.Encode(value)
+}
+
+// needsPrune reports whether prune would change value: always with a depth
+// limit, otherwise only when something exceeds a length limit or a
+// json.RawMessage needs decoding.
+func needsPrune(value interface{}, limits Limits) bool {
+	if limits.Depth >= 0 {
+		return true
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, val := range v {
+			if needsPrune(val, limits) {
+				return true
+			}
+		}
+	case []interface{}:
+		if limits.Array >= 0 && len(v) > limits.Array {
+			return true
+		}
+		for _, val := range v {
+			if needsPrune(val, limits) {
+				return true
+			}
+		}
+	case string:
+		return limits.String >= 0 && len(v) > limits.String
+	case json.RawMessage:
+		return true
+	}
+	return false
+}
+
+// prune applies limits to a copy of value. With keepOrder, json.RawMessage
+// objects decode to orderedObject rather than sorted maps.
+func prune(value interface{}, limits Limits, keepOrder bool) interface{} {
+	if limits.Depth == 0 {
 		return "..."
 	}
 	switch v := value.(type) {
 	case map[string]interface{}:
-		nextDepth := decrement(depth)
+		next := limits
+		next.Depth = decrement(limits.Depth)
 		clone := make(map[string]interface{}, len(v))
 		for key, val := range v {
-			clone[key] = prune(val, nextDepth)
+			clone[key] = prune(val, next, keepOrder)
 		}
 		return clone
 	case []interface{}:
-		nextDepth := decrement(depth)
-		clone := make([]interface{}, len(v))
-		for i, val := range v {
-			clone[i] = prune(val, nextDepth)
+		next := limits
+		next.Depth = decrement(limits.Depth)
+		keep := len(v)
+		if limits.Array >= 0 && keep > limits.Array {
+			keep = limits.Array
+		}
+		clone := make([]interface{}, keep, keep+1)
+		for i, val := range v[:keep] {
+			clone[i] = prune(val, next, keepOrder)
+		}
+		if keep < len(v) {
+			clone = append(clone, fmt.Sprintf("[+%d more]", len(v)-keep))
 		}
 		return clone
+	case string:
+		return truncateString(v, limits.String)
 	case json.RawMessage:
+		if keepOrder {
+			if decoded, err := decodeOrdered(v); err == nil {
+				return prune(decoded, limits, keepOrder)
+			}
+		}
 		var decoded interface{}
 		if err := json.Unmarshal(v, &decoded); err == nil {
-			return prune(decoded, depth)
+			return prune(decoded, limits, keepOrder)
 		}
-		return string(v)
+		return truncateString(string(v), limits.String)
+	case orderedObject:
+		next := limits
+		next.Depth = decrement(limits.Depth)
+		clone := make(orderedObject, len(v))
+		for i, field := range v {
+			clone[i] = orderedField{Key: field.Key, Value: prune(field.Value, next, keepOrder)}
+		}
+		return clone
 	default:
 		return value
 	}
 }
 
+// orderedObject is a JSON object that encodes its keys in the order they
+// were decoded.
+type orderedObject []orderedField
+
+type orderedField struct {
+	Key   string
+	Value interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrdered decodes data like json.Unmarshal into interface{}, except
+// that objects become orderedObject and numbers keep their source text.
+func decodeOrdered(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, orderedField{Key: keyTok.(string), Value: value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// truncateString keeps the first max runes of s and notes how many were cut.
+func truncateString(s string, max int) string {
+	if max < 0 || len(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + fmt.Sprintf("[+%d more]", len(runes)-max)
+}
+
 func decrement(depth int) int {
 	if depth < 0 {
 		return depth
@@ -0,0 +1,106 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteJSONMatchesJSON(t *testing.T) {
+	value := map[string]interface{}{
+		"raw":  json.RawMessage(`{"b":[1,2]}`),
+		"list": []interface{}{"a", map[string]interface{}{"deep": true}},
+	}
+	for _, tc := range []struct {
+		pretty bool
+		depth  int
+	}{{false, -1}, {true, -1}, {false, 1}, {true, 2}} {
+		want, err := JSON(value, tc.pretty, tc.depth)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, value, tc.pretty, DepthLimit(tc.depth)); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != want+"\n" {
+			t.Fatalf("pretty=%v depth=%d:\n got %q\nwant %q", tc.pretty, tc.depth, got, want+"\n")
+		}
+	}
+}
+
+func TestNeedsPrune(t *testing.T) {
+	plain := map[string]interface{}{"a": []interface{}{1, "x"}}
+	if needsPrune(plain, DepthLimit(-1)) {
+		t.Fatal("plain value without depth limit should not need pruning")
+	}
+	if !needsPrune(plain, DepthLimit(3)) {
+		t.Fatal("depth limit should always prune")
+	}
+	if !needsPrune([]interface{}{json.RawMessage(`1`)}, DepthLimit(-1)) {
+		t.Fatal("nested RawMessage should need pruning")
+	}
+}
+
+func TestJSONLimitedLengths(t *testing.T) {
+	value := map[string]interface{}{
+		"items": []interface{}{1, 2, 3, 4, 5},
+		"name":  "héllo world",
+		"short": []interface{}{"ok"},
+	}
+	got, err := JSONLimited(value, false, Limits{Depth: -1, Array: 2, String: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"items":[1,2,"[+3 more]"],"name":"héllo[+6 more]","short":["ok"]}`
+	if got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+	if needsPrune(value, Limits{Depth: -1, Array: 5, String: 20}) {
+		t.Fatal("value within limits should not need pruning")
+	}
+}
+
+func TestStyleIndentAndKeyOrder(t *testing.T) {
+	value := map[string]interface{}{
+		"raw": json.RawMessage(`{"zeta":1,"alpha":{"y":2.50,"x":[true]}}`),
+	}
+	got, err := JSON(value, false, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"raw":{"alpha":{"x":[true],"y":2.5},"zeta":1}}`; got != want {
+		t.Fatalf("default order:\n got %s\nwant %s", got, want)
+	}
+
+	tab, err := ParseIndent("tab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	style := Style{Indent: tab, KeepOrder: true}
+	got, err = style.JSON(value, true, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n\t\"raw\": {\n\t\t\"zeta\": 1,\n\t\t\"alpha\": {\n\t\t\t\"y\": 2.50,\n\t\t\t\"x\": [\n\t\t\t\ttrue\n\t\t\t]\n\t\t}\n\t}\n}"
+	if got != want {
+		t.Fatalf("tab indent, kept order:\n got %q\nwant %q", got, want)
+	}
+	var buf bytes.Buffer
+	if err := style.WriteJSON(&buf, value, true, DepthLimit(-1)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want+"\n" {
+		t.Fatalf("WriteJSON disagrees with JSON: %q", buf.String())
+	}
+	if got, _ := style.JSON(value, false, 3); got != `{"raw":{"zeta":1,"alpha":{"y":"...","x":"..."}}}` {
+		t.Fatalf("depth limit with kept order = %s", got)
+	}
+
+	if indent, err := ParseIndent("4"); err != nil || indent != "    " {
+		t.Fatalf("ParseIndent(4) = %q, %v", indent, err)
+	}
+	if _, err := ParseIndent("wide"); err == nil {
+		t.Fatal("expected an invalid indent to be rejected")
+	}
+}
@@ -22,6 +22,40 @@ type Session struct {
 	Type           string    `json:"type"`
 	LastConnected  time.Time `json:"lastConnected"`
 	LastTargetInfo string    `json:"lastTargetInfo"`
+	// Profile names the connection profile used by connect, if any.
+	Profile string `json:"profile,omitempty"`
+	// BypassCSP re-applies Page.setBypassCSP each time the session is opened.
+	BypassCSP bool `json:"bypassCsp,omitempty"`
+	// UserScripts are injected alongside WebNav whenever a command runs.
+	UserScripts []UserScript `json:"userScripts,omitempty"`
+	// PersistWebNav registers WebNav (and UserScripts) for every new document
+	// on each connection, so navigations mid-command don't lose the helpers.
+	PersistWebNav bool `json:"persistWebNav,omitempty"`
+	// Overrides are page-state CDP overrides (emulation, headers, ...) that
+	// Chrome drops when the DevTools session detaches.
+	Overrides []Override `json:"overrides,omitempty"`
+	// AutoRestore re-applies Overrides every time the session is opened.
+	AutoRestore bool `json:"autoRestore,omitempty"`
+	// LastAttach is how the last command reached the target: "direct" via
+	// WebSocketURL, or "relisted" after finding it again in /json/list.
+	LastAttach string `json:"lastAttach,omitempty"`
+	// ExpectedOrigin is a regex the tab's location.origin must match before
+	// a command runs against it, so a session rebound to another tab fails
+	// instead of acting on the wrong page.
+	ExpectedOrigin string `json:"expectedOrigin,omitempty"`
+}
+
+// Override records one CDP override command so it can be re-applied.
+type Override struct {
+	Method    string                 `json:"method"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+	AppliedAt time.Time              `json:"appliedAt"`
+}
+
+// UserScript is a JS file configured with `cdp connect --user-script`.
+type UserScript struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
 }
 
 // Store keeps sessions on disk.
@@ -31,12 +65,51 @@ type Store struct {
 	Sessions map[string]Session `json:"sessions"`
 }
 
-// Load initializes a store from the default location.
+// Options control where the store lives.
+type Options struct {
+	// Path is the sessions file to use. Empty means $CDP_STORE, then the
+	// default location (see ResolvePath).
+	Path string
+}
+
+// Location is a resolved store path and where it came from.
+type Location struct {
+	Path string
+	// Source is "--store", "CDP_STORE", "default" or "temp" (no config or
+	// state directory could be resolved).
+	Source string
+}
+
+var options Options
+
+// Configure sets the options every later Load uses; the CLI calls it with
+// the global --store flag.
+func Configure(opts Options) {
+	options = opts
+}
+
+// Current resolves the configured location without loading it.
+func Current() Location {
+	return ResolvePath(options)
+}
+
+// Load initializes a store from the configured location.
 func Load() (*Store, error) {
-	path, err := defaultPath()
-	if err != nil {
-		return nil, err
+	return Open(options)
+}
+
+// Open initializes a store from the location opts resolve to.
+func Open(opts Options) (*Store, error) {
+	loc := ResolvePath(opts)
+	if loc.Source == "temp" {
+		if err := checkPrivateDir(filepath.Dir(loc.Path)); err != nil {
+			return nil, err
+		}
+		warnTempOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "warning: no config directory (is HOME set?); keeping sessions in %s\n", loc.Path)
+		})
 	}
+	path := loc.Path
 	s := &Store{path: path, Sessions: make(map[string]Session)}
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -57,11 +130,16 @@ func Load() (*Store, error) {
 	return s, nil
 }
 
+// Path returns the file the store reads and writes.
+func (s *Store) Path() string {
+	return s.path
+}
+
 // Save persists the store to disk.
 func (s *Store) Save() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
 		return err
 	}
 	data, err := json.MarshalIndent(s, "", "  ")
@@ -105,10 +183,51 @@ func (s *Store) List() map[string]Session {
 	return out
 }
 
-func defaultPath() (string, error) {
-	dir, err := os.UserConfigDir()
+var warnTempOnce sync.Once
+
+// ResolvePath picks the sessions file: opts.Path, then $CDP_STORE, then the
+// default. The default is the config-dir file when it already exists (where
+// every earlier version kept it), else $XDG_STATE_HOME/cdp-cli when that is
+// set, else the config dir. Without a config dir (no HOME in a container)
+// it falls back to a per-user directory under os.TempDir.
+func ResolvePath(opts Options) Location {
+	if opts.Path != "" {
+		return Location{Path: opts.Path, Source: "--store"}
+	}
+	if env := os.Getenv("CDP_STORE"); env != "" {
+		return Location{Path: env, Source: "CDP_STORE"}
+	}
+	configPath := ""
+	if dir, err := os.UserConfigDir(); err == nil {
+		configPath = filepath.Join(dir, "cdp-cli", "sessions.json")
+		if _, err := os.Stat(configPath); err == nil {
+			return Location{Path: configPath, Source: "default"}
+		}
+	}
+	if state := os.Getenv("XDG_STATE_HOME"); filepath.IsAbs(state) {
+		return Location{Path: filepath.Join(state, "cdp-cli", "sessions.json"), Source: "default"}
+	}
+	if configPath != "" {
+		return Location{Path: configPath, Source: "default"}
+	}
+	dir := fmt.Sprintf("cdp-cli-%d", os.Getuid())
+	return Location{Path: filepath.Join(os.TempDir(), dir, "sessions.json"), Source: "temp"}
+}
+
+// checkPrivateDir makes sure dir, the temp fallback, is a directory only the
+// current user can use, creating it that way when missing. Its name is
+// predictable, so another user could otherwise plant it (or a symlink) to read
+// or swap the sessions file.
+func checkPrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	info, err := os.Lstat(dir)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if !info.IsDir() || !privateToCurrentUser(info) {
+		return fmt.Errorf("refusing to keep sessions in %s: not a private directory of the current user (mode %s); set CDP_STORE or pass --store", dir, info.Mode())
 	}
-	return filepath.Join(dir, "cdp-cli", "sessions.json"), nil
+	return nil
 }
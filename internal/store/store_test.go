@@ -0,0 +1,93 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolvePathOrder(t *testing.T) {
+	config, state := t.TempDir(), t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("XDG_STATE_HOME", state)
+	t.Setenv("CDP_STORE", "")
+
+	if loc := ResolvePath(Options{}); loc.Path != filepath.Join(state, "cdp-cli", "sessions.json") || loc.Source != "default" {
+		t.Fatalf("fresh install: %+v, want the state dir", loc)
+	}
+	legacy := filepath.Join(config, "cdp-cli", "sessions.json")
+	if err := os.MkdirAll(filepath.Dir(legacy), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacy, []byte(`{"sessions":{}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if loc := ResolvePath(Options{}); loc.Path != legacy {
+		t.Fatalf("existing store: %+v, want %s", loc, legacy)
+	}
+	t.Setenv("CDP_STORE", "/srv/ci/sessions.json")
+	if loc := ResolvePath(Options{}); loc.Path != "/srv/ci/sessions.json" || loc.Source != "CDP_STORE" {
+		t.Fatalf("CDP_STORE: %+v", loc)
+	}
+	if loc := ResolvePath(Options{Path: "/tmp/x.json"}); loc.Path != "/tmp/x.json" || loc.Source != "--store" {
+		t.Fatalf("--store: %+v", loc)
+	}
+}
+
+func TestResolvePathWithoutHome(t *testing.T) {
+	t.Setenv("CDP_STORE", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "")
+	loc := ResolvePath(Options{})
+	if loc.Source != "temp" || filepath.Dir(filepath.Dir(loc.Path)) != filepath.Clean(os.TempDir()) {
+		t.Fatalf("no HOME: %+v, want a file under %s", loc, os.TempDir())
+	}
+}
+
+func TestOpenUsesTheGivenPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "sessions.json")
+	s, err := Open(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set(Session{Name: "app", Port: 9222}); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("store file mode %v, want 0600", info.Mode().Perm())
+	}
+	again, err := Open(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := again.Get("app"); !ok || got.Port != 9222 {
+		t.Fatalf("reloaded %+v, %v", got, ok)
+	}
+}
+
+func TestOpenRefusesASharedTempDir(t *testing.T) {
+	t.Setenv("CDP_STORE", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("HOME", "")
+	t.Setenv("TMPDIR", t.TempDir())
+	if _, err := Open(Options{}); err != nil {
+		t.Fatalf("fresh temp dir: %v", err)
+	}
+	dir := filepath.Dir(ResolvePath(Options{}).Path)
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0o700 {
+		t.Fatalf("temp dir not created private: %v, %v", info, err)
+	}
+	if err := os.Chmod(dir, 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(Options{}); err == nil || !strings.Contains(err.Error(), "refusing to keep sessions") {
+		t.Fatalf("world-writable temp dir accepted: %v", err)
+	}
+}
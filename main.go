@@ -1,7 +1,6 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/veilm/cdp-cli/internal/cli"
@@ -9,7 +8,6 @@ import (
 
 func main() {
 	if err := cli.Run(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		os.Exit(1)
+		os.Exit(cli.ReportError(err))
 	}
 }
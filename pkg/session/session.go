@@ -0,0 +1,164 @@
+// Package session drives a Chrome tab from Go with the same operations the
+// cdp command line offers (read, click, type, eval, screenshot), without
+// shelling out to the binary.
+//
+//	s, err := session.Open(ctx, "manager") // a session saved by `cdp connect`
+//	if err != nil { ... }
+//	defer s.Close()
+//	page, err := s.Read(ctx, session.ReadOptions{})
+//	_, err = s.Click(ctx, session.ClickOptions{Selector: "button.submit"})
+package session
+
+import (
+	"context"
+	"errors"
+
+	"github.com/veilm/cdp-cli/internal/cdp"
+	"github.com/veilm/cdp-cli/internal/cli"
+)
+
+// ReadOptions select what Read renders.
+type ReadOptions struct {
+	Selector   string // root element(s) to read; empty reads the page
+	HasText    string // only elements whose subtree text matches this text/regex
+	AttValue   string // only elements with an attribute value matching this text/regex
+	ClassLimit int    // classes kept in element labels (0 = the default of 3, -1 = none)
+	WaitMS     int    // extra wait before parsing
+}
+
+// ReadResult is the page as `cdp read` prints it. When the selector (or the
+// text filters) matched nothing, Matched is false, Lines is empty and
+// Diagnostics holds the "no matches" line and any "did you mean" suggestion.
+type ReadResult struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Lines       []string `json:"lines"`
+	Matched     bool     `json:"matched"`
+	MatchCount  int      `json:"matchCount"`
+	Diagnostics []string `json:"diagnostics,omitempty"`
+}
+
+// ClickOptions pick the element Click clicks and how.
+type ClickOptions struct {
+	Selector    string // may end in an inline :has-text(...)
+	HasText     string
+	AttValue    string
+	Att         string // name=REGEX: only elements whose name attribute matches
+	PreferInner string // yes, no, or auto (the default)
+	Count       int    // clicks to perform (default 1)
+	NoDblclick  bool   // with Count 2, send two plain clicks instead of a double click
+	ObserveMS   int    // watch for DOM mutations this long after clicking
+	Verbose     bool   // log automatic recovery retries to stderr
+}
+
+// ClickResult describes the clicked element and the page around the click.
+type ClickResult struct {
+	Tag        string                 `json:"tag"`
+	Match      map[string]interface{} `json:"match,omitempty"`
+	Before     string                 `json:"before"`
+	After      string                 `json:"after"`
+	SubmitForm bool                   `json:"submitForm,omitempty"`
+	Events     string                 `json:"events,omitempty"` // e.g. "2 clicks + dblclick"
+	Mutations  int                    `json:"mutations"`
+	URLBefore  string                 `json:"urlBefore"`
+	URLAfter   string                 `json:"urlAfter"`
+}
+
+// TypeOptions pick the field Type types into. Without a Selector, the usual
+// text inputs are searched (HasText is then required) and the focused
+// match, then the first visible one, is used.
+type TypeOptions struct {
+	Selector string // may end in an inline :has-text(...)
+	HasText  string
+	AttValue string
+	Att      string // name=REGEX: only elements whose name attribute matches
+	Append   bool   // append to the current value instead of replacing it
+	Verbose  bool   // log automatic recovery retries to stderr
+}
+
+// TypeResult names the field that was typed into and its value before.
+type TypeResult struct {
+	Selector string `json:"selector"`
+	Before   string `json:"before"`
+}
+
+// ScreenshotOptions choose what Screenshot captures.
+type ScreenshotOptions struct {
+	Selector string  // crop to this element
+	FullPage bool    // capture beyond the viewport (may resize/reflow headful Chrome)
+	CDPClip  bool    // with Selector, crop via a CDP clip instead of locally
+	NoScroll bool    // with Selector, don't scroll the element into view first
+	Scale    float64 // device pixel ratio to capture at; 0 keeps the display's
+}
+
+// Session is one DevTools connection to a tab. It is not safe for
+// concurrent use.
+type Session struct {
+	client *cdp.Client
+	close  func()
+}
+
+// Open attaches to a session saved by `cdp connect`, following the same
+// rules as the commands (rebound tabs, recorded overrides, CSP bypass).
+func Open(ctx context.Context, name string) (*Session, error) {
+	client, closeFn, err := cli.OpenSession(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{client: client, close: closeFn}, nil
+}
+
+// Dial connects directly to a target's webSocketDebuggerUrl.
+func Dial(ctx context.Context, webSocketURL string) (*Session, error) {
+	client, err := cdp.Dial(ctx, webSocketURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Session{client: client, close: func() { client.Close() }}, nil
+}
+
+// Close detaches from the tab; the tab itself stays open.
+func (s *Session) Close() {
+	if s.close != nil {
+		s.close()
+		s.close = nil
+	}
+}
+
+// Eval evaluates a JavaScript expression, awaiting promises, and returns its
+// JSON value.
+func (s *Session) Eval(ctx context.Context, expression string) (interface{}, error) {
+	if expression == "" {
+		return nil, errors.New("empty expression")
+	}
+	return s.client.Evaluate(ctx, expression)
+}
+
+// Read renders the page (or opts.Selector) as readable text lines.
+func (s *Session) Read(ctx context.Context, opts ReadOptions) (ReadResult, error) {
+	result, err := cli.ReadPage(ctx, s.client, cli.ReadOptions(opts))
+	return ReadResult(result), err
+}
+
+// Click clicks the first element matching opts.
+func (s *Session) Click(ctx context.Context, opts ClickOptions) (ClickResult, error) {
+	result, err := cli.ClickElement(ctx, s.client, cli.ClickOptions(opts))
+	return ClickResult(result), err
+}
+
+// Type types text into the field matching opts.
+func (s *Session) Type(ctx context.Context, text string, opts TypeOptions) (TypeResult, error) {
+	result, err := cli.TypeText(ctx, s.client, text, cli.TypeOptions(opts))
+	return TypeResult{Selector: result.Selector, Before: result.Before}, err
+}
+
+// Screenshot returns a PNG of the viewport, the full page, or opts.Selector.
+func (s *Session) Screenshot(ctx context.Context, opts ScreenshotOptions) ([]byte, error) {
+	return cli.CaptureScreenshot(ctx, s.client, cli.ScreenshotOptions{
+		Selector: opts.Selector,
+		FullPage: opts.FullPage,
+		CDPClip:  opts.CDPClip,
+		NoScroll: opts.NoScroll,
+		Scale:    opts.Scale,
+	})
+}
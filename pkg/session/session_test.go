@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/veilm/cdp-cli/internal/cdp/cdptest"
+)
+
+// fakeTab answers Runtime.evaluate with whatever respond returns for the
+// expression, as a by-value result.
+func fakeTab(t *testing.T, respond func(expression string) interface{}) string {
+	t.Helper()
+	return cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		c.Reply(req.ID, cdptest.EvalResult(respond(req.Expression())))
+	})
+}
+
+func TestSessionEvalAndRead(t *testing.T) {
+	var reads []string
+	wsURL := fakeTab(t, func(expression string) interface{} {
+		switch {
+		case strings.Contains(expression, "WebNavInjectedVersion"):
+			return true
+		case strings.HasPrefix(expression, "window.WebNavRead("):
+			reads = append(reads, expression)
+			return map[string]interface{}{"url": "https://example.com/", "title": "Example", "lines": []string{"# Example", "Hello"}}
+		default:
+			return 42
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	value, err := s.Eval(ctx, "6 * 7")
+	if err != nil || value != float64(42) {
+		t.Fatalf("Eval = %v, %v; want 42", value, err)
+	}
+	page, err := s.Read(ctx, ReadOptions{Selector: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Title != "Example" || len(page.Lines) != 2 || page.Lines[1] != "Hello" {
+		t.Fatalf("unexpected read result %+v", page)
+	}
+	if len(reads) != 1 || !strings.Contains(reads[0], `"rootSelector":"main"`) || !strings.Contains(reads[0], `"classLimit":3`) {
+		t.Fatalf("unexpected WebNavRead call %q", reads)
+	}
+}
+
+func TestSessionClickIsNotRepeatedAfterNavigation(t *testing.T) {
+	clicks := 0
+	wsURL := cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		expression := req.Expression()
+		switch {
+		case req.Method == "Runtime.callFunctionOn":
+			// The click navigated, taking its result object with the page.
+			c.Fail(req.ID, "Cannot find context with specified id")
+		case strings.HasPrefix(expression, "typeof window."):
+			c.Reply(req.ID, cdptest.EvalResult("function"))
+		case strings.Contains(expression, "window.WebNavClickWithRead("):
+			clicks++
+			c.Reply(req.ID, map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "1"}})
+		default:
+			c.Reply(req.ID, cdptest.EvalResult(true))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Click(ctx, ClickOptions{Selector: "a.next"}); err == nil {
+		t.Fatal("expected the lost result to be reported")
+	}
+	if clicks != 1 {
+		t.Fatalf("clicked %d times, want once", clicks)
+	}
+}
+
+func TestSessionTypeIsNotRepeatedAfterNavigation(t *testing.T) {
+	prepares := 0
+	wsURL := cdptest.NewTab(t, func(c *cdptest.Conn, req cdptest.Request) {
+		expression := req.Expression()
+		switch {
+		case req.Method == "Runtime.callFunctionOn":
+			// The page's own handler typed and submitted, replacing the page.
+			c.Fail(req.ID, "Cannot find context with specified id")
+		case strings.HasPrefix(expression, "typeof window."):
+			c.Reply(req.ID, cdptest.EvalResult("function"))
+		case strings.Contains(expression, "window.WebNavTypePrepare("):
+			prepares++
+			c.Reply(req.ID, map[string]interface{}{"result": map[string]interface{}{"type": "object", "objectId": "1"}})
+		default:
+			c.Reply(req.ID, cdptest.EvalResult(true))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	s, err := Dial(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Type(ctx, "hello", TypeOptions{Selector: "input.search"}); err == nil {
+		t.Fatal("expected the lost result to be reported")
+	}
+	if prepares != 1 {
+		t.Fatalf("typed %d times, want once", prepares)
+	}
+}